@@ -0,0 +1,289 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/retry"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+)
+
+const (
+	AnalogOutputMaxPresentValue  = zcl.AttributeID(0x0041)
+	AnalogOutputMinPresentValue  = zcl.AttributeID(0x0045)
+	AnalogOutputPresentValue     = zcl.AttributeID(0x0055)
+	AnalogOutputEngineeringUnits = zcl.AttributeID(0x0075)
+)
+
+const analogOutputMaximumReportInterval = 300
+
+// AnalogOutput is a capability which signifies that a device has a writable analog value, such as a valve position or
+// ballast level.
+type AnalogOutput interface {
+	// Set writes a new value to the device, the value is clamped to the range the device reported during enumeration.
+	Set(context.Context, da.Device, float32) error
+
+	// Value returns the last known value of the device, along with its range and units.
+	Value(context.Context, da.Device) (AnalogOutputValue, error)
+}
+
+// AnalogOutputValue is the state of an analog output, Minimum and Maximum are only valid if HasMinimum and HasMaximum
+// are set, as the attributes are optional. Units is a BACnet engineering unit, or 0xffff if not provided.
+type AnalogOutputValue struct {
+	Value float32
+
+	Minimum    float32
+	HasMinimum bool
+	Maximum    float32
+	HasMaximum bool
+
+	Units uint16
+}
+
+// AnalogOutputValueChanged is sent to inform consumers that a devices analog output value has changed.
+type AnalogOutputValueChanged struct {
+	// Device whose value has changed.
+	Device da.Device
+	// New value of the device.
+	Value float32
+}
+
+const analogOutputNoUnits = uint16(0xffff)
+
+type ZigbeeAnalogOutput struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+	nodeStore         nodeStore
+
+	zclCommunicatorCallbacks zclCommunicatorCallbacks
+	zclCommunicatorRequests  zclCommunicatorRequests
+	zclGlobalCommunicator    zclGlobalCommunicator
+
+	nodeBinder  zigbee.NodeBinder
+	eventSender eventSender
+}
+
+func (z *ZigbeeAnalogOutput) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+
+	z.zclCommunicatorCallbacks.AddCallback(z.zclCommunicatorCallbacks.NewMatch(func(address zigbee.IEEEAddress, appMsg zigbee.ApplicationMessage, zclMessage zcl.Message) bool {
+		_, canCast := zclMessage.Command.(*global.ReportAttributes)
+		return canCast
+	}, z.incomingReportAttributes))
+}
+
+func (z *ZigbeeAnalogOutput) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, AnalogOutputFlag); found {
+			addCapability(&dev.device, AnalogOutputFlag)
+
+			dev.analogOutputState = AnalogOutputValue{Units: analogOutputNoUnits}
+
+			if err := retry.Retry(ctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
+				response, err := z.zclGlobalCommunicator.ReadAttributes(ctx, node.ieeeAddress, node.supportsAPSAck, cluster, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, endpoint, node.nextTransactionSequence(), []zcl.AttributeID{AnalogOutputPresentValue, AnalogOutputMinPresentValue, AnalogOutputMaxPresentValue, AnalogOutputEngineeringUnits})
+
+				if err == nil {
+					results := parseReadAttributeResponse(response)
+					markCapabilityUpdated(dev, AnalogOutputFlag)
+
+					if value, ok := results.float32Value(AnalogOutputPresentValue); ok {
+						dev.analogOutputState.Value = value
+					}
+
+					if value, ok := results.float32Value(AnalogOutputMinPresentValue); ok {
+						dev.analogOutputState.Minimum = value
+						dev.analogOutputState.HasMinimum = true
+					}
+
+					if value, ok := results.float32Value(AnalogOutputMaxPresentValue); ok {
+						dev.analogOutputState.Maximum = value
+						dev.analogOutputState.HasMaximum = true
+					}
+
+					if value, ok := results.uint16Value(AnalogOutputEngineeringUnits); ok {
+						dev.analogOutputState.Units = value
+					}
+				}
+
+				return err
+			}); err != nil {
+				log.Printf("failed to read analog output attributes: %s", err)
+			}
+
+			bindErr := bindDeviceToController(ctx, z.nodeBinder, z.eventSender, node, dev, endpoint, cluster)
+			if bindErr != nil {
+				log.Printf("failed to bind to zda: %s", bindErr)
+			}
+
+			reportingErr := configureReporting(ctx, z.zclGlobalCommunicator, node, endpoint, cluster, AnalogOutputPresentValue, zcl.TypeFloatSingle, 0, analogOutputMaximumReportInterval, float32(0))
+			if reportingErr != nil {
+				log.Printf("failed to configure reporting to zda: %s", reportingErr)
+			}
+
+			mode, reason := reportedUpdateMode(bindErr, reportingErr, UpdateModeNone)
+			setCapabilityUpdateMode(z.eventSender, dev, AnalogOutputFlag, mode, reason)
+		} else {
+			removeCapability(&dev.device, AnalogOutputFlag)
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (z *ZigbeeAnalogOutput) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(AnalogOutputFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+func (z *ZigbeeAnalogOutput) Set(ctx context.Context, device da.Device, value float32) error {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return err
+	}
+
+	iNode := iDevice.node
+
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	iDevice.mutex.Lock()
+	defer iDevice.mutex.Unlock()
+
+	endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, AnalogOutputFlag)
+
+	if !found {
+		return fmt.Errorf("unable to find analog output cluster on zigbee device in zda")
+	}
+
+	if iDevice.analogOutputState.HasMinimum && value < iDevice.analogOutputState.Minimum {
+		value = iDevice.analogOutputState.Minimum
+	}
+
+	if iDevice.analogOutputState.HasMaximum && value > iDevice.analogOutputState.Maximum {
+		value = iDevice.analogOutputState.Maximum
+	}
+
+	zclMsg := zcl.Message{
+		FrameType:           zcl.FrameGlobal,
+		Direction:           zcl.ClientToServer,
+		TransactionSequence: iNode.nextTransactionSequence(),
+		Manufacturer:        zigbee.NoManufacturer,
+		ClusterID:           cluster,
+		SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+		DestinationEndpoint: endpoint,
+		Command: &global.WriteAttributes{
+			Records: []global.WriteAttributesRecord{
+				{
+					Identifier:    AnalogOutputPresentValue,
+					DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeFloatSingle, Value: value},
+				},
+			},
+		},
+	}
+
+	cmdCtx, cancel := commandContext(ctx, iDevice)
+	defer cancel()
+
+	response, err := z.zclCommunicatorRequests.RequestResponse(cmdCtx, iNode.ieeeAddress, iNode.supportsAPSAck, zclMsg)
+
+	if err != nil {
+		return err
+	}
+
+	writeResponse, ok := response.Command.(*global.WriteAttributesResponse)
+
+	if !ok {
+		return fmt.Errorf("write attributes received command back which was not WriteAttributesResponse")
+	}
+
+	for _, record := range writeResponse.Records {
+		if record.Status != 0 {
+			return fmt.Errorf("device rejected write of present value: status %d", record.Status)
+		}
+	}
+
+	z.setValue(iDevice, value)
+
+	return nil
+}
+
+func (z *ZigbeeAnalogOutput) Value(ctx context.Context, device da.Device) (AnalogOutputValue, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return AnalogOutputValue{}, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	return iDevice.analogOutputState, nil
+}
+
+func (z *ZigbeeAnalogOutput) setValue(device *internalDevice, value float32) {
+	markCapabilityUpdated(device, AnalogOutputFlag)
+
+	if device.analogOutputState.Value != value {
+		device.analogOutputState.Value = value
+		z.eventSender.sendEvent(AnalogOutputValueChanged{Device: device.device, Value: value})
+	}
+}
+
+func (z *ZigbeeAnalogOutput) incomingReportAttributes(source communicator.MessageWithSource) {
+	node, found := z.nodeStore.getNode(source.SourceAddress)
+
+	if !found {
+		return
+	}
+
+	report := source.Message.Command.(*global.ReportAttributes)
+
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+
+	for _, device := range node.devices {
+		device.mutex.Lock()
+
+		cluster, _ := clusterForCapability(device, AnalogOutputFlag)
+
+		if isEndpointInSlice(device.endpoints, source.Message.SourceEndpoint) && cluster == source.Message.ClusterID && device.device.HasCapability(AnalogOutputFlag) {
+			for _, attributeReport := range report.Records {
+				if attributeReport.Identifier == AnalogOutputPresentValue {
+					if value, ok := attributeReport.DataTypeValue.Value.(float32); ok {
+						z.setValue(device, value)
+					}
+				}
+			}
+		}
+
+		device.mutex.Unlock()
+	}
+}
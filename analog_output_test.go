@@ -0,0 +1,280 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZigbeeAnalogOutput_Contract(t *testing.T) {
+	t.Run("can be assigned to a AnalogOutput", func(t *testing.T) {
+		assert.Implements(t, (*AnalogOutput)(nil), new(ZigbeeAnalogOutput))
+	})
+}
+
+func TestZigbeeAnalogOutput_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with cluster, reads state, binds and configures reporting", func(t *testing.T) {
+		mockNodeBinder := mockNodeBinder{}
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+
+		zao := ZigbeeAnalogOutput{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			nodeBinder:            &mockNodeBinder,
+		}
+
+		node, device := generateTestNodeAndDevice()
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.AnalogOutputBasicId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.AnalogOutputBasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(1), []zcl.AttributeID{AnalogOutputPresentValue, AnalogOutputMinPresentValue, AnalogOutputMaxPresentValue, AnalogOutputEngineeringUnits}).Return([]global.ReadAttributeResponseRecord{
+			{
+				Identifier:    AnalogOutputPresentValue,
+				DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeFloatSingle, Value: float32(20)},
+			},
+			{
+				Identifier:    AnalogOutputMinPresentValue,
+				DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeFloatSingle, Value: float32(0)},
+			},
+			{
+				Identifier:    AnalogOutputMaxPresentValue,
+				DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeFloatSingle, Value: float32(100)},
+			},
+			{
+				Identifier: AnalogOutputEngineeringUnits,
+				Status:     0x86,
+			},
+		}, nil)
+		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, zcl.AnalogOutputBasicId).Return(nil)
+		mockZclGlobalCommunicator.On("ConfigureReporting", mock.Anything, node.ieeeAddress, false, zcl.AnalogOutputBasicId, zigbee.NoManufacturer, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, uint8(2), AnalogOutputPresentValue, zcl.TypeFloatSingle, uint16(0), uint16(analogOutputMaximumReportInterval), float32(0)).Return(nil)
+
+		err := zao.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(AnalogOutputFlag))
+		assert.Equal(t, AnalogOutputValue{Value: 20, Minimum: 0, HasMinimum: true, Maximum: 100, HasMaximum: true, Units: analogOutputNoUnits}, device.analogOutputState)
+
+		mockNodeBinder.AssertExpectations(t)
+		mockZclGlobalCommunicator.AssertExpectations(t)
+	})
+
+	t.Run("removes capability from device without cluster", func(t *testing.T) {
+		zao := ZigbeeAnalogOutput{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{AnalogOutputFlag}
+
+		err := zao.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(AnalogOutputFlag))
+	})
+}
+
+func TestZigbeeAnalogOutput_Set(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zao := ZigbeeAnalogOutput{
+			gateway: &mockGateway{},
+		}
+
+		err := zao.Set(context.Background(), da.Device{}, 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if device does not support it", func(t *testing.T) {
+		zao := ZigbeeAnalogOutput{
+			gateway: &mockGateway{},
+		}
+
+		err := zao.Set(context.Background(), da.Device{Gateway: zao.gateway}, 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("writes the present value clamped to the maximum, and sends an event", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		mockEventSender := mockEventSender{}
+
+		zao := ZigbeeAnalogOutput{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+			eventSender:             &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zao.gateway
+		device.device.Capabilities = []da.Capability{AnalogOutputFlag}
+		device.analogOutputState = AnalogOutputValue{Maximum: 100, HasMaximum: true, Units: analogOutputNoUnits}
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.AnalogOutputBasicId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		expectedMessage := zcl.Message{
+			FrameType:           zcl.FrameGlobal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: 1,
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           zcl.AnalogOutputBasicId,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: deviceEndpoint,
+			Command: &global.WriteAttributes{
+				Records: []global.WriteAttributesRecord{
+					{
+						Identifier:    AnalogOutputPresentValue,
+						DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeFloatSingle, Value: float32(100)},
+					},
+				},
+			},
+		}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, node.ieeeAddress, false, expectedMessage).Return(zcl.Message{
+			Command: &global.WriteAttributesResponse{Records: []global.WriteAttributesResponseRecord{{Status: 0}}},
+		}, nil)
+		mockEventSender.On("sendEvent", AnalogOutputValueChanged{Device: device.device, Value: 100}).Once()
+
+		err := zao.Set(context.Background(), device.device, 150)
+		assert.NoError(t, err)
+
+		value, err := zao.Value(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, float32(100), value.Value)
+
+		mockDeviceStore.AssertExpectations(t)
+		mockZclCommunicatorRequests.AssertExpectations(t)
+		mockEventSender.AssertExpectations(t)
+	})
+
+	t.Run("returns error if the device rejects the write", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+
+		zao := ZigbeeAnalogOutput{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zao.gateway
+		device.device.Capabilities = []da.Capability{AnalogOutputFlag}
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.AnalogOutputBasicId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, node.ieeeAddress, false, mock.Anything).Return(zcl.Message{
+			Command: &global.WriteAttributesResponse{Records: []global.WriteAttributesResponseRecord{{Status: 0x87, Identifier: AnalogOutputPresentValue}}},
+		}, nil)
+
+		err := zao.Set(context.Background(), device.device, 10)
+		assert.Error(t, err)
+	})
+}
+
+func TestZigbeeAnalogOutput_Value(t *testing.T) {
+	t.Run("value is updated and an event sent when reported", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockNodeStore := mockNodeStore{}
+		mockEventSender := mockEventSender{}
+
+		zao := ZigbeeAnalogOutput{
+			gateway:     &mockGateway{},
+			nodeStore:   &mockNodeStore,
+			deviceStore: &mockDeviceStore,
+			eventSender: &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zao.gateway
+		device.device.Capabilities = []da.Capability{AnalogOutputFlag}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+		mockEventSender.On("sendEvent", AnalogOutputValueChanged{Device: device.device, Value: 42.5}).Once()
+
+		zao.incomingReportAttributes(communicator.MessageWithSource{
+			SourceAddress: node.ieeeAddress,
+			Message: zcl.Message{
+				FrameType:           zcl.FrameGlobal,
+				Direction:           zcl.ClientToServer,
+				ClusterID:           zcl.AnalogOutputBasicId,
+				SourceEndpoint:      node.endpoints[0],
+				DestinationEndpoint: DefaultGatewayHomeAutomationEndpoint,
+				Command: &global.ReportAttributes{
+					Records: []global.ReportAttributesRecord{
+						{
+							Identifier:    AnalogOutputPresentValue,
+							DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeFloatSingle, Value: float32(42.5)},
+						},
+					},
+				},
+			},
+		})
+
+		value, err := zao.Value(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, float32(42.5), value.Value)
+
+		mockDeviceStore.AssertExpectations(t)
+		mockNodeStore.AssertExpectations(t)
+		mockEventSender.AssertExpectations(t)
+	})
+
+	t.Run("reports from a cluster which does not back the capability on the device are ignored", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockNodeStore := mockNodeStore{}
+		mockEventSender := mockEventSender{}
+
+		zao := ZigbeeAnalogOutput{
+			gateway:     &mockGateway{},
+			nodeStore:   &mockNodeStore,
+			deviceStore: &mockDeviceStore,
+			eventSender: &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zao.gateway
+		device.device.Capabilities = []da.Capability{AnalogOutputFlag}
+		device.clusterRemaps = map[da.Capability]zigbee.ClusterID{AnalogOutputFlag: 0xfc00}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		zao.incomingReportAttributes(communicator.MessageWithSource{
+			SourceAddress: node.ieeeAddress,
+			Message: zcl.Message{
+				FrameType:      zcl.FrameGlobal,
+				ClusterID:      zcl.AnalogOutputBasicId,
+				SourceEndpoint: node.endpoints[0],
+				Command: &global.ReportAttributes{
+					Records: []global.ReportAttributesRecord{
+						{
+							Identifier:    AnalogOutputPresentValue,
+							DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeFloatSingle, Value: float32(42.5)},
+						},
+					},
+				},
+			},
+		})
+
+		value, err := zao.Value(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, float32(0), value.Value)
+
+		mockEventSender.AssertExpectations(t)
+	})
+}
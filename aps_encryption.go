@@ -0,0 +1,52 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"github.com/shimmeringbee/zigbee"
+)
+
+// APSEncryptionUnavailable is returned when a command must be APS encrypted, but the provider does not implement
+// APSEncryptingProvider and sending without it has not been permitted by WithPlaintextPINManagement.
+var APSEncryptionUnavailable = errors.New("aps encryption required but not supported by the provider")
+
+// APSEncryptingProvider may be implemented by providers able to send frames secured at the APS layer with the link
+// key shared with the destination node, in addition to the network key. It is used for commands carrying secrets,
+// such as door lock PIN codes, which some devices refuse unless they are APS encrypted.
+type APSEncryptingProvider interface {
+	SendApplicationMessageToNodeWithAPSEncryption(ctx context.Context, destinationAddress zigbee.IEEEAddress, message zigbee.ApplicationMessage, requireAck bool) error
+}
+
+type apsEncryptionKey struct{}
+
+// withAPSEncryption marks frames sent to nodes using the context as requiring APS encryption.
+func withAPSEncryption(ctx context.Context) context.Context {
+	return context.WithValue(ctx, apsEncryptionKey{}, true)
+}
+
+func requiresAPSEncryption(ctx context.Context) bool {
+	required, _ := ctx.Value(apsEncryptionKey{}).(bool)
+	return required
+}
+
+// apsEncryptionProvider wraps a provider, sending frames whose context requires APS encryption with it if the provider
+// implements APSEncryptingProvider. Otherwise APSEncryptionUnavailable is returned, unless allowPlaintext is set, in
+// which case they are sent protected by the network key alone.
+type apsEncryptionProvider struct {
+	zigbee.Provider
+	allowPlaintext bool
+}
+
+func (p *apsEncryptionProvider) SendApplicationMessageToNode(ctx context.Context, destinationAddress zigbee.IEEEAddress, message zigbee.ApplicationMessage, requireAck bool) error {
+	if requiresAPSEncryption(ctx) {
+		if encrypting, ok := p.Provider.(APSEncryptingProvider); ok {
+			return encrypting.SendApplicationMessageToNodeWithAPSEncryption(ctx, destinationAddress, message, requireAck)
+		}
+
+		if !p.allowPlaintext {
+			return APSEncryptionUnavailable
+		}
+	}
+
+	return p.Provider.SendApplicationMessageToNode(ctx, destinationAddress, message, requireAck)
+}
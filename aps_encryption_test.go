@@ -0,0 +1,58 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+type mockAPSEncryptingProvider struct {
+	zigbee.MockProvider
+}
+
+func (m *mockAPSEncryptingProvider) SendApplicationMessageToNodeWithAPSEncryption(ctx context.Context, destinationAddress zigbee.IEEEAddress, message zigbee.ApplicationMessage, requireAck bool) error {
+	args := m.Called(ctx, destinationAddress, message, requireAck)
+	return args.Error(0)
+}
+
+func TestAPSEncryptionProvider_SendApplicationMessageToNode(t *testing.T) {
+	address := zigbee.IEEEAddress(0x01)
+	message := zigbee.ApplicationMessage{ClusterID: 0x0101}
+
+	t.Run("sends frames with aps encryption if required and supported by the provider", func(t *testing.T) {
+		mockProvider := &mockAPSEncryptingProvider{}
+		defer mockProvider.AssertExpectations(t)
+
+		mockProvider.On("SendApplicationMessageToNodeWithAPSEncryption", mock.Anything, address, message, true).Return(nil).Once()
+		mockProvider.On("SendApplicationMessageToNode", mock.Anything, address, message, true).Return(nil).Once()
+
+		p := &apsEncryptionProvider{Provider: mockProvider}
+
+		assert.NoError(t, p.SendApplicationMessageToNode(withAPSEncryption(context.Background()), address, message, true))
+		assert.NoError(t, p.SendApplicationMessageToNode(context.Background(), address, message, true))
+	})
+
+	t.Run("refuses frames requiring aps encryption if the provider does not support it", func(t *testing.T) {
+		mockProvider := new(zigbee.MockProvider)
+		defer mockProvider.AssertExpectations(t)
+
+		p := &apsEncryptionProvider{Provider: mockProvider}
+
+		err := p.SendApplicationMessageToNode(withAPSEncryption(context.Background()), address, message, false)
+		assert.Equal(t, APSEncryptionUnavailable, err)
+		mockProvider.AssertNotCalled(t, "SendApplicationMessageToNode", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("sends frames without aps encryption if the provider does not support it and plaintext is allowed", func(t *testing.T) {
+		mockProvider := new(zigbee.MockProvider)
+		defer mockProvider.AssertExpectations(t)
+
+		mockProvider.On("SendApplicationMessageToNode", mock.Anything, address, message, false).Return(nil).Once()
+
+		p := &apsEncryptionProvider{Provider: mockProvider, allowPlaintext: true}
+
+		assert.NoError(t, p.SendApplicationMessageToNode(withAPSEncryption(context.Background()), address, message, false))
+	})
+}
@@ -0,0 +1,207 @@
+package zda
+
+import (
+	"container/list"
+	"context"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultAttributeCacheSize is the maximum number of attributes cached for each node, once full the least recently
+// updated attribute is evicted.
+const DefaultAttributeCacheSize = 128
+
+// AttributeCacheEntry is the most recent value of an attribute read from, or reported by, a node. Value and DataType
+// are only set if Status is success.
+type AttributeCacheEntry struct {
+	Endpoint  zigbee.Endpoint
+	Cluster   zigbee.ClusterID
+	Attribute zcl.AttributeID
+
+	Status   uint8
+	DataType zcl.AttributeDataType
+	Value    interface{}
+
+	Updated time.Time
+}
+
+type attributeCacheKey struct {
+	endpoint  zigbee.Endpoint
+	cluster   zigbee.ClusterID
+	attribute zcl.AttributeID
+}
+
+type nodeAttributeCache struct {
+	entries map[attributeCacheKey]*list.Element
+	order   *list.List
+}
+
+// zdaAttributeCache records the raw value of every attribute read from, or reported by, each node.
+type zdaAttributeCache struct {
+	mutex *sync.Mutex
+	size  int
+	nodes map[zigbee.IEEEAddress]*nodeAttributeCache
+
+	now func() time.Time
+}
+
+func newAttributeCache() *zdaAttributeCache {
+	return &zdaAttributeCache{
+		mutex: &sync.Mutex{},
+		size:  DefaultAttributeCacheSize,
+		nodes: map[zigbee.IEEEAddress]*nodeAttributeCache{},
+		now:   time.Now,
+	}
+}
+
+// Init registers the cache to receive all attribute reports.
+func (c *zdaAttributeCache) Init(zclCommunicatorCallbacks zclCommunicatorCallbacks) {
+	zclCommunicatorCallbacks.AddCallback(zclCommunicatorCallbacks.NewMatch(func(address zigbee.IEEEAddress, appMsg zigbee.ApplicationMessage, zclMessage zcl.Message) bool {
+		_, canCast := zclMessage.Command.(*global.ReportAttributes)
+		return canCast
+	}, c.incomingReportAttributes))
+}
+
+func (c *zdaAttributeCache) incomingReportAttributes(source communicator.MessageWithSource) {
+	report := source.Message.Command.(*global.ReportAttributes)
+
+	for _, record := range report.Records {
+		entry := AttributeCacheEntry{Endpoint: source.Message.SourceEndpoint, Cluster: source.Message.ClusterID, Attribute: record.Identifier}
+
+		if record.DataTypeValue != nil {
+			entry.DataType = record.DataTypeValue.DataType
+			entry.Value = record.DataTypeValue.Value
+		}
+
+		c.record(source.SourceAddress, entry)
+	}
+}
+
+func (c *zdaAttributeCache) recordRead(address zigbee.IEEEAddress, endpoint zigbee.Endpoint, cluster zigbee.ClusterID, records []global.ReadAttributeResponseRecord) {
+	for _, record := range records {
+		entry := AttributeCacheEntry{Endpoint: endpoint, Cluster: cluster, Attribute: record.Identifier, Status: record.Status}
+
+		if record.Status == 0 && record.DataTypeValue != nil {
+			entry.DataType = record.DataTypeValue.DataType
+			entry.Value = record.DataTypeValue.Value
+		}
+
+		c.record(address, entry)
+	}
+}
+
+func (c *zdaAttributeCache) record(address zigbee.IEEEAddress, entry AttributeCacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry.Updated = c.now()
+	key := attributeCacheKey{endpoint: entry.Endpoint, cluster: entry.Cluster, attribute: entry.Attribute}
+
+	node, found := c.nodes[address]
+
+	if !found {
+		node = &nodeAttributeCache{entries: map[attributeCacheKey]*list.Element{}, order: list.New()}
+		c.nodes[address] = node
+	}
+
+	if element, found := node.entries[key]; found {
+		element.Value = entry
+		node.order.MoveToFront(element)
+		return
+	}
+
+	node.entries[key] = node.order.PushFront(entry)
+
+	for node.order.Len() > c.size {
+		oldest := node.order.Back()
+		evicted := oldest.Value.(AttributeCacheEntry)
+
+		delete(node.entries, attributeCacheKey{endpoint: evicted.Endpoint, cluster: evicted.Cluster, attribute: evicted.Attribute})
+		node.order.Remove(oldest)
+	}
+}
+
+// entries returns the cached attributes of the node which belong to any of the endpoints, ordered by endpoint,
+// cluster and then attribute.
+func (c *zdaAttributeCache) entries(address zigbee.IEEEAddress, endpoints []zigbee.Endpoint) []AttributeCacheEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	node, found := c.nodes[address]
+
+	if !found {
+		return nil
+	}
+
+	var entries []AttributeCacheEntry
+
+	for element := node.order.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(AttributeCacheEntry)
+
+		if isEndpointInSlice(endpoints, entry.Endpoint) {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Endpoint != entries[j].Endpoint {
+			return entries[i].Endpoint < entries[j].Endpoint
+		}
+
+		if entries[i].Cluster != entries[j].Cluster {
+			return entries[i].Cluster < entries[j].Cluster
+		}
+
+		return entries[i].Attribute < entries[j].Attribute
+	})
+
+	return entries
+}
+
+// forget removes all cached attributes of the node.
+func (c *zdaAttributeCache) forget(address zigbee.IEEEAddress) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.nodes, address)
+}
+
+// cachingGlobalCommunicator wraps a zclGlobalCommunicator, recording the result of every attribute read in the cache.
+type cachingGlobalCommunicator struct {
+	zclGlobalCommunicator
+	cache *zdaAttributeCache
+}
+
+func (r *cachingGlobalCommunicator) ReadAttributes(ctx context.Context, ieeeAddress zigbee.IEEEAddress, requireAck bool, cluster zigbee.ClusterID, code zigbee.ManufacturerCode, sourceEndpoint zigbee.Endpoint, destEndpoint zigbee.Endpoint, transactionSequence uint8, attributes []zcl.AttributeID) ([]global.ReadAttributeResponseRecord, error) {
+	records, err := r.zclGlobalCommunicator.ReadAttributes(ctx, ieeeAddress, requireAck, cluster, code, sourceEndpoint, destEndpoint, transactionSequence, attributes)
+
+	if err == nil {
+		r.cache.recordRead(ieeeAddress, destEndpoint, cluster, records)
+	}
+
+	return records, err
+}
+
+// cachingCommunicatorRequests wraps zclCommunicatorRequests, recording the result of any attribute read sent directly
+// as a request in the cache.
+type cachingCommunicatorRequests struct {
+	zclCommunicatorRequests
+	cache *zdaAttributeCache
+}
+
+func (r *cachingCommunicatorRequests) RequestResponse(ctx context.Context, address zigbee.IEEEAddress, requireAck bool, message zcl.Message) (zcl.Message, error) {
+	response, err := r.zclCommunicatorRequests.RequestResponse(ctx, address, requireAck, message)
+
+	if err == nil {
+		if readResponse, ok := response.Command.(*global.ReadAttributesResponse); ok {
+			r.cache.recordRead(address, message.DestinationEndpoint, message.ClusterID, readResponse.Records)
+		}
+	}
+
+	return response, err
+}
@@ -0,0 +1,158 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestZdaAttributeCache_record(t *testing.T) {
+	t.Run("records and updates an attribute, returning entries for the requested endpoints in order", func(t *testing.T) {
+		now := time.Now()
+
+		cache := newAttributeCache()
+		cache.now = func() time.Time { return now }
+
+		cache.record(zigbee.IEEEAddress(0x01), AttributeCacheEntry{Endpoint: 2, Cluster: zcl.OnOffId, Attribute: 0x0000, DataType: zcl.TypeBoolean, Value: false})
+		cache.record(zigbee.IEEEAddress(0x01), AttributeCacheEntry{Endpoint: 1, Cluster: zcl.OnOffId, Attribute: 0x0000, DataType: zcl.TypeBoolean, Value: false})
+		cache.record(zigbee.IEEEAddress(0x01), AttributeCacheEntry{Endpoint: 3, Cluster: zcl.OnOffId, Attribute: 0x0000, DataType: zcl.TypeBoolean, Value: false})
+
+		now = now.Add(time.Minute)
+		cache.record(zigbee.IEEEAddress(0x01), AttributeCacheEntry{Endpoint: 1, Cluster: zcl.OnOffId, Attribute: 0x0000, DataType: zcl.TypeBoolean, Value: true})
+
+		entries := cache.entries(zigbee.IEEEAddress(0x01), []zigbee.Endpoint{1, 2})
+
+		assert.Equal(t, []AttributeCacheEntry{
+			{Endpoint: 1, Cluster: zcl.OnOffId, Attribute: 0x0000, DataType: zcl.TypeBoolean, Value: true, Updated: now},
+			{Endpoint: 2, Cluster: zcl.OnOffId, Attribute: 0x0000, DataType: zcl.TypeBoolean, Value: false, Updated: now.Add(-time.Minute)},
+		}, entries)
+	})
+
+	t.Run("evicts the least recently updated attribute when the node is full", func(t *testing.T) {
+		cache := newAttributeCache()
+		cache.size = 2
+
+		cache.record(zigbee.IEEEAddress(0x01), AttributeCacheEntry{Endpoint: 1, Cluster: zcl.OnOffId, Attribute: 0x0000})
+		cache.record(zigbee.IEEEAddress(0x01), AttributeCacheEntry{Endpoint: 1, Cluster: zcl.OnOffId, Attribute: 0x0001})
+		cache.record(zigbee.IEEEAddress(0x01), AttributeCacheEntry{Endpoint: 1, Cluster: zcl.OnOffId, Attribute: 0x0000})
+		cache.record(zigbee.IEEEAddress(0x01), AttributeCacheEntry{Endpoint: 1, Cluster: zcl.OnOffId, Attribute: 0x0002})
+
+		entries := cache.entries(zigbee.IEEEAddress(0x01), []zigbee.Endpoint{1})
+
+		assert.Len(t, entries, 2)
+		assert.Equal(t, zcl.AttributeID(0x0000), entries[0].Attribute)
+		assert.Equal(t, zcl.AttributeID(0x0002), entries[1].Attribute)
+	})
+
+	t.Run("returns nil for an unknown node, and after a node is forgotten", func(t *testing.T) {
+		cache := newAttributeCache()
+
+		assert.Nil(t, cache.entries(zigbee.IEEEAddress(0x01), []zigbee.Endpoint{1}))
+
+		cache.record(zigbee.IEEEAddress(0x01), AttributeCacheEntry{Endpoint: 1, Cluster: zcl.OnOffId, Attribute: 0x0000})
+		cache.forget(zigbee.IEEEAddress(0x01))
+
+		assert.Nil(t, cache.entries(zigbee.IEEEAddress(0x01), []zigbee.Endpoint{1}))
+	})
+}
+
+func TestZdaAttributeCache_Init(t *testing.T) {
+	t.Run("registers a callback which records reported attributes", func(t *testing.T) {
+		mockZclCommunicatorCallbacks := mockZclCommunicatorCallbacks{}
+		defer mockZclCommunicatorCallbacks.AssertExpectations(t)
+
+		mockZclCommunicatorCallbacks.Mock.On("NewMatch", mock.Anything, mock.Anything).Return(communicator.Match{})
+		mockZclCommunicatorCallbacks.Mock.On("AddCallback", mock.Anything)
+
+		cache := newAttributeCache()
+		cache.Init(&mockZclCommunicatorCallbacks)
+
+		cache.incomingReportAttributes(communicator.MessageWithSource{
+			SourceAddress: zigbee.IEEEAddress(0x01),
+			Message: zcl.Message{
+				ClusterID:      zcl.OnOffId,
+				SourceEndpoint: 1,
+				Command: &global.ReportAttributes{
+					Records: []global.ReportAttributesRecord{
+						{
+							Identifier:    0x0000,
+							DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeBoolean, Value: true},
+						},
+					},
+				},
+			},
+		})
+
+		entries := cache.entries(zigbee.IEEEAddress(0x01), []zigbee.Endpoint{1})
+
+		assert.Len(t, entries, 1)
+		assert.Equal(t, zcl.OnOffId, entries[0].Cluster)
+		assert.Equal(t, zcl.TypeBoolean, entries[0].DataType)
+		assert.Equal(t, true, entries[0].Value)
+	})
+}
+
+func TestCachingGlobalCommunicator_ReadAttributes(t *testing.T) {
+	t.Run("records successful reads in the cache", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, zigbee.IEEEAddress(0x01), mock.Anything, zcl.OnOffId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(1), uint8(1), []zcl.AttributeID{0x0000, 0x0001}).
+			Return([]global.ReadAttributeResponseRecord{
+				{Identifier: 0x0000, Status: 0, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeBoolean, Value: true}},
+				{Identifier: 0x0001, Status: 0x86},
+			}, nil)
+
+		cache := newAttributeCache()
+		c := &cachingGlobalCommunicator{zclGlobalCommunicator: &mockZclGlobalCommunicator, cache: cache}
+
+		_, err := c.ReadAttributes(context.Background(), zigbee.IEEEAddress(0x01), false, zcl.OnOffId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, 1, 1, []zcl.AttributeID{0x0000, 0x0001})
+		assert.NoError(t, err)
+
+		entries := cache.entries(zigbee.IEEEAddress(0x01), []zigbee.Endpoint{1})
+
+		assert.Len(t, entries, 2)
+		assert.Equal(t, true, entries[0].Value)
+		assert.Equal(t, uint8(0x86), entries[1].Status)
+		assert.Nil(t, entries[1].Value)
+	})
+}
+
+func TestCachingCommunicatorRequests_RequestResponse(t *testing.T) {
+	t.Run("records read attribute responses in the cache", func(t *testing.T) {
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		request := zcl.Message{
+			ClusterID:           zcl.BasicId,
+			DestinationEndpoint: 1,
+			Command:             &global.ReadAttributes{Identifier: []zcl.AttributeID{0x0004}},
+		}
+
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, zigbee.IEEEAddress(0x01), false, request).Return(zcl.Message{
+			Command: &global.ReadAttributesResponse{
+				Records: []global.ReadAttributeResponseRecord{
+					{Identifier: 0x0004, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeStringCharacter8, Value: "manufacturer"}},
+				},
+			},
+		}, nil)
+
+		cache := newAttributeCache()
+		c := &cachingCommunicatorRequests{zclCommunicatorRequests: &mockZclCommunicatorRequests, cache: cache}
+
+		_, err := c.RequestResponse(context.Background(), zigbee.IEEEAddress(0x01), false, request)
+		assert.NoError(t, err)
+
+		entries := cache.entries(zigbee.IEEEAddress(0x01), []zigbee.Endpoint{1})
+
+		assert.Len(t, entries, 1)
+		assert.Equal(t, zcl.BasicId, entries[0].Cluster)
+		assert.Equal(t, "manufacturer", entries[0].Value)
+	})
+}
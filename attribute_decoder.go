@@ -0,0 +1,188 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+)
+
+// AttributeDecoder converts the value of an attribute, as unmarshalled by zcl, into a typed Go value. Decoders for
+// composite types may call DecodeAttributeValue on their members, which decodes them with the default decoders.
+type AttributeDecoder func(value interface{}) (interface{}, error)
+
+// attributeDecoders is a registry of decoders keyed by data type, used to decode the values of every attribute read by
+// the gateway. It is populated when the gateway is constructed, and not altered afterwards.
+type attributeDecoders struct {
+	decoders map[zcl.AttributeDataType]AttributeDecoder
+}
+
+// defaultAttributeDecoders are the decoders used by DecodeAttributeValue, and those each gateway starts with.
+var defaultAttributeDecoders = newAttributeDecoders()
+
+// newAttributeDecoders returns a registry populated with the default decoders, the composite decoders decode their
+// members with the registry they belong to.
+func newAttributeDecoders() *attributeDecoders {
+	d := &attributeDecoders{}
+
+	d.decoders = map[zcl.AttributeDataType]AttributeDecoder{
+		zcl.TypeStringOctet8:  decodeOctetString,
+		zcl.TypeStringOctet16: decodeOctetString,
+		zcl.TypeArray:         d.decodeSlice,
+		zcl.TypeSet:           d.decodeSlice,
+		zcl.TypeBag:           d.decodeSlice,
+		zcl.TypeStructure:     d.decodeStructure,
+	}
+
+	return d
+}
+
+// set sets the decoder used for attributes of the data type, replacing any existing decoder. A nil decoder removes the
+// decoder for the data type.
+func (d *attributeDecoders) set(dataType zcl.AttributeDataType, decoder AttributeDecoder) {
+	if decoder == nil {
+		delete(d.decoders, dataType)
+	} else {
+		d.decoders[dataType] = decoder
+	}
+}
+
+// decode decodes the value using the decoder for the data type. If there is no decoder, values of data types known to
+// zcl are returned unaltered, and other data types are returned as raw bytes where possible. If the decoder fails the
+// value is returned as raw bytes where possible.
+func (d *attributeDecoders) decode(dataType zcl.AttributeDataType, value interface{}) interface{} {
+	decoder, found := d.decoders[dataType]
+
+	if !found {
+		if _, known := zcl.DiscreteTypes[dataType]; known && dataType != zcl.TypeUnknown {
+			return value
+		}
+
+		return rawAttributeValue(value)
+	}
+
+	decoded, err := decoder(value)
+
+	if err != nil {
+		log.Printf("failed to decode attribute of type %02x: %s", dataType, err)
+		return rawAttributeValue(value)
+	}
+
+	return decoded
+}
+
+// decodeRecords returns a copy of the records with the value of each successfully read attribute decoded.
+func (d *attributeDecoders) decodeRecords(records []global.ReadAttributeResponseRecord) []global.ReadAttributeResponseRecord {
+	if records == nil {
+		return nil
+	}
+
+	decoded := make([]global.ReadAttributeResponseRecord, len(records))
+
+	for i, record := range records {
+		decoded[i] = record
+
+		if record.Status == 0 && record.DataTypeValue != nil {
+			decoded[i].DataTypeValue = &zcl.AttributeDataTypeValue{
+				DataType: record.DataTypeValue.DataType,
+				Value:    d.decode(record.DataTypeValue.DataType, record.DataTypeValue.Value),
+			}
+		}
+	}
+
+	return decoded
+}
+
+// DecodeAttributeValue decodes the value using the default decoder for the data type. Values of data types known to
+// zcl without a decoder are returned unaltered, other data types are returned as raw bytes where possible, as is the
+// value if the decoder fails.
+func DecodeAttributeValue(dataType zcl.AttributeDataType, value interface{}) interface{} {
+	return defaultAttributeDecoders.decode(dataType, value)
+}
+
+// rawAttributeValue returns the value as raw bytes if it is string-like, otherwise it is returned unaltered.
+func rawAttributeValue(value interface{}) interface{} {
+	if str, ok := value.(string); ok {
+		return []byte(str)
+	}
+
+	return value
+}
+
+// decodeOctetString returns octet strings as raw bytes, zcl unmarshals them as a Go string which suggests they are
+// text when they are not.
+func decodeOctetString(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("octet string has unexpected value type %T", value)
+	}
+}
+
+// decodeSlice decodes each element of an array, set or bag with the decoder for the element type.
+func (d *attributeDecoders) decodeSlice(value interface{}) (interface{}, error) {
+	slice, ok := value.(zcl.AttributeSlice)
+
+	if !ok {
+		return nil, fmt.Errorf("array has unexpected value type %T", value)
+	}
+
+	decoded := zcl.AttributeSlice{DataType: slice.DataType, Values: make([]interface{}, len(slice.Values))}
+
+	for i, element := range slice.Values {
+		decoded.Values[i] = d.decode(slice.DataType, element)
+	}
+
+	return decoded, nil
+}
+
+// decodeStructure decodes each member of a structure with the decoder for the member type.
+func (d *attributeDecoders) decodeStructure(value interface{}) (interface{}, error) {
+	members, ok := value.([]zcl.AttributeDataTypeValue)
+
+	if !ok {
+		return nil, fmt.Errorf("structure has unexpected value type %T", value)
+	}
+
+	decoded := make([]zcl.AttributeDataTypeValue, len(members))
+
+	for i, member := range members {
+		decoded[i] = zcl.AttributeDataTypeValue{DataType: member.DataType, Value: d.decode(member.DataType, member.Value)}
+	}
+
+	return decoded, nil
+}
+
+// decodingGlobalCommunicator wraps zclGlobalCommunicator, decoding the values of attributes read with the gateway's
+// decoders.
+type decodingGlobalCommunicator struct {
+	zclGlobalCommunicator
+	decoders *attributeDecoders
+}
+
+func (r *decodingGlobalCommunicator) ReadAttributes(ctx context.Context, ieeeAddress zigbee.IEEEAddress, requireAck bool, cluster zigbee.ClusterID, code zigbee.ManufacturerCode, sourceEndpoint zigbee.Endpoint, destEndpoint zigbee.Endpoint, transactionSequence uint8, attributes []zcl.AttributeID) ([]global.ReadAttributeResponseRecord, error) {
+	records, err := r.zclGlobalCommunicator.ReadAttributes(ctx, ieeeAddress, requireAck, cluster, code, sourceEndpoint, destEndpoint, transactionSequence, attributes)
+	return r.decoders.decodeRecords(records), err
+}
+
+// decodingCommunicatorRequests wraps zclCommunicatorRequests, decoding the values of any attribute read sent directly
+// as a request with the gateway's decoders.
+type decodingCommunicatorRequests struct {
+	zclCommunicatorRequests
+	decoders *attributeDecoders
+}
+
+func (r *decodingCommunicatorRequests) RequestResponse(ctx context.Context, address zigbee.IEEEAddress, requireAck bool, message zcl.Message) (zcl.Message, error) {
+	response, err := r.zclCommunicatorRequests.RequestResponse(ctx, address, requireAck, message)
+
+	if readResponse, ok := response.Command.(*global.ReadAttributesResponse); err == nil && ok {
+		response.Command = &global.ReadAttributesResponse{Records: r.decoders.decodeRecords(readResponse.Records)}
+	}
+
+	return response, err
+}
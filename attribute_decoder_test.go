@@ -0,0 +1,136 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestDecodeAttributeValue(t *testing.T) {
+	t.Run("returns values of types without a decoder unaltered", func(t *testing.T) {
+		assert.Equal(t, uint64(12), DecodeAttributeValue(zcl.TypeUnsignedInt16, uint64(12)))
+		assert.Equal(t, "name", DecodeAttributeValue(zcl.TypeStringCharacter8, "name"))
+	})
+
+	t.Run("returns octet strings as raw bytes", func(t *testing.T) {
+		assert.Equal(t, []byte{0x01, 0x02}, DecodeAttributeValue(zcl.TypeStringOctet8, string([]byte{0x01, 0x02})))
+	})
+
+	t.Run("decodes the elements of arrays and the members of structures", func(t *testing.T) {
+		array := zcl.AttributeSlice{DataType: zcl.TypeStringOctet8, Values: []interface{}{"a", "b"}}
+		assert.Equal(t, zcl.AttributeSlice{DataType: zcl.TypeStringOctet8, Values: []interface{}{[]byte("a"), []byte("b")}}, DecodeAttributeValue(zcl.TypeArray, array))
+
+		structure := []zcl.AttributeDataTypeValue{
+			{DataType: zcl.TypeUnsignedInt8, Value: uint64(1)},
+			{DataType: zcl.TypeStringOctet8, Value: "c"},
+		}
+		assert.Equal(t, []zcl.AttributeDataTypeValue{
+			{DataType: zcl.TypeUnsignedInt8, Value: uint64(1)},
+			{DataType: zcl.TypeStringOctet8, Value: []byte("c")},
+		}, DecodeAttributeValue(zcl.TypeStructure, structure))
+	})
+
+	t.Run("returns values of types unknown to zcl as raw bytes", func(t *testing.T) {
+		assert.Equal(t, []byte("ab"), DecodeAttributeValue(zcl.AttributeDataType(0xfe), "ab"))
+	})
+}
+
+func TestAttributeDecoders_decode(t *testing.T) {
+	const vendorType = zcl.AttributeDataType(0xfe)
+
+	decodeLength := func(value interface{}) (interface{}, error) {
+		if value.(string) == "bad" {
+			return nil, errors.New("failure")
+		}
+
+		return len(value.(string)), nil
+	}
+
+	t.Run("uses a decoder which has been set, and falls back to raw bytes if it fails", func(t *testing.T) {
+		d := newAttributeDecoders()
+		d.set(vendorType, decodeLength)
+
+		assert.Equal(t, 4, d.decode(vendorType, "good"))
+		assert.Equal(t, []byte("bad"), d.decode(vendorType, "bad"))
+	})
+
+	t.Run("decodes members of composite types with the decoders which have been set", func(t *testing.T) {
+		d := newAttributeDecoders()
+		d.set(vendorType, decodeLength)
+
+		array := zcl.AttributeSlice{DataType: vendorType, Values: []interface{}{"a", "bc"}}
+		assert.Equal(t, zcl.AttributeSlice{DataType: vendorType, Values: []interface{}{1, 2}}, d.decode(zcl.TypeArray, array))
+	})
+
+	t.Run("does not alter the default decoders", func(t *testing.T) {
+		d := newAttributeDecoders()
+		d.set(zcl.TypeStringOctet8, nil)
+
+		assert.Equal(t, "c", d.decode(zcl.TypeStringOctet8, "c"))
+		assert.Equal(t, []byte("c"), DecodeAttributeValue(zcl.TypeStringOctet8, "c"))
+	})
+}
+
+func TestDecodingGlobalCommunicator_ReadAttributes(t *testing.T) {
+	t.Run("decodes the values of attributes which were read successfully", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		raw := &zcl.AttributeDataTypeValue{DataType: zcl.TypeStringOctet8, Value: string([]byte{0xde, 0xad})}
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, zigbee.IEEEAddress(0x01), false, zcl.BasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(1), uint8(1), []zcl.AttributeID{0x4000, 0x4001}).
+			Return([]global.ReadAttributeResponseRecord{
+				{Identifier: 0x4000, DataTypeValue: raw},
+				{Identifier: 0x4001, Status: 0x86},
+			}, nil)
+
+		c := &decodingGlobalCommunicator{zclGlobalCommunicator: &mockZclGlobalCommunicator, decoders: newAttributeDecoders()}
+
+		records, err := c.ReadAttributes(context.Background(), zigbee.IEEEAddress(0x01), false, zcl.BasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, 1, 1, []zcl.AttributeID{0x4000, 0x4001})
+		assert.NoError(t, err)
+
+		results := parseReadAttributeResponse(records)
+
+		value, ok := results.bytesValue(0x4000)
+		assert.True(t, ok)
+		assert.Equal(t, []byte{0xde, 0xad}, value)
+		assert.Equal(t, uint8(0x86), results.failed[0x4001])
+
+		assert.Equal(t, string([]byte{0xde, 0xad}), raw.Value)
+	})
+}
+
+func TestDecodingCommunicatorRequests_RequestResponse(t *testing.T) {
+	t.Run("decodes the values of read attribute responses", func(t *testing.T) {
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		request := zcl.Message{
+			ClusterID:           zcl.BasicId,
+			DestinationEndpoint: 1,
+			Command:             &global.ReadAttributes{Identifier: []zcl.AttributeID{0x4000}},
+		}
+
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, zigbee.IEEEAddress(0x01), false, request).Return(zcl.Message{
+			Command: &global.ReadAttributesResponse{
+				Records: []global.ReadAttributeResponseRecord{
+					{Identifier: 0x4000, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeStringOctet8, Value: "ab"}},
+				},
+			},
+		}, nil)
+
+		c := &decodingCommunicatorRequests{zclCommunicatorRequests: &mockZclCommunicatorRequests, decoders: newAttributeDecoders()}
+
+		response, err := c.RequestResponse(context.Background(), zigbee.IEEEAddress(0x01), false, request)
+		assert.NoError(t, err)
+
+		value, ok := parseReadAttributeResponse(response.Command.(*global.ReadAttributesResponse).Records).bytesValue(0x4000)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("ab"), value)
+	})
+}
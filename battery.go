@@ -0,0 +1,281 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+	"time"
+)
+
+const (
+	BatteryVoltage             = zcl.AttributeID(0x0020)
+	BatteryPercentageRemaining = zcl.AttributeID(0x0021)
+)
+
+// batteryInvalidValue is reported by devices for either attribute if they are unable to measure it.
+const batteryInvalidValue = uint64(0xff)
+
+// batteryPollInterval is slow as battery levels change over weeks, and each read wakes the device.
+const batteryPollInterval = time.Hour
+
+// DefaultLowBatteryThreshold is the percentage remaining below which BatteryLow is sent, unless altered with
+// WithLowBatteryThreshold.
+const DefaultLowBatteryThreshold = 10.0
+
+// Battery is a capability which signifies that a device is battery powered, and reports the state of its battery.
+type Battery interface {
+	// Status returns the last state of the battery reported by the device.
+	Status(context.Context, da.Device) (BatteryStatus, error)
+	// LifeRemaining returns the estimated time until the device's battery is exhausted.
+	LifeRemaining(context.Context, da.Device) (time.Duration, error)
+}
+
+// BatteryStatus is the state of a device's battery. Devices may report only one of voltage or percentage remaining.
+type BatteryStatus struct {
+	// Voltage of the battery in volts, only valid if HasVoltage is true.
+	Voltage    float64
+	HasVoltage bool
+	// Percentage of the battery remaining from 0 to 100, only valid if HasPercentage is true.
+	Percentage    float64
+	HasPercentage bool
+}
+
+// BatteryStatusChanged is sent to inform consumers that the state of a device's battery has changed.
+type BatteryStatusChanged struct {
+	// Device whose battery has changed.
+	Device da.Device
+	// New state of the battery.
+	Status BatteryStatus
+}
+
+// BatteryLow is sent when the percentage remaining of a device's battery drops below the low battery threshold. It is
+// not sent again until the percentage has risen back to the threshold, such as when the battery is replaced.
+type BatteryLow struct {
+	// Device whose battery is low.
+	Device da.Device
+	// State of the battery.
+	Status BatteryStatus
+}
+
+// batteryState is the raw values read from the device, voltage is in units of 100mV and percentage in units of half a
+// percent. Each received is false until the first valid value has been read. low and lifeLow are true once BatteryLow
+// and BatteryLifeLow have been sent respectively.
+type batteryState struct {
+	voltage            uint64
+	voltageReceived    bool
+	percentage         uint64
+	percentageReceived bool
+	low                bool
+
+	history []batterySample
+	lifeLow bool
+}
+
+func (s batteryState) status() BatteryStatus {
+	return BatteryStatus{
+		Voltage:       float64(s.voltage) / 10,
+		HasVoltage:    s.voltageReceived,
+		Percentage:    float64(s.percentage) / 2,
+		HasPercentage: s.percentageReceived,
+	}
+}
+
+type ZigbeeBattery struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+
+	zclGlobalCommunicator zclGlobalCommunicator
+
+	poller      poller
+	eventSender eventSender
+
+	lowThreshold     float64
+	lifeLowThreshold time.Duration
+
+	now func() time.Time
+}
+
+func (z *ZigbeeBattery) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+	z.internalCallbacks.Add(z.NodeJoinCallback)
+}
+
+func (z *ZigbeeBattery) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, BatteryFlag); found {
+			addCapability(&dev.device, BatteryFlag)
+			setCapabilityUpdateMode(z.eventSender, dev, BatteryFlag, UpdateModePolled, "polled")
+
+			if err := z.readBattery(ctx, node, dev, endpoint, cluster); err != nil {
+				log.Printf("failed to read battery: %s", err)
+			}
+		} else {
+			removeCapability(&dev.device, BatteryFlag)
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (z *ZigbeeBattery) NodeJoinCallback(ctx context.Context, join internalNodeJoin) error {
+	z.poller.AddNode(join.node, batteryPollInterval, z.pollNode)
+	return nil
+}
+
+// readBattery reads the voltage and percentage remaining from the device and updates the cached state. The node mutex
+// must be held, and the device mutex held for writing, by the caller.
+func (z *ZigbeeBattery) readBattery(ctx context.Context, iNode *internalNode, iDevice *internalDevice, endpoint zigbee.Endpoint, cluster zigbee.ClusterID) error {
+	response, _, err := readAttributesWithEndpointFallback(ctx, z.zclGlobalCommunicator, z.eventSender, iNode, iDevice, BatteryFlag, endpoint, cluster, []zcl.AttributeID{BatteryVoltage, BatteryPercentageRemaining})
+
+	if err == nil {
+		results := parseReadAttributeResponse(response)
+		state := iDevice.batteryState
+
+		if value, ok := results.uintValue(BatteryVoltage); ok && value != batteryInvalidValue {
+			state.voltage = value
+			state.voltageReceived = true
+		}
+
+		if value, ok := results.uintValue(BatteryPercentageRemaining); ok && value != batteryInvalidValue {
+			state.percentage = value
+			state.percentageReceived = true
+			state.history = recordBatterySample(state.history, z.now(), float64(value)/2)
+		}
+
+		z.setBatteryState(iDevice, state)
+	}
+
+	return err
+}
+
+// setBatteryState records the state of the device's battery, sending an event if it has changed, BatteryLow if the
+// percentage remaining has dropped below the threshold, and BatteryLifeLow if the estimated life remaining has dropped
+// below its threshold. The device mutex must be held for writing by the caller.
+func (z *ZigbeeBattery) setBatteryState(iDevice *internalDevice, state batteryState) {
+	previous := iDevice.batteryState
+
+	if state.voltageReceived || state.percentageReceived {
+		markCapabilityUpdated(iDevice, BatteryFlag)
+	}
+
+	if state.percentageReceived {
+		state.low = state.status().Percentage < z.lowThreshold
+	}
+
+	remaining, estimated := estimateBatteryLife(state.history)
+	state.lifeLow = estimated && remaining < z.lifeLowThreshold
+
+	iDevice.batteryState = state
+
+	status := state.status()
+
+	if previous.status() != status {
+		z.eventSender.sendEvent(BatteryStatusChanged{Device: iDevice.device, Status: status})
+	}
+
+	if state.low && !previous.low {
+		z.eventSender.sendEvent(BatteryLow{Device: iDevice.device, Status: status})
+	}
+
+	if state.lifeLow && !previous.lifeLow {
+		z.eventSender.sendEvent(BatteryLifeLow{Device: iDevice.device, Remaining: remaining})
+	}
+}
+
+func (z *ZigbeeBattery) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(BatteryFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+// Status returns the last state of the battery reported by the device. NoReadingAvailable is returned if the device
+// has reported neither its voltage nor percentage remaining, or its state has become stale.
+func (z *ZigbeeBattery) Status(ctx context.Context, device da.Device) (BatteryStatus, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return BatteryStatus{}, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	state := iDevice.batteryState
+
+	if (!state.voltageReceived && !state.percentageReceived) || capabilityUnavailable(iDevice, BatteryFlag) {
+		return BatteryStatus{}, NoReadingAvailable
+	}
+
+	return state.status(), nil
+}
+
+// stateValues returns the voltage and percentage remaining of the battery, each only if the device reports it.
+func (z *ZigbeeBattery) stateValues(ctx context.Context, device da.Device) ([]StateValue, error) {
+	status, err := z.Status(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []StateValue
+
+	if status.HasVoltage {
+		values = append(values, StateValue{Name: "voltage", Value: status.Voltage, Unit: UnitVolt})
+	}
+
+	if status.HasPercentage {
+		values = append(values, StateValue{Name: "percentage", Value: status.Percentage, Unit: UnitPercent})
+	}
+
+	return values, nil
+}
+
+func (z *ZigbeeBattery) pollNode(pctx context.Context, iNode *internalNode) {
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	for _, iDevice := range iNode.devices {
+		z.pollDevice(pctx, iNode, iDevice)
+	}
+}
+
+// pollDevice reads the battery of the device, if it has the capability. The node mutex must be held by the caller.
+func (z *ZigbeeBattery) pollDevice(pctx context.Context, iNode *internalNode, iDevice *internalDevice) {
+	iDevice.mutex.Lock()
+	defer iDevice.mutex.Unlock()
+
+	if !iDevice.device.HasCapability(BatteryFlag) {
+		return
+	}
+
+	if endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, BatteryFlag); found {
+		if err := z.readBattery(pctx, iNode, iDevice, endpoint, cluster); err != nil {
+			log.Printf("failed to query battery in zda: %s", err)
+		}
+	}
+}
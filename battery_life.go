@@ -0,0 +1,132 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"time"
+)
+
+const (
+	// batteryHistoryInterval is the minimum time between samples retained for the battery life estimate, battery
+	// levels change too slowly for every poll to be useful.
+	batteryHistoryInterval = 6 * time.Hour
+	// batteryHistorySize is the number of samples retained, covering a week at batteryHistoryInterval.
+	batteryHistorySize = 28
+	// batteryHistoryMinimumSamples is the number of samples required before a battery life estimate is made.
+	batteryHistoryMinimumSamples = 3
+	// batterySmoothingFactor is the weight given to a new reading when smoothing, damping the bounce of battery
+	// percentages which rise and fall with temperature and load.
+	batterySmoothingFactor = 0.3
+	// batteryReplacedIncrease is the rise in percentage remaining over the smoothed value which is taken to mean the
+	// battery has been replaced or recharged, discarding the history.
+	batteryReplacedIncrease = 20.0
+)
+
+// DefaultBatteryLifeThreshold is the estimated battery life remaining below which BatteryLifeLow is sent, unless
+// altered with WithBatteryLifeThreshold.
+const DefaultBatteryLifeThreshold = 7 * 24 * time.Hour
+
+// BatteryLifeLow is sent when the estimated battery life remaining of a device drops below the battery life threshold.
+// It is not sent again until the estimate has risen back to the threshold, such as when the battery is replaced.
+type BatteryLifeLow struct {
+	// Device whose battery is expected to run out.
+	Device da.Device
+	// Estimated battery life remaining.
+	Remaining time.Duration
+}
+
+// batterySample is a reading of percentage remaining retained for the battery life estimate, along with the
+// smoothed percentage at that time.
+type batterySample struct {
+	time     time.Time
+	smoothed float64
+}
+
+// recordBatterySample adds the percentage remaining read at the time provided to the history, returning the new
+// history. Samples within batteryHistoryInterval of the previous sample are not retained, and the history is
+// restarted if the battery appears to have been replaced.
+func recordBatterySample(history []batterySample, at time.Time, percentage float64) []batterySample {
+	if len(history) == 0 {
+		return []batterySample{{time: at, smoothed: percentage}}
+	}
+
+	last := history[len(history)-1]
+
+	if percentage-last.smoothed >= batteryReplacedIncrease {
+		return []batterySample{{time: at, smoothed: percentage}}
+	}
+
+	if at.Sub(last.time) < batteryHistoryInterval {
+		return history
+	}
+
+	smoothed := batterySmoothingFactor*percentage + (1-batterySmoothingFactor)*last.smoothed
+	history = append(history, batterySample{time: at, smoothed: smoothed})
+
+	if len(history) > batteryHistorySize {
+		history = history[len(history)-batteryHistorySize:]
+	}
+
+	return history
+}
+
+// estimateBatteryLife fits a line to the smoothed history, returning the time until it reaches zero from the latest
+// sample. False is returned if there is insufficient history, or the battery is not discharging.
+func estimateBatteryLife(history []batterySample) (time.Duration, bool) {
+	if len(history) < batteryHistoryMinimumSamples {
+		return 0, false
+	}
+
+	var meanHours, meanPercentage float64
+
+	for _, sample := range history {
+		meanHours += sample.time.Sub(history[0].time).Hours()
+		meanPercentage += sample.smoothed
+	}
+
+	meanHours /= float64(len(history))
+	meanPercentage /= float64(len(history))
+
+	var covariance, variance float64
+
+	for _, sample := range history {
+		hours := sample.time.Sub(history[0].time).Hours() - meanHours
+		covariance += hours * (sample.smoothed - meanPercentage)
+		variance += hours * hours
+	}
+
+	if variance == 0 {
+		return 0, false
+	}
+
+	slope := covariance / variance
+
+	if slope >= 0 {
+		return 0, false
+	}
+
+	remainingHours := history[len(history)-1].smoothed / -slope
+
+	return time.Duration(remainingHours * float64(time.Hour)), true
+}
+
+// LifeRemaining returns the estimated time until the device's battery is exhausted, based on the rate the percentage
+// remaining has fallen over the last week. NoReadingAvailable is returned until enough history has been gathered, or
+// if the battery does not appear to be discharging.
+func (z *ZigbeeBattery) LifeRemaining(ctx context.Context, device da.Device) (time.Duration, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return 0, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	remaining, estimated := estimateBatteryLife(iDevice.batteryState.history)
+
+	if !estimated {
+		return 0, NoReadingAvailable
+	}
+
+	return remaining, nil
+}
@@ -0,0 +1,134 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func Test_recordBatterySample(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("retains samples no more often than the history interval, smoothing each", func(t *testing.T) {
+		history := recordBatterySample(nil, start, 80)
+		history = recordBatterySample(history, start.Add(time.Hour), 10)
+		history = recordBatterySample(history, start.Add(batteryHistoryInterval), 70)
+
+		assert.Equal(t, []batterySample{
+			{time: start, smoothed: 80},
+			{time: start.Add(batteryHistoryInterval), smoothed: 77},
+		}, history)
+	})
+
+	t.Run("restarts the history if the battery appears to have been replaced", func(t *testing.T) {
+		history := recordBatterySample(nil, start, 30)
+		history = recordBatterySample(history, start.Add(time.Minute), 100)
+
+		assert.Equal(t, []batterySample{{time: start.Add(time.Minute), smoothed: 100}}, history)
+	})
+
+	t.Run("retains only the most recent samples", func(t *testing.T) {
+		var history []batterySample
+
+		for i := 0; i < batteryHistorySize+5; i++ {
+			history = recordBatterySample(history, start.Add(time.Duration(i)*batteryHistoryInterval), 100-float64(i))
+		}
+
+		assert.Len(t, history, batteryHistorySize)
+		assert.Equal(t, start.Add(5*batteryHistoryInterval), history[0].time)
+	})
+}
+
+func Test_estimateBatteryLife(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("requires a minimum number of samples", func(t *testing.T) {
+		_, estimated := estimateBatteryLife([]batterySample{{time: start, smoothed: 50}, {time: start.Add(time.Hour), smoothed: 49}})
+		assert.False(t, estimated)
+	})
+
+	t.Run("estimates the time until the battery reaches zero from a linear fit", func(t *testing.T) {
+		remaining, estimated := estimateBatteryLife([]batterySample{
+			{time: start, smoothed: 52},
+			{time: start.Add(24 * time.Hour), smoothed: 51},
+			{time: start.Add(48 * time.Hour), smoothed: 50},
+		})
+
+		assert.True(t, estimated)
+		assert.Equal(t, 50*24*time.Hour, remaining)
+	})
+
+	t.Run("does not estimate if the battery is not discharging", func(t *testing.T) {
+		_, estimated := estimateBatteryLife([]batterySample{
+			{time: start, smoothed: 50},
+			{time: start.Add(24 * time.Hour), smoothed: 51},
+			{time: start.Add(48 * time.Hour), smoothed: 50},
+		})
+
+		assert.False(t, estimated)
+	})
+}
+
+func TestZigbeeBattery_LifeRemaining(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zb := ZigbeeBattery{gateway: &mockGateway{}}
+
+		_, err := zb.LifeRemaining(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns the estimate, or no reading available if there is insufficient history", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zb := ZigbeeBattery{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zb.gateway
+		device.device.Capabilities = []da.Capability{BatteryFlag}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		_, err := zb.LifeRemaining(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+
+		start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		device.batteryState.history = []batterySample{
+			{time: start, smoothed: 12},
+			{time: start.Add(24 * time.Hour), smoothed: 11},
+			{time: start.Add(48 * time.Hour), smoothed: 10},
+		}
+
+		remaining, err := zb.LifeRemaining(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, 10*24*time.Hour, remaining)
+	})
+}
+
+func TestZigbeeBattery_setBatteryState_LifeLow(t *testing.T) {
+	t.Run("sends battery life low once when the estimate drops below the threshold", func(t *testing.T) {
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zb := ZigbeeBattery{eventSender: &mockEventSender, lifeLowThreshold: DefaultBatteryLifeThreshold}
+
+		_, device := generateTestNodeAndDevice()
+
+		start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		state := batteryState{percentage: 12, percentageReceived: true, history: []batterySample{
+			{time: start, smoothed: 8},
+			{time: start.Add(24 * time.Hour), smoothed: 7},
+			{time: start.Add(48 * time.Hour), smoothed: 6},
+		}}
+
+		mockEventSender.On("sendEvent", mock.IsType(BatteryStatusChanged{})).Once()
+		mockEventSender.On("sendEvent", BatteryLifeLow{Device: device.device, Remaining: 6 * 24 * time.Hour}).Once()
+
+		zb.setBatteryState(device, state)
+		zb.setBatteryState(device, state)
+
+		assert.True(t, device.batteryState.lifeLow)
+	})
+}
@@ -0,0 +1,189 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZigbeeBattery_Contract(t *testing.T) {
+	t.Run("can be assigned to a Battery", func(t *testing.T) {
+		assert.Implements(t, (*Battery)(nil), new(ZigbeeBattery))
+	})
+}
+
+func batteryResponse(voltage uint64, percentage uint64) []global.ReadAttributeResponseRecord {
+	return []global.ReadAttributeResponseRecord{
+		{
+			Identifier:    BatteryVoltage,
+			DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeUnsignedInt8, Value: voltage},
+		},
+		{
+			Identifier:    BatteryPercentageRemaining,
+			DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeUnsignedInt8, Value: percentage},
+		},
+	}
+}
+
+func TestZigbeeBattery_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with cluster and reads the battery", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zb := ZigbeeBattery{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+			lowThreshold:          DefaultLowBatteryThreshold,
+			now:                   newFakeClock().Now,
+		}
+
+		node, device := generateTestNodeAndDevice()
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.PowerConfigurationId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.PowerConfigurationId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(1), []zcl.AttributeID{BatteryVoltage, BatteryPercentageRemaining}).Return(batteryResponse(29, 175), nil)
+		mockEventSender.On("sendEvent", mock.MatchedBy(func(e BatteryStatusChanged) bool {
+			return e.Device.Identifier == device.device.Identifier && e.Status == BatteryStatus{Voltage: 2.9, HasVoltage: true, Percentage: 87.5, HasPercentage: true}
+		}))
+
+		err := zb.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(BatteryFlag))
+		assert.Equal(t, uint64(29), device.batteryState.voltage)
+		assert.Equal(t, uint64(175), device.batteryState.percentage)
+		assert.Len(t, device.batteryState.history, 1)
+	})
+
+	t.Run("removes capability from device without cluster", func(t *testing.T) {
+		zb := ZigbeeBattery{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{BatteryFlag}
+
+		err := zb.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(BatteryFlag))
+	})
+}
+
+func TestZigbeeBattery_NodeJoinCallback(t *testing.T) {
+	t.Run("registers new nodes with the poller when they join", func(t *testing.T) {
+		node := &internalNode{}
+
+		mockPoller := mockPoller{}
+		defer mockPoller.AssertExpectations(t)
+
+		zb := ZigbeeBattery{poller: &mockPoller}
+
+		mockPoller.On("AddNode", node, batteryPollInterval, mock.AnythingOfType("func(context.Context, *zda.internalNode)"))
+
+		err := zb.NodeJoinCallback(context.Background(), internalNodeJoin{node: node})
+		assert.NoError(t, err)
+	})
+}
+
+func TestZigbeeBattery_Status(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zb := ZigbeeBattery{gateway: &mockGateway{}}
+
+		_, err := zb.Status(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns error if device does not support it", func(t *testing.T) {
+		zb := ZigbeeBattery{gateway: &mockGateway{}}
+
+		_, err := zb.Status(context.Background(), da.Device{Gateway: zb.gateway})
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns the last status, or no reading available if nothing has been received", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zb := ZigbeeBattery{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zb.gateway
+		device.device.Capabilities = []da.Capability{BatteryFlag}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		_, err := zb.Status(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+
+		device.batteryState = batteryState{voltage: 30, voltageReceived: true}
+
+		status, err := zb.Status(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, BatteryStatus{Voltage: 3.0, HasVoltage: true}, status)
+	})
+}
+
+func TestZigbeeBattery_pollNode(t *testing.T) {
+	t.Run("sends battery low once when the percentage drops below the threshold, ignoring invalid values", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zb := ZigbeeBattery{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+			lowThreshold:          20,
+			now:                   newFakeClock().Now,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{BatteryFlag}
+		device.batteryState = batteryState{voltage: 28, voltageReceived: true, percentage: 50, percentageReceived: true}
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.PowerConfigurationId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.PowerConfigurationId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(1), []zcl.AttributeID{BatteryVoltage, BatteryPercentageRemaining}).Return(batteryResponse(26, 30), nil).Once()
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.PowerConfigurationId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(2), []zcl.AttributeID{BatteryVoltage, BatteryPercentageRemaining}).Return(batteryResponse(batteryInvalidValue, batteryInvalidValue), nil).Once()
+
+		mockEventSender.On("sendEvent", mock.IsType(BatteryStatusChanged{})).Once()
+		mockEventSender.On("sendEvent", mock.MatchedBy(func(e BatteryLow) bool {
+			return e.Status.Percentage == 15
+		})).Once()
+
+		zb.pollNode(context.Background(), node)
+		zb.pollNode(context.Background(), node)
+
+		assert.True(t, device.batteryState.low)
+		assert.Equal(t, uint64(30), device.batteryState.percentage)
+	})
+
+	t.Run("rearms battery low once the percentage rises to the threshold", func(t *testing.T) {
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zb := ZigbeeBattery{eventSender: &mockEventSender, lowThreshold: 20}
+
+		_, device := generateTestNodeAndDevice()
+		device.batteryState = batteryState{percentage: 30, percentageReceived: true, low: true}
+
+		mockEventSender.On("sendEvent", mock.IsType(BatteryStatusChanged{})).Once()
+
+		zb.setBatteryState(device, batteryState{percentage: 200, percentageReceived: true})
+
+		assert.False(t, device.batteryState.low)
+	})
+}
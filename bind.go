@@ -0,0 +1,107 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zigbee"
+	"sort"
+	"time"
+)
+
+const DefaultBindRetries = DefaultNetworkRetries
+const bindInitialBackoff = 50 * time.Millisecond
+
+// DeviceBindFailed is sent when zda has exhausted all attempts to bind a cluster on a device to the gateway, features
+// which depend upon attribute reporting should fall back to polling.
+type DeviceBindFailed struct {
+	Device   da.Device
+	Endpoint zigbee.Endpoint
+	Cluster  zigbee.ClusterID
+	Error    error
+}
+
+// NodeBindingStatus records the outcome of the last attempt to bind a cluster on a node to the gateway.
+type NodeBindingStatus struct {
+	Endpoint  zigbee.Endpoint
+	Cluster   zigbee.ClusterID
+	Bound     bool
+	Attempts  int
+	LastError string
+}
+
+type nodeBindingKey struct {
+	endpoint zigbee.Endpoint
+	cluster  zigbee.ClusterID
+}
+
+// bindDeviceToController binds the cluster on the devices endpoint to the gateway, retrying with an exponential backoff
+// on failure. The providers Bind_rsp status is surfaced as an error, so any non success status is retried. The final
+// status is recorded on the node, and DeviceBindFailed is sent if all attempts fail. The node mutex must be held by
+// the caller.
+func bindDeviceToController(ctx context.Context, nodeBinder zigbee.NodeBinder, sender eventSender, iNode *internalNode, iDev *internalDevice, endpoint zigbee.Endpoint, cluster zigbee.ClusterID) error {
+	status := NodeBindingStatus{Endpoint: endpoint, Cluster: cluster}
+	backoff := bindInitialBackoff
+
+	var err error
+
+	for status.Attempts < DefaultBindRetries {
+		status.Attempts++
+
+		attemptCtx, cancel := context.WithTimeout(ctx, DefaultNetworkTimeout)
+		err = nodeBinder.BindNodeToController(attemptCtx, iNode.ieeeAddress, endpoint, DefaultGatewayHomeAutomationEndpoint, cluster)
+		cancel()
+
+		if err == nil || status.Attempts >= DefaultBindRetries {
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	status.Bound = err == nil
+
+	if err != nil {
+		status.LastError = err.Error()
+	}
+
+	if iNode.bindings == nil {
+		iNode.bindings = map[nodeBindingKey]NodeBindingStatus{}
+	}
+
+	iNode.bindings[nodeBindingKey{endpoint: endpoint, cluster: cluster}] = status
+
+	if err != nil {
+		sender.sendEvent(DeviceBindFailed{Device: iDev.device, Endpoint: endpoint, Cluster: cluster, Error: err})
+	}
+
+	return err
+}
+
+// bindingStatuses returns the binding statuses of a node ordered by endpoint and cluster, the node mutex must be held
+// by the caller.
+func bindingStatuses(iNode *internalNode) []NodeBindingStatus {
+	var statuses []NodeBindingStatus
+
+	for _, status := range iNode.bindings {
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Endpoint == statuses[j].Endpoint {
+			return statuses[i].Cluster < statuses[j].Cluster
+		}
+
+		return statuses[i].Endpoint < statuses[j].Endpoint
+	})
+
+	return statuses
+}
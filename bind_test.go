@@ -0,0 +1,71 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"github.com/shimmeringbee/zcl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func Test_bindDeviceToController(t *testing.T) {
+	t.Run("records a successful bind on the node", func(t *testing.T) {
+		mockNodeBinder := mockNodeBinder{}
+		mockEventSender := mockEventSender{}
+
+		node, device := generateTestNodeAndDevice()
+		endpoint := node.endpoints[0]
+
+		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, endpoint, DefaultGatewayHomeAutomationEndpoint, zcl.OnOffId).Return(nil).Once()
+
+		err := bindDeviceToController(context.Background(), &mockNodeBinder, &mockEventSender, node, device, endpoint, zcl.OnOffId)
+		assert.NoError(t, err)
+
+		expected := []NodeBindingStatus{{Endpoint: endpoint, Cluster: zcl.OnOffId, Bound: true, Attempts: 1}}
+		assert.Equal(t, expected, bindingStatuses(node))
+
+		mockNodeBinder.AssertExpectations(t)
+		mockEventSender.AssertExpectations(t)
+	})
+
+	t.Run("retries a failed bind and records the number of attempts", func(t *testing.T) {
+		mockNodeBinder := mockNodeBinder{}
+		mockEventSender := mockEventSender{}
+
+		node, device := generateTestNodeAndDevice()
+		endpoint := node.endpoints[0]
+
+		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, endpoint, DefaultGatewayHomeAutomationEndpoint, zcl.OnOffId).Return(errors.New("failure")).Once()
+		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, endpoint, DefaultGatewayHomeAutomationEndpoint, zcl.OnOffId).Return(nil).Once()
+
+		err := bindDeviceToController(context.Background(), &mockNodeBinder, &mockEventSender, node, device, endpoint, zcl.OnOffId)
+		assert.NoError(t, err)
+
+		expected := []NodeBindingStatus{{Endpoint: endpoint, Cluster: zcl.OnOffId, Bound: true, Attempts: 2}}
+		assert.Equal(t, expected, bindingStatuses(node))
+
+		mockNodeBinder.AssertExpectations(t)
+	})
+
+	t.Run("sends an event and records the error if all attempts fail", func(t *testing.T) {
+		mockNodeBinder := mockNodeBinder{}
+		mockEventSender := mockEventSender{}
+
+		node, device := generateTestNodeAndDevice()
+		endpoint := node.endpoints[0]
+		expectedError := errors.New("failure")
+
+		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, endpoint, DefaultGatewayHomeAutomationEndpoint, zcl.OnOffId).Return(expectedError).Times(DefaultBindRetries)
+		mockEventSender.On("sendEvent", DeviceBindFailed{Device: device.device, Endpoint: endpoint, Cluster: zcl.OnOffId, Error: expectedError}).Once()
+
+		err := bindDeviceToController(context.Background(), &mockNodeBinder, &mockEventSender, node, device, endpoint, zcl.OnOffId)
+		assert.Equal(t, expectedError, err)
+
+		expected := []NodeBindingStatus{{Endpoint: endpoint, Cluster: zcl.OnOffId, Bound: false, Attempts: DefaultBindRetries, LastError: expectedError.Error()}}
+		assert.Equal(t, expected, bindingStatuses(node))
+
+		mockNodeBinder.AssertExpectations(t)
+		mockEventSender.AssertExpectations(t)
+	})
+}
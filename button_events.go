@@ -0,0 +1,211 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/local/onoff"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+	"sort"
+	"time"
+)
+
+type ButtonAction uint8
+
+const (
+	// ButtonOn indicates the button sent an On command.
+	ButtonOn ButtonAction = 0x00
+	// ButtonOff indicates the button sent an Off command.
+	ButtonOff ButtonAction = 0x01
+	// ButtonToggle indicates the button sent a Toggle command.
+	ButtonToggle ButtonAction = 0x02
+)
+
+// ButtonPress is a press of a button on a remote or wall switch.
+type ButtonPress struct {
+	// Button pressed, numbered from 1 in ascending order of the endpoints of the device which have an On/Off client.
+	Button uint8
+	// Endpoint the button's command was sent from.
+	Endpoint zigbee.Endpoint
+	// Action the button performed.
+	Action ButtonAction
+	// Time the press was received.
+	Time time.Time
+}
+
+// ButtonPressed is sent to inform consumers that a button on a device has been pressed.
+type ButtonPressed struct {
+	// Device whose button was pressed.
+	Device da.Device
+	// Press received from the device.
+	Press ButtonPress
+}
+
+// ZigbeeButtonEvents translates commands sent to the gateway by remotes and wall switches into button presses. Devices
+// are bound to the gateway for each endpoint with an On/Off client during enumeration.
+type ZigbeeButtonEvents struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+	nodeStore         nodeStore
+
+	zclCommunicatorCallbacks zclCommunicatorCallbacks
+	nodeBinder               zigbee.NodeBinder
+
+	eventSender eventSender
+
+	now func() time.Time
+}
+
+func (z *ZigbeeButtonEvents) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+
+	z.zclCommunicatorCallbacks.AddCallback(z.zclCommunicatorCallbacks.NewMatch(func(address zigbee.IEEEAddress, appMsg zigbee.ApplicationMessage, zclMessage zcl.Message) bool {
+		_, isAction := buttonActionForCommand(zclMessage.Command)
+		return isAction && zclMessage.ClusterID == zcl.OnOffId
+	}, z.incomingCommand))
+}
+
+func (z *ZigbeeButtonEvents) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		buttonEndpoints := findButtonEndpoints(node, dev)
+
+		if len(buttonEndpoints) > 0 {
+			addCapability(&dev.device, ButtonEventsFlag)
+
+			for _, endpoint := range buttonEndpoints {
+				if err := bindDeviceToController(ctx, z.nodeBinder, z.eventSender, node, dev, endpoint, zcl.OnOffId); err != nil {
+					log.Printf("failed to bind button to zda: %s", err)
+				}
+			}
+		} else {
+			removeCapability(&dev.device, ButtonEventsFlag)
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// findButtonEndpoints returns the endpoints of the device which have an On/Off client, in ascending order so that
+// button numbers are stable regardless of the order the node reported its endpoints. The node and device mutexes must
+// be held by the caller.
+func findButtonEndpoints(iNode *internalNode, iDev *internalDevice) []zigbee.Endpoint {
+	var endpoints []zigbee.Endpoint
+
+	for _, endpoint := range iDev.endpoints {
+		if isClusterIdInSlice(iNode.endpointDescriptions[endpoint].OutClusterList, zcl.OnOffId) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i] < endpoints[j] })
+
+	return endpoints
+}
+
+func buttonActionForCommand(command interface{}) (ButtonAction, bool) {
+	switch command.(type) {
+	case *onoff.On:
+		return ButtonOn, true
+	case *onoff.Off:
+		return ButtonOff, true
+	case *onoff.Toggle:
+		return ButtonToggle, true
+	default:
+		return 0, false
+	}
+}
+
+func (z *ZigbeeButtonEvents) incomingCommand(source communicator.MessageWithSource) {
+	node, found := z.nodeStore.getNode(source.SourceAddress)
+
+	if !found {
+		return
+	}
+
+	action, _ := buttonActionForCommand(source.Message.Command)
+
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+
+	for _, device := range node.devices {
+		device.mutex.Lock()
+
+		if device.device.HasCapability(ButtonEventsFlag) {
+			for i, endpoint := range findButtonEndpoints(node, device) {
+				if endpoint == source.Message.SourceEndpoint {
+					press := ButtonPress{Button: uint8(i + 1), Endpoint: endpoint, Action: action, Time: z.now()}
+
+					device.lastButtonPress = press
+					markCapabilityUpdated(device, ButtonEventsFlag)
+
+					z.eventSender.sendEvent(ButtonPressed{Device: device.device, Press: press})
+				}
+			}
+		}
+
+		device.mutex.Unlock()
+	}
+}
+
+func (z *ZigbeeButtonEvents) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(ButtonEventsFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+// LastPress returns the most recent button press received from the device, the Time of the press is zero if no button
+// has been pressed since the gateway started.
+func (z *ZigbeeButtonEvents) LastPress(ctx context.Context, device da.Device) (ButtonPress, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return ButtonPress{}, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	return iDevice.lastButtonPress, nil
+}
+
+// Buttons returns the number of buttons the device has, one for each endpoint with an On/Off client.
+func (z *ZigbeeButtonEvents) Buttons(ctx context.Context, device da.Device) (int, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return 0, err
+	}
+
+	iDevice.node.mutex.RLock()
+	defer iDevice.node.mutex.RUnlock()
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	return len(findButtonEndpoints(iDevice.node, iDevice)), nil
+}
@@ -0,0 +1,194 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/local/onoff"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestZigbeeButtonEvents_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability and binds each endpoint with an On/Off client", func(t *testing.T) {
+		mockNodeBinder := mockNodeBinder{}
+		defer mockNodeBinder.AssertExpectations(t)
+
+		zbe := ZigbeeButtonEvents{
+			nodeBinder: &mockNodeBinder,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.endpoints = []zigbee.Endpoint{1, 2, 3}
+
+		node.endpointDescriptions[1] = zigbee.EndpointDescription{Endpoint: 1, OutClusterList: []zigbee.ClusterID{zcl.OnOffId}}
+		node.endpointDescriptions[2] = zigbee.EndpointDescription{Endpoint: 2, InClusterList: []zigbee.ClusterID{zcl.OnOffId}}
+		node.endpointDescriptions[3] = zigbee.EndpointDescription{Endpoint: 3, OutClusterList: []zigbee.ClusterID{zcl.OnOffId}}
+
+		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, zigbee.Endpoint(1), DefaultGatewayHomeAutomationEndpoint, zcl.OnOffId).Return(nil)
+		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, zigbee.Endpoint(3), DefaultGatewayHomeAutomationEndpoint, zcl.OnOffId).Return(nil)
+
+		err := zbe.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(ButtonEventsFlag))
+	})
+
+	t.Run("removes capability if no endpoint has an On/Off client", func(t *testing.T) {
+		zbe := ZigbeeButtonEvents{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{ButtonEventsFlag}
+
+		err := zbe.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(ButtonEventsFlag))
+	})
+}
+
+func TestZigbeeButtonEvents_incomingCommand(t *testing.T) {
+	t.Run("translates a command into a press of the button on the source endpoint", func(t *testing.T) {
+		mockNodeStore := mockNodeStore{}
+		defer mockNodeStore.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		now := time.Now()
+
+		zbe := ZigbeeButtonEvents{
+			nodeStore:   &mockNodeStore,
+			eventSender: &mockEventSender,
+			now:         func() time.Time { return now },
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{ButtonEventsFlag}
+		device.endpoints = []zigbee.Endpoint{1, 2}
+
+		node.endpointDescriptions[1] = zigbee.EndpointDescription{Endpoint: 1, OutClusterList: []zigbee.ClusterID{zcl.OnOffId}}
+		node.endpointDescriptions[2] = zigbee.EndpointDescription{Endpoint: 2, OutClusterList: []zigbee.ClusterID{zcl.OnOffId}}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+
+		expectedPress := ButtonPress{Button: 2, Endpoint: 2, Action: ButtonToggle, Time: now}
+		mockEventSender.On("sendEvent", ButtonPressed{Device: device.device, Press: expectedPress})
+
+		zbe.incomingCommand(communicator.MessageWithSource{
+			SourceAddress: node.ieeeAddress,
+			Message: zcl.Message{
+				FrameType:      zcl.FrameLocal,
+				Direction:      zcl.ClientToServer,
+				ClusterID:      zcl.OnOffId,
+				SourceEndpoint: 2,
+				Command:        &onoff.Toggle{},
+			},
+		})
+
+		assert.Equal(t, expectedPress, device.lastButtonPress)
+	})
+
+	t.Run("numbers the buttons of a 4 button remote by ascending endpoint", func(t *testing.T) {
+		mockNodeStore := mockNodeStore{}
+		defer mockNodeStore.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		now := time.Now()
+
+		zbe := ZigbeeButtonEvents{
+			nodeStore:   &mockNodeStore,
+			eventSender: &mockEventSender,
+			now:         func() time.Time { return now },
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{ButtonEventsFlag}
+		device.endpoints = []zigbee.Endpoint{4, 2, 1, 3}
+
+		for _, endpoint := range device.endpoints {
+			node.endpointDescriptions[endpoint] = zigbee.EndpointDescription{Endpoint: endpoint, OutClusterList: []zigbee.ClusterID{zcl.OnOffId}}
+		}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true).Twice()
+
+		mockEventSender.On("sendEvent", ButtonPressed{Device: device.device, Press: ButtonPress{Button: 1, Endpoint: 1, Action: ButtonOn, Time: now}})
+		mockEventSender.On("sendEvent", ButtonPressed{Device: device.device, Press: ButtonPress{Button: 4, Endpoint: 4, Action: ButtonOff, Time: now}})
+
+		zbe.incomingCommand(communicator.MessageWithSource{
+			SourceAddress: node.ieeeAddress,
+			Message:       zcl.Message{FrameType: zcl.FrameLocal, ClusterID: zcl.OnOffId, SourceEndpoint: 1, Command: &onoff.On{}},
+		})
+
+		zbe.incomingCommand(communicator.MessageWithSource{
+			SourceAddress: node.ieeeAddress,
+			Message:       zcl.Message{FrameType: zcl.FrameLocal, ClusterID: zcl.OnOffId, SourceEndpoint: 4, Command: &onoff.Off{}},
+		})
+	})
+}
+
+func TestZigbeeButtonEvents_LastPress(t *testing.T) {
+	t.Run("returns error if device does not have capability", func(t *testing.T) {
+		zbe := ZigbeeButtonEvents{gateway: &mockGateway{}}
+
+		_, err := zbe.LastPress(context.Background(), da.Device{Gateway: zbe.gateway})
+		assert.Error(t, err)
+	})
+
+	t.Run("returns the last press of the device", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		zbe := ZigbeeButtonEvents{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zbe.gateway
+		device.device.Capabilities = []da.Capability{ButtonEventsFlag}
+		device.lastButtonPress = ButtonPress{Button: 1, Action: ButtonOn}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		press, err := zbe.LastPress(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, device.lastButtonPress, press)
+	})
+}
+
+func TestZigbeeButtonEvents_Buttons(t *testing.T) {
+	t.Run("returns error if device does not have capability", func(t *testing.T) {
+		zbe := ZigbeeButtonEvents{gateway: &mockGateway{}}
+
+		_, err := zbe.Buttons(context.Background(), da.Device{Gateway: zbe.gateway})
+		assert.Error(t, err)
+	})
+
+	t.Run("returns the number of endpoints with an On/Off client", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		zbe := ZigbeeButtonEvents{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zbe.gateway
+		device.device.Capabilities = []da.Capability{ButtonEventsFlag}
+		device.endpoints = []zigbee.Endpoint{1, 2, 3, 4, 5}
+
+		for _, endpoint := range device.endpoints[:4] {
+			node.endpointDescriptions[endpoint] = zigbee.EndpointDescription{Endpoint: endpoint, OutClusterList: []zigbee.ClusterID{zcl.OnOffId}}
+		}
+
+		node.endpointDescriptions[5] = zigbee.EndpointDescription{Endpoint: 5, InClusterList: []zigbee.ClusterID{zcl.OnOffId}}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		buttons, err := zbe.Buttons(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, buttons)
+	})
+}
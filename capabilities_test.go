@@ -59,3 +59,264 @@ func TestZigbeeGateway_ReturnsEnumerateCapabilitiesCapability(t *testing.T) {
 		assert.IsType(t, (*ZigbeeEnumerateDevice)(nil), actualZdd)
 	})
 }
+
+func TestZigbeeGateway_ReturnsIlluminanceLevelSensingCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actualZils := zgw.Capability(IlluminanceLevelSensingFlag)
+		assert.IsType(t, (*ZigbeeIlluminanceLevelSensing)(nil), actualZils)
+	})
+}
+
+func TestZigbeeGateway_ReturnsPriceCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actualZp := zgw.Capability(PriceFlag)
+		assert.IsType(t, (*ZigbeePrice)(nil), actualZp)
+	})
+}
+
+func TestZigbeeGateway_ReturnsAnalogOutputCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actualZao := zgw.Capability(AnalogOutputFlag)
+		assert.IsType(t, (*ZigbeeAnalogOutput)(nil), actualZao)
+	})
+}
+
+func TestZigbeeGateway_ReturnsThermostatUIConfigurationCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actualZtui := zgw.Capability(ThermostatUIConfigurationFlag)
+		assert.IsType(t, (*ZigbeeThermostatUIConfiguration)(nil), actualZtui)
+	})
+}
+
+func TestZigbeeGateway_ReturnsButtonEventsCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actualZbe := zgw.Capability(ButtonEventsFlag)
+		assert.IsType(t, (*ZigbeeButtonEvents)(nil), actualZbe)
+	})
+}
+
+func TestZigbeeGateway_ReturnsLevelControlCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actualZlc := zgw.Capability(LevelControlFlag)
+		assert.IsType(t, (*ZigbeeLevelControl)(nil), actualZlc)
+	})
+}
+
+func TestZigbeeGateway_ReturnsColorControlCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actualZcc := zgw.Capability(ColorControlFlag)
+		assert.IsType(t, (*ZigbeeColorControl)(nil), actualZcc)
+	})
+}
+
+func TestZigbeeGateway_ReturnsTemperatureSensorCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actualZts := zgw.Capability(TemperatureSensorFlag)
+		assert.IsType(t, (*ZigbeeTemperatureSensor)(nil), actualZts)
+	})
+}
+
+func TestZigbeeGateway_ReturnsIlluminanceSensorCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actualZis := zgw.Capability(IlluminanceSensorFlag)
+		assert.IsType(t, (*ZigbeeIlluminanceSensor)(nil), actualZis)
+	})
+}
+
+func TestZigbeeGateway_ReturnsBatteryCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actualZb := zgw.Capability(BatteryFlag)
+		assert.IsType(t, (*ZigbeeBattery)(nil), actualZb)
+	})
+}
+
+func TestZigbeeGateway_ReturnsIdentifyCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actualZi := zgw.Capability(IdentifyFlag)
+		assert.IsType(t, (*ZigbeeIdentify)(nil), actualZi)
+	})
+}
+
+func TestZigbeeGateway_ReturnsOccupancySensorCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actualZos := zgw.Capability(OccupancySensorFlag)
+		assert.IsType(t, (*ZigbeeOccupancySensor)(nil), actualZos)
+	})
+}
+
+func TestZigbeeGateway_ReturnsPressureSensorCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actualZps := zgw.Capability(PressureSensorFlag)
+		assert.IsType(t, (*ZigbeePressureSensor)(nil), actualZps)
+	})
+}
+
+func TestZigbeeGateway_ReturnsRelativeHumiditySensorCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actualZrhs := zgw.Capability(RelativeHumiditySensorFlag)
+		assert.IsType(t, (*ZigbeeRelativeHumiditySensor)(nil), actualZrhs)
+	})
+}
+
+func TestZigbeeGateway_ReturnsOnOffEffectCapability(t *testing.T) {
+	t.Run("returns the OnOff capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actualZoo := zgw.Capability(OnOffEffectFlag)
+		assert.IsType(t, (*ZigbeeOnOff)(nil), actualZoo)
+		assert.Same(t, zgw.Capability(capabilities.OnOffFlag), actualZoo)
+	})
+}
+
+func TestZigbeeGateway_ReturnsElectricalMeasurementCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actual := zgw.Capability(ElectricalMeasurementFlag)
+		assert.IsType(t, (*ZigbeeElectricalMeasurement)(nil), actual)
+	})
+}
+
+func TestZigbeeGateway_ReturnsMeteringCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actual := zgw.Capability(MeteringFlag)
+		assert.IsType(t, (*ZigbeeMetering)(nil), actual)
+	})
+}
+
+func TestZigbeeGateway_ReturnsIASZoneCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actual := zgw.Capability(IASZoneFlag)
+		assert.IsType(t, (*ZigbeeIASZone)(nil), actual)
+	})
+}
+
+func TestZigbeeGateway_ReturnsDoorLockCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actual := zgw.Capability(DoorLockFlag)
+		assert.IsType(t, (*ZigbeeDoorLock)(nil), actual)
+	})
+}
+
+func TestZigbeeGateway_ReturnsWindowCoveringCapability(t *testing.T) {
+	t.Run("returns capability on query", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		zgw.Start()
+		defer stop(t)
+
+		actual := zgw.Capability(WindowCoveringFlag)
+		assert.IsType(t, (*ZigbeeWindowCovering)(nil), actual)
+	})
+}
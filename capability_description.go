@@ -0,0 +1,150 @@
+package zda
+
+import (
+	. "github.com/shimmeringbee/da"
+	. "github.com/shimmeringbee/da/capabilities"
+)
+
+// ParameterDescription describes a parameter of a capability operation, Type is the Go type name of the parameter.
+type ParameterDescription struct {
+	Name string
+	Type string
+}
+
+// OperationDescription describes a callable operation of a capability. Every operation also accepts a
+// context.Context and the da.Device it is being invoked upon as its first two arguments, these are not listed in
+// Parameters. Returns lists the Go type names of the values returned, excluding the trailing error.
+type OperationDescription struct {
+	Name       string
+	Parameters []ParameterDescription
+	Returns    []string
+}
+
+// CapabilityDescription describes the operations a capability exposes, allowing generic frontends to build controls
+// for capabilities without needing to know about them in advance.
+type CapabilityDescription struct {
+	Capability Capability
+	Operations []OperationDescription
+}
+
+var capabilityDescriptions = map[Capability][]OperationDescription{
+	DeviceDiscoveryFlag: {
+		{Name: "Enable", Parameters: []ParameterDescription{{Name: "duration", Type: "time.Duration"}}},
+		{Name: "EnableUntilCancelled", Parameters: []ParameterDescription{{Name: "duration", Type: "time.Duration"}}},
+		{Name: "Disable"},
+		{Name: "Status", Returns: []string{"capabilities.DeviceDiscoveryStatus"}},
+		{Name: "DiscoveredNodes", Returns: []string{"[]zda.DiscoveredNode"}},
+	},
+	EnumerateDeviceFlag: {
+		{Name: "Enumerate"},
+	},
+	LocalDebugFlag: {
+		{Name: "Start"},
+		{Name: "QueryEndpointDescription", Parameters: []ParameterDescription{{Name: "endpoint", Type: "zigbee.Endpoint"}}, Returns: []string{"zigbee.EndpointDescription"}},
+	},
+	HasProductInformationFlag: {
+		{Name: "ProductInformation", Returns: []string{"capabilities.ProductInformation"}},
+	},
+	OnOffFlag: {
+		{Name: "On"},
+		{Name: "Off"},
+		{Name: "State", Returns: []string{"bool"}},
+	},
+	OnOffEffectFlag: {
+		{Name: "OffWithEffect", Parameters: []ParameterDescription{{Name: "effect", Type: "zda.OffEffect"}, {Name: "variant", Type: "uint8"}}},
+	},
+	IlluminanceLevelSensingFlag: {
+		{Name: "LevelStatus", Returns: []string{"zda.LevelStatus"}},
+		{Name: "TargetLevel", Returns: []string{"uint16"}},
+	},
+	PriceFlag: {
+		{Name: "CurrentPrice", Returns: []string{"zda.PriceInformation"}},
+	},
+	AnalogOutputFlag: {
+		{Name: "Set", Parameters: []ParameterDescription{{Name: "value", Type: "float32"}}},
+		{Name: "Value", Returns: []string{"zda.AnalogOutputValue"}},
+	},
+	ThermostatUIConfigurationFlag: {
+		{Name: "UIConfiguration", Returns: []string{"zda.ThermostatUIConfigurationState"}},
+		{Name: "SetTemperatureDisplayMode", Parameters: []ParameterDescription{{Name: "mode", Type: "zda.TemperatureDisplayMode"}}},
+		{Name: "SetKeypadLockout", Parameters: []ParameterDescription{{Name: "lockout", Type: "zda.KeypadLockout"}}},
+	},
+	ButtonEventsFlag: {
+		{Name: "LastPress", Returns: []string{"zda.ButtonPress"}},
+		{Name: "Buttons", Returns: []string{"int"}},
+	},
+	LevelControlFlag: {
+		{Name: "MoveToLevel", Parameters: []ParameterDescription{{Name: "level", Type: "uint8"}, {Name: "transitionTime", Type: "time.Duration"}}},
+		{Name: "CurrentLevel", Returns: []string{"uint8"}},
+	},
+	ColorControlFlag: {
+		{Name: "ChangeColorTemperature", Parameters: []ParameterDescription{{Name: "mireds", Type: "uint16"}, {Name: "transitionTime", Type: "time.Duration"}}},
+		{Name: "ChangeColorXY", Parameters: []ParameterDescription{{Name: "x", Type: "float64"}, {Name: "y", Type: "float64"}, {Name: "transitionTime", Type: "time.Duration"}}},
+		{Name: "State", Returns: []string{"zda.ColorControlState"}},
+	},
+	TemperatureSensorFlag: {
+		{Name: "Reading", Returns: []string{"float64"}},
+	},
+	RelativeHumiditySensorFlag: {
+		{Name: "Reading", Returns: []string{"float64"}},
+	},
+	PressureSensorFlag: {
+		{Name: "Reading", Returns: []string{"float64"}},
+	},
+	OccupancySensorFlag: {
+		{Name: "Status", Returns: []string{"zda.OccupancyStatus"}},
+	},
+	IlluminanceSensorFlag: {
+		{Name: "Reading", Returns: []string{"float64"}},
+	},
+	BatteryFlag: {
+		{Name: "Status", Returns: []string{"zda.BatteryStatus"}},
+		{Name: "LifeRemaining", Returns: []string{"time.Duration"}},
+	},
+	IdentifyFlag: {
+		{Name: "Identify", Parameters: []ParameterDescription{{Name: "duration", Type: "time.Duration"}}},
+		{Name: "IdentifyQuery", Returns: []string{"time.Duration"}},
+	},
+	ElectricalMeasurementFlag: {
+		{Name: "Reading", Returns: []string{"float64"}},
+	},
+	MeteringFlag: {
+		{Name: "Reading", Returns: []string{"float64"}},
+		{Name: "Unit", Returns: []string{"zda.MeteringUnit"}},
+	},
+	IASZoneFlag: {
+		{Name: "Status", Returns: []string{"zda.IASZoneState"}},
+	},
+	DoorLockFlag: {
+		{Name: "Lock"},
+		{Name: "Unlock"},
+		{Name: "State", Returns: []string{"zda.DoorLockState"}},
+		{Name: "SetPINCode", Parameters: []ParameterDescription{{Name: "userID", Type: "uint16"}, {Name: "pinCode", Type: "string"}}},
+		{Name: "PINCode", Parameters: []ParameterDescription{{Name: "userID", Type: "uint16"}}, Returns: []string{"zda.DoorLockUser"}},
+		{Name: "ClearPINCode", Parameters: []ParameterDescription{{Name: "userID", Type: "uint16"}}},
+		{Name: "SetUserEnabled", Parameters: []ParameterDescription{{Name: "userID", Type: "uint16"}, {Name: "enabled", Type: "bool"}}},
+		{Name: "PINUsersSupported", Returns: []string{"uint16"}},
+	},
+	WindowCoveringFlag: {
+		{Name: "Open"},
+		{Name: "Close"},
+		{Name: "Stop"},
+		{Name: "GoToLiftPercentage", Parameters: []ParameterDescription{{Name: "percentage", Type: "uint8"}}},
+		{Name: "Position", Returns: []string{"uint8"}},
+	},
+}
+
+// DescribeCapability returns a description of the operations exposed by the capability, false is returned if the
+// capability is not supported by the gateway.
+func (z *ZigbeeGateway) DescribeCapability(capability Capability) (CapabilityDescription, bool) {
+	if _, found := z.capabilities[capability]; !found {
+		return CapabilityDescription{}, false
+	}
+
+	operations, found := capabilityDescriptions[capability]
+	if !found {
+		return CapabilityDescription{}, false
+	}
+
+	return CapabilityDescription{Capability: capability, Operations: operations}, true
+}
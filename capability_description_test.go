@@ -0,0 +1,56 @@
+package zda
+
+import (
+	. "github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+func TestZigbeeGateway_DescribeCapability(t *testing.T) {
+	t.Run("returns a description for every capability the gateway supports", func(t *testing.T) {
+		zgw := New(new(zigbee.MockProvider))
+
+		for capability := range zgw.capabilities {
+			description, found := zgw.DescribeCapability(capability)
+			assert.True(t, found, "capability %v has no description", capability)
+			assert.Equal(t, capability, description.Capability)
+			assert.NotEmpty(t, description.Operations)
+		}
+	})
+
+	t.Run("returns false for a capability the gateway does not support", func(t *testing.T) {
+		zgw := New(new(zigbee.MockProvider))
+
+		_, found := zgw.DescribeCapability(Capability(0xffff))
+		assert.False(t, found)
+	})
+
+	t.Run("descriptions match the methods of the capability implementations", func(t *testing.T) {
+		zgw := New(new(zigbee.MockProvider))
+
+		for capability, operations := range capabilityDescriptions {
+			impl := reflect.TypeOf(zgw.capabilities[capability])
+
+			for _, operation := range operations {
+				method, found := impl.MethodByName(operation.Name)
+				if !assert.True(t, found, "%v has no method %s", impl, operation.Name) {
+					continue
+				}
+
+				// Receiver, context and device precede the described parameters, error follows the described returns.
+				assert.Equal(t, len(operation.Parameters)+3, method.Type.NumIn(), "%v.%s parameters", impl, operation.Name)
+				assert.Equal(t, len(operation.Returns)+1, method.Type.NumOut(), "%v.%s returns", impl, operation.Name)
+
+				for i, parameter := range operation.Parameters {
+					assert.Equal(t, parameter.Type, method.Type.In(i+3).String())
+				}
+
+				for i, returned := range operation.Returns {
+					assert.Equal(t, returned, method.Type.Out(i).String())
+				}
+			}
+		}
+	})
+}
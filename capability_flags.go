@@ -0,0 +1,30 @@
+package zda
+
+import "github.com/shimmeringbee/da"
+
+/*
+ * Capabilities which are provided by zda but are not yet defined by da, these are allocated from the top of the
+ * functional range to avoid colliding with future da allocations.
+ */
+const (
+	IlluminanceLevelSensingFlag   = da.Capability(0x1f00)
+	PriceFlag                     = da.Capability(0x1f01)
+	AnalogOutputFlag              = da.Capability(0x1f02)
+	OnOffEffectFlag               = da.Capability(0x1f03)
+	ThermostatUIConfigurationFlag = da.Capability(0x1f04)
+	ButtonEventsFlag              = da.Capability(0x1f05)
+	LevelControlFlag              = da.Capability(0x1f06)
+	ColorControlFlag              = da.Capability(0x1f07)
+	TemperatureSensorFlag         = da.Capability(0x1f08)
+	RelativeHumiditySensorFlag    = da.Capability(0x1f09)
+	PressureSensorFlag            = da.Capability(0x1f0a)
+	OccupancySensorFlag           = da.Capability(0x1f0b)
+	IlluminanceSensorFlag         = da.Capability(0x1f0c)
+	BatteryFlag                   = da.Capability(0x1f0d)
+	IdentifyFlag                  = da.Capability(0x1f0e)
+	ElectricalMeasurementFlag     = da.Capability(0x1f0f)
+	MeteringFlag                  = da.Capability(0x1f10)
+	IASZoneFlag                   = da.Capability(0x1f11)
+	DoorLockFlag                  = da.Capability(0x1f12)
+	WindowCoveringFlag            = da.Capability(0x1f13)
+)
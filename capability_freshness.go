@@ -0,0 +1,45 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/da"
+	"time"
+)
+
+// markCapabilityUpdated records that data for the capability was successfully received from the device, the device
+// mutex must be held for writing by the caller.
+func markCapabilityUpdated(iDev *internalDevice, capability da.Capability) {
+	if iDev.capabilityUpdated == nil {
+		iDev.capabilityUpdated = map[da.Capability]time.Time{}
+	}
+
+	iDev.capabilityUpdated[capability] = time.Now()
+}
+
+// capabilityUpdatedTimes returns a copy of the times each capability was last updated, the device mutex must be held
+// by the caller.
+func capabilityUpdatedTimes(iDev *internalDevice) map[da.Capability]time.Time {
+	times := map[da.Capability]time.Time{}
+
+	for capability, updated := range iDev.capabilityUpdated {
+		times[capability] = updated
+	}
+
+	return times
+}
+
+// CapabilityLastUpdated returns the time data for the capability was last successfully received from the device,
+// either by a read, a report or an acknowledged command. False is returned if no data has yet been received, which
+// consumers may use to grey out stale or absent readings.
+func (z *ZigbeeGateway) CapabilityLastUpdated(device da.Device, capability da.Capability) (time.Time, bool, error) {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	iDev.mutex.RLock()
+	defer iDev.mutex.RUnlock()
+
+	updated, found := iDev.capabilityUpdated[capability]
+	return updated, found, nil
+}
@@ -0,0 +1,48 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestZigbeeGateway_CapabilityLastUpdated(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		_, _, err := zgw.CapabilityLastUpdated(da.Device{}, capabilities.OnOffFlag)
+		assert.Error(t, err)
+	})
+
+	t.Run("returns false if the capability has never been updated", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		_, found, err := zgw.CapabilityLastUpdated(iDev.device, capabilities.OnOffFlag)
+		assert.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("returns the time the capability was last updated", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		markCapabilityUpdated(iDev, capabilities.OnOffFlag)
+
+		updated, found, err := zgw.CapabilityLastUpdated(iDev.device, capabilities.OnOffFlag)
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.WithinDuration(t, time.Now(), updated, time.Second)
+
+		_, found, err = zgw.CapabilityLastUpdated(iDev.device, PriceFlag)
+		assert.NoError(t, err)
+		assert.False(t, found)
+	})
+}
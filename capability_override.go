@@ -0,0 +1,148 @@
+package zda
+
+import (
+	"fmt"
+	"github.com/shimmeringbee/da"
+	"log"
+)
+
+// DeviceCapabilityAdded is sent when a capability is added to a device outside of enumeration.
+type DeviceCapabilityAdded struct {
+	Device     da.Device
+	Capability da.Capability
+}
+
+// DeviceCapabilityRemoved is sent when a capability is removed from a device outside of enumeration.
+type DeviceCapabilityRemoved struct {
+	Device     da.Device
+	Capability da.Capability
+}
+
+// OverrideCapability forces a capability to be present or absent on a device regardless of what enumeration detects,
+// the override is retained across re-enumeration until cleared with ClearCapabilityOverride. Overriding a capability
+// onto a device whose endpoints lack the backing cluster is permitted, but a warning is logged.
+func (z *ZigbeeGateway) OverrideCapability(device da.Device, capability da.Capability, present bool) error {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return err
+	}
+
+	iDev.node.mutex.RLock()
+	defer iDev.node.mutex.RUnlock()
+
+	iDev.mutex.Lock()
+
+	if present {
+		if cluster, known := clusterForCapability(iDev, capability); known {
+			if _, found := findEndpointWithClusterId(iDev.node, iDev, cluster); !found {
+				log.Printf("warning: capability %d overridden onto device %s which lacks cluster %d", capability, iDev.device.Identifier, cluster)
+			}
+		}
+	}
+
+	if iDev.capabilityOverrides == nil {
+		iDev.capabilityOverrides = map[da.Capability]bool{}
+	}
+
+	hadCapability := iDev.device.HasCapability(capability)
+
+	if _, overridden := iDev.capabilityOverrides[capability]; !overridden {
+		if iDev.detectedCapabilities == nil {
+			iDev.detectedCapabilities = map[da.Capability]bool{}
+		}
+
+		iDev.detectedCapabilities[capability] = hadCapability
+	}
+
+	iDev.capabilityOverrides[capability] = present
+	applyCapabilityOverrides(iDev)
+	updatedDevice := iDev.device
+
+	iDev.mutex.Unlock()
+
+	if present && !hadCapability {
+		z.sendEvent(DeviceCapabilityAdded{Device: updatedDevice, Capability: capability})
+	} else if !present && hadCapability {
+		z.sendEvent(DeviceCapabilityRemoved{Device: updatedDevice, Capability: capability})
+	}
+
+	return nil
+}
+
+// ClearCapabilityOverride removes any override for the capability on the device, immediately restoring whether the
+// capability is present to what enumeration last detected.
+func (z *ZigbeeGateway) ClearCapabilityOverride(device da.Device, capability da.Capability) error {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return err
+	}
+
+	iDev.mutex.Lock()
+
+	if _, overridden := iDev.capabilityOverrides[capability]; !overridden {
+		iDev.mutex.Unlock()
+		return nil
+	}
+
+	hadCapability := iDev.device.HasCapability(capability)
+	detected := iDev.detectedCapabilities[capability]
+
+	delete(iDev.capabilityOverrides, capability)
+	delete(iDev.detectedCapabilities, capability)
+
+	if detected {
+		addCapability(&iDev.device, capability)
+	} else {
+		removeCapability(&iDev.device, capability)
+	}
+
+	updatedDevice := iDev.device
+
+	iDev.mutex.Unlock()
+
+	if detected && !hadCapability {
+		z.sendEvent(DeviceCapabilityAdded{Device: updatedDevice, Capability: capability})
+	} else if !detected && hadCapability {
+		z.sendEvent(DeviceCapabilityRemoved{Device: updatedDevice, Capability: capability})
+	}
+
+	return nil
+}
+
+func (z *ZigbeeGateway) getOverridableDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	iDev, found := z.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDev, nil
+}
+
+// applyCapabilityOverrides adds or removes capabilities on the device to match any overrides, the device mutex must be
+// held by the caller.
+func applyCapabilityOverrides(iDev *internalDevice) {
+	for capability, present := range iDev.capabilityOverrides {
+		if present {
+			addCapability(&iDev.device, capability)
+		} else {
+			removeCapability(&iDev.device, capability)
+		}
+	}
+}
+
+// applyEnumeratedCapabilityOverrides records which overridden capabilities enumeration detected, before applying the
+// overrides. The device mutex must be held by the caller.
+func applyEnumeratedCapabilityOverrides(iDev *internalDevice) {
+	for capability := range iDev.capabilityOverrides {
+		iDev.detectedCapabilities[capability] = iDev.device.HasCapability(capability)
+	}
+
+	applyCapabilityOverrides(iDev)
+}
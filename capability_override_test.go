@@ -0,0 +1,107 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestZigbeeGateway_OverrideCapability(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		err := zgw.OverrideCapability(da.Device{}, capabilities.OnOffFlag, true)
+		assert.Error(t, err)
+	})
+
+	t.Run("adds a capability to a device and sends an event", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		err := zgw.OverrideCapability(iDev.device, capabilities.OnOffFlag, true)
+		assert.NoError(t, err)
+		assert.True(t, iDev.device.HasCapability(capabilities.OnOffFlag))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, _ = zgw.ReadEvent(ctx)
+		event, err := zgw.ReadEvent(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, DeviceCapabilityAdded{Device: iDev.device, Capability: capabilities.OnOffFlag}, event)
+	})
+
+	t.Run("removes a capability from a device and retains the override through enumeration", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		err := zgw.OverrideCapability(iDev.device, capabilities.LocalDebugFlag, false)
+		assert.NoError(t, err)
+		assert.False(t, iDev.device.HasCapability(capabilities.LocalDebugFlag))
+
+		addCapability(&iDev.device, capabilities.LocalDebugFlag)
+		applyEnumeratedCapabilityOverrides(iDev)
+		assert.False(t, iDev.device.HasCapability(capabilities.LocalDebugFlag))
+
+		err = zgw.ClearCapabilityOverride(iDev.device, capabilities.LocalDebugFlag)
+		assert.NoError(t, err)
+
+		addCapability(&iDev.device, capabilities.LocalDebugFlag)
+		applyEnumeratedCapabilityOverrides(iDev)
+		assert.True(t, iDev.device.HasCapability(capabilities.LocalDebugFlag))
+	})
+	t.Run("clearing an override immediately restores the capability detected by enumeration and sends an event", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		err := zgw.OverrideCapability(iDev.device, capabilities.OnOffFlag, true)
+		assert.NoError(t, err)
+		assert.True(t, iDev.device.HasCapability(capabilities.OnOffFlag))
+
+		err = zgw.ClearCapabilityOverride(iDev.device, capabilities.OnOffFlag)
+		assert.NoError(t, err)
+		assert.False(t, iDev.device.HasCapability(capabilities.OnOffFlag))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		var events []interface{}
+		for {
+			event, err := zgw.ReadEvent(ctx)
+			if err != nil {
+				break
+			}
+			events = append(events, event)
+		}
+
+		assert.Contains(t, events, DeviceCapabilityRemoved{Device: iDev.device, Capability: capabilities.OnOffFlag})
+	})
+
+	t.Run("clearing an override restores the capability if a later enumeration detected it", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		err := zgw.OverrideCapability(iDev.device, capabilities.OnOffFlag, false)
+		assert.NoError(t, err)
+
+		addCapability(&iDev.device, capabilities.OnOffFlag)
+		applyEnumeratedCapabilityOverrides(iDev)
+		assert.False(t, iDev.device.HasCapability(capabilities.OnOffFlag))
+
+		err = zgw.ClearCapabilityOverride(iDev.device, capabilities.OnOffFlag)
+		assert.NoError(t, err)
+		assert.True(t, iDev.device.HasCapability(capabilities.OnOffFlag))
+	})
+}
@@ -0,0 +1,167 @@
+package zda
+
+import (
+	"fmt"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+)
+
+// capabilityClusters is the registry of which cluster backs each capability, capabilities consult this during
+// enumeration rather than hardcoding their cluster, so that a device may have a capability remapped onto another
+// cluster. This is also the list of clusters which zda understands.
+var capabilityClusters = map[da.Capability]zigbee.ClusterID{
+	capabilities.HasProductInformationFlag: zcl.BasicId,
+	capabilities.OnOffFlag:                 zcl.OnOffId,
+	IlluminanceLevelSensingFlag:            zcl.IlluminanceLevelSensingId,
+	PriceFlag:                              zcl.PriceId,
+	AnalogOutputFlag:                       zcl.AnalogOutputBasicId,
+	OnOffEffectFlag:                        zcl.OnOffId,
+	ThermostatUIConfigurationFlag:          zcl.ThermostatUserInterfaceConfigurationId,
+	LevelControlFlag:                       zcl.LevelControlId,
+	ColorControlFlag:                       zcl.ColorControlId,
+	TemperatureSensorFlag:                  zcl.TemperatureMeasurementId,
+	RelativeHumiditySensorFlag:             zcl.RelativeHumidityMeasurementId,
+	PressureSensorFlag:                     zcl.PressureMeasurementId,
+	OccupancySensorFlag:                    zcl.OccupancySensingId,
+	IlluminanceSensorFlag:                  zcl.IlluminanceMeasurementId,
+	BatteryFlag:                            zcl.PowerConfigurationId,
+	IdentifyFlag:                           zcl.IdentifyId,
+	ElectricalMeasurementFlag:              zcl.ElectricalMeasurementId,
+	MeteringFlag:                           zcl.MeteringId,
+	IASZoneFlag:                            zcl.IASZoneId,
+	DoorLockFlag:                           zcl.DoorLockId,
+	WindowCoveringFlag:                     zcl.WindowCoveringId,
+}
+
+// clusterForCapability returns the cluster which backs the capability on the device, taking into account any remap on
+// the device. The device mutex must be held by the caller.
+func clusterForCapability(iDev *internalDevice, capability da.Capability) (zigbee.ClusterID, bool) {
+	if cluster, found := iDev.clusterRemaps[capability]; found {
+		return cluster, true
+	}
+
+	cluster, found := capabilityClusters[capability]
+	return cluster, found
+}
+
+// findEndpointForCapability returns the first endpoint on the device which has the cluster backing the capability, as
+// well as the cluster. If an endpoint has been selected for the capability on the device it is used instead, but only
+// if it has the cluster. Failing that an endpoint found by readAttributesWithEndpointFallback is used, regardless of
+// the clusters it advertises. The node and device mutexes must be held by the caller.
+func findEndpointForCapability(iNode *internalNode, iDev *internalDevice, capability da.Capability) (zigbee.Endpoint, zigbee.ClusterID, bool) {
+	cluster, known := clusterForCapability(iDev, capability)
+
+	if !known {
+		return 0, 0, false
+	}
+
+	if endpoint, selected := iDev.endpointSelections[capability]; selected {
+		found := isEndpointInSlice(iDev.endpoints, endpoint) && isClusterIdInSlice(iNode.endpointDescriptions[endpoint].InClusterList, cluster)
+		return endpoint, cluster, found
+	}
+
+	endpoint, found := findEndpointWithClusterId(iNode, iDev, cluster)
+
+	if corrected, correctedFound := iDev.endpointCorrections[capability]; found && correctedFound {
+		return corrected, cluster, true
+	}
+
+	return endpoint, cluster, found
+}
+
+// RemapCapabilityCluster causes the capability to be backed by a different cluster on the device, for devices which
+// implement a standard function on a non-standard cluster. The device is expected to accept the same attributes and
+// commands on the remapped cluster. The remap takes effect the next time the device is enumerated, and is retained
+// until cleared with ClearCapabilityClusterRemap.
+func (z *ZigbeeGateway) RemapCapabilityCluster(device da.Device, capability da.Capability, cluster zigbee.ClusterID) error {
+	if _, known := capabilityClusters[capability]; !known {
+		return fmt.Errorf("capability %d is not backed by a cluster", capability)
+	}
+
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return err
+	}
+
+	iDev.mutex.Lock()
+	defer iDev.mutex.Unlock()
+
+	if iDev.clusterRemaps == nil {
+		iDev.clusterRemaps = map[da.Capability]zigbee.ClusterID{}
+	}
+
+	iDev.clusterRemaps[capability] = cluster
+
+	return nil
+}
+
+// ClearCapabilityClusterRemap returns the capability to being backed by its standard cluster on the device, this
+// takes effect the next time the device is enumerated.
+func (z *ZigbeeGateway) ClearCapabilityClusterRemap(device da.Device, capability da.Capability) error {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return err
+	}
+
+	iDev.mutex.Lock()
+	defer iDev.mutex.Unlock()
+
+	delete(iDev.clusterRemaps, capability)
+
+	return nil
+}
+
+// SelectCapabilityEndpoint causes the capability to be backed by the endpoint provided on the device, rather than the
+// first endpoint which has the capability's cluster. This resolves devices which offer the same cluster on several
+// endpoints where the first is not the one that should be controlled. If the endpoint does not have the cluster the
+// device will lose the capability. The selection takes effect the next time the device is enumerated, and is retained
+// until cleared with ClearCapabilityEndpoint.
+func (z *ZigbeeGateway) SelectCapabilityEndpoint(device da.Device, capability da.Capability, endpoint zigbee.Endpoint) error {
+	if _, known := capabilityClusters[capability]; !known {
+		return fmt.Errorf("capability %d is not backed by a cluster", capability)
+	}
+
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return err
+	}
+
+	iDev.mutex.Lock()
+	defer iDev.mutex.Unlock()
+
+	if !isEndpointInSlice(iDev.endpoints, endpoint) {
+		return fmt.Errorf("endpoint %d does not belong to device", endpoint)
+	}
+
+	if iDev.endpointSelections == nil {
+		iDev.endpointSelections = map[da.Capability]zigbee.Endpoint{}
+	}
+
+	iDev.endpointSelections[capability] = endpoint
+
+	return nil
+}
+
+// ClearCapabilityEndpoint returns the capability to being backed by the first endpoint with its cluster on the device,
+// discarding any correction found by probing, this takes effect the next time the device is enumerated.
+func (z *ZigbeeGateway) ClearCapabilityEndpoint(device da.Device, capability da.Capability) error {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return err
+	}
+
+	iDev.mutex.Lock()
+	defer iDev.mutex.Unlock()
+
+	delete(iDev.endpointSelections, capability)
+	delete(iDev.endpointCorrections, capability)
+	delete(iDev.endpointsProbed, capability)
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_clusterForCapability(t *testing.T) {
+	t.Run("returns the registered cluster for a capability", func(t *testing.T) {
+		cluster, found := clusterForCapability(&internalDevice{}, capabilities.OnOffFlag)
+		assert.True(t, found)
+		assert.Equal(t, zcl.OnOffId, cluster)
+	})
+
+	t.Run("returns false for a capability which is not backed by a cluster", func(t *testing.T) {
+		_, found := clusterForCapability(&internalDevice{}, capabilities.EnumerateDeviceFlag)
+		assert.False(t, found)
+	})
+
+	t.Run("returns the remapped cluster if the device has a remap", func(t *testing.T) {
+		iDev := &internalDevice{clusterRemaps: map[da.Capability]zigbee.ClusterID{capabilities.OnOffFlag: 0xfc00}}
+
+		cluster, found := clusterForCapability(iDev, capabilities.OnOffFlag)
+		assert.True(t, found)
+		assert.Equal(t, zigbee.ClusterID(0xfc00), cluster)
+	})
+}
+
+func Test_findEndpointForCapability(t *testing.T) {
+	t.Run("returns the endpoint and cluster backing the capability", func(t *testing.T) {
+		node, device := generateTestNodeAndDevice()
+
+		endpoint := node.endpoints[0]
+		description := node.endpointDescriptions[endpoint]
+		description.InClusterList = []zigbee.ClusterID{zcl.OnOffId}
+		node.endpointDescriptions[endpoint] = description
+
+		foundEndpoint, cluster, found := findEndpointForCapability(node, device, capabilities.OnOffFlag)
+		assert.True(t, found)
+		assert.Equal(t, endpoint, foundEndpoint)
+		assert.Equal(t, zcl.OnOffId, cluster)
+	})
+
+	t.Run("does not find the standard cluster if the capability has been remapped", func(t *testing.T) {
+		node, device := generateTestNodeAndDevice()
+		device.clusterRemaps = map[da.Capability]zigbee.ClusterID{capabilities.OnOffFlag: 0xfc00}
+
+		endpoint := node.endpoints[0]
+		description := node.endpointDescriptions[endpoint]
+		description.InClusterList = []zigbee.ClusterID{zcl.OnOffId}
+		node.endpointDescriptions[endpoint] = description
+
+		_, _, found := findEndpointForCapability(node, device, capabilities.OnOffFlag)
+		assert.False(t, found)
+	})
+
+	t.Run("returns the selected endpoint rather than the first with the cluster", func(t *testing.T) {
+		node, device := generateTestNodeAndDevice()
+		device.endpoints = []zigbee.Endpoint{1, 2}
+		device.endpointSelections = map[da.Capability]zigbee.Endpoint{capabilities.OnOffFlag: 2}
+
+		node.endpointDescriptions[1] = zigbee.EndpointDescription{Endpoint: 1, InClusterList: []zigbee.ClusterID{zcl.OnOffId}}
+		node.endpointDescriptions[2] = zigbee.EndpointDescription{Endpoint: 2, InClusterList: []zigbee.ClusterID{zcl.OnOffId}}
+
+		foundEndpoint, _, found := findEndpointForCapability(node, device, capabilities.OnOffFlag)
+		assert.True(t, found)
+		assert.Equal(t, zigbee.Endpoint(2), foundEndpoint)
+	})
+
+	t.Run("does not find an endpoint if the selected endpoint lacks the cluster", func(t *testing.T) {
+		node, device := generateTestNodeAndDevice()
+		device.endpoints = []zigbee.Endpoint{1, 2}
+		device.endpointSelections = map[da.Capability]zigbee.Endpoint{capabilities.OnOffFlag: 2}
+
+		node.endpointDescriptions[1] = zigbee.EndpointDescription{Endpoint: 1, InClusterList: []zigbee.ClusterID{zcl.OnOffId}}
+		node.endpointDescriptions[2] = zigbee.EndpointDescription{Endpoint: 2}
+
+		_, _, found := findEndpointForCapability(node, device, capabilities.OnOffFlag)
+		assert.False(t, found)
+	})
+
+	t.Run("returns the corrected endpoint even though it does not advertise the cluster", func(t *testing.T) {
+		node, device := generateTestNodeAndDevice()
+		device.endpoints = []zigbee.Endpoint{1}
+		device.endpointCorrections = map[da.Capability]zigbee.Endpoint{capabilities.OnOffFlag: 2}
+
+		node.endpointDescriptions[1] = zigbee.EndpointDescription{Endpoint: 1, InClusterList: []zigbee.ClusterID{zcl.OnOffId}}
+		node.endpointDescriptions[2] = zigbee.EndpointDescription{Endpoint: 2}
+
+		foundEndpoint, _, found := findEndpointForCapability(node, device, capabilities.OnOffFlag)
+		assert.True(t, found)
+		assert.Equal(t, zigbee.Endpoint(2), foundEndpoint)
+	})
+}
+
+func TestZigbeeGateway_RemapCapabilityCluster(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		err := zgw.RemapCapabilityCluster(da.Device{}, capabilities.OnOffFlag, 0xfc00)
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if the capability is not backed by a cluster", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		err := zgw.RemapCapabilityCluster(iDev.device, capabilities.EnumerateDeviceFlag, 0xfc00)
+		assert.Error(t, err)
+	})
+
+	t.Run("remaps the cluster on the device until cleared", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		err := zgw.RemapCapabilityCluster(iDev.device, capabilities.OnOffFlag, 0xfc00)
+		assert.NoError(t, err)
+
+		cluster, _ := clusterForCapability(iDev, capabilities.OnOffFlag)
+		assert.Equal(t, zigbee.ClusterID(0xfc00), cluster)
+
+		err = zgw.ClearCapabilityClusterRemap(iDev.device, capabilities.OnOffFlag)
+		assert.NoError(t, err)
+
+		cluster, _ = clusterForCapability(iDev, capabilities.OnOffFlag)
+		assert.Equal(t, zcl.OnOffId, cluster)
+	})
+}
+
+func TestZigbeeGateway_SelectCapabilityEndpoint(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		err := zgw.SelectCapabilityEndpoint(da.Device{}, capabilities.OnOffFlag, 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if the endpoint does not belong to the device", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+		iDev.endpoints = []zigbee.Endpoint{1}
+
+		err := zgw.SelectCapabilityEndpoint(iDev.device, capabilities.OnOffFlag, 2)
+		assert.Error(t, err)
+	})
+
+	t.Run("selects the endpoint on the device until cleared", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+		iDev.endpoints = []zigbee.Endpoint{1, 2}
+
+		err := zgw.SelectCapabilityEndpoint(iDev.device, capabilities.OnOffFlag, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, zigbee.Endpoint(2), iDev.endpointSelections[capabilities.OnOffFlag])
+
+		iDev.endpointCorrections = map[da.Capability]zigbee.Endpoint{capabilities.OnOffFlag: 3}
+		iDev.endpointsProbed = map[da.Capability]bool{capabilities.OnOffFlag: true}
+
+		err = zgw.ClearCapabilityEndpoint(iDev.device, capabilities.OnOffFlag)
+		assert.NoError(t, err)
+		assert.NotContains(t, iDev.endpointSelections, capabilities.OnOffFlag)
+		assert.NotContains(t, iDev.endpointCorrections, capabilities.OnOffFlag)
+		assert.NotContains(t, iDev.endpointsProbed, capabilities.OnOffFlag)
+	})
+}
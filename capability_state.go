@@ -0,0 +1,94 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shimmeringbee/da"
+)
+
+const (
+	UnitCelsius     = "°C"
+	UnitPercent     = "%"
+	UnitHectopascal = "hPa"
+	UnitLux         = "lx"
+	UnitWatt        = "W"
+	UnitVolt        = "V"
+	UnitMired       = "mired"
+)
+
+// StateValue is a single value of the state of a capability, normalised so that consumers can render it without
+// knowing the concrete type of the capability. Value is always a bool, float64 or string.
+type StateValue struct {
+	Name  string
+	Value interface{}
+	// Unit of the value, empty if it has no unit.
+	Unit string
+}
+
+// CapabilityState is the normalised state of a capability of a device.
+type CapabilityState struct {
+	Capability da.Capability
+	Values     []StateValue
+}
+
+// stateReporter is implemented by capabilities whose state can be normalised into StateValues, alongside their typed
+// accessors.
+type stateReporter interface {
+	stateValues(ctx context.Context, device da.Device) ([]StateValue, error)
+}
+
+// CapabilityState returns the normalised state of the capability of the device. An error is returned if the capability
+// does not support normalising its state, and NoReadingAvailable if the device has not yet provided its state.
+func (z *ZigbeeGateway) CapabilityState(ctx context.Context, device da.Device, capability da.Capability) (CapabilityState, error) {
+	reporter, ok := z.capabilities[capability].(stateReporter)
+
+	if !ok {
+		return CapabilityState{}, fmt.Errorf("capability %d does not support normalised state", capability)
+	}
+
+	values, err := reporter.stateValues(ctx, device)
+	if err != nil {
+		return CapabilityState{}, err
+	}
+
+	return CapabilityState{Capability: capability, Values: values}, nil
+}
+
+// CapabilityStates returns the normalised state of each capability of the device which supports it, in the order of
+// the device's capabilities. Capabilities whose state has not yet been provided by the device are omitted.
+func (z *ZigbeeGateway) CapabilityStates(ctx context.Context, device da.Device) ([]CapabilityState, error) {
+	if da.DeviceDoesNotBelongToGateway(z, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	var states []CapabilityState
+
+	for _, capability := range device.Capabilities {
+		if _, ok := z.capabilities[capability].(stateReporter); !ok {
+			continue
+		}
+
+		state, err := z.CapabilityState(ctx, device, capability)
+
+		if errors.Is(err, NoReadingAvailable) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		states = append(states, state)
+	}
+
+	return states, nil
+}
+
+// readingStateValues returns a single named float64 value from a sensor reading, for capabilities whose state is a
+// single measurement.
+func readingStateValues(name string, unit string, reading float64, err error) ([]StateValue, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return []StateValue{{Name: name, Value: reading, Unit: unit}}, nil
+}
@@ -0,0 +1,189 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_readingStateValues(t *testing.T) {
+	t.Run("returns the reading as a single value with its unit", func(t *testing.T) {
+		values, err := readingStateValues("temperature", UnitCelsius, 21.5, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []StateValue{{Name: "temperature", Value: 21.5, Unit: UnitCelsius}}, values)
+	})
+
+	t.Run("returns the error from the reading", func(t *testing.T) {
+		_, err := readingStateValues("temperature", UnitCelsius, 0, NoReadingAvailable)
+		assert.True(t, errors.Is(err, NoReadingAvailable))
+	})
+}
+
+func TestZigbeeColorControl_stateValues(t *testing.T) {
+	t.Run("returns the mode and the values of the color spaces the device supports", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zcc := ZigbeeColorControl{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zcc.gateway
+		device.device.Capabilities = []da.Capability{ColorControlFlag}
+		device.colorControlState = ColorControlState{Mode: ColorModeColorTemperature, ColorTemperature: 370, SupportsColorTemperature: true}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		values, err := zcc.stateValues(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, []StateValue{
+			{Name: "mode", Value: "color_temperature"},
+			{Name: "color_temperature", Value: 370.0, Unit: UnitMired},
+		}, values)
+	})
+}
+
+func TestZigbeeBattery_stateValues(t *testing.T) {
+	t.Run("returns only the values the device reports", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zb := ZigbeeBattery{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zb.gateway
+		device.device.Capabilities = []da.Capability{BatteryFlag}
+		device.batteryState = batteryState{percentage: 150, percentageReceived: true}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		values, err := zb.stateValues(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, []StateValue{{Name: "percentage", Value: 75.0, Unit: UnitPercent}}, values)
+	})
+}
+
+func TestZigbeeMetering_stateValues(t *testing.T) {
+	t.Run("returns the summation with the symbol of the meter's unit", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zm := ZigbeeMetering{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zm.gateway
+		device.device.Capabilities = []da.Capability{MeteringFlag}
+		device.meteringState = meteringState{unit: MeteringUnitKilowattHours, scaled: true, summation: 12.5, available: true}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		values, err := zm.stateValues(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, []StateValue{{Name: "summation", Value: 12.5, Unit: "kWh"}}, values)
+	})
+}
+
+func TestZigbeeIASZone_stateValues(t *testing.T) {
+	t.Run("returns the alarm, tamper and battery low flags of the zone", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zi := ZigbeeIASZone{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zi.gateway
+		device.device.Capabilities = []da.Capability{IASZoneFlag}
+		device.iasZoneState = iasZoneState{status: iasZoneStatusAlarm2 | iasZoneStatusBattery, statusReceived: true}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		values, err := zi.stateValues(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, []StateValue{
+			{Name: "alarm", Value: true},
+			{Name: "tamper", Value: false},
+			{Name: "battery_low", Value: true},
+		}, values)
+	})
+}
+
+func TestZigbeeDoorLock_stateValues(t *testing.T) {
+	t.Run("returns the name of the lock state", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zdl := ZigbeeDoorLock{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zdl.gateway
+		device.device.Capabilities = []da.Capability{DoorLockFlag}
+		device.doorLockState = doorLockState{state: DoorLockLocked, received: true}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		values, err := zdl.stateValues(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, []StateValue{{Name: "state", Value: "locked"}}, values)
+	})
+}
+
+func TestZigbeeWindowCovering_stateValues(t *testing.T) {
+	t.Run("returns the lift percentage of the covering", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zwc := ZigbeeWindowCovering{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zwc.gateway
+		device.device.Capabilities = []da.Capability{WindowCoveringFlag}
+		device.windowCoveringState = windowCoveringState{position: 40, available: true}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		values, err := zwc.stateValues(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, []StateValue{{Name: "position", Value: 40.0, Unit: UnitPercent}}, values)
+	})
+}
+
+func TestZigbeeGateway_CapabilityState(t *testing.T) {
+	t.Run("returns error if the capability does not support normalised state", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		_, err := zgw.CapabilityState(context.Background(), da.Device{Gateway: zgw}, capabilities.HasProductInformationFlag)
+		assert.Error(t, err)
+	})
+
+	t.Run("returns the normalised state of the capability", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+		iDev.device.Capabilities = []da.Capability{capabilities.OnOffFlag}
+		iDev.onOffState.State = true
+
+		state, err := zgw.CapabilityState(context.Background(), iDev.device, capabilities.OnOffFlag)
+		assert.NoError(t, err)
+		assert.Equal(t, CapabilityState{Capability: capabilities.OnOffFlag, Values: []StateValue{{Name: "on", Value: true}}}, state)
+	})
+}
+
+func TestZigbeeGateway_CapabilityStates(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		_, err := zgw.CapabilityStates(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns states of capabilities which support it, omitting those with no reading", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+		iDev.device.Capabilities = []da.Capability{capabilities.HasProductInformationFlag, TemperatureSensorFlag, LevelControlFlag}
+		iDev.currentLevel = 127
+
+		states, err := zgw.CapabilityStates(context.Background(), iDev.device)
+		assert.NoError(t, err)
+		assert.Equal(t, []CapabilityState{{Capability: LevelControlFlag, Values: []StateValue{{Name: "level", Value: 127.0}}}}, states)
+	})
+}
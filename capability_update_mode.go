@@ -0,0 +1,131 @@
+package zda
+
+import (
+	"fmt"
+	"github.com/shimmeringbee/da"
+	"sort"
+	"time"
+)
+
+// CapabilityUpdateMode is how zda learns of changes to a capability's state on a device.
+type CapabilityUpdateMode uint8
+
+const (
+	// UpdateModeNone is used when reporting could not be configured and the capability is not polled, its state only
+	// updates on enumeration or if the device reports unsolicited.
+	UpdateModeNone CapabilityUpdateMode = iota
+	// UpdateModeReported is used when the device has accepted a reporting configuration, updates arrive as the state
+	// changes.
+	UpdateModeReported
+	// UpdateModePolled is used when zda periodically reads the state, updates are delayed by up to the poll interval.
+	UpdateModePolled
+)
+
+// maximumCapabilityUpdateModeHistory is the number of changes in update mode retained per capability.
+const maximumCapabilityUpdateModeHistory = 10
+
+// CapabilityUpdateModeChange records a decision on how a capability is updated, Reason explains why, such as the
+// error returned when configuring reporting.
+type CapabilityUpdateModeChange struct {
+	Mode   CapabilityUpdateMode
+	Reason string
+	At     time.Time
+}
+
+// CapabilityUpdateModeStatus is the current update mode of a capability on a device, and the history of decisions
+// that led to it, oldest first.
+type CapabilityUpdateModeStatus struct {
+	Capability da.Capability
+	Mode       CapabilityUpdateMode
+	History    []CapabilityUpdateModeChange
+}
+
+// CapabilityUpdateModeChanged is sent when a capability on a device switches between being reported and polled, such
+// as when reporting can no longer be configured after a re-enumeration.
+type CapabilityUpdateModeChanged struct {
+	Device     da.Device
+	Capability da.Capability
+	Previous   CapabilityUpdateMode
+	Mode       CapabilityUpdateMode
+	Reason     string
+}
+
+// setCapabilityUpdateMode records how the capability on the device is updated, sending CapabilityUpdateModeChanged if
+// the mode differs from the one previously recorded. The first decision for a capability is recorded without an
+// event. The device mutex must be held for writing by the caller.
+func setCapabilityUpdateMode(sender eventSender, iDev *internalDevice, capability da.Capability, mode CapabilityUpdateMode, reason string) {
+	if iDev.capabilityUpdateModes == nil {
+		iDev.capabilityUpdateModes = map[da.Capability][]CapabilityUpdateModeChange{}
+	}
+
+	history := iDev.capabilityUpdateModes[capability]
+
+	if len(history) > 0 && history[len(history)-1].Mode == mode {
+		return
+	}
+
+	history = append(history, CapabilityUpdateModeChange{Mode: mode, Reason: reason, At: time.Now()})
+
+	if len(history) > maximumCapabilityUpdateModeHistory {
+		history = history[len(history)-maximumCapabilityUpdateModeHistory:]
+	}
+
+	iDev.capabilityUpdateModes[capability] = history
+
+	if len(history) > 1 {
+		previous := history[len(history)-2].Mode
+		sender.sendEvent(CapabilityUpdateModeChanged{Device: iDev.device, Capability: capability, Previous: previous, Mode: mode, Reason: reason})
+	}
+}
+
+// capabilityUpdateModes returns the update mode of each capability the device currently has, ordered by capability.
+// The device mutex must be held by the caller.
+func capabilityUpdateModes(iDev *internalDevice) []CapabilityUpdateModeStatus {
+	var statuses []CapabilityUpdateModeStatus
+
+	for capability, history := range iDev.capabilityUpdateModes {
+		if !iDev.device.HasCapability(capability) || len(history) == 0 {
+			continue
+		}
+
+		statuses = append(statuses, CapabilityUpdateModeStatus{
+			Capability: capability,
+			Mode:       history[len(history)-1].Mode,
+			History:    append([]CapabilityUpdateModeChange(nil), history...),
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Capability < statuses[j].Capability
+	})
+
+	return statuses
+}
+
+// CapabilityUpdateModes returns whether each capability of the device is updated by reports or by polling, along with
+// the history of changes. This allows operators to understand why a reading may update slowly.
+func (z *ZigbeeGateway) CapabilityUpdateModes(device da.Device) ([]CapabilityUpdateModeStatus, error) {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return nil, err
+	}
+
+	iDev.mutex.RLock()
+	defer iDev.mutex.RUnlock()
+
+	return capabilityUpdateModes(iDev), nil
+}
+
+// reportedUpdateMode returns the update mode of a capability which relies on attribute reporting, along with the
+// reason, from the outcome of binding and configuring reporting. If either failed the fallback mode is returned.
+func reportedUpdateMode(bindErr error, reportingErr error, fallback CapabilityUpdateMode) (CapabilityUpdateMode, string) {
+	switch {
+	case bindErr != nil:
+		return fallback, fmt.Sprintf("binding failed: %s", bindErr)
+	case reportingErr != nil:
+		return fallback, fmt.Sprintf("configuring reporting failed: %s", reportingErr)
+	default:
+		return UpdateModeReported, "reporting configured"
+	}
+}
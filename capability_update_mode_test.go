@@ -0,0 +1,122 @@
+package zda
+
+import (
+	"errors"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func Test_reportedUpdateMode(t *testing.T) {
+	t.Run("returns reported if binding and configuring reporting succeeded", func(t *testing.T) {
+		mode, reason := reportedUpdateMode(nil, nil, UpdateModePolled)
+		assert.Equal(t, UpdateModeReported, mode)
+		assert.Equal(t, "reporting configured", reason)
+	})
+
+	t.Run("returns the fallback with the error if either failed", func(t *testing.T) {
+		mode, reason := reportedUpdateMode(errors.New("no route"), nil, UpdateModePolled)
+		assert.Equal(t, UpdateModePolled, mode)
+		assert.Equal(t, "binding failed: no route", reason)
+
+		mode, reason = reportedUpdateMode(nil, errors.New("unsupported attribute"), UpdateModeNone)
+		assert.Equal(t, UpdateModeNone, mode)
+		assert.Equal(t, "configuring reporting failed: unsupported attribute", reason)
+	})
+}
+
+func Test_setCapabilityUpdateMode(t *testing.T) {
+	t.Run("records the first decision without sending an event", func(t *testing.T) {
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		_, iDev := generateTestNodeAndDevice()
+		iDev.device.Capabilities = []da.Capability{capabilities.OnOffFlag}
+
+		setCapabilityUpdateMode(&mockEventSender, iDev, capabilities.OnOffFlag, UpdateModeReported, "reporting configured")
+
+		statuses := capabilityUpdateModes(iDev)
+		assert.Len(t, statuses, 1)
+		assert.Equal(t, UpdateModeReported, statuses[0].Mode)
+		assert.Len(t, statuses[0].History, 1)
+	})
+
+	t.Run("sends an event when the mode switches, and ignores decisions which do not change the mode", func(t *testing.T) {
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		_, iDev := generateTestNodeAndDevice()
+		iDev.device.Capabilities = []da.Capability{capabilities.OnOffFlag}
+
+		mockEventSender.On("sendEvent", CapabilityUpdateModeChanged{Device: iDev.device, Capability: capabilities.OnOffFlag, Previous: UpdateModeReported, Mode: UpdateModePolled, Reason: "binding failed"}).Once()
+
+		setCapabilityUpdateMode(&mockEventSender, iDev, capabilities.OnOffFlag, UpdateModeReported, "reporting configured")
+		setCapabilityUpdateMode(&mockEventSender, iDev, capabilities.OnOffFlag, UpdateModeReported, "reporting configured")
+		setCapabilityUpdateMode(&mockEventSender, iDev, capabilities.OnOffFlag, UpdateModePolled, "binding failed")
+
+		statuses := capabilityUpdateModes(iDev)
+		assert.Equal(t, UpdateModePolled, statuses[0].Mode)
+		assert.Len(t, statuses[0].History, 2)
+		assert.Equal(t, "binding failed", statuses[0].History[1].Reason)
+	})
+
+	t.Run("retains only the most recent history", func(t *testing.T) {
+		mockEventSender := mockEventSender{}
+		mockEventSender.On("sendEvent", mock.Anything)
+
+		_, iDev := generateTestNodeAndDevice()
+		iDev.device.Capabilities = []da.Capability{capabilities.OnOffFlag}
+
+		for i := 0; i < maximumCapabilityUpdateModeHistory+3; i++ {
+			setCapabilityUpdateMode(&mockEventSender, iDev, capabilities.OnOffFlag, CapabilityUpdateMode(i%2+1), "")
+		}
+
+		statuses := capabilityUpdateModes(iDev)
+		assert.Len(t, statuses[0].History, maximumCapabilityUpdateModeHistory)
+	})
+}
+
+func Test_capabilityUpdateModes(t *testing.T) {
+	t.Run("returns only capabilities the device has, ordered by capability", func(t *testing.T) {
+		mockEventSender := mockEventSender{}
+
+		_, iDev := generateTestNodeAndDevice()
+		iDev.device.Capabilities = []da.Capability{TemperatureSensorFlag, capabilities.OnOffFlag}
+
+		setCapabilityUpdateMode(&mockEventSender, iDev, TemperatureSensorFlag, UpdateModeReported, "reporting configured")
+		setCapabilityUpdateMode(&mockEventSender, iDev, capabilities.OnOffFlag, UpdateModePolled, "binding failed")
+		setCapabilityUpdateMode(&mockEventSender, iDev, BatteryFlag, UpdateModePolled, "polled")
+
+		statuses := capabilityUpdateModes(iDev)
+		assert.Len(t, statuses, 2)
+		assert.Equal(t, capabilities.OnOffFlag, statuses[0].Capability)
+		assert.Equal(t, TemperatureSensorFlag, statuses[1].Capability)
+	})
+}
+
+func TestZigbeeGateway_CapabilityUpdateModes(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		_, err := zgw.CapabilityUpdateModes(da.Device{})
+		assert.Error(t, err)
+	})
+
+	t.Run("returns the update modes of the device", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+		iDev.device.Capabilities = []da.Capability{capabilities.OnOffFlag}
+
+		setCapabilityUpdateMode(zgw, iDev, capabilities.OnOffFlag, UpdateModeReported, "reporting configured")
+
+		statuses, err := zgw.CapabilityUpdateModes(iDev.device)
+		assert.NoError(t, err)
+		assert.Len(t, statuses, 1)
+		assert.Equal(t, UpdateModeReported, statuses[0].Mode)
+	})
+}
@@ -0,0 +1,27 @@
+package zda
+
+import "time"
+
+// clock is the source of time for the gateway, time dependent behaviour such as discovery windows, polling and
+// staleness use it rather than the time package so that tests may control the passage of time.
+type clock interface {
+	Now() time.Time
+	AfterFunc(time.Duration, func()) clockTimer
+}
+
+// clockTimer is a timer created by a clock, as with time.Timer Stop returns false if the timer had already fired or
+// been stopped.
+type clockTimer interface {
+	Stop() bool
+}
+
+// realClock is the clock used by the gateway, backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) clockTimer {
+	return time.AfterFunc(d, f)
+}
@@ -0,0 +1,144 @@
+package zda
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock for tests whose time only moves when advanced, timers due within the advance are fired in
+// order on the advancing goroutine.
+type fakeClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock *fakeClock
+	at    time.Time
+	f     func()
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) clockTimer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	timer := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+
+	return timer
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	for i, timer := range t.clock.timers {
+		if timer == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Advance moves the clock forward, firing each timer as the clock reaches it.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	end := c.now.Add(d)
+	c.mutex.Unlock()
+
+	for {
+		c.mutex.Lock()
+		sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].at.Before(c.timers[j].at) })
+
+		if len(c.timers) == 0 || c.timers[0].at.After(end) {
+			c.now = end
+			c.mutex.Unlock()
+			return
+		}
+
+		timer := c.timers[0]
+		c.timers = c.timers[1:]
+		c.now = timer.at
+		c.mutex.Unlock()
+
+		timer.f()
+	}
+}
+
+// WaitForTimers blocks until at least count timers are pending, for use when timers are created on other goroutines.
+// False is returned if they are not created within a second.
+func (c *fakeClock) WaitForTimers(count int) bool {
+	deadline := time.Now().Add(time.Second)
+
+	for time.Now().Before(deadline) {
+		c.mutex.Lock()
+		pending := len(c.timers)
+		c.mutex.Unlock()
+
+		if pending >= count {
+			return true
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	return false
+}
+
+func TestRealClock(t *testing.T) {
+	t.Run("fires timers after the duration, unless stopped", func(t *testing.T) {
+		c := realClock{}
+
+		fired := make(chan struct{})
+		c.AfterFunc(time.Millisecond, func() { close(fired) })
+
+		select {
+		case <-fired:
+		case <-time.After(time.Second):
+			assert.Fail(t, "timer did not fire")
+		}
+
+		stopped := c.AfterFunc(time.Hour, func() {})
+		assert.True(t, stopped.Stop())
+	})
+}
+
+func TestFakeClock(t *testing.T) {
+	t.Run("fires timers in order as time is advanced, and not once stopped", func(t *testing.T) {
+		c := newFakeClock()
+		start := c.Now()
+
+		var fired []time.Duration
+
+		c.AfterFunc(20*time.Millisecond, func() { fired = append(fired, c.Now().Sub(start)) })
+		c.AfterFunc(10*time.Millisecond, func() { fired = append(fired, c.Now().Sub(start)) })
+		stopped := c.AfterFunc(15*time.Millisecond, func() { fired = append(fired, c.Now().Sub(start)) })
+
+		assert.True(t, stopped.Stop())
+		assert.False(t, stopped.Stop())
+
+		c.Advance(15 * time.Millisecond)
+		assert.Equal(t, []time.Duration{10 * time.Millisecond}, fired)
+		assert.Equal(t, 15*time.Millisecond, c.Now().Sub(start))
+
+		c.Advance(5 * time.Millisecond)
+		assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, fired)
+	})
+}
@@ -0,0 +1,114 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/retry"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+	"sort"
+)
+
+// ClusterRevisionAttribute is the global attribute present on every cluster from ZCL revision 6 onwards, declaring
+// the revision of the cluster specification implemented.
+const ClusterRevisionAttribute = zcl.AttributeID(0xfffd)
+
+// isKeyCluster returns true if the cluster backs a capability, only these clusters have their revision read during
+// enumeration.
+func isKeyCluster(cluster zigbee.ClusterID) bool {
+	for _, keyCluster := range capabilityClusters {
+		if keyCluster == cluster {
+			return true
+		}
+	}
+
+	return false
+}
+
+// enumerateClusterRevisions reads the cluster revision of every key cluster on each endpoint of the node. Clusters
+// which do not declare a revision, or which fail to be read, are left absent as they predate ZCL revision 6.
+func (z *ZigbeeEnumerateDevice) enumerateClusterRevisions(ctx context.Context, iNode *internalNode) {
+	iNode.mutex.RLock()
+
+	var endpoints []zigbee.Endpoint
+	keyClusters := map[zigbee.Endpoint][]zigbee.ClusterID{}
+
+	for endpoint, desc := range iNode.endpointDescriptions {
+		for _, cluster := range desc.InClusterList {
+			if isKeyCluster(cluster) {
+				keyClusters[endpoint] = append(keyClusters[endpoint], cluster)
+			}
+		}
+
+		endpoints = append(endpoints, endpoint)
+	}
+
+	supportsAPSAck := iNode.supportsAPSAck
+
+	iNode.mutex.RUnlock()
+
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i] < endpoints[j] })
+
+	revisions := map[zigbee.Endpoint]map[zigbee.ClusterID]uint16{}
+
+	for _, endpoint := range endpoints {
+		for _, cluster := range keyClusters[endpoint] {
+			if err := retry.Retry(ctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
+				records, err := z.zclGlobalCommunicator.ReadAttributes(ctx, iNode.ieeeAddress, supportsAPSAck, cluster, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, endpoint, iNode.nextTransactionSequence(), []zcl.AttributeID{ClusterRevisionAttribute})
+
+				if err == nil {
+					if revision, ok := parseReadAttributeResponse(records).uintValue(ClusterRevisionAttribute); ok {
+						if revisions[endpoint] == nil {
+							revisions[endpoint] = map[zigbee.ClusterID]uint16{}
+						}
+
+						revisions[endpoint][cluster] = uint16(revision)
+					}
+				}
+
+				return err
+			}); err != nil {
+				log.Printf("failed to read cluster revision: %s: %d: %04x: %s", iNode.ieeeAddress, endpoint, cluster, err)
+			}
+		}
+	}
+
+	iNode.mutex.Lock()
+	iNode.clusterRevisions = revisions
+	iNode.mutex.Unlock()
+}
+
+// clusterRevision returns the revision of the cluster on the endpoint of the node, false is returned if the cluster
+// did not declare a revision. The node mutex must be held by the caller.
+func clusterRevision(iNode *internalNode, endpoint zigbee.Endpoint, cluster zigbee.ClusterID) (uint16, bool) {
+	revision, found := iNode.clusterRevisions[endpoint][cluster]
+	return revision, found
+}
+
+// ClusterRevision returns the revision of the cluster implemented by the device as read during enumeration, false is
+// returned if the device does not have the cluster or the cluster did not declare a revision.
+func (z *ZigbeeGateway) ClusterRevision(device da.Device, cluster zigbee.ClusterID) (uint16, bool, error) {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	iNode := iDev.node
+
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	iDev.mutex.RLock()
+	defer iDev.mutex.RUnlock()
+
+	endpoint, found := findEndpointWithClusterId(iNode, iDev, cluster)
+
+	if !found {
+		return 0, false, nil
+	}
+
+	revision, found := clusterRevision(iNode, endpoint, cluster)
+	return revision, found, nil
+}
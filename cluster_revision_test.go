@@ -0,0 +1,74 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZigbeeEnumerateDevice_enumerateClusterRevisions(t *testing.T) {
+	t.Run("reads the cluster revision of key clusters only, omitting clusters which do not declare one", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		node, _ := generateTestNodeAndDevice()
+
+		node.endpointDescriptions[1] = zigbee.EndpointDescription{
+			Endpoint:      1,
+			InClusterList: []zigbee.ClusterID{zcl.BasicId, zcl.OnOffId, zcl.GroupsId},
+		}
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.BasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(1), mock.Anything, []zcl.AttributeID{ClusterRevisionAttribute}).
+			Return([]global.ReadAttributeResponseRecord{{Identifier: ClusterRevisionAttribute, Status: 0x86}}, nil)
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.OnOffId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(1), mock.Anything, []zcl.AttributeID{ClusterRevisionAttribute}).
+			Return([]global.ReadAttributeResponseRecord{
+				{Identifier: ClusterRevisionAttribute, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeUnsignedInt16, Value: uint64(2)}},
+			}, nil)
+
+		zed := ZigbeeEnumerateDevice{zclGlobalCommunicator: &mockZclGlobalCommunicator}
+		zed.enumerateClusterRevisions(context.Background(), node)
+
+		assert.Equal(t, map[zigbee.Endpoint]map[zigbee.ClusterID]uint16{1: {zcl.OnOffId: 2}}, node.clusterRevisions)
+	})
+}
+
+func TestZigbeeGateway_ClusterRevision(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		_, _, err := zgw.ClusterRevision(da.Device{}, zcl.OnOffId)
+		assert.Error(t, err)
+	})
+
+	t.Run("returns the revision of the cluster on the device if known", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		node.endpoints = []zigbee.Endpoint{1}
+		node.endpointDescriptions[1] = zigbee.EndpointDescription{Endpoint: 1, InClusterList: []zigbee.ClusterID{zcl.OnOffId, zcl.BasicId}}
+		iDev.endpoints = []zigbee.Endpoint{1}
+
+		node.clusterRevisions = map[zigbee.Endpoint]map[zigbee.ClusterID]uint16{1: {zcl.OnOffId: 2}}
+
+		revision, found, err := zgw.ClusterRevision(iDev.device, zcl.OnOffId)
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, uint16(2), revision)
+
+		_, found, err = zgw.ClusterRevision(iDev.device, zcl.BasicId)
+		assert.NoError(t, err)
+		assert.False(t, found)
+
+		_, found, err = zgw.ClusterRevision(iDev.device, zcl.PriceId)
+		assert.NoError(t, err)
+		assert.False(t, found)
+	})
+}
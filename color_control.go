@@ -0,0 +1,465 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/retry"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+	"time"
+)
+
+const (
+	MoveToColorId            = zcl.CommandIdentifier(0x07)
+	MoveToColorTemperatureId = zcl.CommandIdentifier(0x0a)
+)
+
+const (
+	ColorControlCurrentX               = zcl.AttributeID(0x0003)
+	ColorControlCurrentY               = zcl.AttributeID(0x0004)
+	ColorControlColorTemperatureMireds = zcl.AttributeID(0x0007)
+	ColorControlColorMode              = zcl.AttributeID(0x0008)
+	ColorControlColorCapabilities      = zcl.AttributeID(0x400a)
+)
+
+const (
+	colorCapabilityXY               = uint64(1 << 3)
+	colorCapabilityColorTemperature = uint64(1 << 4)
+)
+
+// colorCoordinateScale converts between CIE 1931 coordinates and their ZCL representation, the maximum coordinate the
+// ZCL can represent is 0xfeff.
+const colorCoordinateScale = 65536
+const maximumColorCoordinate = float64(0xfeff) / colorCoordinateScale
+
+const colorControlPollInterval = 30 * time.Second
+
+// MoveToColor is sent to a Color Control cluster server to move to the CIE 1931 coordinates over the transition time,
+// in tenths of a second.
+type MoveToColor struct {
+	ColorX         uint16
+	ColorY         uint16
+	TransitionTime uint16
+}
+
+// MoveToColorTemperature is sent to a Color Control cluster server to move to the color temperature, in mireds, over
+// the transition time, in tenths of a second.
+type MoveToColorTemperature struct {
+	ColorTemperatureMireds uint16
+	TransitionTime         uint16
+}
+
+func registerColorControlCommands(cr *zcl.CommandRegistry) {
+	cr.RegisterLocal(zcl.ColorControlId, zigbee.NoManufacturer, MoveToColorId, &MoveToColor{})
+	cr.RegisterLocal(zcl.ColorControlId, zigbee.NoManufacturer, MoveToColorTemperatureId, &MoveToColorTemperature{})
+}
+
+type ColorMode uint8
+
+const (
+	ColorModeHueSaturation    ColorMode = 0x00
+	ColorModeXY               ColorMode = 0x01
+	ColorModeColorTemperature ColorMode = 0x02
+)
+
+// ColorControl is a capability which signifies that a device can change the color of its light, by CIE 1931
+// coordinates, color temperature or both.
+type ColorControl interface {
+	// ChangeColorTemperature moves the device to the color temperature, in mireds, over the transition time.
+	ChangeColorTemperature(context.Context, da.Device, uint16, time.Duration) error
+
+	// ChangeColorXY moves the device to the CIE 1931 coordinates over the transition time.
+	ChangeColorXY(context.Context, da.Device, float64, float64, time.Duration) error
+
+	// State returns the last known color of the device, and which color modes it supports.
+	State(context.Context, da.Device) (ColorControlState, error)
+}
+
+// ColorControlState is the color of a device. Mode indicates which of the XY coordinates or ColorTemperature the
+// device is currently using, the other may be stale.
+type ColorControlState struct {
+	Mode ColorMode
+
+	X float64
+	Y float64
+	// ColorTemperature in mireds.
+	ColorTemperature uint16
+
+	SupportsXY               bool
+	SupportsColorTemperature bool
+}
+
+// ColorChanged is sent to inform consumers that a devices color has changed.
+type ColorChanged struct {
+	// Device whose color has changed.
+	Device da.Device
+	// New color of the device.
+	State ColorControlState
+}
+
+type ZigbeeColorControl struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+	nodeStore         nodeStore
+
+	zclCommunicatorRequests zclCommunicatorRequests
+	zclGlobalCommunicator   zclGlobalCommunicator
+
+	poller      poller
+	eventSender eventSender
+}
+
+func (z *ZigbeeColorControl) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+	z.internalCallbacks.Add(z.NodeJoinCallback)
+}
+
+func (z *ZigbeeColorControl) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, ColorControlFlag); found {
+			addCapability(&dev.device, ColorControlFlag)
+			setCapabilityUpdateMode(z.eventSender, dev, ColorControlFlag, UpdateModePolled, "polled")
+
+			dev.colorControlState.SupportsXY, dev.colorControlState.SupportsColorTemperature = z.readColorCapabilities(ctx, node, endpoint, cluster)
+
+			if err := z.readColor(ctx, node, dev, endpoint, cluster); err != nil {
+				log.Printf("failed to read color: %s", err)
+			}
+		} else {
+			removeCapability(&dev.device, ColorControlFlag)
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// readColorCapabilities reads which color modes the device supports. The attribute was introduced in later revisions
+// of the cluster, if it can not be read only XY is presumed to be supported, as the coordinates are mandatory. The
+// node mutex must be held by the caller.
+func (z *ZigbeeColorControl) readColorCapabilities(ctx context.Context, iNode *internalNode, endpoint zigbee.Endpoint, cluster zigbee.ClusterID) (bool, bool) {
+	colorCapabilities := colorCapabilityXY
+
+	if err := retry.Retry(ctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
+		response, err := z.zclGlobalCommunicator.ReadAttributes(ctx, iNode.ieeeAddress, iNode.supportsAPSAck, cluster, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, endpoint, iNode.nextTransactionSequence(), []zcl.AttributeID{ColorControlColorCapabilities})
+
+		if err == nil {
+			if value, ok := parseReadAttributeResponse(response).uintValue(ColorControlColorCapabilities); ok {
+				colorCapabilities = value
+			}
+		}
+
+		return err
+	}); err != nil {
+		log.Printf("failed to read color capabilities: %s", err)
+	}
+
+	return colorCapabilities&colorCapabilityXY != 0, colorCapabilities&colorCapabilityColorTemperature != 0
+}
+
+// readColor reads the color mode and the attributes of each supported mode from the device, updating the cached
+// state. The node mutex must be held, and the device mutex held for writing, by the caller.
+func (z *ZigbeeColorControl) readColor(ctx context.Context, iNode *internalNode, iDevice *internalDevice, endpoint zigbee.Endpoint, cluster zigbee.ClusterID) error {
+	attributes := []zcl.AttributeID{ColorControlColorMode}
+
+	if iDevice.colorControlState.SupportsXY {
+		attributes = append(attributes, ColorControlCurrentX, ColorControlCurrentY)
+	}
+
+	if iDevice.colorControlState.SupportsColorTemperature {
+		attributes = append(attributes, ColorControlColorTemperatureMireds)
+	}
+
+	return retry.Retry(ctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
+		response, err := z.zclGlobalCommunicator.ReadAttributes(ctx, iNode.ieeeAddress, iNode.supportsAPSAck, cluster, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, endpoint, iNode.nextTransactionSequence(), attributes)
+
+		if err == nil {
+			results := parseReadAttributeResponse(response)
+			state := iDevice.colorControlState
+
+			if value, ok := results.uint8Value(ColorControlColorMode); ok {
+				state.Mode = ColorMode(value)
+			}
+
+			if value, ok := results.uintValue(ColorControlCurrentX); ok {
+				state.X = float64(value) / colorCoordinateScale
+			}
+
+			if value, ok := results.uintValue(ColorControlCurrentY); ok {
+				state.Y = float64(value) / colorCoordinateScale
+			}
+
+			if value, ok := results.uintValue(ColorControlColorTemperatureMireds); ok {
+				state.ColorTemperature = uint16(value)
+			}
+
+			z.setState(iDevice, state)
+		}
+
+		return err
+	})
+}
+
+func (z *ZigbeeColorControl) NodeJoinCallback(ctx context.Context, join internalNodeJoin) error {
+	z.poller.AddNode(join.node, colorControlPollInterval, z.pollNode)
+	return nil
+}
+
+func (z *ZigbeeColorControl) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(ColorControlFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+// validateColorXY checks the coordinates can be represented by the Move to Color command.
+func validateColorXY(x float64, y float64) error {
+	if x < 0 || x > maximumColorCoordinate || y < 0 || y > maximumColorCoordinate {
+		return fmt.Errorf("color coordinates (%f, %f) are outside of the range 0 to %f", x, y, maximumColorCoordinate)
+	}
+
+	return nil
+}
+
+// validateColorTransitionTime checks the transition time can be represented in tenths of a second, 0xffff is not
+// reserved by the Color Control cluster, but is excluded for consistency with Level Control.
+func validateColorTransitionTime(transitionTime time.Duration) error {
+	if transitionTime < 0 || transitionTime > MaximumLevelTransitionTime {
+		return fmt.Errorf("transition time %s is outside of the range 0 to %s", transitionTime, MaximumLevelTransitionTime)
+	}
+
+	return nil
+}
+
+// validateColorXYSupported checks the device supports setting its color by xy coordinates.
+func validateColorXYSupported(state ColorControlState) error {
+	if !state.SupportsXY {
+		return fmt.Errorf("device does not support xy color")
+	}
+
+	return nil
+}
+
+// validateColorTemperatureSupported checks the device supports setting its color temperature.
+func validateColorTemperatureSupported(state ColorControlState) error {
+	if !state.SupportsColorTemperature {
+		return fmt.Errorf("device does not support color temperature")
+	}
+
+	return nil
+}
+
+func (z *ZigbeeColorControl) validateArguments(iDevice *internalDevice, operation string, args []interface{}) error {
+	iDevice.mutex.RLock()
+	state := iDevice.colorControlState
+	iDevice.mutex.RUnlock()
+
+	switch operation {
+	case "ChangeColorXY":
+		if err := validateColorXY(args[0].(float64), args[1].(float64)); err != nil {
+			return err
+		}
+
+		if err := validateColorTransitionTime(args[2].(time.Duration)); err != nil {
+			return err
+		}
+
+		return validateColorXYSupported(state)
+	case "ChangeColorTemperature":
+		if err := validateColorTransitionTime(args[1].(time.Duration)); err != nil {
+			return err
+		}
+
+		return validateColorTemperatureSupported(state)
+	}
+
+	return nil
+}
+
+func (z *ZigbeeColorControl) ChangeColorTemperature(ctx context.Context, device da.Device, mireds uint16, transitionTime time.Duration) error {
+	if err := validateColorTransitionTime(transitionTime); err != nil {
+		return err
+	}
+
+	return z.sendCommand(ctx, device, transitionTime, func(state ColorControlState) (interface{}, error) {
+		if err := validateColorTemperatureSupported(state); err != nil {
+			return nil, err
+		}
+
+		return &MoveToColorTemperature{ColorTemperatureMireds: mireds, TransitionTime: transitionTenths(transitionTime)}, nil
+	})
+}
+
+func (z *ZigbeeColorControl) ChangeColorXY(ctx context.Context, device da.Device, x float64, y float64, transitionTime time.Duration) error {
+	if err := validateColorXY(x, y); err != nil {
+		return err
+	}
+
+	if err := validateColorTransitionTime(transitionTime); err != nil {
+		return err
+	}
+
+	return z.sendCommand(ctx, device, transitionTime, func(state ColorControlState) (interface{}, error) {
+		if err := validateColorXYSupported(state); err != nil {
+			return nil, err
+		}
+
+		return &MoveToColor{ColorX: uint16(x * colorCoordinateScale), ColorY: uint16(y * colorCoordinateScale), TransitionTime: transitionTenths(transitionTime)}, nil
+	})
+}
+
+// sendCommand sends the command built for the device's state, then polls the color of the device once the transition
+// has completed.
+func (z *ZigbeeColorControl) sendCommand(ctx context.Context, device da.Device, transitionTime time.Duration, buildCommand func(ColorControlState) (interface{}, error)) error {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return err
+	}
+
+	iNode := iDevice.node
+
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	command, err := buildCommand(iDevice.colorControlState)
+
+	if err != nil {
+		return err
+	}
+
+	endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, ColorControlFlag)
+
+	if !found {
+		return fmt.Errorf("unable to find color control cluster on zigbee device in zda")
+	}
+
+	zclMsg := zcl.Message{
+		FrameType:           zcl.FrameLocal,
+		Direction:           zcl.ClientToServer,
+		TransactionSequence: iNode.nextTransactionSequence(),
+		Manufacturer:        zigbee.NoManufacturer,
+		ClusterID:           cluster,
+		SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+		DestinationEndpoint: endpoint,
+		Command:             command,
+	}
+
+	cmdCtx, cancel := commandContext(ctx, iDevice)
+	defer cancel()
+
+	if err := z.zclCommunicatorRequests.Request(cmdCtx, iNode.ieeeAddress, iNode.supportsAPSAck, zclMsg); err != nil {
+		return err
+	}
+
+	time.AfterFunc(transitionTime+delayAfterSetForPolling, func() {
+		ctx, done := context.WithTimeout(context.Background(), DefaultNetworkTimeout)
+		defer done()
+
+		iNode.mutex.RLock()
+		defer iNode.mutex.RUnlock()
+
+		z.pollDevice(ctx, iNode, iDevice)
+	})
+
+	return nil
+}
+
+func (z *ZigbeeColorControl) State(ctx context.Context, device da.Device) (ColorControlState, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return ColorControlState{}, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	return iDevice.colorControlState, nil
+}
+
+var colorModeNames = map[ColorMode]string{
+	ColorModeHueSaturation:    "hue_saturation",
+	ColorModeXY:               "xy",
+	ColorModeColorTemperature: "color_temperature",
+}
+
+// stateValues returns the color mode, along with the xy coordinates and color temperature if the device supports them.
+func (z *ZigbeeColorControl) stateValues(ctx context.Context, device da.Device) ([]StateValue, error) {
+	state, err := z.State(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []StateValue{{Name: "mode", Value: colorModeNames[state.Mode]}}
+
+	if state.SupportsXY {
+		values = append(values, StateValue{Name: "x", Value: state.X}, StateValue{Name: "y", Value: state.Y})
+	}
+
+	if state.SupportsColorTemperature {
+		values = append(values, StateValue{Name: "color_temperature", Value: float64(state.ColorTemperature), Unit: UnitMired})
+	}
+
+	return values, nil
+}
+
+func (z *ZigbeeColorControl) setState(device *internalDevice, state ColorControlState) {
+	markCapabilityUpdated(device, ColorControlFlag)
+
+	if device.colorControlState != state {
+		device.colorControlState = state
+		z.eventSender.sendEvent(ColorChanged{Device: device.device, State: state})
+	}
+}
+
+func (z *ZigbeeColorControl) pollNode(pctx context.Context, iNode *internalNode) {
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	for _, iDevice := range iNode.devices {
+		z.pollDevice(pctx, iNode, iDevice)
+	}
+}
+
+// pollDevice reads the color of the device, if it has the capability and is mains powered. The node mutex must be held
+// by the caller.
+func (z *ZigbeeColorControl) pollDevice(pctx context.Context, iNode *internalNode, iDevice *internalDevice) {
+	iDevice.mutex.Lock()
+	defer iDevice.mutex.Unlock()
+
+	if !iDevice.device.HasCapability(ColorControlFlag) || iNode.nodeDesc.LogicalType != zigbee.Router {
+		return
+	}
+
+	if endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, ColorControlFlag); found {
+		if err := z.readColor(pctx, iNode, iDevice, endpoint, cluster); err != nil {
+			log.Printf("failed to query color in zda: %s", err)
+		}
+	}
+}
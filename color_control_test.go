@@ -0,0 +1,315 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestZigbeeColorControl_Contract(t *testing.T) {
+	t.Run("can be assigned to a ColorControl", func(t *testing.T) {
+		assert.Implements(t, (*ColorControl)(nil), new(ZigbeeColorControl))
+	})
+}
+
+func TestZigbeeColorControl_Init(t *testing.T) {
+	t.Run("initialises the color control capability by registering internalCallbacks", func(t *testing.T) {
+		mIntCallbacks := mockAdderCaller{}
+		defer mIntCallbacks.AssertExpectations(t)
+
+		zcc := ZigbeeColorControl{internalCallbacks: &mIntCallbacks}
+
+		mIntCallbacks.On("Add", mock.Anything).Twice()
+
+		zcc.Init()
+	})
+}
+
+func generateTestColorNodeAndDevice() (*internalNode, *internalDevice) {
+	node, device := generateTestNodeAndDevice()
+
+	deviceEndpoint := node.endpoints[0]
+	endpointDescription := node.endpointDescriptions[deviceEndpoint]
+	endpointDescription.InClusterList = []zigbee.ClusterID{zcl.ColorControlId}
+	node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+	return node, device
+}
+
+func uint16AttributeRecord(id zcl.AttributeID, value uint64) global.ReadAttributeResponseRecord {
+	return global.ReadAttributeResponseRecord{Identifier: id, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeUnsignedInt16, Value: value}}
+}
+
+func TestZigbeeColorControl_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability, reads color capabilities and the color of each supported mode", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zcc := ZigbeeColorControl{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestColorNodeAndDevice()
+		deviceEndpoint := node.endpoints[0]
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.ColorControlId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(1), []zcl.AttributeID{ColorControlColorCapabilities}).Return([]global.ReadAttributeResponseRecord{
+			{Identifier: ColorControlColorCapabilities, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeBitmap16, Value: uint64(0x0018)}},
+		}, nil)
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.ColorControlId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(2), []zcl.AttributeID{ColorControlColorMode, ColorControlCurrentX, ColorControlCurrentY, ColorControlColorTemperatureMireds}).Return([]global.ReadAttributeResponseRecord{
+			{Identifier: ColorControlColorMode, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeEnum8, Value: uint8(ColorModeColorTemperature)}},
+			uint16AttributeRecord(ColorControlCurrentX, 0x8000),
+			uint16AttributeRecord(ColorControlCurrentY, 0x4000),
+			uint16AttributeRecord(ColorControlColorTemperatureMireds, 370),
+		}, nil)
+		mockEventSender.On("sendEvent", mock.AnythingOfType("zda.ColorChanged"))
+
+		err := zcc.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(ColorControlFlag))
+		assert.Equal(t, ColorControlState{
+			Mode:                     ColorModeColorTemperature,
+			X:                        0.5,
+			Y:                        0.25,
+			ColorTemperature:         370,
+			SupportsXY:               true,
+			SupportsColorTemperature: true,
+		}, device.colorControlState)
+	})
+
+	t.Run("presumes only XY is supported if color capabilities can not be read", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+
+		zcc := ZigbeeColorControl{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestColorNodeAndDevice()
+		deviceEndpoint := node.endpoints[0]
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.ColorControlId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(1), []zcl.AttributeID{ColorControlColorCapabilities}).Return([]global.ReadAttributeResponseRecord{
+			{Identifier: ColorControlColorCapabilities, Status: 0x86},
+		}, nil)
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.ColorControlId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(2), []zcl.AttributeID{ColorControlColorMode, ColorControlCurrentX, ColorControlCurrentY}).Return([]global.ReadAttributeResponseRecord{}, nil)
+		mockEventSender.On("sendEvent", mock.Anything).Maybe()
+
+		err := zcc.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.colorControlState.SupportsXY)
+		assert.False(t, device.colorControlState.SupportsColorTemperature)
+	})
+
+	t.Run("removes capability from device without Color Control cluster", func(t *testing.T) {
+		zcc := ZigbeeColorControl{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{ColorControlFlag}
+
+		err := zcc.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(ColorControlFlag))
+	})
+}
+
+func TestZigbeeColorControl_ChangeColorTemperature(t *testing.T) {
+	t.Run("returns error if device does not have capability", func(t *testing.T) {
+		zcc := ZigbeeColorControl{gateway: &mockGateway{}}
+
+		err := zcc.ChangeColorTemperature(context.Background(), da.Device{Gateway: zcc.gateway}, 370, 0)
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns error if device does not support color temperature", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zcc := ZigbeeColorControl{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestColorNodeAndDevice()
+		device.device.Gateway = zcc.gateway
+		device.device.Capabilities = []da.Capability{ColorControlFlag}
+		device.colorControlState.SupportsXY = true
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		err := zcc.ChangeColorTemperature(context.Background(), device.device, 370, 0)
+		assert.Error(t, err)
+		assert.Error(t, zcc.validateArguments(device, "ChangeColorTemperature", []interface{}{uint16(370), time.Duration(0)}))
+	})
+
+	t.Run("sends Move to Color Temperature command to endpoint on device", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zcc := ZigbeeColorControl{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+
+		node, device := generateTestColorNodeAndDevice()
+		device.device.Gateway = zcc.gateway
+		device.device.Capabilities = []da.Capability{ColorControlFlag}
+		device.colorControlState.SupportsColorTemperature = true
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		expectedRequest := zcl.Message{
+			FrameType:           zcl.FrameLocal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: 1,
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           zcl.ColorControlId,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: node.endpoints[0],
+			Command:             &MoveToColorTemperature{ColorTemperatureMireds: 370, TransitionTime: 10},
+		}
+		mockZclCommunicatorRequests.On("Request", mock.Anything, node.ieeeAddress, false, expectedRequest).Return(nil)
+
+		err := zcc.ChangeColorTemperature(context.Background(), device.device, 370, time.Second)
+		assert.NoError(t, err)
+	})
+}
+
+func TestZigbeeColorControl_ChangeColorXY(t *testing.T) {
+	t.Run("returns error if coordinates are out of range", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zcc := ZigbeeColorControl{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestColorNodeAndDevice()
+		device.device.Gateway = zcc.gateway
+		device.device.Capabilities = []da.Capability{ColorControlFlag}
+		device.colorControlState.SupportsXY = true
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true).Maybe()
+
+		assert.Error(t, zcc.ChangeColorXY(context.Background(), device.device, -0.1, 0.5, 0))
+		assert.Error(t, zcc.ChangeColorXY(context.Background(), device.device, 0.5, 1.0, 0))
+	})
+
+	t.Run("returns error if device does not support xy", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zcc := ZigbeeColorControl{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestColorNodeAndDevice()
+		device.device.Gateway = zcc.gateway
+		device.device.Capabilities = []da.Capability{ColorControlFlag}
+		device.colorControlState.SupportsColorTemperature = true
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		err := zcc.ChangeColorXY(context.Background(), device.device, 0.3, 0.3, 0)
+		assert.Error(t, err)
+		assert.Error(t, zcc.validateArguments(device, "ChangeColorXY", []interface{}{0.3, 0.3, time.Duration(0)}))
+	})
+
+	t.Run("sends Move to Color command to endpoint on device", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zcc := ZigbeeColorControl{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+
+		node, device := generateTestColorNodeAndDevice()
+		device.device.Gateway = zcc.gateway
+		device.device.Capabilities = []da.Capability{ColorControlFlag}
+		device.colorControlState.SupportsXY = true
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		expectedRequest := zcl.Message{
+			FrameType:           zcl.FrameLocal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: 1,
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           zcl.ColorControlId,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: node.endpoints[0],
+			Command:             &MoveToColor{ColorX: 0x8000, ColorY: 0x4000, TransitionTime: 5},
+		}
+		mockZclCommunicatorRequests.On("Request", mock.Anything, node.ieeeAddress, false, expectedRequest).Return(nil)
+
+		err := zcc.ChangeColorXY(context.Background(), device.device, 0.5, 0.25, 500*time.Millisecond)
+		assert.NoError(t, err)
+	})
+}
+
+func TestZigbeeColorControl_State(t *testing.T) {
+	t.Run("returns the last known color of the device", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		zcc := ZigbeeColorControl{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestColorNodeAndDevice()
+		device.device.Gateway = zcc.gateway
+		device.device.Capabilities = []da.Capability{ColorControlFlag}
+		device.colorControlState = ColorControlState{Mode: ColorModeXY, X: 0.3, Y: 0.3, SupportsXY: true}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		state, err := zcc.State(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, device.colorControlState, state)
+	})
+}
+
+func TestZigbeeColorControl_pollNode(t *testing.T) {
+	t.Run("reads the color of mains powered devices, sending an event only if it changed", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zcc := ZigbeeColorControl{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestColorNodeAndDevice()
+		node.nodeDesc.LogicalType = zigbee.Router
+		device.device.Capabilities = []da.Capability{ColorControlFlag}
+		device.colorControlState = ColorControlState{Mode: ColorModeColorTemperature, ColorTemperature: 300, SupportsColorTemperature: true}
+
+		attributes := []zcl.AttributeID{ColorControlColorMode, ColorControlColorTemperatureMireds}
+		mode := global.ReadAttributeResponseRecord{Identifier: ColorControlColorMode, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeEnum8, Value: uint8(ColorModeColorTemperature)}}
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.ColorControlId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], mock.Anything, attributes).Return([]global.ReadAttributeResponseRecord{mode, uint16AttributeRecord(ColorControlColorTemperatureMireds, 300)}, nil).Once()
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.ColorControlId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], mock.Anything, attributes).Return([]global.ReadAttributeResponseRecord{mode, uint16AttributeRecord(ColorControlColorTemperatureMireds, 250)}, nil).Once()
+
+		expectedState := ColorControlState{Mode: ColorModeColorTemperature, ColorTemperature: 250, SupportsColorTemperature: true}
+		mockEventSender.On("sendEvent", ColorChanged{Device: device.device, State: expectedState}).Once()
+
+		zcc.pollNode(context.Background(), node)
+		zcc.pollNode(context.Background(), node)
+
+		assert.Equal(t, expectedState, device.colorControlState)
+	})
+}
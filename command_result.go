@@ -0,0 +1,93 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+)
+
+// CommandCompleted is the final outcome of a command sent to a node with a correlation ID or completion callback
+// attached to its context.
+type CommandCompleted struct {
+	// CorrelationID is the value attached with WithCommandCorrelationID, nil if only a callback was attached.
+	CorrelationID interface{}
+
+	IEEEAddress zigbee.IEEEAddress
+	Endpoint    zigbee.Endpoint
+	Cluster     zigbee.ClusterID
+	// Command is the ZCL command which was sent.
+	Command interface{}
+
+	// Status is the ZCL status returned by the node in a default response, or 0 (success) if the command was
+	// delivered (and acknowledged, if an APS ACK was requested) without one.
+	Status uint8
+	// Err is set if the command could not be delivered, or no response was received.
+	Err error
+}
+
+type commandCorrelationKey struct{}
+type commandCallbackKey struct{}
+
+// WithCommandCorrelationID attaches a correlation ID to the context, any command sent to a node using the context
+// will result in a CommandCompleted event carrying the ID once it has completed.
+func WithCommandCorrelationID(ctx context.Context, id interface{}) context.Context {
+	return context.WithValue(ctx, commandCorrelationKey{}, id)
+}
+
+// WithCommandCallback attaches a completion callback to the context, any command sent to a node using the context
+// will call it once the command has completed. The callback is called on its own goroutine.
+func WithCommandCallback(ctx context.Context, callback func(CommandCompleted)) context.Context {
+	return context.WithValue(ctx, commandCallbackKey{}, callback)
+}
+
+// resultCommunicatorRequests wraps zclCommunicatorRequests, reporting the outcome of any request made with a
+// correlation ID or completion callback in its context.
+type resultCommunicatorRequests struct {
+	zclCommunicatorRequests
+	eventSender eventSender
+}
+
+func (r *resultCommunicatorRequests) Request(ctx context.Context, address zigbee.IEEEAddress, requireAck bool, message zcl.Message) error {
+	err := r.zclCommunicatorRequests.Request(ctx, address, requireAck, message)
+	r.complete(ctx, address, message, nil, err)
+
+	return err
+}
+
+func (r *resultCommunicatorRequests) RequestResponse(ctx context.Context, address zigbee.IEEEAddress, requireAck bool, message zcl.Message) (zcl.Message, error) {
+	response, err := r.zclCommunicatorRequests.RequestResponse(ctx, address, requireAck, message)
+	r.complete(ctx, address, message, response.Command, err)
+
+	return response, err
+}
+
+func (r *resultCommunicatorRequests) complete(ctx context.Context, address zigbee.IEEEAddress, message zcl.Message, response interface{}, err error) {
+	correlationID := ctx.Value(commandCorrelationKey{})
+	callback, hasCallback := ctx.Value(commandCallbackKey{}).(func(CommandCompleted))
+
+	if correlationID == nil && !hasCallback {
+		return
+	}
+
+	result := CommandCompleted{
+		CorrelationID: correlationID,
+		IEEEAddress:   address,
+		Endpoint:      message.DestinationEndpoint,
+		Cluster:       message.ClusterID,
+		Command:       message.Command,
+		Err:           err,
+	}
+
+	if defaultResponse, ok := response.(*global.DefaultResponse); ok {
+		result.Status = defaultResponse.Status
+	}
+
+	if correlationID != nil {
+		r.eventSender.sendEvent(result)
+	}
+
+	if hasCallback {
+		go callback(result)
+	}
+}
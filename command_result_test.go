@@ -0,0 +1,106 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/commands/local/onoff"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestResultCommunicatorRequests_Request(t *testing.T) {
+	message := zcl.Message{
+		FrameType:           zcl.FrameLocal,
+		ClusterID:           zcl.OnOffId,
+		DestinationEndpoint: 1,
+		Command:             &onoff.On{},
+	}
+
+	t.Run("does nothing extra if the context has no correlation ID or callback", func(t *testing.T) {
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		mockZclCommunicatorRequests.On("Request", mock.Anything, zigbee.IEEEAddress(0x01), true, message).Return(nil)
+
+		r := &resultCommunicatorRequests{zclCommunicatorRequests: &mockZclCommunicatorRequests, eventSender: &mockEventSender}
+
+		err := r.Request(context.Background(), zigbee.IEEEAddress(0x01), true, message)
+		assert.NoError(t, err)
+	})
+
+	t.Run("sends a CommandCompleted event carrying the correlation ID and error", func(t *testing.T) {
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		expectedErr := errors.New("no ack")
+		mockZclCommunicatorRequests.On("Request", mock.Anything, zigbee.IEEEAddress(0x01), true, message).Return(expectedErr)
+
+		mockEventSender.On("sendEvent", CommandCompleted{
+			CorrelationID: "id",
+			IEEEAddress:   zigbee.IEEEAddress(0x01),
+			Endpoint:      1,
+			Cluster:       zcl.OnOffId,
+			Command:       &onoff.On{},
+			Err:           expectedErr,
+		})
+
+		r := &resultCommunicatorRequests{zclCommunicatorRequests: &mockZclCommunicatorRequests, eventSender: &mockEventSender}
+
+		ctx := WithCommandCorrelationID(context.Background(), "id")
+
+		err := r.Request(ctx, zigbee.IEEEAddress(0x01), true, message)
+		assert.Equal(t, expectedErr, err)
+	})
+}
+
+func TestResultCommunicatorRequests_RequestResponse(t *testing.T) {
+	t.Run("calls the callback with the status of a default response", func(t *testing.T) {
+		message := zcl.Message{
+			FrameType:           zcl.FrameLocal,
+			ClusterID:           zcl.BasicId,
+			DestinationEndpoint: 1,
+			Command:             &ResetToFactoryDefaults{},
+		}
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, zigbee.IEEEAddress(0x01), false, message).Return(zcl.Message{
+			Command: &global.DefaultResponse{Status: 0x81},
+		}, nil)
+
+		r := &resultCommunicatorRequests{zclCommunicatorRequests: &mockZclCommunicatorRequests, eventSender: &mockEventSender}
+
+		results := make(chan CommandCompleted, 1)
+		ctx := WithCommandCallback(context.Background(), func(result CommandCompleted) {
+			results <- result
+		})
+
+		_, err := r.RequestResponse(ctx, zigbee.IEEEAddress(0x01), false, message)
+		assert.NoError(t, err)
+
+		select {
+		case result := <-results:
+			assert.Nil(t, result.CorrelationID)
+			assert.Equal(t, zcl.BasicId, result.Cluster)
+			assert.Equal(t, uint8(0x81), result.Status)
+			assert.NoError(t, result.Err)
+		case <-time.After(time.Second):
+			assert.Fail(t, "callback was not called")
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/commands/local/onoff"
+	"github.com/shimmeringbee/zigbee"
+	"time"
+)
+
+// CommandRetryPolicy controls how commands which fail to be delivered are resent. Unlike attribute reads, resending a
+// command may cause it to be acted upon twice, so only idempotent commands are ever resent.
+type CommandRetryPolicy struct {
+	// Retries is the number of times an idempotent command is resent after failing, 0 disables resending.
+	Retries int
+	// Backoff is the delay before the first resend, doubling for each subsequent resend.
+	Backoff time.Duration
+	// Idempotent classifies commands, only commands for which it returns true are resent. If nil,
+	// IsIdempotentCommand is used.
+	Idempotent func(command interface{}) bool
+}
+
+// DefaultCommandRetryPolicy resends idempotent commands once, all attempts are bounded by the device's command timeout.
+var DefaultCommandRetryPolicy = CommandRetryPolicy{Retries: 1, Backoff: 100 * time.Millisecond}
+
+// IsIdempotentCommand returns true if sending the command more than once has the same effect as sending it once, such
+// as an explicit On or a move to a specific level. Commands relative to the device's current state, such as Toggle,
+// and any command zda does not recognise are not idempotent. IdentifyQuery is also excluded, as devices which are not
+// identifying may legitimately not respond, and resending it would only extend the wait.
+func IsIdempotentCommand(command interface{}) bool {
+	switch command.(type) {
+	case *onoff.On, *onoff.Off, *onoff.OffWithEffect, *onoff.OnWithTimedOff:
+		return true
+	case *MoveToLevel, *MoveToLevelWithOnOff:
+		return true
+	case *MoveToColor, *MoveToColorTemperature:
+		return true
+	case *Identify:
+		return true
+	case *LockDoor, *UnlockDoor, *SetPINCode, *GetPINCode, *ClearPINCode, *SetUserStatus:
+		return true
+	case *WindowCoveringUpOpen, *WindowCoveringDownClose, *WindowCoveringStop, *WindowCoveringGoToLiftPercentage:
+		return true
+	case *global.ReadAttributes, *global.WriteAttributes:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryingCommunicatorRequests wraps zclCommunicatorRequests, resending idempotent commands which fail according to
+// the policy. Retries are made within the context of the request, and so do not extend the command timeout.
+type retryingCommunicatorRequests struct {
+	zclCommunicatorRequests
+	policy CommandRetryPolicy
+}
+
+func (r *retryingCommunicatorRequests) Request(ctx context.Context, address zigbee.IEEEAddress, requireAck bool, message zcl.Message) error {
+	return r.attempt(ctx, message, func() error {
+		return r.zclCommunicatorRequests.Request(ctx, address, requireAck, message)
+	})
+}
+
+func (r *retryingCommunicatorRequests) RequestResponse(ctx context.Context, address zigbee.IEEEAddress, requireAck bool, message zcl.Message) (zcl.Message, error) {
+	var response zcl.Message
+
+	err := r.attempt(ctx, message, func() error {
+		var err error
+		response, err = r.zclCommunicatorRequests.RequestResponse(ctx, address, requireAck, message)
+		return err
+	})
+
+	return response, err
+}
+
+// attempt calls send, calling it again after a backoff if it fails and the message's command may be resent. Resending
+// stops once the retries are exhausted or the context is done, failures which would recur, such as
+// APSEncryptionUnavailable, are never resent.
+func (r *retryingCommunicatorRequests) attempt(ctx context.Context, message zcl.Message, send func() error) error {
+	idempotent := r.policy.Idempotent
+
+	if idempotent == nil {
+		idempotent = IsIdempotentCommand
+	}
+
+	retries := 0
+
+	if idempotent(message.Command) {
+		retries = r.policy.Retries
+	}
+
+	backoff := r.policy.Backoff
+
+	for attempt := 0; ; attempt++ {
+		err := send()
+
+		if err == nil || attempt >= retries || ctx.Err() != nil || errors.Is(err, APSEncryptionUnavailable) {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
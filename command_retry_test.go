@@ -0,0 +1,125 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/commands/local/onoff"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestIsIdempotentCommand(t *testing.T) {
+	t.Run("classifies explicit commands as idempotent", func(t *testing.T) {
+		assert.True(t, IsIdempotentCommand(&onoff.On{}))
+		assert.True(t, IsIdempotentCommand(&onoff.Off{}))
+		assert.True(t, IsIdempotentCommand(&MoveToLevelWithOnOff{}))
+		assert.True(t, IsIdempotentCommand(&global.ReadAttributes{}))
+		assert.True(t, IsIdempotentCommand(&UnlockDoor{}))
+	})
+
+	t.Run("classifies relative and unknown commands as not idempotent", func(t *testing.T) {
+		assert.False(t, IsIdempotentCommand(&onoff.Toggle{}))
+		assert.False(t, IsIdempotentCommand(&ResetToFactoryDefaults{}))
+		assert.False(t, IsIdempotentCommand(struct{}{}))
+	})
+
+	t.Run("classifies queries which may go unanswered as not idempotent", func(t *testing.T) {
+		assert.False(t, IsIdempotentCommand(&IdentifyQuery{}))
+	})
+}
+
+func Test_retryingCommunicatorRequests(t *testing.T) {
+	address := zigbee.IEEEAddress(0x01)
+	failure := errors.New("delivery failed")
+
+	t.Run("resends an idempotent command until it succeeds", func(t *testing.T) {
+		mockRequests := mockZclCommunicatorRequests{}
+		defer mockRequests.AssertExpectations(t)
+
+		r := &retryingCommunicatorRequests{zclCommunicatorRequests: &mockRequests, policy: CommandRetryPolicy{Retries: 2, Backoff: time.Millisecond}}
+
+		message := zcl.Message{Command: &onoff.On{}}
+
+		mockRequests.On("Request", mock.Anything, address, false, message).Return(failure).Once()
+		mockRequests.On("Request", mock.Anything, address, false, message).Return(nil).Once()
+
+		assert.NoError(t, r.Request(context.Background(), address, false, message))
+	})
+
+	t.Run("returns the last error once retries are exhausted", func(t *testing.T) {
+		mockRequests := mockZclCommunicatorRequests{}
+		defer mockRequests.AssertExpectations(t)
+
+		r := &retryingCommunicatorRequests{zclCommunicatorRequests: &mockRequests, policy: CommandRetryPolicy{Retries: 2, Backoff: time.Millisecond}}
+
+		message := zcl.Message{Command: &onoff.Off{}}
+
+		mockRequests.On("Request", mock.Anything, address, false, message).Return(failure).Times(3)
+
+		assert.Equal(t, failure, r.Request(context.Background(), address, false, message))
+	})
+
+	t.Run("never resends a command which could not be aps encrypted", func(t *testing.T) {
+		mockRequests := mockZclCommunicatorRequests{}
+		defer mockRequests.AssertExpectations(t)
+
+		r := &retryingCommunicatorRequests{zclCommunicatorRequests: &mockRequests, policy: CommandRetryPolicy{Retries: 2, Backoff: time.Millisecond}}
+
+		message := zcl.Message{Command: &SetPINCode{UserID: 1, PINCode: "1234"}}
+
+		mockRequests.On("RequestResponse", mock.Anything, address, false, message).Return(zcl.Message{}, APSEncryptionUnavailable).Once()
+
+		_, err := r.RequestResponse(context.Background(), address, false, message)
+		assert.Equal(t, APSEncryptionUnavailable, err)
+	})
+
+	t.Run("never resends a command which is not idempotent", func(t *testing.T) {
+		mockRequests := mockZclCommunicatorRequests{}
+		defer mockRequests.AssertExpectations(t)
+
+		r := &retryingCommunicatorRequests{zclCommunicatorRequests: &mockRequests, policy: CommandRetryPolicy{Retries: 2, Backoff: time.Millisecond}}
+
+		message := zcl.Message{Command: &onoff.Toggle{}}
+
+		mockRequests.On("Request", mock.Anything, address, false, message).Return(failure).Once()
+
+		assert.Equal(t, failure, r.Request(context.Background(), address, false, message))
+	})
+
+	t.Run("uses the policy's classification of commands if provided", func(t *testing.T) {
+		mockRequests := mockZclCommunicatorRequests{}
+		defer mockRequests.AssertExpectations(t)
+
+		r := &retryingCommunicatorRequests{zclCommunicatorRequests: &mockRequests, policy: CommandRetryPolicy{Retries: 1, Backoff: time.Millisecond, Idempotent: func(interface{}) bool { return true }}}
+
+		message := zcl.Message{Command: &onoff.Toggle{}}
+
+		mockRequests.On("RequestResponse", mock.Anything, address, false, message).Return(zcl.Message{}, failure).Once()
+		mockRequests.On("RequestResponse", mock.Anything, address, false, message).Return(zcl.Message{TransactionSequence: 2}, nil).Once()
+
+		response, err := r.RequestResponse(context.Background(), address, false, message)
+		assert.NoError(t, err)
+		assert.Equal(t, uint8(2), response.TransactionSequence)
+	})
+
+	t.Run("stops resending once the context is done", func(t *testing.T) {
+		mockRequests := mockZclCommunicatorRequests{}
+		defer mockRequests.AssertExpectations(t)
+
+		r := &retryingCommunicatorRequests{zclCommunicatorRequests: &mockRequests, policy: CommandRetryPolicy{Retries: 5, Backoff: time.Hour}}
+
+		message := zcl.Message{Command: &onoff.On{}}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		mockRequests.On("Request", mock.Anything, address, false, message).Return(failure).Once()
+
+		assert.Equal(t, failure, r.Request(ctx, address, false, message))
+	})
+}
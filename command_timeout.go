@@ -0,0 +1,40 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"time"
+)
+
+// DefaultCommandTimeout is the time permitted for a command sent to a device to be acknowledged, this is shorter than
+// DefaultNetworkTimeout as commands are expected to be acted upon promptly and a user is likely to be waiting.
+const DefaultCommandTimeout = 1000 * time.Millisecond
+
+// SetCommandTimeout overrides the timeout used when sending commands to the device, allowing devices which are slow
+// to respond to be given longer. A timeout of 0 restores DefaultCommandTimeout. Attribute reads are unaffected.
+func (z *ZigbeeGateway) SetCommandTimeout(device da.Device, timeout time.Duration) error {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return err
+	}
+
+	iDev.mutex.Lock()
+	defer iDev.mutex.Unlock()
+
+	iDev.commandTimeout = timeout
+
+	return nil
+}
+
+// commandContext returns a context bounded by the command timeout of the device, the device mutex must be held by the
+// caller.
+func commandContext(ctx context.Context, iDev *internalDevice) (context.Context, context.CancelFunc) {
+	timeout := iDev.commandTimeout
+
+	if timeout == 0 {
+		timeout = DefaultCommandTimeout
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
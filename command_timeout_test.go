@@ -0,0 +1,55 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestZigbeeGateway_SetCommandTimeout(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		err := zgw.SetCommandTimeout(da.Device{}, time.Second)
+		assert.Error(t, err)
+	})
+
+	t.Run("sets the command timeout on the device", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		err := zgw.SetCommandTimeout(iDev.device, 5*time.Second)
+		assert.NoError(t, err)
+		assert.Equal(t, 5*time.Second, iDev.commandTimeout)
+	})
+}
+
+func TestCommandContext(t *testing.T) {
+	t.Run("uses the default command timeout if the device has none set", func(t *testing.T) {
+		_, iDev := generateTestNodeAndDevice()
+
+		ctx, cancel := commandContext(context.Background(), iDev)
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(DefaultCommandTimeout), deadline, 100*time.Millisecond)
+	})
+
+	t.Run("uses the command timeout of the device if set", func(t *testing.T) {
+		_, iDev := generateTestNodeAndDevice()
+		iDev.commandTimeout = 10 * time.Second
+
+		ctx, cancel := commandContext(context.Background(), iDev)
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(10*time.Second), deadline, 100*time.Millisecond)
+	})
+}
@@ -0,0 +1,88 @@
+package zda
+
+import (
+	"fmt"
+	"github.com/shimmeringbee/da"
+	"reflect"
+)
+
+// argumentValidator is implemented by capabilities whose operations constrain the values of their arguments, beyond
+// their types, or which depend upon what the device supports. The arguments have already been checked against the
+// capability description. The device is nil for the gateway's own device, no mutexes are held by the caller.
+type argumentValidator interface {
+	validateArguments(iDevice *internalDevice, operation string, args []interface{}) error
+}
+
+// ValidateCommand checks that the operation of the capability would be accepted for the device with the arguments
+// provided, without transmitting anything to the device. The arguments are those following the context and device in
+// the operation's signature. The same checks are made by the operation itself, but a nil error does not guarantee the
+// device will accept the command.
+func (z *ZigbeeGateway) ValidateCommand(device da.Device, capability da.Capability, operation string, args ...interface{}) error {
+	description, found := z.DescribeCapability(capability)
+
+	if !found {
+		return fmt.Errorf("capability %d is not supported by the gateway", capability)
+	}
+
+	operationDescription, found := findOperationDescription(description, operation)
+
+	if !found {
+		return fmt.Errorf("capability %d has no operation %s", capability, operation)
+	}
+
+	if len(args) != len(operationDescription.Parameters) {
+		return fmt.Errorf("operation %s expects %d arguments, %d provided", operation, len(operationDescription.Parameters), len(args))
+	}
+
+	for i, parameter := range operationDescription.Parameters {
+		if argType := reflect.TypeOf(args[i]); argType == nil || argType.String() != parameter.Type {
+			return fmt.Errorf("operation %s argument %s must be of type %s", operation, parameter.Name, parameter.Type)
+		}
+	}
+
+	if da.DeviceDoesNotBelongToGateway(z, device) {
+		return da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(capability) {
+		return da.DeviceDoesNotHaveCapability
+	}
+
+	var iDev *internalDevice
+
+	if device.Identifier != z.self.device.Identifier {
+		var found bool
+		iDev, found = z.getDevice(device.Identifier)
+
+		if !found {
+			return fmt.Errorf("unable to find zigbee device in zda, likely old device")
+		}
+
+		iDev.node.mutex.RLock()
+		iDev.mutex.RLock()
+		_, cluster, found := findEndpointForCapability(iDev.node, iDev, capability)
+		_, backed := clusterForCapability(iDev, capability)
+		iDev.mutex.RUnlock()
+		iDev.node.mutex.RUnlock()
+
+		if backed && !found {
+			return fmt.Errorf("unable to find cluster %d on zigbee device in zda", cluster)
+		}
+	}
+
+	if validator, ok := z.capabilities[capability].(argumentValidator); ok {
+		return validator.validateArguments(iDev, operation, args)
+	}
+
+	return nil
+}
+
+func findOperationDescription(description CapabilityDescription, operation string) (OperationDescription, bool) {
+	for _, operationDescription := range description.Operations {
+		if operationDescription.Name == operation {
+			return operationDescription, true
+		}
+	}
+
+	return OperationDescription{}, false
+}
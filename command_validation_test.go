@@ -0,0 +1,139 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestZigbeeGateway_ValidateCommand(t *testing.T) {
+	newOnOffDevice := func(zgw *ZigbeeGateway) *internalDevice {
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		node.endpoints = []zigbee.Endpoint{1}
+		node.endpointDescriptions[1] = zigbee.EndpointDescription{Endpoint: 1, InClusterList: []zigbee.ClusterID{zcl.OnOffId}}
+		iDev.endpoints = []zigbee.Endpoint{1}
+
+		addCapability(&iDev.device, capabilities.OnOffFlag)
+		addCapability(&iDev.device, OnOffEffectFlag)
+
+		return iDev
+	}
+
+	t.Run("returns error if the capability is not supported by the gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		iDev := newOnOffDevice(zgw)
+
+		err := zgw.ValidateCommand(iDev.device, da.Capability(0xffff), "On")
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if the operation is unknown", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		iDev := newOnOffDevice(zgw)
+
+		err := zgw.ValidateCommand(iDev.device, capabilities.OnOffFlag, "Toggle")
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if the arguments do not match the operation", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		iDev := newOnOffDevice(zgw)
+
+		err := zgw.ValidateCommand(iDev.device, OnOffEffectFlag, "OffWithEffect", DyingLight)
+		assert.Error(t, err)
+
+		err = zgw.ValidateCommand(iDev.device, OnOffEffectFlag, "OffWithEffect", DyingLight, 0)
+		assert.Error(t, err)
+
+		err = zgw.ValidateCommand(iDev.device, OnOffEffectFlag, "OffWithEffect", nil, uint8(0))
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		err := zgw.ValidateCommand(da.Device{}, capabilities.OnOffFlag, "On")
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns error if device does not have the capability", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		iDev := newOnOffDevice(zgw)
+
+		err := zgw.ValidateCommand(iDev.device, AnalogOutputFlag, "Set", float32(1))
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns error if the device lacks the cluster backing the capability", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		iDev := newOnOffDevice(zgw)
+		iDev.endpoints = []zigbee.Endpoint{}
+
+		err := zgw.ValidateCommand(iDev.device, capabilities.OnOffFlag, "On")
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if an argument is out of range for the capability", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		iDev := newOnOffDevice(zgw)
+
+		err := zgw.ValidateCommand(iDev.device, OnOffEffectFlag, "OffWithEffect", DyingLight, uint8(1))
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if the device does not support the operation", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		node.endpoints = []zigbee.Endpoint{1}
+		node.endpointDescriptions[1] = zigbee.EndpointDescription{Endpoint: 1, InClusterList: []zigbee.ClusterID{zcl.ColorControlId}}
+		iDev.endpoints = []zigbee.Endpoint{1}
+		iDev.colorControlState.SupportsXY = true
+
+		addCapability(&iDev.device, ColorControlFlag)
+
+		err := zgw.ValidateCommand(iDev.device, ColorControlFlag, "ChangeColorTemperature", uint16(370), time.Duration(0))
+		assert.Error(t, err)
+
+		err = zgw.ValidateCommand(iDev.device, ColorControlFlag, "ChangeColorXY", 0.3, 0.3, time.Duration(0))
+		assert.NoError(t, err)
+	})
+
+	t.Run("returns nil for a command which would be accepted", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		iDev := newOnOffDevice(zgw)
+
+		assert.NoError(t, zgw.ValidateCommand(iDev.device, capabilities.OnOffFlag, "On"))
+		assert.NoError(t, zgw.ValidateCommand(iDev.device, OnOffEffectFlag, "OffWithEffect", DelayedAllOff, uint8(2)))
+	})
+
+	t.Run("validates commands against the gateway's self device", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		zgw.self.device = da.Device{Gateway: zgw, Identifier: zgw.self.device.Identifier, Capabilities: []da.Capability{capabilities.DeviceDiscoveryFlag}}
+
+		err := zgw.ValidateCommand(zgw.Self(), capabilities.DeviceDiscoveryFlag, "Enable", time.Minute)
+		assert.NoError(t, err)
+	})
+}
+
+func Test_validateOffEffect(t *testing.T) {
+	t.Run("accepts variants defined for each effect", func(t *testing.T) {
+		assert.NoError(t, validateOffEffect(DelayedAllOff, 0))
+		assert.NoError(t, validateOffEffect(DelayedAllOff, 2))
+		assert.NoError(t, validateOffEffect(DyingLight, 0))
+	})
+
+	t.Run("rejects undefined effects and variants", func(t *testing.T) {
+		assert.Error(t, validateOffEffect(DelayedAllOff, 3))
+		assert.Error(t, validateOffEffect(DyingLight, 1))
+		assert.Error(t, validateOffEffect(OffEffect(2), 0))
+	})
+}
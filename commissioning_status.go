@@ -0,0 +1,63 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/zigbee"
+)
+
+// EnumerationState is the progress of a nodes interview by the gateway.
+type EnumerationState string
+
+const (
+	EnumerationNotStarted   EnumerationState = "not-started"
+	EnumerationPending      EnumerationState = "pending"
+	EnumerationInterviewing EnumerationState = "interviewing"
+	EnumerationComplete     EnumerationState = "complete"
+	EnumerationFailed       EnumerationState = "failed"
+)
+
+// CommissioningStatus is a snapshot of the interview state of every node on the network, with counts of nodes in each
+// state to allow a commissioning UI to display overall progress.
+type CommissioningStatus struct {
+	Nodes map[zigbee.IEEEAddress]EnumerationState
+
+	Pending      int
+	Interviewing int
+	Complete     int
+	Failed       int
+}
+
+// CommissioningStatus returns a snapshot of the interview state of all nodes known to the gateway.
+func (z *ZigbeeGateway) CommissioningStatus() CommissioningStatus {
+	status := CommissioningStatus{Nodes: map[zigbee.IEEEAddress]EnumerationState{}}
+
+	z.nodesLock.RLock()
+	defer z.nodesLock.RUnlock()
+
+	for address, iNode := range z.nodes {
+		iNode.mutex.RLock()
+		state := iNode.enumerationState
+		iNode.mutex.RUnlock()
+
+		status.Nodes[address] = state
+
+		switch state {
+		case EnumerationPending:
+			status.Pending++
+		case EnumerationInterviewing:
+			status.Interviewing++
+		case EnumerationComplete:
+			status.Complete++
+		case EnumerationFailed:
+			status.Failed++
+		}
+	}
+
+	return status
+}
+
+func setEnumerationState(iNode *internalNode, state EnumerationState) {
+	iNode.mutex.Lock()
+	defer iNode.mutex.Unlock()
+
+	iNode.enumerationState = state
+}
@@ -0,0 +1,35 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestZigbeeGateway_CommissioningStatus(t *testing.T) {
+	t.Run("returns the enumeration state of each node with counts of each state", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		nodeOne := zgw.addNode(zigbee.IEEEAddress(0x01))
+		nodeTwo := zgw.addNode(zigbee.IEEEAddress(0x02))
+		nodeThree := zgw.addNode(zigbee.IEEEAddress(0x03))
+		zgw.addNode(zigbee.IEEEAddress(0x04))
+
+		setEnumerationState(nodeOne, EnumerationPending)
+		setEnumerationState(nodeTwo, EnumerationComplete)
+		setEnumerationState(nodeThree, EnumerationComplete)
+
+		expected := CommissioningStatus{
+			Nodes: map[zigbee.IEEEAddress]EnumerationState{
+				0x01: EnumerationPending,
+				0x02: EnumerationComplete,
+				0x03: EnumerationComplete,
+				0x04: EnumerationNotStarted,
+			},
+			Pending:  1,
+			Complete: 2,
+		}
+
+		assert.Equal(t, expected, zgw.CommissioningStatus())
+	})
+}
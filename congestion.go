@@ -0,0 +1,172 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"github.com/shimmeringbee/zigbee"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrProviderQueueFull should be returned, or wrapped, by providers which can not accept an outgoing frame because
+// their queue to the adapter is full. Providers may instead return an error with a QueueFull() bool method.
+var ErrProviderQueueFull = errors.New("provider queue full")
+
+// DefaultCongestionRetries is the number of times a frame rejected by the provider due to backpressure is resent.
+const DefaultCongestionRetries = 5
+
+// DefaultCongestionCooldown is how long after the provider last rejected a frame that congestion is considered over.
+const DefaultCongestionCooldown = 30 * time.Second
+
+// CongestionSlowdownFactor is the factor by which background polling and bulk operations are slowed while the
+// provider is congested.
+const CongestionSlowdownFactor = 4
+
+const congestionInitialBackoff = 50 * time.Millisecond
+const congestionMaximumBackoff = 2 * time.Second
+
+// ProviderCongestionChanged is sent when the provider starts rejecting frames due to backpressure, and when it has
+// not done so for DefaultCongestionCooldown.
+type ProviderCongestionChanged struct {
+	Congested bool
+	// Rejections is the total number of frames the provider has rejected due to backpressure.
+	Rejections uint64
+}
+
+// isProviderBackpressure returns true if the error returned by the provider indicates it can not currently accept
+// frames, rather than that the frame failed.
+func isProviderBackpressure(err error) bool {
+	if errors.Is(err, ErrProviderQueueFull) {
+		return true
+	}
+
+	var queueFull interface{ QueueFull() bool }
+
+	return errors.As(err, &queueFull) && queueFull.QueueFull()
+}
+
+// congestionProvider wraps a provider, resending frames it rejects due to backpressure with an exponential backoff.
+// The first rejection marks the provider as congested, and it remains so until a frame is sent successfully after the
+// cooldown has passed. onChange is called on each transition.
+type congestionProvider struct {
+	// Accessed atomically, kept first to guarantee 64 bit alignment.
+	rejections uint64
+
+	zigbee.Provider
+
+	retries        int
+	initialBackoff time.Duration
+	cooldown       time.Duration
+	now            func() time.Time
+	onChange       func(congested bool, rejections uint64)
+
+	mutex         sync.Mutex
+	congested     bool
+	lastRejection time.Time
+}
+
+func newCongestionProvider(provider zigbee.Provider, now func() time.Time) *congestionProvider {
+	return &congestionProvider{
+		Provider:       provider,
+		retries:        DefaultCongestionRetries,
+		initialBackoff: congestionInitialBackoff,
+		cooldown:       DefaultCongestionCooldown,
+		now:            now,
+		onChange:       func(bool, uint64) {},
+	}
+}
+
+func (p *congestionProvider) SendApplicationMessageToNode(ctx context.Context, destinationAddress zigbee.IEEEAddress, message zigbee.ApplicationMessage, requireAck bool) error {
+	return p.send(ctx, func() error {
+		return p.Provider.SendApplicationMessageToNode(ctx, destinationAddress, message, requireAck)
+	})
+}
+
+func (p *congestionProvider) BindNodeToController(ctx context.Context, nodeAddress zigbee.IEEEAddress, sourceEndpoint zigbee.Endpoint, destinationEndpoint zigbee.Endpoint, cluster zigbee.ClusterID) error {
+	return p.send(ctx, func() error {
+		return p.Provider.BindNodeToController(ctx, nodeAddress, sourceEndpoint, destinationEndpoint, cluster)
+	})
+}
+
+// send calls fn, calling it again after a backoff while the provider rejects it due to backpressure. Resending stops
+// once the retries are exhausted or the context is done, returning the provider's error.
+func (p *congestionProvider) send(ctx context.Context, fn func() error) error {
+	backoff := p.initialBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+
+		if !isProviderBackpressure(err) {
+			p.accepted()
+			return err
+		}
+
+		p.rejected()
+
+		if attempt >= p.retries {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+			if backoff *= 2; backoff > congestionMaximumBackoff {
+				backoff = congestionMaximumBackoff
+			}
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+func (p *congestionProvider) rejected() {
+	rejections := atomic.AddUint64(&p.rejections, 1)
+
+	p.mutex.Lock()
+	p.lastRejection = p.now()
+	changed := !p.congested
+	p.congested = true
+	p.mutex.Unlock()
+
+	if changed {
+		p.onChange(true, rejections)
+	}
+}
+
+func (p *congestionProvider) accepted() {
+	p.mutex.Lock()
+	changed := p.congested && p.now().Sub(p.lastRejection) >= p.cooldown
+
+	if changed {
+		p.congested = false
+	}
+	p.mutex.Unlock()
+
+	if changed {
+		p.onChange(false, p.Rejections())
+	}
+}
+
+// Congested returns true if the provider has rejected a frame due to backpressure within the cooldown.
+func (p *congestionProvider) Congested() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.congested
+}
+
+// Rejections returns the total number of frames the provider has rejected due to backpressure.
+func (p *congestionProvider) Rejections() uint64 {
+	return atomic.LoadUint64(&p.rejections)
+}
+
+// congestionChanged slows background polling while the provider is congested, and informs the consumer.
+func (z *ZigbeeGateway) congestionChanged(congested bool, rejections uint64) {
+	if congested {
+		z.poller.setSlowdown(CongestionSlowdownFactor)
+	} else {
+		z.poller.setSlowdown(1)
+	}
+
+	z.sendEvent(ProviderCongestionChanged{Congested: congested, Rejections: rejections})
+}
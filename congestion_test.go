@@ -0,0 +1,155 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"sync"
+	"testing"
+	"time"
+)
+
+type queueFullError struct{}
+
+func (queueFullError) Error() string   { return "adapter busy" }
+func (queueFullError) QueueFull() bool { return true }
+
+func Test_isProviderBackpressure(t *testing.T) {
+	t.Run("recognises the sentinel, wrapped or not, and errors declaring themselves queue full", func(t *testing.T) {
+		assert.True(t, isProviderBackpressure(ErrProviderQueueFull))
+		assert.True(t, isProviderBackpressure(fmt.Errorf("send failed: %w", ErrProviderQueueFull)))
+		assert.True(t, isProviderBackpressure(queueFullError{}))
+	})
+
+	t.Run("does not treat other errors as backpressure", func(t *testing.T) {
+		assert.False(t, isProviderBackpressure(nil))
+		assert.False(t, isProviderBackpressure(errors.New("no route")))
+	})
+}
+
+func newTestCongestionProvider(provider zigbee.Provider, clock *fakeClock) (*congestionProvider, *[]bool) {
+	p := newCongestionProvider(provider, clock.Now)
+	p.initialBackoff = time.Millisecond
+
+	changes := &[]bool{}
+	lock := &sync.Mutex{}
+
+	p.onChange = func(congested bool, rejections uint64) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		*changes = append(*changes, congested)
+	}
+
+	return p, changes
+}
+
+func TestCongestionProvider_SendApplicationMessageToNode(t *testing.T) {
+	address := zigbee.IEEEAddress(0x01)
+	message := zigbee.ApplicationMessage{ClusterID: 0x0006}
+
+	t.Run("resends frames rejected by a provider under load, marking it as congested", func(t *testing.T) {
+		mockProvider := new(zigbee.MockProvider)
+		defer mockProvider.AssertExpectations(t)
+
+		mockProvider.On("SendApplicationMessageToNode", mock.Anything, address, message, false).Return(ErrProviderQueueFull).Twice()
+		mockProvider.On("SendApplicationMessageToNode", mock.Anything, address, message, false).Return(nil).Once()
+
+		p, changes := newTestCongestionProvider(mockProvider, newFakeClock())
+
+		err := p.SendApplicationMessageToNode(context.Background(), address, message, false)
+		assert.NoError(t, err)
+
+		assert.Equal(t, uint64(2), p.Rejections())
+		assert.True(t, p.Congested())
+		assert.Equal(t, []bool{true}, *changes)
+	})
+
+	t.Run("returns the provider's error once retries are exhausted", func(t *testing.T) {
+		mockProvider := new(zigbee.MockProvider)
+		defer mockProvider.AssertExpectations(t)
+
+		mockProvider.On("SendApplicationMessageToNode", mock.Anything, address, message, false).Return(ErrProviderQueueFull).Times(DefaultCongestionRetries + 1)
+
+		p, _ := newTestCongestionProvider(mockProvider, newFakeClock())
+
+		err := p.SendApplicationMessageToNode(context.Background(), address, message, false)
+		assert.Equal(t, ErrProviderQueueFull, err)
+	})
+
+	t.Run("does not resend frames which failed for other reasons", func(t *testing.T) {
+		mockProvider := new(zigbee.MockProvider)
+		defer mockProvider.AssertExpectations(t)
+
+		failure := errors.New("no route")
+		mockProvider.On("SendApplicationMessageToNode", mock.Anything, address, message, false).Return(failure).Once()
+
+		p, changes := newTestCongestionProvider(mockProvider, newFakeClock())
+
+		err := p.SendApplicationMessageToNode(context.Background(), address, message, false)
+		assert.Equal(t, failure, err)
+		assert.False(t, p.Congested())
+		assert.Empty(t, *changes)
+	})
+
+	t.Run("clears congestion once a frame is accepted after the cooldown", func(t *testing.T) {
+		mockProvider := new(zigbee.MockProvider)
+		defer mockProvider.AssertExpectations(t)
+
+		mockProvider.On("SendApplicationMessageToNode", mock.Anything, address, message, false).Return(ErrProviderQueueFull).Once()
+		mockProvider.On("SendApplicationMessageToNode", mock.Anything, address, message, false).Return(nil)
+
+		clock := newFakeClock()
+		p, changes := newTestCongestionProvider(mockProvider, clock)
+
+		assert.NoError(t, p.SendApplicationMessageToNode(context.Background(), address, message, false))
+		assert.True(t, p.Congested())
+
+		clock.Advance(DefaultCongestionCooldown / 2)
+		assert.NoError(t, p.SendApplicationMessageToNode(context.Background(), address, message, false))
+		assert.True(t, p.Congested())
+
+		clock.Advance(DefaultCongestionCooldown)
+		assert.NoError(t, p.SendApplicationMessageToNode(context.Background(), address, message, false))
+		assert.False(t, p.Congested())
+
+		assert.Equal(t, []bool{true, false}, *changes)
+	})
+}
+
+func TestCongestionProvider_BindNodeToController(t *testing.T) {
+	t.Run("resends binds rejected by a provider under load", func(t *testing.T) {
+		mockProvider := new(zigbee.MockProvider)
+		defer mockProvider.AssertExpectations(t)
+
+		address := zigbee.IEEEAddress(0x01)
+
+		mockProvider.On("BindNodeToController", mock.Anything, address, zigbee.Endpoint(1), zigbee.Endpoint(1), zigbee.ClusterID(0x0006)).Return(queueFullError{}).Once()
+		mockProvider.On("BindNodeToController", mock.Anything, address, zigbee.Endpoint(1), zigbee.Endpoint(1), zigbee.ClusterID(0x0006)).Return(nil).Once()
+
+		p, _ := newTestCongestionProvider(mockProvider, newFakeClock())
+
+		err := p.BindNodeToController(context.Background(), address, 1, 1, 0x0006)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), p.Rejections())
+	})
+}
+
+func TestZigbeeGateway_congestionChanged(t *testing.T) {
+	t.Run("slows polling and sends an event while congested, restoring polling afterwards", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		zgw.congestionChanged(true, 3)
+		assert.Equal(t, CongestionSlowdownFactor*time.Minute, zgw.poller.slowedInterval(time.Minute))
+
+		event, err := zgw.ReadEvent(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, ProviderCongestionChanged{Congested: true, Rejections: 3}, event)
+
+		zgw.congestionChanged(false, 3)
+		assert.Equal(t, time.Minute, zgw.poller.slowedInterval(time.Minute))
+	})
+}
@@ -6,6 +6,7 @@ import (
 	. "github.com/shimmeringbee/da/capabilities"
 	"github.com/shimmeringbee/zigbee"
 	"sync"
+	"time"
 )
 
 type internalDevice struct {
@@ -20,7 +21,41 @@ type internalDevice struct {
 	endpoints     []zigbee.Endpoint
 
 	productInformation ProductInformation
+	firmwareVersion    *FirmwareVersion
 	onOffState         ZigbeeOnOffState
+
+	illuminanceLevelSensingState   ZigbeeIlluminanceLevelSensingState
+	priceState                     ZigbeePriceState
+	analogOutputState              AnalogOutputValue
+	thermostatUIConfigurationState ThermostatUIConfigurationState
+	lastButtonPress                ButtonPress
+	currentLevel                   uint8
+	colorControlState              ColorControlState
+	temperatureSensorState         temperatureSensorState
+	relativeHumiditySensorState    relativeHumiditySensorState
+	pressureSensorState            pressureSensorState
+	occupancySensorState           occupancySensorState
+	illuminanceSensorState         illuminanceSensorState
+	batteryState                   batteryState
+	electricalMeasurementState     electricalMeasurementState
+	meteringState                  meteringState
+	iasZoneState                   iasZoneState
+	doorLockState                  doorLockState
+	windowCoveringState            windowCoveringState
+	commandTimeout                 time.Duration
+	capabilityUpdated              map[Capability]time.Time
+	capabilityUpdateModes          map[Capability][]CapabilityUpdateModeChange
+	staleTimeouts                  map[Capability]time.Duration
+	unavailableCapabilities        map[Capability]bool
+
+	enumerationResult   EnumerationResult
+	capabilityOverrides map[Capability]bool
+	clusterRemaps       map[Capability]zigbee.ClusterID
+	endpointSelections  map[Capability]zigbee.Endpoint
+	endpointCorrections map[Capability]zigbee.Endpoint
+	endpointsProbed     map[Capability]bool
+
+	detectedCapabilities map[Capability]bool
 }
 
 func (z *ZigbeeGateway) getDevice(identifier Identifier) (*internalDevice, bool) {
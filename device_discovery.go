@@ -2,24 +2,77 @@ package zda
 
 import (
 	"context"
+	"github.com/shimmeringbee/callbacks"
 	. "github.com/shimmeringbee/da"
 	. "github.com/shimmeringbee/da/capabilities"
 	"github.com/shimmeringbee/zigbee"
 	"log"
+	"sync"
 	"time"
 )
 
+// DiscoveredNode is a node which joined the network during a discovery window.
+type DiscoveredNode struct {
+	IEEEAddress zigbee.IEEEAddress
+	JoinedAt    time.Time
+}
+
+// DeviceDiscoveryNodeJoined is sent when a node joins the network during a discovery window, devices for the node will
+// be announced as normal once it has been enumerated.
+type DeviceDiscoveryNodeJoined struct {
+	Gateway Gateway
+	Node    DiscoveredNode
+}
+
 type ZigbeeDeviceDiscovery struct {
-	gateway        Gateway
-	networkJoining zigbee.NetworkJoining
-	eventSender    eventSender
+	gateway           Gateway
+	networkJoining    zigbee.NetworkJoining
+	eventSender       eventSender
+	internalCallbacks callbacks.Adder
+	clock             clock
 
+	mutex          sync.Mutex
 	discovering    bool
-	allowTimer     *time.Timer
+	allowTimer     clockTimer
 	allowExpiresAt time.Time
+	allowEnded     chan struct{}
+	allowWindow    uint64
+	discovered     []DiscoveredNode
+}
+
+func (d *ZigbeeDeviceDiscovery) Init() {
+	d.internalCallbacks.Add(d.NodeJoinCallback)
+}
+
+// NodeJoinCallback records nodes which join while discovery is enabled.
+func (d *ZigbeeDeviceDiscovery) NodeJoinCallback(ctx context.Context, join internalNodeJoin) error {
+	d.mutex.Lock()
+
+	if !d.discovering {
+		d.mutex.Unlock()
+		return nil
+	}
+
+	node := DiscoveredNode{IEEEAddress: join.node.ieeeAddress, JoinedAt: d.clock.Now()}
+	d.discovered = append(d.discovered, node)
+	d.mutex.Unlock()
+
+	d.eventSender.sendEvent(DeviceDiscoveryNodeJoined{Gateway: d.gateway, Node: node})
+	return nil
 }
 
 func (d *ZigbeeDeviceDiscovery) Enable(ctx context.Context, device Device, duration time.Duration) error {
+	return d.enable(ctx, device, duration, nil)
+}
+
+// EnableUntilCancelled enables device discovery in the same way as Enable, however discovery will also be disabled
+// if the context provided is cancelled before the duration expires. This permits a discovery window to be tied to
+// the lifetime of the caller.
+func (d *ZigbeeDeviceDiscovery) EnableUntilCancelled(ctx context.Context, device Device, duration time.Duration) error {
+	return d.enable(ctx, device, duration, ctx.Done())
+}
+
+func (d *ZigbeeDeviceDiscovery) enable(ctx context.Context, device Device, duration time.Duration, cancelled <-chan struct{}) error {
 	if DeviceIsNotGatewaySelf(d.gateway, device) {
 		return DeviceIsNotGatewaySelfDeviceError
 	}
@@ -28,18 +81,31 @@ func (d *ZigbeeDeviceDiscovery) Enable(ctx context.Context, device Device, durat
 		return err
 	}
 
-	if d.allowTimer != nil {
-		d.allowTimer.Stop()
-	}
+	d.mutex.Lock()
+	d.endWindow()
+
+	window := d.allowWindow
+	ended := make(chan struct{})
 
-	d.allowExpiresAt = time.Now().Add(duration)
-	d.allowTimer = time.AfterFunc(duration, func() {
-		if err := d.Disable(ctx, device); err != nil {
-			log.Printf("error while denying discovery after duration: %+v", err)
-		}
+	d.allowEnded = ended
+	d.discovered = nil
+	d.allowExpiresAt = d.clock.Now().Add(duration)
+	d.allowTimer = d.clock.AfterFunc(duration, func() {
+		d.expireWindow(window)
 	})
 
 	d.discovering = true
+	d.mutex.Unlock()
+
+	if cancelled != nil {
+		go func() {
+			select {
+			case <-cancelled:
+				d.expireWindow(window)
+			case <-ended:
+			}
+		}()
+	}
 
 	d.eventSender.sendEvent(DeviceDiscoveryEnabled{
 		Gateway:  d.gateway,
@@ -53,12 +119,22 @@ func (d *ZigbeeDeviceDiscovery) Disable(ctx context.Context, device Device) erro
 		return DeviceIsNotGatewaySelfDeviceError
 	}
 
+	d.mutex.Lock()
+	d.endWindow()
+	d.mutex.Unlock()
+
+	return d.deny(ctx)
+}
+
+func (d *ZigbeeDeviceDiscovery) deny(ctx context.Context) error {
 	if err := d.networkJoining.DenyJoin(ctx); err != nil {
 		return err
 	}
 
+	d.mutex.Lock()
 	d.discovering = false
-	d.allowTimer = nil
+	d.endWindow()
+	d.mutex.Unlock()
 
 	d.eventSender.sendEvent(DeviceDiscoveryDisabled{
 		Gateway: d.gateway,
@@ -66,12 +142,51 @@ func (d *ZigbeeDeviceDiscovery) Disable(ctx context.Context, device Device) erro
 	return nil
 }
 
+// expireWindow disables discovery if the window specified is still the current window. Both the duration timer
+// and context cancellation call this, the window is claimed under lock so only one of them will deny joining.
+func (d *ZigbeeDeviceDiscovery) expireWindow(window uint64) {
+	d.mutex.Lock()
+
+	if !d.discovering || d.allowWindow != window {
+		d.mutex.Unlock()
+		return
+	}
+
+	d.endWindow()
+	d.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultNetworkTimeout)
+	defer cancel()
+
+	if err := d.deny(ctx); err != nil {
+		log.Printf("error while denying discovery after window ended: %+v", err)
+	}
+}
+
+// endWindow stops any outstanding discovery window, the mutex must be held by the caller.
+func (d *ZigbeeDeviceDiscovery) endWindow() {
+	if d.allowTimer != nil {
+		d.allowTimer.Stop()
+		d.allowTimer = nil
+	}
+
+	if d.allowEnded != nil {
+		close(d.allowEnded)
+		d.allowEnded = nil
+	}
+
+	d.allowWindow++
+}
+
 func (d *ZigbeeDeviceDiscovery) Status(ctx context.Context, device Device) (DeviceDiscoveryStatus, error) {
 	if DeviceIsNotGatewaySelf(d.gateway, device) {
 		return DeviceDiscoveryStatus{}, DeviceIsNotGatewaySelfDeviceError
 	}
 
-	remainingDuration := d.allowExpiresAt.Sub(time.Now())
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	remainingDuration := d.allowExpiresAt.Sub(d.clock.Now())
 	if remainingDuration < 0 {
 		remainingDuration = 0
 	}
@@ -79,8 +194,25 @@ func (d *ZigbeeDeviceDiscovery) Status(ctx context.Context, device Device) (Devi
 	return DeviceDiscoveryStatus{Discovering: d.discovering, RemainingDuration: remainingDuration}, nil
 }
 
-func (d *ZigbeeDeviceDiscovery) Stop() {
-	if d.allowTimer != nil {
-		d.allowTimer.Stop()
+// DiscoveredNodes returns the nodes which have joined since discovery was last enabled, in the order they joined. The
+// nodes are retained after discovery ends, until discovery is next enabled.
+func (d *ZigbeeDeviceDiscovery) DiscoveredNodes(ctx context.Context, device Device) ([]DiscoveredNode, error) {
+	if DeviceIsNotGatewaySelf(d.gateway, device) {
+		return nil, DeviceIsNotGatewaySelfDeviceError
 	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	discovered := make([]DiscoveredNode, len(d.discovered))
+	copy(discovered, d.discovered)
+
+	return discovered, nil
+}
+
+func (d *ZigbeeDeviceDiscovery) Stop() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.endWindow()
 }
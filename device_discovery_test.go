@@ -55,6 +55,7 @@ func TestZigbeeDeviceDiscovery_Enable(t *testing.T) {
 			gateway:        &mockGateway,
 			eventSender:    &mockEventSender,
 			networkJoining: &mockNetworkJoining,
+			clock:          newFakeClock(),
 		}
 		defer zdd.Stop()
 
@@ -88,6 +89,7 @@ func TestZigbeeDeviceDiscovery_Enable(t *testing.T) {
 			gateway:        &mockGateway,
 			eventSender:    &mockEventSender,
 			networkJoining: &mockNetworkJoining,
+			clock:          newFakeClock(),
 		}
 		defer zdd.Stop()
 
@@ -142,6 +144,7 @@ func TestZigbeeDeviceDiscovery_Disable(t *testing.T) {
 			gateway:        &mockGateway,
 			eventSender:    &mockEventSender,
 			networkJoining: &mockNetworkJoining,
+			clock:          newFakeClock(),
 		}
 		defer zdd.Stop()
 
@@ -178,6 +181,7 @@ func TestZigbeeDeviceDiscovery_Disable(t *testing.T) {
 			gateway:        &mockGateway,
 			eventSender:    &mockEventSender,
 			networkJoining: &mockNetworkJoining,
+			clock:          newFakeClock(),
 		}
 		defer zdd.Stop()
 
@@ -214,10 +218,13 @@ func TestZigbeeDeviceDiscovery_DurationBehaviour(t *testing.T) {
 		mockNetworkJoining.On("PermitJoin", mock.Anything, true).Return(nil)
 		mockNetworkJoining.On("DenyJoin", mock.Anything).Return(nil)
 
+		clock := newFakeClock()
+
 		zdd := ZigbeeDeviceDiscovery{
 			gateway:        &mockGateway,
 			eventSender:    &mockEventSender,
 			networkJoining: &mockNetworkJoining,
+			clock:          clock,
 		}
 
 		defer zdd.Stop()
@@ -229,7 +236,7 @@ func TestZigbeeDeviceDiscovery_DurationBehaviour(t *testing.T) {
 		assert.NoError(t, err)
 		assert.True(t, status.Discovering)
 
-		time.Sleep(150 * time.Millisecond)
+		clock.Advance(100 * time.Millisecond)
 
 		status, err = zdd.Status(context.Background(), zdd.gateway.Self())
 		assert.NoError(t, err)
@@ -255,10 +262,13 @@ func TestZigbeeDeviceDiscovery_DurationBehaviour(t *testing.T) {
 		mockNetworkJoining.On("PermitJoin", mock.Anything, true).Return(nil)
 		mockNetworkJoining.On("DenyJoin", mock.Anything).Return(nil).Maybe()
 
+		clock := newFakeClock()
+
 		zdd := ZigbeeDeviceDiscovery{
 			gateway:        &mockGateway,
 			eventSender:    &mockEventSender,
 			networkJoining: &mockNetworkJoining,
+			clock:          clock,
 		}
 
 		defer zdd.Stop()
@@ -269,7 +279,7 @@ func TestZigbeeDeviceDiscovery_DurationBehaviour(t *testing.T) {
 		status, err := zdd.Status(context.Background(), zdd.gateway.Self())
 		assert.NoError(t, err)
 		assert.True(t, status.Discovering)
-		assert.Greater(t, int64(status.RemainingDuration), int64(45*time.Millisecond))
+		assert.Equal(t, 50*time.Millisecond, status.RemainingDuration)
 
 		err = zdd.Enable(context.Background(), zdd.gateway.Self(), 200*time.Millisecond)
 		assert.NoError(t, err)
@@ -277,16 +287,190 @@ func TestZigbeeDeviceDiscovery_DurationBehaviour(t *testing.T) {
 		status, err = zdd.Status(context.Background(), zdd.gateway.Self())
 		assert.NoError(t, err)
 		assert.True(t, status.Discovering)
-		assert.Greater(t, int64(status.RemainingDuration), int64(145*time.Millisecond))
+		assert.Equal(t, 200*time.Millisecond, status.RemainingDuration)
 
-		time.Sleep(150 * time.Millisecond)
+		clock.Advance(150 * time.Millisecond)
 
 		status, err = zdd.Status(context.Background(), zdd.gateway.Self())
 		assert.NoError(t, err)
 		assert.True(t, status.Discovering)
+		assert.Equal(t, 50*time.Millisecond, status.RemainingDuration)
+
+		mockGateway.AssertExpectations(t)
+		mockEventSender.AssertExpectations(t)
+		mockNetworkJoining.AssertExpectations(t)
+	})
+}
+
+func TestZigbeeDeviceDiscovery_EnableUntilCancelled(t *testing.T) {
+	t.Run("cancelling the context disables discovery before the duration expires", func(t *testing.T) {
+		mockGateway := mockGateway{}
+		gatewayDevice := da.Device{
+			Gateway:    &mockGateway,
+			Identifier: zigbee.IEEEAddress(0x01),
+		}
+		mockGateway.On("Self").Return(gatewayDevice).Maybe()
+
+		mockEventSender := mockEventSender{}
+		mockEventSender.On("sendEvent", mock.IsType(DeviceDiscoveryEnabled{})).Once()
+		mockEventSender.On("sendEvent", mock.IsType(DeviceDiscoveryDisabled{})).Once()
+
+		mockNetworkJoining := mockNetworkJoining{}
+		mockNetworkJoining.On("PermitJoin", mock.Anything, true).Return(nil)
+		mockNetworkJoining.On("DenyJoin", mock.Anything).Return(nil).Once()
+
+		clock := newFakeClock()
+
+		zdd := ZigbeeDeviceDiscovery{
+			gateway:        &mockGateway,
+			eventSender:    &mockEventSender,
+			networkJoining: &mockNetworkJoining,
+			clock:          clock,
+		}
+		defer zdd.Stop()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		err := zdd.EnableUntilCancelled(ctx, gatewayDevice, 50*time.Millisecond)
+		assert.NoError(t, err)
+
+		cancel()
+		time.Sleep(10 * time.Millisecond)
+
+		status, err := zdd.Status(context.Background(), gatewayDevice)
+		assert.NoError(t, err)
+		assert.False(t, status.Discovering)
+
+		clock.Advance(50 * time.Millisecond)
+
+		mockGateway.AssertExpectations(t)
+		mockEventSender.AssertExpectations(t)
+		mockNetworkJoining.AssertExpectations(t)
+	})
+
+	t.Run("duration expiring disables discovery only once when the context is later cancelled", func(t *testing.T) {
+		mockGateway := mockGateway{}
+		gatewayDevice := da.Device{
+			Gateway:    &mockGateway,
+			Identifier: zigbee.IEEEAddress(0x01),
+		}
+		mockGateway.On("Self").Return(gatewayDevice).Maybe()
+
+		mockEventSender := mockEventSender{}
+		mockEventSender.On("sendEvent", mock.Anything).Twice()
+
+		mockNetworkJoining := mockNetworkJoining{}
+		mockNetworkJoining.On("PermitJoin", mock.Anything, true).Return(nil)
+		mockNetworkJoining.On("DenyJoin", mock.Anything).Return(nil).Once()
+
+		clock := newFakeClock()
+
+		zdd := ZigbeeDeviceDiscovery{
+			gateway:        &mockGateway,
+			eventSender:    &mockEventSender,
+			networkJoining: &mockNetworkJoining,
+			clock:          clock,
+		}
+		defer zdd.Stop()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		err := zdd.EnableUntilCancelled(ctx, gatewayDevice, 10*time.Millisecond)
+		assert.NoError(t, err)
+
+		clock.Advance(10 * time.Millisecond)
+		cancel()
+		time.Sleep(10 * time.Millisecond)
 
 		mockGateway.AssertExpectations(t)
 		mockEventSender.AssertExpectations(t)
 		mockNetworkJoining.AssertExpectations(t)
 	})
 }
+
+func TestZigbeeDeviceDiscovery_Init(t *testing.T) {
+	t.Run("registers the node join callback", func(t *testing.T) {
+		mIntCallbacks := mockAdderCaller{}
+		defer mIntCallbacks.AssertExpectations(t)
+
+		zdd := ZigbeeDeviceDiscovery{internalCallbacks: &mIntCallbacks}
+
+		mIntCallbacks.On("Add", mock.Anything).Once()
+
+		zdd.Init()
+	})
+}
+
+func TestZigbeeDeviceDiscovery_DiscoveredNodes(t *testing.T) {
+	t.Run("calling discovered nodes on Device which is not the gateway self errors", func(t *testing.T) {
+		mockGateway := mockGateway{}
+		mockGateway.On("Self").Return(da.Device{Gateway: &mockGateway, Identifier: zigbee.IEEEAddress(0x01)})
+
+		zdd := ZigbeeDeviceDiscovery{gateway: &mockGateway}
+
+		_, err := zdd.DiscoveredNodes(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceIsNotGatewaySelfDeviceError, err)
+	})
+
+	t.Run("records nodes which join during the window, until a new window is opened", func(t *testing.T) {
+		mockGateway := mockGateway{}
+		gatewayDevice := da.Device{
+			Gateway:    &mockGateway,
+			Identifier: zigbee.IEEEAddress(0x01),
+		}
+		mockGateway.On("Self").Return(gatewayDevice).Maybe()
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		mockNetworkJoining := mockNetworkJoining{}
+		mockNetworkJoining.On("PermitJoin", mock.Anything, true).Return(nil)
+		mockNetworkJoining.On("DenyJoin", mock.Anything).Return(nil)
+
+		clock := newFakeClock()
+
+		zdd := ZigbeeDeviceDiscovery{
+			gateway:        &mockGateway,
+			eventSender:    &mockEventSender,
+			networkJoining: &mockNetworkJoining,
+			clock:          clock,
+		}
+		defer zdd.Stop()
+
+		beforeWindow := &internalNode{ieeeAddress: zigbee.IEEEAddress(0x10)}
+		firstNode := &internalNode{ieeeAddress: zigbee.IEEEAddress(0x11)}
+		secondNode := &internalNode{ieeeAddress: zigbee.IEEEAddress(0x12)}
+
+		expectedFirst := DiscoveredNode{IEEEAddress: firstNode.ieeeAddress, JoinedAt: clock.Now().Add(time.Second)}
+		expectedSecond := DiscoveredNode{IEEEAddress: secondNode.ieeeAddress, JoinedAt: clock.Now().Add(2 * time.Second)}
+
+		mockEventSender.On("sendEvent", mock.IsType(DeviceDiscoveryEnabled{})).Twice()
+		mockEventSender.On("sendEvent", mock.IsType(DeviceDiscoveryDisabled{})).Once()
+		mockEventSender.On("sendEvent", DeviceDiscoveryNodeJoined{Gateway: &mockGateway, Node: expectedFirst}).Once()
+		mockEventSender.On("sendEvent", DeviceDiscoveryNodeJoined{Gateway: &mockGateway, Node: expectedSecond}).Once()
+
+		assert.NoError(t, zdd.NodeJoinCallback(context.Background(), internalNodeJoin{node: beforeWindow}))
+
+		err := zdd.Enable(context.Background(), gatewayDevice, time.Minute)
+		assert.NoError(t, err)
+
+		clock.Advance(time.Second)
+		assert.NoError(t, zdd.NodeJoinCallback(context.Background(), internalNodeJoin{node: firstNode}))
+
+		clock.Advance(time.Second)
+		assert.NoError(t, zdd.NodeJoinCallback(context.Background(), internalNodeJoin{node: secondNode}))
+
+		clock.Advance(time.Minute)
+
+		discovered, err := zdd.DiscoveredNodes(context.Background(), gatewayDevice)
+		assert.NoError(t, err)
+		assert.Equal(t, []DiscoveredNode{expectedFirst, expectedSecond}, discovered)
+
+		err = zdd.Enable(context.Background(), gatewayDevice, time.Minute)
+		assert.NoError(t, err)
+
+		discovered, err = zdd.DiscoveredNodes(context.Background(), gatewayDevice)
+		assert.NoError(t, err)
+		assert.Empty(t, discovered)
+	})
+}
@@ -0,0 +1,50 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zigbee"
+)
+
+// DeviceRole is the role of the node backing a device within the Zigbee network, routers are usually mains powered
+// and always listening, end devices are usually battery powered and may sleep.
+type DeviceRole string
+
+const (
+	RoleUnknown     DeviceRole = "unknown"
+	RoleCoordinator DeviceRole = "coordinator"
+	RoleRouter      DeviceRole = "router"
+	RoleEndDevice   DeviceRole = "end-device"
+)
+
+// DeviceRole returns the role of the node backing the device. The role is taken from the join announcement and
+// refreshed from the node description each time the device is enumerated, RoleUnknown is returned if neither has
+// been received.
+func (z *ZigbeeGateway) DeviceRole(device da.Device) (DeviceRole, error) {
+	if !da.DeviceDoesNotBelongToGateway(z, device) && device.Identifier == z.self.device.Identifier {
+		return RoleCoordinator, nil
+	}
+
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return RoleUnknown, err
+	}
+
+	iDev.node.mutex.RLock()
+	defer iDev.node.mutex.RUnlock()
+
+	return roleFromLogicalType(iDev.node.logicalType), nil
+}
+
+func roleFromLogicalType(logicalType zigbee.LogicalType) DeviceRole {
+	switch logicalType {
+	case zigbee.Coordinator:
+		return RoleCoordinator
+	case zigbee.Router:
+		return RoleRouter
+	case zigbee.EndDevice:
+		return RoleEndDevice
+	default:
+		return RoleUnknown
+	}
+}
@@ -0,0 +1,50 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestZigbeeGateway_DeviceRole(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		_, err := zgw.DeviceRole(da.Device{})
+		assert.Error(t, err)
+	})
+
+	t.Run("returns unknown for a device whose node has not announced its logical type", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		role, err := zgw.DeviceRole(iDev.device)
+		assert.NoError(t, err)
+		assert.Equal(t, RoleUnknown, role)
+	})
+
+	t.Run("returns the role of the node backing the device", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+		node.logicalType = zigbee.EndDevice
+
+		role, err := zgw.DeviceRole(iDev.device)
+		assert.NoError(t, err)
+		assert.Equal(t, RoleEndDevice, role)
+	})
+
+	t.Run("returns coordinator for the gateways self device", func(t *testing.T) {
+		zgw, mockProvider, _ := NewTestZigbeeGateway()
+		zgw.self.device.Gateway = zgw
+		zgw.self.device.Identifier = mockProvider.AdapterNode().IEEEAddress
+
+		role, err := zgw.DeviceRole(zgw.Self())
+		assert.NoError(t, err)
+		assert.Equal(t, RoleCoordinator, role)
+	})
+}
@@ -0,0 +1,172 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"sync"
+	"time"
+)
+
+// MaximumTraceEntries is the maximum number of frames retained by a single trace, frames beyond this are counted but
+// not retained.
+const MaximumTraceEntries = 1000
+
+type TraceDirection uint8
+
+const (
+	// TraceOutgoing indicates the frame was sent by the gateway to the node.
+	TraceOutgoing TraceDirection = 0x00
+	// TraceIncoming indicates the frame was received by the gateway from the node.
+	TraceIncoming TraceDirection = 0x01
+)
+
+// TraceEntry is a single frame exchanged with a traced node.
+type TraceEntry struct {
+	Time      time.Time
+	Direction TraceDirection
+
+	ApplicationMessage zigbee.ApplicationMessage
+	// Message is the decoded ZCL message, nil if the frame could not be decoded, in which case DecodeError explains
+	// why.
+	Message     *zcl.Message
+	DecodeError string
+}
+
+// DeviceTrace is a time ordered record of the frames exchanged with a device's node during a trace.
+type DeviceTrace struct {
+	IEEEAddress zigbee.IEEEAddress
+
+	Start time.Time
+	End   time.Time
+
+	Entries []TraceEntry
+	// Dropped is the number of frames which were not retained as the trace had reached MaximumTraceEntries.
+	Dropped int
+}
+
+type zdaDeviceTracer struct {
+	mutex    *sync.Mutex
+	registry *zcl.CommandRegistry
+
+	traces map[zigbee.IEEEAddress][]*DeviceTrace
+
+	now func() time.Time
+}
+
+func newDeviceTracer(registry *zcl.CommandRegistry) *zdaDeviceTracer {
+	return &zdaDeviceTracer{
+		mutex:    &sync.Mutex{},
+		registry: registry,
+		traces:   map[zigbee.IEEEAddress][]*DeviceTrace{},
+		now:      time.Now,
+	}
+}
+
+// start begins recording frames exchanged with the node, more than one trace of a node may be active at once.
+func (t *zdaDeviceTracer) start(ieeeAddress zigbee.IEEEAddress) *DeviceTrace {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	trace := &DeviceTrace{IEEEAddress: ieeeAddress, Start: t.now()}
+	t.traces[ieeeAddress] = append(t.traces[ieeeAddress], trace)
+
+	return trace
+}
+
+// stop ends the trace, returning a copy of what was recorded.
+func (t *zdaDeviceTracer) stop(trace *DeviceTrace) DeviceTrace {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	traces := t.traces[trace.IEEEAddress]
+
+	for i, active := range traces {
+		if active == trace {
+			traces = append(traces[:i], traces[i+1:]...)
+			break
+		}
+	}
+
+	if len(traces) == 0 {
+		delete(t.traces, trace.IEEEAddress)
+	} else {
+		t.traces[trace.IEEEAddress] = traces
+	}
+
+	trace.End = t.now()
+
+	return *trace
+}
+
+// record adds the frame to every active trace of the node, frames for nodes which are not being traced are ignored
+// without being decoded.
+func (t *zdaDeviceTracer) record(ieeeAddress zigbee.IEEEAddress, direction TraceDirection, appMsg zigbee.ApplicationMessage) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	traces, found := t.traces[ieeeAddress]
+
+	if !found {
+		return
+	}
+
+	entry := TraceEntry{Time: t.now(), Direction: direction, ApplicationMessage: appMsg}
+
+	if message, err := t.registry.Unmarshal(appMsg); err != nil {
+		entry.DecodeError = err.Error()
+	} else {
+		entry.Message = &message
+	}
+
+	for _, trace := range traces {
+		if len(trace.Entries) >= MaximumTraceEntries {
+			trace.Dropped++
+		} else {
+			trace.Entries = append(trace.Entries, entry)
+		}
+	}
+}
+
+// tracingProvider records frames sent to nodes with the tracer before passing them to the provider.
+type tracingProvider struct {
+	zigbee.Provider
+	tracer *zdaDeviceTracer
+}
+
+func (p *tracingProvider) SendApplicationMessageToNode(ctx context.Context, destinationAddress zigbee.IEEEAddress, message zigbee.ApplicationMessage, requireAck bool) error {
+	p.tracer.record(destinationAddress, TraceOutgoing, message)
+	return p.Provider.SendApplicationMessageToNode(ctx, destinationAddress, message, requireAck)
+}
+
+// TraceDevice records every frame sent to and received from the device's node for the duration provided, returning
+// the trace once the duration has elapsed. As frames are exchanged with nodes, the trace includes frames for all
+// devices on the same node. If the context is cancelled the trace recorded so far is returned along with the context
+// error. At most MaximumTraceEntries frames are retained.
+func (z *ZigbeeLocalDebug) TraceDevice(ctx context.Context, device da.Device, duration time.Duration) (DeviceTrace, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return DeviceTrace{}, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(capabilities.LocalDebugFlag) {
+		return DeviceTrace{}, da.DeviceDoesNotHaveCapability
+	}
+
+	iDev, found := z.gateway.getDevice(device.Identifier)
+
+	if !found {
+		return DeviceTrace{}, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	trace := z.gateway.tracer.start(iDev.node.ieeeAddress)
+
+	select {
+	case <-time.After(duration):
+		return z.gateway.tracer.stop(trace), nil
+	case <-ctx.Done():
+		return z.gateway.tracer.stop(trace), ctx.Err()
+	}
+}
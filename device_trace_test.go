@@ -0,0 +1,183 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func newTestTraceRegistry() *zcl.CommandRegistry {
+	registry := zcl.NewCommandRegistry()
+	global.Register(registry)
+	return registry
+}
+
+func Test_zdaDeviceTracer(t *testing.T) {
+	address := zigbee.IEEEAddress(0x01)
+
+	t.Run("records decoded frames for traced nodes only", func(t *testing.T) {
+		registry := newTestTraceRegistry()
+		tracer := newDeviceTracer(registry)
+
+		message := zcl.Message{
+			FrameType:           zcl.FrameGlobal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: 1,
+			ClusterID:           zcl.BasicId,
+			SourceEndpoint:      1,
+			DestinationEndpoint: 1,
+			Command:             &global.ReadAttributes{Identifier: []zcl.AttributeID{0x0000}},
+		}
+
+		appMsg, err := registry.Marshal(message)
+		assert.NoError(t, err)
+
+		tracer.record(address, TraceOutgoing, appMsg)
+
+		trace := tracer.start(address)
+		tracer.record(zigbee.IEEEAddress(0x02), TraceOutgoing, appMsg)
+		tracer.record(address, TraceIncoming, appMsg)
+		result := tracer.stop(trace)
+
+		tracer.record(address, TraceIncoming, appMsg)
+
+		assert.Len(t, result.Entries, 1)
+		assert.Equal(t, TraceIncoming, result.Entries[0].Direction)
+		assert.Equal(t, appMsg, result.Entries[0].ApplicationMessage)
+		assert.Equal(t, &message, result.Entries[0].Message)
+		assert.Empty(t, result.Entries[0].DecodeError)
+		assert.False(t, result.End.Before(result.Start))
+
+		assert.Empty(t, tracer.traces)
+	})
+
+	t.Run("records frames which can not be decoded with the error", func(t *testing.T) {
+		tracer := newDeviceTracer(newTestTraceRegistry())
+
+		trace := tracer.start(address)
+		tracer.record(address, TraceIncoming, zigbee.ApplicationMessage{ClusterID: zcl.BasicId, Data: []byte{0x00}})
+		result := tracer.stop(trace)
+
+		assert.Len(t, result.Entries, 1)
+		assert.Nil(t, result.Entries[0].Message)
+		assert.NotEmpty(t, result.Entries[0].DecodeError)
+	})
+
+	t.Run("counts frames beyond the maximum as dropped", func(t *testing.T) {
+		tracer := newDeviceTracer(newTestTraceRegistry())
+
+		trace := tracer.start(address)
+		for i := 0; i < MaximumTraceEntries+2; i++ {
+			tracer.record(address, TraceIncoming, zigbee.ApplicationMessage{})
+		}
+		result := tracer.stop(trace)
+
+		assert.Len(t, result.Entries, MaximumTraceEntries)
+		assert.Equal(t, 2, result.Dropped)
+	})
+
+	t.Run("records frames in every active trace of the node", func(t *testing.T) {
+		tracer := newDeviceTracer(newTestTraceRegistry())
+
+		first := tracer.start(address)
+		second := tracer.start(address)
+		tracer.record(address, TraceIncoming, zigbee.ApplicationMessage{})
+
+		assert.Len(t, tracer.stop(first).Entries, 1)
+		tracer.record(address, TraceIncoming, zigbee.ApplicationMessage{})
+		assert.Len(t, tracer.stop(second).Entries, 2)
+	})
+}
+
+func Test_tracingProvider(t *testing.T) {
+	t.Run("records outgoing frames and passes them to the provider", func(t *testing.T) {
+		mockProvider := new(zigbee.MockProvider)
+		defer mockProvider.AssertExpectations(t)
+
+		address := zigbee.IEEEAddress(0x01)
+		appMsg := zigbee.ApplicationMessage{ClusterID: zcl.BasicId}
+
+		mockProvider.On("SendApplicationMessageToNode", mock.Anything, address, appMsg, true).Return(nil)
+
+		tracer := newDeviceTracer(newTestTraceRegistry())
+		provider := &tracingProvider{Provider: mockProvider, tracer: tracer}
+
+		trace := tracer.start(address)
+		err := provider.SendApplicationMessageToNode(context.Background(), address, appMsg, true)
+		assert.NoError(t, err)
+
+		result := tracer.stop(trace)
+		assert.Len(t, result.Entries, 1)
+		assert.Equal(t, TraceOutgoing, result.Entries[0].Direction)
+	})
+}
+
+func TestZigbeeLocalDebug_TraceDevice(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		zld := zgw.capabilities[capabilities.LocalDebugFlag].(*ZigbeeLocalDebug)
+
+		_, err := zld.TraceDevice(context.Background(), da.Device{}, time.Millisecond)
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns error if device does not have capability", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		zld := zgw.capabilities[capabilities.LocalDebugFlag].(*ZigbeeLocalDebug)
+
+		_, err := zld.TraceDevice(context.Background(), da.Device{Gateway: zgw}, time.Millisecond)
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns frames exchanged with the device's node during the duration", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		zld := zgw.capabilities[capabilities.LocalDebugFlag].(*ZigbeeLocalDebug)
+
+		node := zgw.addNode(zigbee.IEEEAddress(0x01))
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		go func() {
+			for {
+				zgw.tracer.mutex.Lock()
+				tracing := len(zgw.tracer.traces) > 0
+				zgw.tracer.mutex.Unlock()
+
+				if tracing {
+					break
+				}
+
+				time.Sleep(time.Millisecond)
+			}
+
+			zgw.tracer.record(node.ieeeAddress, TraceIncoming, zigbee.ApplicationMessage{})
+		}()
+
+		trace, err := zld.TraceDevice(context.Background(), iDev.device, 50*time.Millisecond)
+		assert.NoError(t, err)
+		assert.Equal(t, node.ieeeAddress, trace.IEEEAddress)
+		assert.Len(t, trace.Entries, 1)
+	})
+
+	t.Run("returns the trace so far and the context error if cancelled", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		zld := zgw.capabilities[capabilities.LocalDebugFlag].(*ZigbeeLocalDebug)
+
+		node := zgw.addNode(zigbee.IEEEAddress(0x01))
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		trace, err := zld.TraceDevice(ctx, iDev.device, time.Minute)
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, node.ieeeAddress, trace.IEEEAddress)
+		assert.Empty(t, zgw.tracer.traces)
+	})
+}
@@ -0,0 +1,658 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+)
+
+const (
+	DoorLockLockState                 = zcl.AttributeID(0x0000)
+	DoorLockNumberOfPINUsersSupported = zcl.AttributeID(0x0012)
+)
+
+const (
+	LockDoorId              = zcl.CommandIdentifier(0x00)
+	UnlockDoorId            = zcl.CommandIdentifier(0x01)
+	SetPINCodeId            = zcl.CommandIdentifier(0x05)
+	GetPINCodeId            = zcl.CommandIdentifier(0x06)
+	ClearPINCodeId          = zcl.CommandIdentifier(0x07)
+	SetUserStatusId         = zcl.CommandIdentifier(0x09)
+	LockDoorResponseId      = zcl.CommandIdentifier(0x00)
+	UnlockDoorResponseId    = zcl.CommandIdentifier(0x01)
+	SetPINCodeResponseId    = zcl.CommandIdentifier(0x05)
+	GetPINCodeResponseId    = zcl.CommandIdentifier(0x06)
+	ClearPINCodeResponseId  = zcl.CommandIdentifier(0x07)
+	SetUserStatusResponseId = zcl.CommandIdentifier(0x09)
+)
+
+// LockDoor is sent to a Door Lock cluster server to lock the door. The PIN code is only required by locks configured
+// to require a PIN for RF operation, otherwise it is sent empty.
+type LockDoor struct {
+	PINCode string
+}
+
+// UnlockDoor is sent to a Door Lock cluster server to unlock the door.
+type UnlockDoor struct {
+	PINCode string
+}
+
+// LockDoorResponse is sent by a Door Lock cluster server in response to LockDoor, a non zero status indicates the
+// lock refused the command.
+type LockDoorResponse struct {
+	Status uint8
+}
+
+// UnlockDoorResponse is sent by a Door Lock cluster server in response to UnlockDoor, a non zero status indicates the
+// lock refused the command.
+type UnlockDoorResponse struct {
+	Status uint8
+}
+
+// SetPINCode is sent to a Door Lock cluster server to assign a PIN code to a user.
+type SetPINCode struct {
+	UserID     uint16
+	UserStatus uint8
+	UserType   uint8
+	PINCode    string
+}
+
+// SetPINCodeResponse is sent by a Door Lock cluster server in response to SetPINCode.
+type SetPINCodeResponse struct {
+	Status uint8
+}
+
+// GetPINCode is sent to a Door Lock cluster server to read the PIN code of a user.
+type GetPINCode struct {
+	UserID uint16
+}
+
+// GetPINCodeResponse is sent by a Door Lock cluster server in response to GetPINCode.
+type GetPINCodeResponse struct {
+	UserID     uint16
+	UserStatus uint8
+	UserType   uint8
+	PINCode    string
+}
+
+// ClearPINCode is sent to a Door Lock cluster server to remove the PIN code of a user, freeing the user slot.
+type ClearPINCode struct {
+	UserID uint16
+}
+
+// ClearPINCodeResponse is sent by a Door Lock cluster server in response to ClearPINCode.
+type ClearPINCodeResponse struct {
+	Status uint8
+}
+
+// SetUserStatus is sent to a Door Lock cluster server to enable or disable a user.
+type SetUserStatus struct {
+	UserID     uint16
+	UserStatus uint8
+}
+
+// SetUserStatusResponse is sent by a Door Lock cluster server in response to SetUserStatus.
+type SetUserStatusResponse struct {
+	Status uint8
+}
+
+// registerDoorLockCommands registers the Door Lock cluster commands. The registry does not distinguish direction, and
+// each response shares its identifier with its command, so responses are registered last so that they are the
+// commands unmarshalled when a lock responds.
+func registerDoorLockCommands(cr *zcl.CommandRegistry) {
+	cr.RegisterLocal(zcl.DoorLockId, zigbee.NoManufacturer, LockDoorId, &LockDoor{})
+	cr.RegisterLocal(zcl.DoorLockId, zigbee.NoManufacturer, UnlockDoorId, &UnlockDoor{})
+	cr.RegisterLocal(zcl.DoorLockId, zigbee.NoManufacturer, SetPINCodeId, &SetPINCode{})
+	cr.RegisterLocal(zcl.DoorLockId, zigbee.NoManufacturer, GetPINCodeId, &GetPINCode{})
+	cr.RegisterLocal(zcl.DoorLockId, zigbee.NoManufacturer, ClearPINCodeId, &ClearPINCode{})
+	cr.RegisterLocal(zcl.DoorLockId, zigbee.NoManufacturer, SetUserStatusId, &SetUserStatus{})
+	cr.RegisterLocal(zcl.DoorLockId, zigbee.NoManufacturer, LockDoorResponseId, &LockDoorResponse{})
+	cr.RegisterLocal(zcl.DoorLockId, zigbee.NoManufacturer, UnlockDoorResponseId, &UnlockDoorResponse{})
+	cr.RegisterLocal(zcl.DoorLockId, zigbee.NoManufacturer, SetPINCodeResponseId, &SetPINCodeResponse{})
+	cr.RegisterLocal(zcl.DoorLockId, zigbee.NoManufacturer, GetPINCodeResponseId, &GetPINCodeResponse{})
+	cr.RegisterLocal(zcl.DoorLockId, zigbee.NoManufacturer, ClearPINCodeResponseId, &ClearPINCodeResponse{})
+	cr.RegisterLocal(zcl.DoorLockId, zigbee.NoManufacturer, SetUserStatusResponseId, &SetUserStatusResponse{})
+}
+
+// DoorLockState is the state of the bolt of a lock, as defined by the LockState attribute.
+type DoorLockState uint8
+
+const (
+	DoorLockNotFullyLocked = DoorLockState(0x00)
+	DoorLockLocked         = DoorLockState(0x01)
+	DoorLockUnlocked       = DoorLockState(0x02)
+	DoorLockUndefined      = DoorLockState(0xff)
+)
+
+// DoorLockUserStatus is the status of a user slot of a lock.
+type DoorLockUserStatus uint8
+
+const (
+	DoorLockUserAvailable    = DoorLockUserStatus(0x00)
+	DoorLockUserEnabled      = DoorLockUserStatus(0x01)
+	DoorLockUserDisabled     = DoorLockUserStatus(0x03)
+	DoorLockUserNotSupported = DoorLockUserStatus(0xff)
+)
+
+// DoorLockUserType is the kind of access granted to a user of a lock. Users added by zda are unrestricted, schedules
+// are not supported.
+type DoorLockUserType uint8
+
+const (
+	DoorLockUserUnrestricted     = DoorLockUserType(0x00)
+	DoorLockUserYearDaySchedule  = DoorLockUserType(0x01)
+	DoorLockUserWeekDaySchedule  = DoorLockUserType(0x02)
+	DoorLockUserMaster           = DoorLockUserType(0x03)
+	DoorLockUserNonAccess        = DoorLockUserType(0x04)
+	DoorLockUserTypeNotSupported = DoorLockUserType(0xff)
+)
+
+// DoorLockUser is a user slot of a lock and its PIN code.
+type DoorLockUser struct {
+	ID      uint16
+	Status  DoorLockUserStatus
+	Type    DoorLockUserType
+	PINCode string
+}
+
+// DoorLockMemoryFull is returned if the lock has no room to store another PIN code.
+var DoorLockMemoryFull = errors.New("door lock memory full")
+
+// DoorLockDuplicateCode is returned if the PIN code is already assigned to another user of the lock.
+var DoorLockDuplicateCode = errors.New("door lock pin code already in use")
+
+// DoorLockNotAuthorized is returned if the lock refused a command as not authorized, locks commonly refuse PIN code
+// management unless it is APS encrypted, see APSEncryptingProvider.
+var DoorLockNotAuthorized = errors.New("door lock refused command as not authorized")
+
+const (
+	doorLockStatusSuccess       = uint8(0x00)
+	doorLockStatusMemoryFull    = uint8(0x02)
+	doorLockStatusDuplicateCode = uint8(0x03)
+)
+
+// zclStatusNotAuthorized is returned in a default response when the sender is not authorized to issue the command.
+const zclStatusNotAuthorized = uint8(0x7e)
+
+const doorLockMaximumReportInterval = 3600
+
+// DoorLock is a capability which signifies that a device is a lock which can be locked and unlocked remotely.
+type DoorLock interface {
+	// Lock locks the door.
+	Lock(context.Context, da.Device) error
+	// Unlock unlocks the door.
+	Unlock(context.Context, da.Device) error
+	// State returns the last known state of the lock.
+	State(context.Context, da.Device) (DoorLockState, error)
+	// SetPINCode assigns the PIN code to the user, enabling them.
+	SetPINCode(context.Context, da.Device, uint16, string) error
+	// PINCode returns the user and their PIN code.
+	PINCode(context.Context, da.Device, uint16) (DoorLockUser, error)
+	// ClearPINCode removes the PIN code of the user, freeing the user slot.
+	ClearPINCode(context.Context, da.Device, uint16) error
+	// SetUserEnabled enables or disables the user, without removing their PIN code.
+	SetUserEnabled(context.Context, da.Device, uint16, bool) error
+	// PINUsersSupported returns the number of users with PIN codes the lock can store.
+	PINUsersSupported(context.Context, da.Device) (uint16, error)
+}
+
+// DoorLockStateChanged is sent to inform consumers that the state of a lock has changed.
+type DoorLockStateChanged struct {
+	// Device whose state has changed.
+	Device da.Device
+	// New state of the lock.
+	State DoorLockState
+}
+
+// doorLockState is the lock state last read or reported, received is false until the first value has been read or
+// reported. pinUsersSupported is read during enumeration, and is 0 if the lock does not support PIN codes.
+type doorLockState struct {
+	state    DoorLockState
+	received bool
+
+	pinUsersSupported uint16
+}
+
+type ZigbeeDoorLock struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+	nodeStore         nodeStore
+
+	zclCommunicatorCallbacks zclCommunicatorCallbacks
+	zclCommunicatorRequests  zclCommunicatorRequests
+	zclGlobalCommunicator    zclGlobalCommunicator
+
+	nodeBinder  zigbee.NodeBinder
+	eventSender eventSender
+}
+
+func (z *ZigbeeDoorLock) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+
+	z.zclCommunicatorCallbacks.AddCallback(z.zclCommunicatorCallbacks.NewMatch(func(address zigbee.IEEEAddress, appMsg zigbee.ApplicationMessage, zclMessage zcl.Message) bool {
+		_, canCast := zclMessage.Command.(*global.ReportAttributes)
+		return canCast
+	}, z.incomingReportAttributes))
+}
+
+func (z *ZigbeeDoorLock) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, DoorLockFlag); found {
+			addCapability(&dev.device, DoorLockFlag)
+
+			dev.doorLockState.pinUsersSupported = 0
+
+			response, _, err := readAttributesWithEndpointFallback(ctx, z.zclGlobalCommunicator, z.eventSender, node, dev, DoorLockFlag, endpoint, cluster, []zcl.AttributeID{DoorLockLockState, DoorLockNumberOfPINUsersSupported})
+
+			if err == nil {
+				results := parseReadAttributeResponse(response)
+
+				if value, ok := results.uint8Value(DoorLockLockState); ok {
+					z.setState(dev, DoorLockState(value))
+				}
+
+				if users, ok := results.uintValue(DoorLockNumberOfPINUsersSupported); ok {
+					dev.doorLockState.pinUsersSupported = uint16(users)
+				}
+			} else {
+				log.Printf("failed to read lock state: %s", err)
+			}
+
+			bindErr := bindDeviceToController(ctx, z.nodeBinder, z.eventSender, node, dev, endpoint, cluster)
+			if bindErr != nil {
+				log.Printf("failed to bind to zda: %s", bindErr)
+			}
+
+			reportingErr := configureReporting(ctx, z.zclGlobalCommunicator, node, endpoint, cluster, DoorLockLockState, zcl.TypeEnum8, 0, doorLockMaximumReportInterval, nil)
+			if reportingErr != nil {
+				log.Printf("failed to configure reporting to zda: %s", reportingErr)
+			}
+
+			mode, reason := reportedUpdateMode(bindErr, reportingErr, UpdateModeNone)
+			setCapabilityUpdateMode(z.eventSender, dev, DoorLockFlag, mode, reason)
+		} else {
+			removeCapability(&dev.device, DoorLockFlag)
+			dev.doorLockState = doorLockState{}
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// setState records the lock state of the device, sending an event if it has changed. The device mutex must be held
+// for writing by the caller.
+func (z *ZigbeeDoorLock) setState(iDevice *internalDevice, state DoorLockState) {
+	markCapabilityUpdated(iDevice, DoorLockFlag)
+
+	previous := iDevice.doorLockState
+	iDevice.doorLockState.state = state
+	iDevice.doorLockState.received = true
+
+	if !previous.received || previous.state != state {
+		z.eventSender.sendEvent(DoorLockStateChanged{Device: iDevice.device, State: state})
+	}
+}
+
+func (z *ZigbeeDoorLock) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(DoorLockFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+// sendCommand sends a command to the Door Lock cluster of the device, returning the response from the lock. A default
+// response is returned as an error, as each command has a response of its own.
+func (z *ZigbeeDoorLock) sendCommand(ctx context.Context, iDevice *internalDevice, command interface{}) (interface{}, error) {
+	iNode := iDevice.node
+
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, DoorLockFlag)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find door lock cluster on zigbee device in zda")
+	}
+
+	zclMsg := zcl.Message{
+		FrameType:           zcl.FrameLocal,
+		Direction:           zcl.ClientToServer,
+		TransactionSequence: iNode.nextTransactionSequence(),
+		Manufacturer:        zigbee.NoManufacturer,
+		ClusterID:           cluster,
+		SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+		DestinationEndpoint: endpoint,
+		Command:             command,
+	}
+
+	cmdCtx, cancel := commandContext(ctx, iDevice)
+	defer cancel()
+
+	response, err := z.zclCommunicatorRequests.RequestResponse(cmdCtx, iNode.ieeeAddress, iNode.supportsAPSAck, zclMsg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if defaultResponse, ok := response.Command.(*global.DefaultResponse); ok {
+		if defaultResponse.Status == zclStatusNotAuthorized {
+			return nil, DoorLockNotAuthorized
+		}
+
+		return nil, fmt.Errorf("device responded with default response: status %d", defaultResponse.Status)
+	}
+
+	return response.Command, nil
+}
+
+// sendPINCommand sends a command carrying or returning PIN codes, requiring it to be APS encrypted. The user ID is
+// checked against the number of users supported by the lock, if it is known.
+func (z *ZigbeeDoorLock) sendPINCommand(ctx context.Context, device da.Device, userID uint16, command interface{}) (interface{}, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validatePINUser(iDevice, userID); err != nil {
+		return nil, err
+	}
+
+	return z.sendCommand(withAPSEncryption(ctx), iDevice, command)
+}
+
+// doorLockStatusError returns the error for the status in a response from the lock, nil if it indicates success.
+func doorLockStatusError(operation string, status uint8) error {
+	switch status {
+	case doorLockStatusSuccess:
+		return nil
+	case doorLockStatusMemoryFull:
+		return DoorLockMemoryFull
+	case doorLockStatusDuplicateCode:
+		return DoorLockDuplicateCode
+	default:
+		return fmt.Errorf("device rejected %s: status %d", operation, status)
+	}
+}
+
+// Lock locks the door, an error is returned if the lock responds with a failure status. The lock state is updated
+// when the lock reports it, as the response only indicates the lock accepted the command.
+func (z *ZigbeeDoorLock) Lock(ctx context.Context, device da.Device) error {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return err
+	}
+
+	response, err := z.sendCommand(ctx, iDevice, &LockDoor{})
+	if err != nil {
+		return err
+	}
+
+	lockResponse, ok := response.(*LockDoorResponse)
+
+	if !ok {
+		return fmt.Errorf("lock door received command back which was not LockDoorResponse")
+	}
+
+	return doorLockStatusError("lock door", lockResponse.Status)
+}
+
+// Unlock unlocks the door, an error is returned if the lock responds with a failure status.
+func (z *ZigbeeDoorLock) Unlock(ctx context.Context, device da.Device) error {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return err
+	}
+
+	response, err := z.sendCommand(ctx, iDevice, &UnlockDoor{})
+	if err != nil {
+		return err
+	}
+
+	unlockResponse, ok := response.(*UnlockDoorResponse)
+
+	if !ok {
+		return fmt.Errorf("unlock door received command back which was not UnlockDoorResponse")
+	}
+
+	return doorLockStatusError("unlock door", unlockResponse.Status)
+}
+
+// validatePINCode checks the PIN code can be sent to a lock, the lock may impose further limits on its length.
+func validatePINCode(pinCode string) error {
+	if len(pinCode) == 0 || len(pinCode) > 0xff {
+		return fmt.Errorf("pin code must be between 1 and 255 characters")
+	}
+
+	return nil
+}
+
+// validatePINUser checks the user is within those supported by the lock, if the lock has reported how many it supports.
+func validatePINUser(iDevice *internalDevice, userID uint16) error {
+	iDevice.mutex.RLock()
+	supported := iDevice.doorLockState.pinUsersSupported
+	iDevice.mutex.RUnlock()
+
+	if supported > 0 && userID >= supported {
+		return fmt.Errorf("user %d is outside of the %d users supported by the lock", userID, supported)
+	}
+
+	return nil
+}
+
+func (z *ZigbeeDoorLock) validateArguments(iDevice *internalDevice, operation string, args []interface{}) error {
+	switch operation {
+	case "SetPINCode":
+		if err := validatePINCode(args[1].(string)); err != nil {
+			return err
+		}
+
+		return validatePINUser(iDevice, args[0].(uint16))
+	case "PINCode", "ClearPINCode", "SetUserEnabled":
+		return validatePINUser(iDevice, args[0].(uint16))
+	}
+
+	return nil
+}
+
+// SetPINCode assigns the PIN code to the user as an unrestricted user, enabling them. DoorLockMemoryFull or
+// DoorLockDuplicateCode are returned if the lock rejects the code for those reasons.
+func (z *ZigbeeDoorLock) SetPINCode(ctx context.Context, device da.Device, userID uint16, pinCode string) error {
+	if err := validatePINCode(pinCode); err != nil {
+		return err
+	}
+
+	response, err := z.sendPINCommand(ctx, device, userID, &SetPINCode{
+		UserID:     userID,
+		UserStatus: uint8(DoorLockUserEnabled),
+		UserType:   uint8(DoorLockUserUnrestricted),
+		PINCode:    pinCode,
+	})
+	if err != nil {
+		return err
+	}
+
+	setResponse, ok := response.(*SetPINCodeResponse)
+
+	if !ok {
+		return fmt.Errorf("set pin code received command back which was not SetPINCodeResponse")
+	}
+
+	return doorLockStatusError("set pin code", setResponse.Status)
+}
+
+// PINCode returns the user and their PIN code, users whose slot is not in use have the status DoorLockUserAvailable.
+func (z *ZigbeeDoorLock) PINCode(ctx context.Context, device da.Device, userID uint16) (DoorLockUser, error) {
+	response, err := z.sendPINCommand(ctx, device, userID, &GetPINCode{UserID: userID})
+	if err != nil {
+		return DoorLockUser{}, err
+	}
+
+	getResponse, ok := response.(*GetPINCodeResponse)
+
+	if !ok {
+		return DoorLockUser{}, fmt.Errorf("get pin code received command back which was not GetPINCodeResponse")
+	}
+
+	return DoorLockUser{
+		ID:      getResponse.UserID,
+		Status:  DoorLockUserStatus(getResponse.UserStatus),
+		Type:    DoorLockUserType(getResponse.UserType),
+		PINCode: getResponse.PINCode,
+	}, nil
+}
+
+// ClearPINCode removes the PIN code of the user, freeing the user slot.
+func (z *ZigbeeDoorLock) ClearPINCode(ctx context.Context, device da.Device, userID uint16) error {
+	response, err := z.sendPINCommand(ctx, device, userID, &ClearPINCode{UserID: userID})
+	if err != nil {
+		return err
+	}
+
+	clearResponse, ok := response.(*ClearPINCodeResponse)
+
+	if !ok {
+		return fmt.Errorf("clear pin code received command back which was not ClearPINCodeResponse")
+	}
+
+	return doorLockStatusError("clear pin code", clearResponse.Status)
+}
+
+// SetUserEnabled enables or disables the user, a disabled user keeps their PIN code but it will not operate the lock.
+func (z *ZigbeeDoorLock) SetUserEnabled(ctx context.Context, device da.Device, userID uint16, enabled bool) error {
+	status := DoorLockUserDisabled
+
+	if enabled {
+		status = DoorLockUserEnabled
+	}
+
+	response, err := z.sendPINCommand(ctx, device, userID, &SetUserStatus{UserID: userID, UserStatus: uint8(status)})
+	if err != nil {
+		return err
+	}
+
+	statusResponse, ok := response.(*SetUserStatusResponse)
+
+	if !ok {
+		return fmt.Errorf("set user status received command back which was not SetUserStatusResponse")
+	}
+
+	return doorLockStatusError("set user status", statusResponse.Status)
+}
+
+// PINUsersSupported returns the number of users with PIN codes the lock can store, read during enumeration. 0 is
+// returned if the lock does not support PIN codes.
+func (z *ZigbeeDoorLock) PINUsersSupported(ctx context.Context, device da.Device) (uint16, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return 0, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	return iDevice.doorLockState.pinUsersSupported, nil
+}
+
+// State returns the last known state of the lock, NoReadingAvailable is returned if the state has not yet been read
+// or reported.
+func (z *ZigbeeDoorLock) State(ctx context.Context, device da.Device) (DoorLockState, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return DoorLockUndefined, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	if !iDevice.doorLockState.received {
+		return DoorLockUndefined, NoReadingAvailable
+	}
+
+	return iDevice.doorLockState.state, nil
+}
+
+var doorLockStateNames = map[DoorLockState]string{
+	DoorLockNotFullyLocked: "not_fully_locked",
+	DoorLockLocked:         "locked",
+	DoorLockUnlocked:       "unlocked",
+	DoorLockUndefined:      "undefined",
+}
+
+func (z *ZigbeeDoorLock) stateValues(ctx context.Context, device da.Device) ([]StateValue, error) {
+	state, err := z.State(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+
+	name, found := doorLockStateNames[state]
+	if !found {
+		name = doorLockStateNames[DoorLockUndefined]
+	}
+
+	return []StateValue{{Name: "state", Value: name}}, nil
+}
+
+func (z *ZigbeeDoorLock) incomingReportAttributes(source communicator.MessageWithSource) {
+	node, found := z.nodeStore.getNode(source.SourceAddress)
+
+	if !found {
+		return
+	}
+
+	report := source.Message.Command.(*global.ReportAttributes)
+
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+
+	for _, device := range node.devices {
+		device.mutex.Lock()
+
+		cluster, _ := clusterForCapability(device, DoorLockFlag)
+
+		if isEndpointInSlice(device.endpoints, source.Message.SourceEndpoint) && cluster == source.Message.ClusterID && device.device.HasCapability(DoorLockFlag) {
+			for _, attributeReport := range report.Records {
+				if attributeReport.Identifier != DoorLockLockState {
+					continue
+				}
+
+				if value, ok := attributeReport.DataTypeValue.Value.(uint8); ok {
+					z.setState(device, DoorLockState(value))
+				}
+			}
+		}
+
+		device.mutex.Unlock()
+	}
+}
@@ -0,0 +1,535 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZigbeeDoorLock_Contract(t *testing.T) {
+	t.Run("can be assigned to a DoorLock", func(t *testing.T) {
+		assert.Implements(t, (*DoorLock)(nil), new(ZigbeeDoorLock))
+	})
+}
+
+func Test_registerDoorLockCommands(t *testing.T) {
+	t.Run("unmarshals commands 0x00 and 0x01 as responses, and marshals lock commands", func(t *testing.T) {
+		cr := zcl.NewCommandRegistry()
+		registerDoorLockCommands(cr)
+
+		command, err := cr.GetLocalCommand(zcl.DoorLockId, zigbee.NoManufacturer, 0x00)
+		assert.NoError(t, err)
+		assert.IsType(t, &LockDoorResponse{}, command)
+
+		command, err = cr.GetLocalCommand(zcl.DoorLockId, zigbee.NoManufacturer, 0x01)
+		assert.NoError(t, err)
+		assert.IsType(t, &UnlockDoorResponse{}, command)
+
+		identifier, err := cr.GetLocalCommandIdentifier(zcl.DoorLockId, zigbee.NoManufacturer, &UnlockDoor{})
+		assert.NoError(t, err)
+		assert.Equal(t, UnlockDoorId, identifier)
+	})
+}
+
+func doorLockReport(node *internalNode, state DoorLockState) communicator.MessageWithSource {
+	return communicator.MessageWithSource{
+		SourceAddress: node.ieeeAddress,
+		Message: zcl.Message{
+			FrameType:           zcl.FrameGlobal,
+			Direction:           zcl.ClientToServer,
+			ClusterID:           zcl.DoorLockId,
+			SourceEndpoint:      node.endpoints[0],
+			DestinationEndpoint: DefaultGatewayHomeAutomationEndpoint,
+			Command: &global.ReportAttributes{
+				Records: []global.ReportAttributesRecord{
+					{
+						Identifier:    DoorLockLockState,
+						DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeEnum8, Value: uint8(state)},
+					},
+				},
+			},
+		},
+	}
+}
+
+func generateTestDoorLockDevice(zdl *ZigbeeDoorLock, mockDeviceStore *mockDeviceStore) (*internalNode, *internalDevice) {
+	node, device := generateTestNodeAndDevice()
+	device.device.Gateway = zdl.gateway
+	device.device.Capabilities = []da.Capability{DoorLockFlag}
+
+	deviceEndpoint := node.endpoints[0]
+	endpointDescription := node.endpointDescriptions[deviceEndpoint]
+	endpointDescription.InClusterList = []zigbee.ClusterID{zcl.DoorLockId}
+	node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+	mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+	return node, device
+}
+
+func TestZigbeeDoorLock_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with cluster, reads lock state, binds and configures reporting", func(t *testing.T) {
+		mockNodeBinder := mockNodeBinder{}
+		defer mockNodeBinder.AssertExpectations(t)
+
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zdl := ZigbeeDoorLock{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			nodeBinder:            &mockNodeBinder,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.DoorLockId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.DoorLockId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(1), []zcl.AttributeID{DoorLockLockState, DoorLockNumberOfPINUsersSupported}).Return([]global.ReadAttributeResponseRecord{
+			{
+				Identifier:    DoorLockLockState,
+				DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeEnum8, Value: uint8(DoorLockLocked)},
+			},
+			uint16AttributeRecord(DoorLockNumberOfPINUsersSupported, 30),
+		}, nil)
+		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, zcl.DoorLockId).Return(nil)
+		mockZclGlobalCommunicator.On("ConfigureReporting", mock.Anything, node.ieeeAddress, false, zcl.DoorLockId, zigbee.NoManufacturer, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, uint8(2), DoorLockLockState, zcl.TypeEnum8, uint16(0), uint16(doorLockMaximumReportInterval), nil).Return(nil)
+		mockEventSender.On("sendEvent", mock.MatchedBy(func(e DoorLockStateChanged) bool {
+			return e.Device.Identifier == device.device.Identifier && e.State == DoorLockLocked
+		}))
+
+		err := zdl.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(DoorLockFlag))
+		assert.Equal(t, doorLockState{state: DoorLockLocked, received: true, pinUsersSupported: 30}, device.doorLockState)
+		assert.Equal(t, UpdateModeReported, capabilityUpdateModes(device)[0].Mode)
+	})
+
+	t.Run("removes capability from device without cluster", func(t *testing.T) {
+		zdl := ZigbeeDoorLock{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{DoorLockFlag}
+		device.doorLockState = doorLockState{state: DoorLockLocked, received: true}
+
+		err := zdl.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(DoorLockFlag))
+		assert.Equal(t, doorLockState{}, device.doorLockState)
+	})
+}
+
+func TestZigbeeDoorLock_Lock(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zdl := ZigbeeDoorLock{gateway: &mockGateway{}}
+
+		err := zdl.Lock(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns error if device does not have capability", func(t *testing.T) {
+		zdl := ZigbeeDoorLock{gateway: &mockGateway{}}
+
+		err := zdl.Lock(context.Background(), da.Device{Gateway: zdl.gateway})
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("sends Lock Door command and succeeds if the lock responds with success", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zdl := ZigbeeDoorLock{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		node, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+
+		expectedRequest := zcl.Message{
+			FrameType:           zcl.FrameLocal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: 1,
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           zcl.DoorLockId,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: node.endpoints[0],
+			Command:             &LockDoor{},
+		}
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, node.ieeeAddress, false, expectedRequest).Return(zcl.Message{Command: &LockDoorResponse{Status: 0}}, nil)
+
+		err := zdl.Lock(context.Background(), device.device)
+		assert.NoError(t, err)
+	})
+
+	t.Run("returns error if the lock responds with a failure status", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zdl := ZigbeeDoorLock{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		_, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(zcl.Message{Command: &LockDoorResponse{Status: 0x01}}, nil)
+
+		err := zdl.Lock(context.Background(), device.device)
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if the response is not a Lock Door Response", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zdl := ZigbeeDoorLock{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		_, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(zcl.Message{Command: &UnlockDoorResponse{}}, nil)
+
+		err := zdl.Lock(context.Background(), device.device)
+		assert.Error(t, err)
+	})
+}
+
+func TestZigbeeDoorLock_Unlock(t *testing.T) {
+	t.Run("sends Unlock Door command and succeeds if the lock responds with success", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zdl := ZigbeeDoorLock{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		node, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, node.ieeeAddress, false, mock.MatchedBy(func(m zcl.Message) bool {
+			_, isUnlock := m.Command.(*UnlockDoor)
+			return isUnlock && m.ClusterID == zcl.DoorLockId
+		})).Return(zcl.Message{Command: &UnlockDoorResponse{Status: 0}}, nil)
+
+		err := zdl.Unlock(context.Background(), device.device)
+		assert.NoError(t, err)
+	})
+
+	t.Run("returns error if the lock responds with a failure status", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zdl := ZigbeeDoorLock{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		_, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(zcl.Message{Command: &UnlockDoorResponse{Status: 0x01}}, nil)
+
+		err := zdl.Unlock(context.Background(), device.device)
+		assert.Error(t, err)
+	})
+}
+
+func TestZigbeeDoorLock_State(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zdl := ZigbeeDoorLock{gateway: &mockGateway{}}
+
+		_, err := zdl.State(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns no reading available if the state has not been received", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zdl := ZigbeeDoorLock{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+		_, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+
+		_, err := zdl.State(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+	})
+
+	t.Run("state is updated by reports, and an event sent only when it changes", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		mockNodeStore := mockNodeStore{}
+		defer mockNodeStore.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zdl := ZigbeeDoorLock{
+			gateway:     &mockGateway{},
+			nodeStore:   &mockNodeStore,
+			deviceStore: &mockDeviceStore,
+			eventSender: &mockEventSender,
+		}
+		node, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+		device.doorLockState = doorLockState{state: DoorLockLocked, received: true}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+		mockEventSender.On("sendEvent", DoorLockStateChanged{Device: device.device, State: DoorLockUnlocked}).Once()
+
+		zdl.incomingReportAttributes(doorLockReport(node, DoorLockLocked))
+		zdl.incomingReportAttributes(doorLockReport(node, DoorLockUnlocked))
+		zdl.incomingReportAttributes(doorLockReport(node, DoorLockUnlocked))
+
+		state, err := zdl.State(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, DoorLockUnlocked, state)
+	})
+
+	t.Run("reports from a cluster which does not back the capability on the device are ignored", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockNodeStore := mockNodeStore{}
+		defer mockNodeStore.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zdl := ZigbeeDoorLock{
+			gateway:     &mockGateway{},
+			nodeStore:   &mockNodeStore,
+			deviceStore: &mockDeviceStore,
+			eventSender: &mockEventSender,
+		}
+
+		node, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+		device.clusterRemaps = map[da.Capability]zigbee.ClusterID{DoorLockFlag: 0xfc00}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+
+		zdl.incomingReportAttributes(doorLockReport(node, DoorLockLocked))
+
+		_, err := zdl.State(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+	})
+}
+
+func Test_doorLockStatusError(t *testing.T) {
+	t.Run("maps statuses to errors, returning nil for success", func(t *testing.T) {
+		assert.NoError(t, doorLockStatusError("set pin code", 0x00))
+		assert.Equal(t, DoorLockMemoryFull, doorLockStatusError("set pin code", 0x02))
+		assert.Equal(t, DoorLockDuplicateCode, doorLockStatusError("set pin code", 0x03))
+		assert.Error(t, doorLockStatusError("set pin code", 0x01))
+	})
+}
+
+func TestZigbeeDoorLock_SetPINCode(t *testing.T) {
+	t.Run("returns error if the pin code is empty", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zdl := ZigbeeDoorLock{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+		_, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+
+		assert.Error(t, zdl.SetPINCode(context.Background(), device.device, 1, ""))
+		assert.Error(t, zdl.validateArguments(device, "SetPINCode", []interface{}{uint16(1), ""}))
+	})
+
+	t.Run("returns error if the user is beyond those supported by the lock", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zdl := ZigbeeDoorLock{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+		_, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+		device.doorLockState.pinUsersSupported = 10
+
+		assert.Error(t, zdl.SetPINCode(context.Background(), device.device, 10, "1234"))
+		assert.Error(t, zdl.validateArguments(device, "SetPINCode", []interface{}{uint16(10), "1234"}))
+		assert.Error(t, zdl.validateArguments(device, "PINCode", []interface{}{uint16(10)}))
+		assert.Error(t, zdl.validateArguments(device, "ClearPINCode", []interface{}{uint16(10)}))
+		assert.Error(t, zdl.validateArguments(device, "SetUserEnabled", []interface{}{uint16(10), true}))
+		assert.NoError(t, zdl.validateArguments(device, "PINCode", []interface{}{uint16(9)}))
+	})
+
+	t.Run("sends Set PIN Code requiring aps encryption, enabling the user as unrestricted", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zdl := ZigbeeDoorLock{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		node, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+		device.doorLockState.pinUsersSupported = 10
+
+		expectedRequest := zcl.Message{
+			FrameType:           zcl.FrameLocal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: 1,
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           zcl.DoorLockId,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: node.endpoints[0],
+			Command:             &SetPINCode{UserID: 3, UserStatus: uint8(DoorLockUserEnabled), UserType: uint8(DoorLockUserUnrestricted), PINCode: "1234"},
+		}
+		mockZclCommunicatorRequests.On("RequestResponse", mock.MatchedBy(requiresAPSEncryption), node.ieeeAddress, false, expectedRequest).Return(zcl.Message{Command: &SetPINCodeResponse{Status: 0}}, nil)
+
+		err := zdl.SetPINCode(context.Background(), device.device, 3, "1234")
+		assert.NoError(t, err)
+	})
+
+	t.Run("returns duplicate code if the lock rejects the code as in use", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zdl := ZigbeeDoorLock{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		_, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(zcl.Message{Command: &SetPINCodeResponse{Status: 0x03}}, nil)
+
+		err := zdl.SetPINCode(context.Background(), device.device, 3, "1234")
+		assert.Equal(t, DoorLockDuplicateCode, err)
+	})
+
+	t.Run("returns not authorized if the lock responds with a not authorized default response", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zdl := ZigbeeDoorLock{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		_, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(zcl.Message{Command: &global.DefaultResponse{CommandIdentifier: uint8(SetPINCodeId), Status: 0x7e}}, nil)
+
+		err := zdl.SetPINCode(context.Background(), device.device, 3, "1234")
+		assert.Equal(t, DoorLockNotAuthorized, err)
+	})
+}
+
+func TestZigbeeDoorLock_PINCode(t *testing.T) {
+	t.Run("sends Get PIN Code requiring aps encryption and returns the user", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zdl := ZigbeeDoorLock{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		node, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+
+		mockZclCommunicatorRequests.On("RequestResponse", mock.MatchedBy(requiresAPSEncryption), node.ieeeAddress, false, mock.MatchedBy(func(m zcl.Message) bool {
+			get, ok := m.Command.(*GetPINCode)
+			return ok && get.UserID == 3
+		})).Return(zcl.Message{Command: &GetPINCodeResponse{UserID: 3, UserStatus: 0x03, UserType: 0x00, PINCode: "1234"}}, nil)
+
+		user, err := zdl.PINCode(context.Background(), device.device, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, DoorLockUser{ID: 3, Status: DoorLockUserDisabled, Type: DoorLockUserUnrestricted, PINCode: "1234"}, user)
+	})
+}
+
+func TestZigbeeDoorLock_ClearPINCode(t *testing.T) {
+	t.Run("sends Clear PIN Code and returns error if the lock fails to clear it", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zdl := ZigbeeDoorLock{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		_, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+
+		mockZclCommunicatorRequests.On("RequestResponse", mock.MatchedBy(requiresAPSEncryption), mock.Anything, mock.Anything, mock.MatchedBy(func(m zcl.Message) bool {
+			clear, ok := m.Command.(*ClearPINCode)
+			return ok && clear.UserID == 3
+		})).Return(zcl.Message{Command: &ClearPINCodeResponse{Status: 0x00}}, nil).Once()
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(zcl.Message{Command: &ClearPINCodeResponse{Status: 0x01}}, nil).Once()
+
+		assert.NoError(t, zdl.ClearPINCode(context.Background(), device.device, 3))
+		assert.Error(t, zdl.ClearPINCode(context.Background(), device.device, 3))
+	})
+}
+
+func TestZigbeeDoorLock_SetUserEnabled(t *testing.T) {
+	t.Run("sends Set User Status with the enabled or disabled status", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zdl := ZigbeeDoorLock{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		_, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+
+		for _, status := range []DoorLockUserStatus{DoorLockUserDisabled, DoorLockUserEnabled} {
+			expected := &SetUserStatus{UserID: 3, UserStatus: uint8(status)}
+
+			mockZclCommunicatorRequests.On("RequestResponse", mock.MatchedBy(requiresAPSEncryption), mock.Anything, mock.Anything, mock.MatchedBy(func(m zcl.Message) bool {
+				return assert.ObjectsAreEqual(expected, m.Command)
+			})).Return(zcl.Message{Command: &SetUserStatusResponse{Status: 0x00}}, nil).Once()
+		}
+
+		assert.NoError(t, zdl.SetUserEnabled(context.Background(), device.device, 3, false))
+		assert.NoError(t, zdl.SetUserEnabled(context.Background(), device.device, 3, true))
+	})
+}
+
+func TestZigbeeDoorLock_PINUsersSupported(t *testing.T) {
+	t.Run("returns the number of users read during enumeration", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zdl := ZigbeeDoorLock{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+		_, device := generateTestDoorLockDevice(&zdl, &mockDeviceStore)
+		device.doorLockState.pinUsersSupported = 30
+
+		users, err := zdl.PINUsersSupported(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(30), users)
+	})
+}
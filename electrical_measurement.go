@@ -0,0 +1,226 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+	"time"
+)
+
+const (
+	ElectricalMeasurementActivePower       = zcl.AttributeID(0x050b)
+	ElectricalMeasurementACPowerMultiplier = zcl.AttributeID(0x0604)
+	ElectricalMeasurementACPowerDivisor    = zcl.AttributeID(0x0605)
+)
+
+// electricalMeasurementInvalidActivePower is reported by devices which are unable to measure the power.
+const electricalMeasurementInvalidActivePower = int64(-0x8000)
+
+// electricalMeasurementPollInterval is short as devices measuring power are mains powered, and consumers expect
+// power to track loads being switched.
+const electricalMeasurementPollInterval = time.Minute
+
+// ElectricalMeasurement is a capability which signifies that a device measures the power drawn through it.
+type ElectricalMeasurement interface {
+	// Reading returns the last active power measured by the device, in watts.
+	Reading(context.Context, da.Device) (float64, error)
+}
+
+// ElectricalMeasurementReadingChanged is sent to inform consumers that the active power measured by a device has
+// changed.
+type ElectricalMeasurementReadingChanged struct {
+	// Device whose reading has changed.
+	Device da.Device
+	// New active power measured, in watts.
+	Power float64
+}
+
+// electricalMeasurementState is the scaling of the device, which is constant and so only read once, and the active
+// power last measured. scaled is false until the scaling has been read, available is false until a valid measurement
+// has been read.
+type electricalMeasurementState struct {
+	multiplier uint64
+	divisor    uint64
+	scaled     bool
+
+	watts     float64
+	available bool
+}
+
+type ZigbeeElectricalMeasurement struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+
+	zclGlobalCommunicator zclGlobalCommunicator
+
+	poller      poller
+	eventSender eventSender
+}
+
+func (z *ZigbeeElectricalMeasurement) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+	z.internalCallbacks.Add(z.NodeJoinCallback)
+}
+
+func (z *ZigbeeElectricalMeasurement) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, ElectricalMeasurementFlag); found {
+			addCapability(&dev.device, ElectricalMeasurementFlag)
+			setCapabilityUpdateMode(z.eventSender, dev, ElectricalMeasurementFlag, UpdateModePolled, "polled")
+			dev.electricalMeasurementState.scaled = false
+
+			if err := z.readPower(ctx, node, dev, endpoint, cluster); err != nil {
+				log.Printf("failed to read active power: %s", err)
+			}
+		} else {
+			removeCapability(&dev.device, ElectricalMeasurementFlag)
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (z *ZigbeeElectricalMeasurement) NodeJoinCallback(ctx context.Context, join internalNodeJoin) error {
+	z.poller.AddNode(join.node, electricalMeasurementPollInterval, z.pollNode)
+	return nil
+}
+
+// readPower reads the active power from the device and updates the cached reading. The scaling is read along with
+// the power if it has not yet been read. The node mutex must be held, and the device mutex held for writing, by the
+// caller.
+func (z *ZigbeeElectricalMeasurement) readPower(ctx context.Context, iNode *internalNode, iDevice *internalDevice, endpoint zigbee.Endpoint, cluster zigbee.ClusterID) error {
+	attributes := []zcl.AttributeID{ElectricalMeasurementActivePower}
+
+	if !iDevice.electricalMeasurementState.scaled {
+		attributes = append(attributes, ElectricalMeasurementACPowerMultiplier, ElectricalMeasurementACPowerDivisor)
+	}
+
+	response, _, err := readAttributesWithEndpointFallback(ctx, z.zclGlobalCommunicator, z.eventSender, iNode, iDevice, ElectricalMeasurementFlag, endpoint, cluster, attributes)
+
+	if err == nil {
+		markCapabilityUpdated(iDevice, ElectricalMeasurementFlag)
+
+		results := parseReadAttributeResponse(response)
+		state := &iDevice.electricalMeasurementState
+
+		if !state.scaled {
+			state.multiplier, _ = results.uintValue(ElectricalMeasurementACPowerMultiplier)
+			state.divisor, _ = results.uintValue(ElectricalMeasurementACPowerDivisor)
+			state.scaled = true
+		}
+
+		activePower, ok := results.intValue(ElectricalMeasurementActivePower)
+		z.setPower(iDevice, activePowerToWatts(activePower, state.multiplier, state.divisor), ok && activePower != electricalMeasurementInvalidActivePower)
+	}
+
+	return err
+}
+
+// activePowerToWatts applies the device's scaling to the raw active power. A multiplier or divisor of 0 is not
+// permitted by the ZCL, but is reported by devices which do not support scaling, and so is treated as 1.
+func activePowerToWatts(activePower int64, multiplier uint64, divisor uint64) float64 {
+	if multiplier == 0 {
+		multiplier = 1
+	}
+
+	if divisor == 0 {
+		divisor = 1
+	}
+
+	return float64(activePower) * float64(multiplier) / float64(divisor)
+}
+
+// setPower records the active power of the device, sending an event if it has changed. The device mutex must be held
+// for writing by the caller.
+func (z *ZigbeeElectricalMeasurement) setPower(iDevice *internalDevice, watts float64, available bool) {
+	state := &iDevice.electricalMeasurementState
+
+	previousWatts, previousAvailable := state.watts, state.available
+	state.watts, state.available = watts, available
+
+	if available && (!previousAvailable || previousWatts != watts) {
+		z.eventSender.sendEvent(ElectricalMeasurementReadingChanged{Device: iDevice.device, Power: watts})
+	}
+}
+
+func (z *ZigbeeElectricalMeasurement) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(ElectricalMeasurementFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+// Reading returns the last active power measured by the device, in watts. NoReadingAvailable is returned if no
+// measurement has been received, the device reported that its measurement is invalid, or the reading is stale.
+func (z *ZigbeeElectricalMeasurement) Reading(ctx context.Context, device da.Device) (float64, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return 0, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	if !iDevice.electricalMeasurementState.available || capabilityUnavailable(iDevice, ElectricalMeasurementFlag) {
+		return 0, NoReadingAvailable
+	}
+
+	return iDevice.electricalMeasurementState.watts, nil
+}
+
+func (z *ZigbeeElectricalMeasurement) stateValues(ctx context.Context, device da.Device) ([]StateValue, error) {
+	reading, err := z.Reading(ctx, device)
+	return readingStateValues("active_power", UnitWatt, reading, err)
+}
+
+func (z *ZigbeeElectricalMeasurement) pollNode(pctx context.Context, iNode *internalNode) {
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	for _, iDevice := range iNode.devices {
+		z.pollDevice(pctx, iNode, iDevice)
+	}
+}
+
+// pollDevice reads the active power of the device, if it has the capability. The node mutex must be held by the
+// caller.
+func (z *ZigbeeElectricalMeasurement) pollDevice(pctx context.Context, iNode *internalNode, iDevice *internalDevice) {
+	iDevice.mutex.Lock()
+	defer iDevice.mutex.Unlock()
+
+	if !iDevice.device.HasCapability(ElectricalMeasurementFlag) {
+		return
+	}
+
+	if endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, ElectricalMeasurementFlag); found {
+		if err := z.readPower(pctx, iNode, iDevice, endpoint, cluster); err != nil {
+			log.Printf("failed to query active power in zda: %s", err)
+		}
+	}
+}
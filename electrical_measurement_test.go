@@ -0,0 +1,164 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZigbeeElectricalMeasurement_Contract(t *testing.T) {
+	t.Run("can be assigned to an ElectricalMeasurement", func(t *testing.T) {
+		assert.Implements(t, (*ElectricalMeasurement)(nil), new(ZigbeeElectricalMeasurement))
+	})
+}
+
+func generateTestElectricalMeasurementNodeAndDevice() (*internalNode, *internalDevice) {
+	node, device := generateTestNodeAndDevice()
+
+	deviceEndpoint := node.endpoints[0]
+	endpointDescription := node.endpointDescriptions[deviceEndpoint]
+	endpointDescription.InClusterList = []zigbee.ClusterID{zcl.ElectricalMeasurementId}
+	node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+	return node, device
+}
+
+func Test_activePowerToWatts(t *testing.T) {
+	t.Run("applies the multiplier and divisor", func(t *testing.T) {
+		assert.Equal(t, 123.4, activePowerToWatts(1234, 1, 10))
+		assert.Equal(t, 40.0, activePowerToWatts(20, 2, 1))
+	})
+
+	t.Run("treats a multiplier or divisor of zero as one", func(t *testing.T) {
+		assert.Equal(t, 1234.0, activePowerToWatts(1234, 0, 0))
+	})
+}
+
+func TestZigbeeElectricalMeasurement_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with cluster and reads the scaling along with the active power", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zem := ZigbeeElectricalMeasurement{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestElectricalMeasurementNodeAndDevice()
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.ElectricalMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], uint8(1), []zcl.AttributeID{ElectricalMeasurementActivePower, ElectricalMeasurementACPowerMultiplier, ElectricalMeasurementACPowerDivisor}).Return([]global.ReadAttributeResponseRecord{
+			int16AttributeRecord(ElectricalMeasurementActivePower, 605),
+			uint16AttributeRecord(ElectricalMeasurementACPowerMultiplier, 1),
+			uint16AttributeRecord(ElectricalMeasurementACPowerDivisor, 10),
+		}, nil)
+		mockEventSender.On("sendEvent", mock.MatchedBy(func(e ElectricalMeasurementReadingChanged) bool {
+			return e.Device.Identifier == device.device.Identifier && e.Power == 60.5
+		}))
+
+		err := zem.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(ElectricalMeasurementFlag))
+		assert.Equal(t, electricalMeasurementState{multiplier: 1, divisor: 10, scaled: true, watts: 60.5, available: true}, device.electricalMeasurementState)
+	})
+
+	t.Run("removes capability from device without cluster", func(t *testing.T) {
+		zem := ZigbeeElectricalMeasurement{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{ElectricalMeasurementFlag}
+
+		err := zem.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(ElectricalMeasurementFlag))
+	})
+}
+
+func TestZigbeeElectricalMeasurement_NodeJoinCallback(t *testing.T) {
+	t.Run("registers new nodes with the poller when they join", func(t *testing.T) {
+		node := &internalNode{}
+
+		mockPoller := mockPoller{}
+		defer mockPoller.AssertExpectations(t)
+
+		zem := ZigbeeElectricalMeasurement{poller: &mockPoller}
+
+		mockPoller.On("AddNode", node, electricalMeasurementPollInterval, mock.AnythingOfType("func(context.Context, *zda.internalNode)"))
+
+		err := zem.NodeJoinCallback(context.Background(), internalNodeJoin{node: node})
+		assert.NoError(t, err)
+	})
+}
+
+func TestZigbeeElectricalMeasurement_Reading(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zem := ZigbeeElectricalMeasurement{gateway: &mockGateway{}}
+
+		_, err := zem.Reading(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns error if device does not support it", func(t *testing.T) {
+		zem := ZigbeeElectricalMeasurement{gateway: &mockGateway{}}
+
+		_, err := zem.Reading(context.Background(), da.Device{Gateway: zem.gateway})
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns the last reading, or no reading available if none has been received", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zem := ZigbeeElectricalMeasurement{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zem.gateway
+		device.device.Capabilities = []da.Capability{ElectricalMeasurementFlag}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		_, err := zem.Reading(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+
+		device.electricalMeasurementState = electricalMeasurementState{watts: 12.5, available: true}
+
+		reading, err := zem.Reading(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, 12.5, reading)
+	})
+}
+
+func TestZigbeeElectricalMeasurement_pollNode(t *testing.T) {
+	t.Run("reads only the active power once the scaling is known, sending an event only if it changed", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zem := ZigbeeElectricalMeasurement{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestElectricalMeasurementNodeAndDevice()
+		device.device.Capabilities = []da.Capability{ElectricalMeasurementFlag}
+		device.electricalMeasurementState = electricalMeasurementState{multiplier: 2, divisor: 1, scaled: true, watts: 100, available: true}
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.ElectricalMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], uint8(1), []zcl.AttributeID{ElectricalMeasurementActivePower}).Return([]global.ReadAttributeResponseRecord{int16AttributeRecord(ElectricalMeasurementActivePower, 50)}, nil).Once()
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.ElectricalMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], uint8(2), []zcl.AttributeID{ElectricalMeasurementActivePower}).Return([]global.ReadAttributeResponseRecord{int16AttributeRecord(ElectricalMeasurementActivePower, electricalMeasurementInvalidActivePower)}, nil).Once()
+
+		zem.pollNode(context.Background(), node)
+		zem.pollNode(context.Background(), node)
+
+		assert.False(t, device.electricalMeasurementState.available)
+	})
+}
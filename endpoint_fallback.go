@@ -0,0 +1,80 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/retry"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+)
+
+// CapabilityEndpointCorrected is sent when a device failed to answer for a capability's cluster on the endpoint it
+// advertised it on, but did answer on another endpoint of the node. The capability is backed by the corrected endpoint
+// from then on.
+type CapabilityEndpointCorrected struct {
+	Device     da.Device
+	Capability da.Capability
+	Cluster    zigbee.ClusterID
+
+	AdvertisedEndpoint zigbee.Endpoint
+	Endpoint           zigbee.Endpoint
+}
+
+// readAttributesWithEndpointFallback reads the attributes from the endpoint backing the capability. If the device
+// answers the read with an error, every other endpoint on the node is probed for the cluster, and the first to answer is
+// recorded as a correction on the device and an event sent. Probing happens at most once per capability, and not at all
+// if the read timed out, as a device which does not answer is not misadvertising. Endpoints selected by the user are
+// never corrected. The endpoint that answered is returned. The node mutex must be held, and the device mutex held for
+// writing, by the caller.
+func readAttributesWithEndpointFallback(ctx context.Context, zclGlobalCommunicator zclGlobalCommunicator, eventSender eventSender, iNode *internalNode, iDev *internalDevice, capability da.Capability, endpoint zigbee.Endpoint, cluster zigbee.ClusterID, attributes []zcl.AttributeID) ([]global.ReadAttributeResponseRecord, zigbee.Endpoint, error) {
+	var response []global.ReadAttributeResponseRecord
+
+	err := retry.Retry(ctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
+		var err error
+		response, err = zclGlobalCommunicator.ReadAttributes(ctx, iNode.ieeeAddress, iNode.supportsAPSAck, cluster, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, endpoint, iNode.nextTransactionSequence(), attributes)
+		return err
+	})
+
+	if err == nil {
+		return response, endpoint, nil
+	}
+
+	if _, selected := iDev.endpointSelections[capability]; selected {
+		return nil, endpoint, err
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || iDev.endpointsProbed[capability] {
+		return nil, endpoint, err
+	}
+
+	if iDev.endpointsProbed == nil {
+		iDev.endpointsProbed = map[da.Capability]bool{}
+	}
+
+	iDev.endpointsProbed[capability] = true
+
+	for _, candidate := range iNode.endpoints {
+		if candidate == endpoint {
+			continue
+		}
+
+		probeCtx, cancel := context.WithTimeout(ctx, DefaultNetworkTimeout)
+		probeResponse, probeErr := zclGlobalCommunicator.ReadAttributes(probeCtx, iNode.ieeeAddress, iNode.supportsAPSAck, cluster, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, candidate, iNode.nextTransactionSequence(), attributes)
+		cancel()
+
+		if probeErr == nil {
+			if iDev.endpointCorrections == nil {
+				iDev.endpointCorrections = map[da.Capability]zigbee.Endpoint{}
+			}
+
+			iDev.endpointCorrections[capability] = candidate
+			eventSender.sendEvent(CapabilityEndpointCorrected{Device: iDev.device, Capability: capability, Cluster: cluster, AdvertisedEndpoint: endpoint, Endpoint: candidate})
+
+			return probeResponse, candidate, nil
+		}
+	}
+
+	return nil, endpoint, err
+}
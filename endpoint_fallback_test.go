@@ -0,0 +1,122 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func Test_readAttributesWithEndpointFallback(t *testing.T) {
+	attributes := []zcl.AttributeID{TemperatureMeasuredValue}
+	records := []global.ReadAttributeResponseRecord{
+		{Identifier: TemperatureMeasuredValue, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeSignedInt16, Value: int64(2000)}},
+	}
+
+	generateMisadvertisingNode := func() (*internalNode, *internalDevice) {
+		node, device := generateTestNodeAndDevice()
+		node.endpoints = append(node.endpoints, 2)
+		node.endpointDescriptions[2] = zigbee.EndpointDescription{Endpoint: 2}
+		return node, device
+	}
+
+	t.Run("returns the response from the advertised endpoint without probing", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		node, device := generateMisadvertisingNode()
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.TemperatureMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(0), mock.Anything, attributes).Return(records, nil).Once()
+
+		response, endpoint, err := readAttributesWithEndpointFallback(context.Background(), &mockZclGlobalCommunicator, &mockEventSender{}, node, device, TemperatureSensorFlag, 0, zcl.TemperatureMeasurementId, attributes)
+		assert.NoError(t, err)
+		assert.Equal(t, records, response)
+		assert.Equal(t, zigbee.Endpoint(0), endpoint)
+		assert.Empty(t, device.endpointCorrections)
+	})
+
+	t.Run("probes other endpoints when the advertised endpoint fails, recording the correction", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		node, device := generateMisadvertisingNode()
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.TemperatureMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(0), mock.Anything, attributes).Return([]global.ReadAttributeResponseRecord{}, errors.New("unsupported cluster"))
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.TemperatureMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(2), mock.Anything, attributes).Return(records, nil).Once()
+		mockEventSender.On("sendEvent", CapabilityEndpointCorrected{Device: device.device, Capability: TemperatureSensorFlag, Cluster: zcl.TemperatureMeasurementId, AdvertisedEndpoint: 0, Endpoint: 2})
+
+		response, endpoint, err := readAttributesWithEndpointFallback(context.Background(), &mockZclGlobalCommunicator, &mockEventSender, node, device, TemperatureSensorFlag, 0, zcl.TemperatureMeasurementId, attributes)
+		assert.NoError(t, err)
+		assert.Equal(t, records, response)
+		assert.Equal(t, zigbee.Endpoint(2), endpoint)
+		assert.Equal(t, map[da.Capability]zigbee.Endpoint{TemperatureSensorFlag: 2}, device.endpointCorrections)
+	})
+
+	t.Run("returns the original error if no endpoint answers", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		node, device := generateMisadvertisingNode()
+		expectedErr := errors.New("unsupported cluster")
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.TemperatureMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(0), mock.Anything, attributes).Return([]global.ReadAttributeResponseRecord{}, expectedErr)
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.TemperatureMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(2), mock.Anything, attributes).Return([]global.ReadAttributeResponseRecord{}, errors.New("timeout")).Once()
+
+		_, endpoint, err := readAttributesWithEndpointFallback(context.Background(), &mockZclGlobalCommunicator, &mockEventSender{}, node, device, TemperatureSensorFlag, 0, zcl.TemperatureMeasurementId, attributes)
+		assert.Equal(t, expectedErr, err)
+		assert.Equal(t, zigbee.Endpoint(0), endpoint)
+		assert.Empty(t, device.endpointCorrections)
+	})
+
+	t.Run("does not probe if the user selected the endpoint", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		node, device := generateMisadvertisingNode()
+		device.endpointSelections = map[da.Capability]zigbee.Endpoint{TemperatureSensorFlag: 0}
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.TemperatureMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(0), mock.Anything, attributes).Return([]global.ReadAttributeResponseRecord{}, errors.New("unsupported cluster"))
+
+		_, _, err := readAttributesWithEndpointFallback(context.Background(), &mockZclGlobalCommunicator, &mockEventSender{}, node, device, TemperatureSensorFlag, 0, zcl.TemperatureMeasurementId, attributes)
+		assert.Error(t, err)
+	})
+
+	t.Run("only probes other endpoints once for a capability", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		node, device := generateMisadvertisingNode()
+		expectedErr := errors.New("unsupported cluster")
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.TemperatureMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(0), mock.Anything, attributes).Return([]global.ReadAttributeResponseRecord{}, expectedErr)
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.TemperatureMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(2), mock.Anything, attributes).Return([]global.ReadAttributeResponseRecord{}, expectedErr).Once()
+
+		_, _, err := readAttributesWithEndpointFallback(context.Background(), &mockZclGlobalCommunicator, &mockEventSender{}, node, device, TemperatureSensorFlag, 0, zcl.TemperatureMeasurementId, attributes)
+		assert.Equal(t, expectedErr, err)
+
+		_, _, err = readAttributesWithEndpointFallback(context.Background(), &mockZclGlobalCommunicator, &mockEventSender{}, node, device, TemperatureSensorFlag, 0, zcl.TemperatureMeasurementId, attributes)
+		assert.Equal(t, expectedErr, err)
+	})
+
+	t.Run("does not probe if the advertised endpoint timed out", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		node, device := generateMisadvertisingNode()
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.TemperatureMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(0), mock.Anything, attributes).Return([]global.ReadAttributeResponseRecord{}, context.DeadlineExceeded)
+
+		_, endpoint, err := readAttributesWithEndpointFallback(context.Background(), &mockZclGlobalCommunicator, &mockEventSender{}, node, device, TemperatureSensorFlag, 0, zcl.TemperatureMeasurementId, attributes)
+		assert.Equal(t, context.DeadlineExceeded, err)
+		assert.Equal(t, zigbee.Endpoint(0), endpoint)
+		assert.NotContains(t, device.endpointsProbed, TemperatureSensorFlag)
+	})
+}
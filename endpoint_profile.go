@@ -0,0 +1,41 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zigbee"
+)
+
+// endpointProfiles returns the profile each of the endpoints provided was described under during enumeration,
+// endpoints which have not been described are omitted. The node mutex must be held by the caller.
+func endpointProfiles(iNode *internalNode, endpoints []zigbee.Endpoint) map[zigbee.Endpoint]zigbee.ProfileID {
+	profiles := map[zigbee.Endpoint]zigbee.ProfileID{}
+
+	for _, endpoint := range endpoints {
+		if description, found := iNode.endpointDescriptions[endpoint]; found {
+			profiles[endpoint] = description.ProfileID
+		}
+	}
+
+	return profiles
+}
+
+// EndpointProfiles returns the profile of each endpoint of the device from its simple descriptor, keyed by endpoint.
+// The gateway registers under the Home Automation profile, but devices may also place endpoints under Zigbee Light
+// Link or manufacturer profiles, which can alter the behaviour of their clusters.
+func (z *ZigbeeGateway) EndpointProfiles(device da.Device) (map[zigbee.Endpoint]zigbee.ProfileID, error) {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return nil, err
+	}
+
+	iNode := iDev.node
+
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	iDev.mutex.RLock()
+	defer iDev.mutex.RUnlock()
+
+	return endpointProfiles(iNode, iDev.endpoints), nil
+}
@@ -0,0 +1,40 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestZigbeeGateway_EndpointProfiles(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		_, err := zgw.EndpointProfiles(da.Device{})
+		assert.Error(t, err)
+	})
+
+	t.Run("returns the profile of each described endpoint of the device", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		node.endpoints = []zigbee.Endpoint{0x01, 0x02, 0x03, 0x04}
+		node.endpointDescriptions[0x01] = zigbee.EndpointDescription{Endpoint: 0x01, ProfileID: zigbee.ProfileHomeAutomation}
+		node.endpointDescriptions[0x02] = zigbee.EndpointDescription{Endpoint: 0x02, ProfileID: 0xc05e}
+		node.endpointDescriptions[0x03] = zigbee.EndpointDescription{Endpoint: 0x03, ProfileID: 0xc25d}
+		iDev.endpoints = []zigbee.Endpoint{0x01, 0x02, 0x04}
+
+		profiles, err := zgw.EndpointProfiles(iDev.device)
+		assert.NoError(t, err)
+
+		expected := map[zigbee.Endpoint]zigbee.ProfileID{
+			0x01: zigbee.ProfileHomeAutomation,
+			0x02: 0xc05e,
+		}
+
+		assert.Equal(t, expected, profiles)
+	})
+}
@@ -7,7 +7,9 @@ import (
 	"github.com/shimmeringbee/da"
 	"github.com/shimmeringbee/da/capabilities"
 	"github.com/shimmeringbee/retry"
+	"github.com/shimmeringbee/zcl"
 	"github.com/shimmeringbee/zigbee"
+	"log"
 	"sort"
 	"time"
 )
@@ -18,12 +20,37 @@ const MaximumEnumerationTime = 1 * time.Minute
 const DefaultNetworkTimeout = 1500 * time.Millisecond
 const DefaultNetworkRetries = 5
 
+// EnumerateDeviceFullyEnumerated is sent once all reads of a device's enumeration have completed. Without fast
+// enumeration this immediately follows EnumerateDeviceSuccess, with it this follows once the optional reads which were
+// deferred have been made in the background.
+type EnumerateDeviceFullyEnumerated struct {
+	Device da.Device
+}
+
+// EnumerationFailedError is provided as the error of EnumerateDeviceFailure, it carries the number of consecutive
+// failed attempts to enumerate the node, to help distinguish a slow device from one which can not be enumerated.
+type EnumerationFailedError struct {
+	Attempts int
+	Err      error
+}
+
+func (e EnumerationFailedError) Error() string {
+	return fmt.Sprintf("enumeration failed, attempt %d: %s", e.Attempts, e.Err)
+}
+
+func (e EnumerationFailedError) Unwrap() error {
+	return e.Err
+}
+
 type ZigbeeEnumerateDevice struct {
 	gateway           da.Gateway
 	deviceStore       deviceStore
 	eventSender       eventSender
 	nodeQuerier       zigbee.NodeQuerier
 	internalCallbacks callbacks.AdderCaller
+	fastEnumeration   bool
+
+	zclGlobalCommunicator zclGlobalCommunicator
 
 	queue     chan *internalNode
 	queueStop chan bool
@@ -52,22 +79,29 @@ func (z *ZigbeeEnumerateDevice) Enumerate(ctx context.Context, device da.Device)
 }
 
 func (z *ZigbeeEnumerateDevice) queueEnumeration(ctx context.Context, node *internalNode) error {
+	setEnumerationState(node, EnumerationPending)
+
 	select {
 	case z.queue <- node:
-		node.mutex.RLock()
-		for _, device := range node.getDevices() {
-			z.eventSender.sendEvent(capabilities.EnumerateDeviceStart{
-				Device: device.device,
-			})
-		}
-		node.mutex.RUnlock()
-
+		z.sendStart(node)
 		return nil
 	default:
+		setEnumerationState(node, EnumerationFailed)
 		return fmt.Errorf("unable to queue enumeration request, likely channel full")
 	}
 }
 
+func (z *ZigbeeEnumerateDevice) sendStart(node *internalNode) {
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+
+	for _, device := range node.getDevices() {
+		z.eventSender.sendEvent(capabilities.EnumerateDeviceStart{
+			Device: device.device,
+		})
+	}
+}
+
 func (z *ZigbeeEnumerateDevice) Init() {
 	z.internalCallbacks.Add(z.NodeJoinCallback)
 }
@@ -87,27 +121,126 @@ func (z *ZigbeeEnumerateDevice) enumerateLoop() {
 		case <-z.queueStop:
 			return
 		case node := <-z.queue:
-			if err := z.enumerateNode(node); err != nil {
-				fmt.Printf("failed to enumerate node: %s: %s", node.ieeeAddress, err)
-
-				node.mutex.RLock()
-				for _, device := range node.getDevices() {
-					z.eventSender.sendEvent(capabilities.EnumerateDeviceFailure{
-						Device: device.device,
-						Error:  err,
-					})
-				}
-				node.mutex.RUnlock()
-			} else {
-				node.mutex.RLock()
-				for _, device := range node.getDevices() {
-					z.eventSender.sendEvent(capabilities.EnumerateDeviceSuccess{
-						Device: device.device,
-					})
-				}
-				node.mutex.RUnlock()
-			}
+			z.enumerate(node)
+		}
+	}
+}
+
+// enumerate interviews the node, sending the outcome to the consumer for each device, and returns any error which
+// caused the enumeration to fail.
+func (z *ZigbeeEnumerateDevice) enumerate(node *internalNode) error {
+	setEnumerationState(node, EnumerationInterviewing)
+
+	startedAt := time.Now()
+	previousCapabilities := z.deviceCapabilities(node)
+	recorder := newEnumerationRecorder()
+
+	err := z.enumerateNode(node, recorder)
+
+	if err != nil {
+		fmt.Printf("failed to enumerate node: %s: %s", node.ieeeAddress, err)
+		recorder.recordError(err)
+		setEnumerationState(node, EnumerationFailed)
+		attempts := recordEnumerationOutcome(node, err)
+
+		node.mutex.RLock()
+		for _, device := range node.getDevices() {
+			z.eventSender.sendEvent(capabilities.EnumerateDeviceFailure{
+				Device: device.device,
+				Error:  EnumerationFailedError{Attempts: attempts, Err: err},
+			})
+		}
+		node.mutex.RUnlock()
+	} else {
+		setEnumerationState(node, EnumerationComplete)
+		recordEnumerationOutcome(node, nil)
+
+		node.mutex.RLock()
+		for _, device := range node.getDevices() {
+			z.eventSender.sendEvent(capabilities.EnumerateDeviceSuccess{
+				Device: device.device,
+			})
 		}
+		node.mutex.RUnlock()
+	}
+
+	z.storeEnumerationResults(node, recorder, startedAt, previousCapabilities)
+
+	if err == nil {
+		if z.fastEnumeration {
+			go z.enumerateDeferred(node)
+		} else {
+			z.sendFullyEnumerated(node)
+		}
+	}
+
+	return err
+}
+
+// recordEnumerationOutcome records the error of the node's latest enumeration, returning the number of consecutive
+// failed attempts. A successful enumeration resets the count and clears the error.
+func recordEnumerationOutcome(iNode *internalNode, err error) int {
+	iNode.mutex.Lock()
+	defer iNode.mutex.Unlock()
+
+	if err == nil {
+		iNode.failedEnumerationAttempts = 0
+	} else {
+		iNode.failedEnumerationAttempts++
+	}
+
+	iNode.lastEnumerationError = err
+
+	return iNode.failedEnumerationAttempts
+}
+
+// enumerateDeferred makes the optional reads of a node which were skipped by fast enumeration.
+func (z *ZigbeeEnumerateDevice) enumerateDeferred(iNode *internalNode) {
+	ctx, cancel := context.WithTimeout(context.Background(), MaximumEnumerationTime)
+	defer cancel()
+
+	if err := z.internalCallbacks.Call(ctx, internalNodeDeferredEnumeration{node: iNode}); err != nil {
+		log.Printf("failed deferred enumeration of node: %s: %s", iNode.ieeeAddress, err)
+	}
+
+	z.sendFullyEnumerated(iNode)
+}
+
+func (z *ZigbeeEnumerateDevice) sendFullyEnumerated(iNode *internalNode) {
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	for _, device := range iNode.getDevices() {
+		z.eventSender.sendEvent(EnumerateDeviceFullyEnumerated{
+			Device: device.device,
+		})
+	}
+}
+
+func (z *ZigbeeEnumerateDevice) deviceCapabilities(iNode *internalNode) map[da.Identifier][]da.Capability {
+	deviceCapabilities := map[da.Identifier][]da.Capability{}
+
+	for _, iDev := range iNode.getDevices() {
+		iDev.mutex.RLock()
+		deviceCapabilities[iDev.device.Identifier] = append([]da.Capability{}, iDev.device.Capabilities...)
+		iDev.mutex.RUnlock()
+	}
+
+	return deviceCapabilities
+}
+
+func (z *ZigbeeEnumerateDevice) storeEnumerationResults(iNode *internalNode, recorder *enumerationRecorder, startedAt time.Time, previousCapabilities map[da.Identifier][]da.Capability) {
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	for _, iDev := range iNode.devices {
+		iDev.mutex.Lock()
+		result := recorder.result(iNode, iDev, startedAt, previousCapabilities[iDev.device.Identifier])
+		iDev.enumerationResult = result
+		device := iDev.device
+		iDev.mutex.Unlock()
+
+		z.eventSender.sendEvent(EnumerateDeviceResult{Device: device, Result: result})
 	}
 }
 
@@ -117,10 +250,12 @@ func (z *ZigbeeEnumerateDevice) Stop() {
 	}
 }
 
-func (z *ZigbeeEnumerateDevice) enumerateNode(iNode *internalNode) error {
+func (z *ZigbeeEnumerateDevice) enumerateNode(iNode *internalNode, recorder *enumerationRecorder) error {
 	ctx, cancel := context.WithTimeout(context.Background(), MaximumEnumerationTime)
 	defer cancel()
 
+	ctx = withEnumerationRecorder(ctx, recorder)
+
 	if err := z.enumerateNodeDescription(ctx, iNode); err != nil {
 		return err
 	}
@@ -143,10 +278,24 @@ func (z *ZigbeeEnumerateDevice) enumerateNode(iNode *internalNode) error {
 	z.allocateEndpointsToDevices(iNode)
 	z.deallocateDevicesFromMissingEndpoints(iNode)
 
+	z.enumerateClusterRevisions(ctx, iNode)
+
 	if err := z.internalCallbacks.Call(ctx, internalNodeEnumeration{node: iNode}); err != nil {
 		return err
 	}
 
+	if !z.fastEnumeration {
+		if err := z.internalCallbacks.Call(ctx, internalNodeDeferredEnumeration{node: iNode}); err != nil {
+			return err
+		}
+	}
+
+	for _, iDev := range iNode.getDevices() {
+		iDev.mutex.Lock()
+		applyEnumeratedCapabilityOverrides(iDev)
+		iDev.mutex.Unlock()
+	}
+
 	return nil
 }
 
@@ -157,6 +306,7 @@ func (z *ZigbeeEnumerateDevice) enumerateNodeDescription(pCtx context.Context, i
 		if err == nil {
 			iNode.mutex.Lock()
 			iNode.nodeDesc = nd
+			iNode.logicalType = nd.LogicalType
 			iNode.mutex.Unlock()
 		}
 
@@ -209,7 +359,7 @@ func (z *ZigbeeEnumerateDevice) allocateEndpointsToDevices(iNode *internalNode)
 
 	for _, endpoint := range endpoints {
 		desc := endpointDescriptions[endpoint]
-		iDev := z.findDeviceWithDeviceId(iNode, desc.DeviceID, desc.DeviceVersion)
+		iDev := z.findDeviceForEndpoint(iNode, endpoint, desc)
 
 		iDev.mutex.Lock()
 		if !isEndpointInSlice(iDev.endpoints, endpoint) {
@@ -261,7 +411,14 @@ func (z *ZigbeeEnumerateDevice) deallocateDevicesFromMissingEndpoints(iNode *int
 	}
 }
 
-func (z *ZigbeeEnumerateDevice) findDeviceWithDeviceId(iNode *internalNode, deviceId uint16, deviceVersion uint8) *internalDevice {
+// independentClusters are clusters which, when present on multiple endpoints sharing a device ID, indicate that each
+// endpoint is an independently controllable device, such as each gang of a multi-gang switch.
+var independentClusters = []zigbee.ClusterID{zcl.OnOffId}
+
+func (z *ZigbeeEnumerateDevice) findDeviceForEndpoint(iNode *internalNode, endpoint zigbee.Endpoint, desc zigbee.EndpointDescription) *internalDevice {
+	deviceId := desc.DeviceID
+	deviceVersion := desc.DeviceVersion
+
 	iNode.mutex.Lock()
 	nodeDevices := iNode.devices
 	iNode.mutex.Unlock()
@@ -269,7 +426,18 @@ func (z *ZigbeeEnumerateDevice) findDeviceWithDeviceId(iNode *internalNode, devi
 	for _, iDev := range nodeDevices {
 		iDev.mutex.RLock()
 
-		if iDev.deviceID == deviceId {
+		if iDev.deviceID == deviceId && isEndpointInSlice(iDev.endpoints, endpoint) {
+			iDev.mutex.RUnlock()
+			return iDev
+		}
+
+		iDev.mutex.RUnlock()
+	}
+
+	for _, iDev := range nodeDevices {
+		iDev.mutex.RLock()
+
+		if iDev.deviceID == deviceId && !hasIndependentClusterConflict(iNode, iDev, desc) {
 			iDev.mutex.RUnlock()
 			return iDev
 		}
@@ -300,3 +468,17 @@ func (z *ZigbeeEnumerateDevice) findDeviceWithDeviceId(iNode *internalNode, devi
 	iDev.mutex.Unlock()
 	return iDev
 }
+
+func hasIndependentClusterConflict(iNode *internalNode, iDev *internalDevice, desc zigbee.EndpointDescription) bool {
+	for _, cluster := range independentClusters {
+		if !isClusterIdInSlice(desc.InClusterList, cluster) {
+			continue
+		}
+
+		if _, found := findEndpointWithClusterId(iNode, iDev, cluster); found {
+			return true
+		}
+	}
+
+	return false
+}
@@ -176,6 +176,7 @@ func TestZigbeeEnumerateDevice_enumerateDevice(t *testing.T) {
 
 		mockAdderCaller := mockAdderCaller{}
 		mockAdderCaller.On("Call", mock.Anything, mock.AnythingOfType("zda.internalNodeEnumeration")).Return(nil)
+		mockAdderCaller.On("Call", mock.Anything, mock.AnythingOfType("zda.internalNodeDeferredEnumeration")).Return(nil)
 
 		expectedStart := EnumerateDeviceStart{
 			Device: iDev.device,
@@ -187,7 +188,9 @@ func TestZigbeeEnumerateDevice_enumerateDevice(t *testing.T) {
 
 		mockEventSender := mockEventSender{}
 		mockEventSender.On("sendEvent", expectedSuccess)
+		mockEventSender.On("sendEvent", EnumerateDeviceFullyEnumerated{Device: iDev.device})
 		mockEventSender.On("sendEvent", expectedStart)
+		mockEventSender.On("sendEvent", mock.IsType(EnumerateDeviceResult{}))
 
 		mockDeviceStore := mockDeviceStore{}
 		mockDeviceStore.On("getDevice", iDev.device.Identifier).Return(iDev, true)
@@ -215,6 +218,7 @@ func TestZigbeeEnumerateDevice_enumerateDevice(t *testing.T) {
 		assert.Equal(t, expectedEndpointDescs[1], iNode.endpointDescriptions[0x02])
 
 		assert.False(t, iNode.supportsAPSAck)
+		assert.Equal(t, EnumerationComplete, iNode.enumerationState)
 
 		mockNodeQuerier.AssertExpectations(t)
 		mockAdderCaller.AssertExpectations(t)
@@ -222,6 +226,78 @@ func TestZigbeeEnumerateDevice_enumerateDevice(t *testing.T) {
 		mockDeviceStore.AssertExpectations(t)
 	})
 
+	t.Run("fast enumeration makes deferred reads in the background after the device is usable", func(t *testing.T) {
+		iNode, iDev := generateTestNodeAndDevice()
+		iDev.device.Capabilities = []da.Capability{EnumerateDeviceFlag}
+
+		expectedIEEE := iNode.ieeeAddress
+
+		mockNodeQuerier := mockNodeQuerier{}
+		mockNodeQuerier.On("QueryNodeDescription", mock.Anything, expectedIEEE).Return(zigbee.NodeDescription{LogicalType: zigbee.Router}, nil)
+		mockNodeQuerier.On("QueryNodeEndpoints", mock.Anything, expectedIEEE).Return([]zigbee.Endpoint{0x01}, nil)
+		mockNodeQuerier.On("QueryNodeEndpointDescription", mock.Anything, expectedIEEE, zigbee.Endpoint(0x01)).Return(zigbee.EndpointDescription{Endpoint: 0x01}, nil)
+
+		deferredCalled := make(chan bool, 1)
+
+		mockAdderCaller := mockAdderCaller{}
+		mockAdderCaller.On("Call", mock.Anything, mock.AnythingOfType("zda.internalNodeEnumeration")).Return(nil)
+		mockAdderCaller.On("Call", mock.Anything, mock.AnythingOfType("zda.internalNodeDeferredEnumeration")).Return(nil).Run(func(args mock.Arguments) {
+			deferredCalled <- true
+		})
+
+		var events []interface{}
+		eventsLock := &sync.Mutex{}
+
+		mockEventSender := mockEventSender{}
+		mockEventSender.On("sendEvent", mock.Anything).Run(func(args mock.Arguments) {
+			eventsLock.Lock()
+			defer eventsLock.Unlock()
+
+			events = append(events, args.Get(0))
+		})
+
+		mockDeviceStore := mockDeviceStore{}
+		mockDeviceStore.On("getDevice", iDev.device.Identifier).Return(iDev, true)
+
+		zed := ZigbeeEnumerateDevice{
+			deviceStore:       &mockDeviceStore,
+			eventSender:       &mockEventSender,
+			nodeQuerier:       &mockNodeQuerier,
+			internalCallbacks: &mockAdderCaller,
+			fastEnumeration:   true,
+		}
+
+		zed.Start()
+		err := zed.Enumerate(context.TODO(), iDev.device)
+		assert.NoError(t, err)
+
+		select {
+		case <-deferredCalled:
+		case <-time.After(time.Second):
+			assert.Fail(t, "deferred enumeration was not called")
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		zed.Stop()
+
+		eventsLock.Lock()
+		defer eventsLock.Unlock()
+
+		successAt, fullyEnumeratedAt := -1, -1
+
+		for i, event := range events {
+			switch event.(type) {
+			case EnumerateDeviceSuccess:
+				successAt = i
+			case EnumerateDeviceFullyEnumerated:
+				fullyEnumeratedAt = i
+			}
+		}
+
+		assert.NotEqual(t, -1, successAt)
+		assert.Greater(t, fullyEnumeratedAt, successAt)
+	})
+
 	t.Run("enumerating a device handles a failure during QueryNodeDescription", func(t *testing.T) {
 		iNode, iDev := generateTestNodeAndDevice()
 		iDev.device.Capabilities = []da.Capability{EnumerateDeviceFlag}
@@ -246,12 +322,13 @@ func TestZigbeeEnumerateDevice_enumerateDevice(t *testing.T) {
 
 		expectedFailure := EnumerateDeviceFailure{
 			Device: iDev.device,
-			Error:  expectedError,
+			Error:  EnumerationFailedError{Attempts: 1, Err: expectedError},
 		}
 
 		mockEventSender := mockEventSender{}
 		mockEventSender.On("sendEvent", expectedFailure)
 		mockEventSender.On("sendEvent", expectedStart)
+		mockEventSender.On("sendEvent", mock.IsType(EnumerateDeviceResult{}))
 
 		mockDeviceStore := mockDeviceStore{}
 		mockDeviceStore.On("getDevice", iDev.device.Identifier).Return(iDev, true)
@@ -273,6 +350,12 @@ func TestZigbeeEnumerateDevice_enumerateDevice(t *testing.T) {
 		time.Sleep(20 * time.Millisecond)
 		zed.Stop()
 
+		iDev.mutex.RLock()
+		assert.Equal(t, []string{expectedError.Error()}, iDev.enumerationResult.Errors)
+		iDev.mutex.RUnlock()
+
+		assert.Equal(t, EnumerationFailed, iNode.enumerationState)
+
 		mockNodeQuerier.AssertExpectations(t)
 		mockAdderCaller.AssertExpectations(t)
 		mockEventSender.AssertExpectations(t)
@@ -516,3 +599,30 @@ func TestZigbeeEnumerateDevice_deallocateDevicesFromMissingEndpoints(t *testing.
 		mockDeviceStore.AssertExpectations(t)
 	})
 }
+
+func Test_recordEnumerationOutcome(t *testing.T) {
+	t.Run("counts consecutive failures and records the last error, resetting on success", func(t *testing.T) {
+		iNode, _ := generateTestNodeAndDevice()
+
+		firstErr := errors.New("first")
+		secondErr := errors.New("second")
+
+		assert.Equal(t, 1, recordEnumerationOutcome(iNode, firstErr))
+		assert.Equal(t, 2, recordEnumerationOutcome(iNode, secondErr))
+		assert.Equal(t, secondErr, iNode.lastEnumerationError)
+
+		assert.Equal(t, 0, recordEnumerationOutcome(iNode, nil))
+		assert.Equal(t, 0, iNode.failedEnumerationAttempts)
+		assert.Nil(t, iNode.lastEnumerationError)
+	})
+}
+
+func TestEnumerationFailedError(t *testing.T) {
+	t.Run("includes the attempt in its message and unwraps to the cause", func(t *testing.T) {
+		cause := errors.New("cause")
+		err := EnumerationFailedError{Attempts: 3, Err: cause}
+
+		assert.Equal(t, "enumeration failed, attempt 3: cause", err.Error())
+		assert.True(t, errors.Is(err, cause))
+	})
+}
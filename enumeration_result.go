@@ -0,0 +1,140 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"sync"
+	"time"
+)
+
+// EnumerationResult describes what was discovered about a device during its most recent enumeration, it is intended
+// to assist debugging why a device did or did not gain a capability.
+type EnumerationResult struct {
+	StartedAt time.Time
+	Duration  time.Duration
+
+	Clusters          map[zigbee.Endpoint][]zigbee.ClusterID
+	CapabilitiesAdded []da.Capability
+	AttributesRead    []EnumerationAttributeRead
+	Errors            []string
+}
+
+// EnumerationAttributeRead records a single attribute read made by a capability during enumeration.
+type EnumerationAttributeRead struct {
+	Endpoint  zigbee.Endpoint
+	Cluster   zigbee.ClusterID
+	Attribute zcl.AttributeID
+	Status    uint8
+}
+
+// EnumerateDeviceResult is sent after EnumerateDeviceSuccess or EnumerateDeviceFailure, and contains the result of
+// the enumeration.
+type EnumerateDeviceResult struct {
+	Device da.Device
+	Result EnumerationResult
+}
+
+type enumerationRecorderKey struct{}
+
+type enumerationRecorder struct {
+	mutex          *sync.Mutex
+	attributesRead map[zigbee.Endpoint][]EnumerationAttributeRead
+	errors         []string
+}
+
+func newEnumerationRecorder() *enumerationRecorder {
+	return &enumerationRecorder{
+		mutex:          &sync.Mutex{},
+		attributesRead: map[zigbee.Endpoint][]EnumerationAttributeRead{},
+	}
+}
+
+func withEnumerationRecorder(ctx context.Context, recorder *enumerationRecorder) context.Context {
+	return context.WithValue(ctx, enumerationRecorderKey{}, recorder)
+}
+
+func enumerationRecorderFromContext(ctx context.Context) (*enumerationRecorder, bool) {
+	recorder, ok := ctx.Value(enumerationRecorderKey{}).(*enumerationRecorder)
+	return recorder, ok
+}
+
+func (r *enumerationRecorder) recordAttributes(endpoint zigbee.Endpoint, cluster zigbee.ClusterID, records []global.ReadAttributeResponseRecord) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, record := range records {
+		r.attributesRead[endpoint] = append(r.attributesRead[endpoint], EnumerationAttributeRead{
+			Endpoint:  endpoint,
+			Cluster:   cluster,
+			Attribute: record.Identifier,
+			Status:    record.Status,
+		})
+	}
+}
+
+func (r *enumerationRecorder) recordError(err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.errors = append(r.errors, err.Error())
+}
+
+// result builds the EnumerationResult for a device from what has been recorded, the device mutex must be held by
+// the caller.
+func (r *enumerationRecorder) result(iNode *internalNode, iDev *internalDevice, startedAt time.Time, previousCapabilities []da.Capability) EnumerationResult {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	result := EnumerationResult{
+		StartedAt: startedAt,
+		Duration:  time.Since(startedAt),
+		Clusters:  map[zigbee.Endpoint][]zigbee.ClusterID{},
+		Errors:    append([]string{}, r.errors...),
+	}
+
+	for _, endpoint := range iDev.endpoints {
+		result.Clusters[endpoint] = iNode.endpointDescriptions[endpoint].InClusterList
+		result.AttributesRead = append(result.AttributesRead, r.attributesRead[endpoint]...)
+	}
+
+	for _, capability := range iDev.device.Capabilities {
+		if !isCapabilityInSlice(previousCapabilities, capability) {
+			result.CapabilitiesAdded = append(result.CapabilitiesAdded, capability)
+		}
+	}
+
+	return result
+}
+
+// recordingGlobalCommunicator wraps a zclGlobalCommunicator, recording any attribute reads and errors against the
+// enumerationRecorder present in the context, if there is one.
+type recordingGlobalCommunicator struct {
+	zclGlobalCommunicator
+}
+
+func (r *recordingGlobalCommunicator) ReadAttributes(ctx context.Context, ieeeAddress zigbee.IEEEAddress, requireAck bool, cluster zigbee.ClusterID, code zigbee.ManufacturerCode, sourceEndpoint zigbee.Endpoint, destEndpoint zigbee.Endpoint, transactionSequence uint8, attributes []zcl.AttributeID) ([]global.ReadAttributeResponseRecord, error) {
+	records, err := r.zclGlobalCommunicator.ReadAttributes(ctx, ieeeAddress, requireAck, cluster, code, sourceEndpoint, destEndpoint, transactionSequence, attributes)
+
+	if recorder, found := enumerationRecorderFromContext(ctx); found {
+		if err != nil {
+			recorder.recordError(err)
+		} else {
+			recorder.recordAttributes(destEndpoint, cluster, records)
+		}
+	}
+
+	return records, err
+}
+
+func (r *recordingGlobalCommunicator) ConfigureReporting(ctx context.Context, ieeeAddress zigbee.IEEEAddress, requireAck bool, cluster zigbee.ClusterID, code zigbee.ManufacturerCode, sourceEndpoint zigbee.Endpoint, destEndpoint zigbee.Endpoint, transactionSequence uint8, attributeId zcl.AttributeID, dataType zcl.AttributeDataType, minimumReportingInterval uint16, maximumReportingInterval uint16, reportableChange interface{}) error {
+	err := r.zclGlobalCommunicator.ConfigureReporting(ctx, ieeeAddress, requireAck, cluster, code, sourceEndpoint, destEndpoint, transactionSequence, attributeId, dataType, minimumReportingInterval, maximumReportingInterval, reportableChange)
+
+	if recorder, found := enumerationRecorderFromContext(ctx); found && err != nil {
+		recorder.recordError(err)
+	}
+
+	return err
+}
@@ -0,0 +1,80 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestRecordingGlobalCommunicator_ReadAttributes(t *testing.T) {
+	t.Run("records attributes read against the recorder in the context", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		rgc := recordingGlobalCommunicator{zclGlobalCommunicator: &mockZclGlobalCommunicator}
+
+		records := []global.ReadAttributeResponseRecord{{Identifier: 0x0004, Status: 0}, {Identifier: 0x0005, Status: 0x86}}
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, zigbee.IEEEAddress(1), false, zcl.BasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(2), uint8(1), []zcl.AttributeID{0x0004, 0x0005}).Return(records, nil)
+
+		recorder := newEnumerationRecorder()
+		ctx := withEnumerationRecorder(context.Background(), recorder)
+
+		_, err := rgc.ReadAttributes(ctx, zigbee.IEEEAddress(1), false, zcl.BasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(2), uint8(1), []zcl.AttributeID{0x0004, 0x0005})
+		assert.NoError(t, err)
+
+		expected := []EnumerationAttributeRead{
+			{Endpoint: 2, Cluster: zcl.BasicId, Attribute: 0x0004, Status: 0},
+			{Endpoint: 2, Cluster: zcl.BasicId, Attribute: 0x0005, Status: 0x86},
+		}
+
+		assert.Equal(t, expected, recorder.attributesRead[2])
+		mockZclGlobalCommunicator.AssertExpectations(t)
+	})
+
+	t.Run("records errors against the recorder in the context", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		rgc := recordingGlobalCommunicator{zclGlobalCommunicator: &mockZclGlobalCommunicator}
+
+		expectedError := errors.New("timeout")
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]global.ReadAttributeResponseRecord{}, expectedError)
+
+		recorder := newEnumerationRecorder()
+		ctx := withEnumerationRecorder(context.Background(), recorder)
+
+		_, err := rgc.ReadAttributes(ctx, zigbee.IEEEAddress(1), false, zcl.BasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(2), uint8(1), []zcl.AttributeID{0x0004})
+		assert.Equal(t, expectedError, err)
+
+		assert.Equal(t, []string{expectedError.Error()}, recorder.errors)
+	})
+}
+
+func TestEnumerationRecorder_result(t *testing.T) {
+	t.Run("builds a result containing clusters, new capabilities and attributes read for the device", func(t *testing.T) {
+		node, device := generateTestNodeAndDevice()
+		endpoint := node.endpoints[0]
+
+		endpointDescription := node.endpointDescriptions[endpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.BasicId, zcl.OnOffId}
+		node.endpointDescriptions[endpoint] = endpointDescription
+
+		device.device.Capabilities = []da.Capability{capabilities.EnumerateDeviceFlag, capabilities.OnOffFlag}
+
+		recorder := newEnumerationRecorder()
+		recorder.recordAttributes(endpoint, zcl.BasicId, []global.ReadAttributeResponseRecord{{Identifier: 0x0004}})
+		recorder.recordAttributes(endpoint+1, zcl.BasicId, []global.ReadAttributeResponseRecord{{Identifier: 0x0005}})
+
+		startedAt := time.Now()
+		result := recorder.result(node, device, startedAt, []da.Capability{capabilities.EnumerateDeviceFlag})
+
+		assert.Equal(t, startedAt, result.StartedAt)
+		assert.Equal(t, map[zigbee.Endpoint][]zigbee.ClusterID{endpoint: {zcl.BasicId, zcl.OnOffId}}, result.Clusters)
+		assert.Equal(t, []da.Capability{capabilities.OnOffFlag}, result.CapabilitiesAdded)
+		assert.Equal(t, []EnumerationAttributeRead{{Endpoint: endpoint, Cluster: zcl.BasicId, Attribute: 0x0004}}, result.AttributesRead)
+	})
+}
@@ -0,0 +1,150 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// StateChangeBatch is sent in place of individual state change events when batching is enabled with
+// WithStateChangeBatching. Events holds the state changes received within the window, in the order they were first
+// received. Where a device changed the same state more than once within the window, only the latest is included,
+// except for alarm, occupancy and lock state changes where every transition is included so none are missed.
+type StateChangeBatch struct {
+	Events []interface{}
+}
+
+// coalescable returns true if only the latest of the event's state changes for a device needs to be delivered. Events
+// which consumers act upon as transitions, such as an alarm which was raised and cleared within the window, are not.
+func coalescable(event interface{}) bool {
+	switch event.(type) {
+	case IASZoneStatusChanged, OccupancyChanged, DoorLockStateChanged:
+		return false
+	default:
+		return true
+	}
+}
+
+// stateChange returns the device and capability of the event if the event reports a change to capability state, only
+// these events are batched or suppressed.
+func stateChange(event interface{}) (da.Device, da.Capability, bool) {
+	switch e := event.(type) {
+	case capabilities.OnOffState:
+		return e.Device, capabilities.OnOffFlag, true
+	case AnalogOutputValueChanged:
+		return e.Device, AnalogOutputFlag, true
+	case IlluminanceLevelStatusChanged:
+		return e.Device, IlluminanceLevelSensingFlag, true
+	case PriceUpdate:
+		return e.Device, PriceFlag, true
+	case ThermostatUIConfigurationChanged:
+		return e.Device, ThermostatUIConfigurationFlag, true
+	case LevelChanged:
+		return e.Device, LevelControlFlag, true
+	case ColorChanged:
+		return e.Device, ColorControlFlag, true
+	case TemperatureReadingChanged:
+		return e.Device, TemperatureSensorFlag, true
+	case RelativeHumidityReadingChanged:
+		return e.Device, RelativeHumiditySensorFlag, true
+	case PressureReadingChanged:
+		return e.Device, PressureSensorFlag, true
+	case OccupancyChanged:
+		return e.Device, OccupancySensorFlag, true
+	case IlluminanceReadingChanged:
+		return e.Device, IlluminanceSensorFlag, true
+	case BatteryStatusChanged:
+		return e.Device, BatteryFlag, true
+	case ElectricalMeasurementReadingChanged:
+		return e.Device, ElectricalMeasurementFlag, true
+	case MeteringReadingChanged:
+		return e.Device, MeteringFlag, true
+	case IASZoneStatusChanged:
+		return e.Device, IASZoneFlag, true
+	case DoorLockStateChanged:
+		return e.Device, DoorLockFlag, true
+	case WindowCoveringPositionChanged:
+		return e.Device, WindowCoveringFlag, true
+	default:
+		return da.Device{}, 0, false
+	}
+}
+
+type stateChangeKey struct {
+	identifier da.Identifier
+	eventType  reflect.Type
+}
+
+type zdaEventBatcher struct {
+	mutex *sync.Mutex
+
+	window  time.Duration
+	deliver func(interface{})
+
+	pending []interface{}
+	index   map[stateChangeKey]int
+	timer   *time.Timer
+}
+
+func newEventBatcher() *zdaEventBatcher {
+	return &zdaEventBatcher{mutex: &sync.Mutex{}, index: map[stateChangeKey]int{}}
+}
+
+// add holds the event for the next batch, returning false if the event should be delivered immediately because
+// batching is disabled or the event is not a state change. The first event of a batch starts the window.
+func (b *zdaEventBatcher) add(event interface{}) bool {
+	if b.window <= 0 {
+		return false
+	}
+
+	device, _, isStateChange := stateChange(event)
+
+	if !isStateChange {
+		return false
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if coalescable(event) {
+		key := stateChangeKey{identifier: device.Identifier, eventType: reflect.TypeOf(event)}
+
+		if i, found := b.index[key]; found {
+			b.pending[i] = event
+			return true
+		}
+
+		b.index[key] = len(b.pending)
+	}
+
+	b.pending = append(b.pending, event)
+
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.window, b.flush)
+	}
+
+	return true
+}
+
+// flush delivers any pending state changes as a single batch.
+func (b *zdaEventBatcher) flush() {
+	b.mutex.Lock()
+
+	events := b.pending
+
+	b.pending = nil
+	b.index = map[stateChangeKey]int{}
+
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+
+	b.mutex.Unlock()
+
+	if len(events) > 0 {
+		b.deliver(StateChangeBatch{Events: events})
+	}
+}
@@ -0,0 +1,123 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_zdaEventBatcher(t *testing.T) {
+	deviceOne := da.Device{Identifier: IEEEAddressWithSubIdentifier{IEEEAddress: zigbee.IEEEAddress(0x01)}}
+	deviceTwo := da.Device{Identifier: IEEEAddressWithSubIdentifier{IEEEAddress: zigbee.IEEEAddress(0x02)}}
+
+	t.Run("does not hold events if batching is disabled", func(t *testing.T) {
+		b := newEventBatcher()
+
+		assert.False(t, b.add(capabilities.OnOffState{Device: deviceOne, State: true}))
+	})
+
+	t.Run("does not hold events which are not state changes", func(t *testing.T) {
+		b := newEventBatcher()
+		b.window = time.Minute
+
+		assert.False(t, b.add(da.DeviceAdded{Device: deviceOne}))
+	})
+
+	t.Run("delivers state changes within the window as a single batch, coalescing repeated changes", func(t *testing.T) {
+		b := newEventBatcher()
+		b.window = 10 * time.Millisecond
+
+		delivered := make(chan interface{}, 2)
+		b.deliver = func(event interface{}) { delivered <- event }
+
+		assert.True(t, b.add(capabilities.OnOffState{Device: deviceOne, State: true}))
+		assert.True(t, b.add(capabilities.OnOffState{Device: deviceTwo, State: true}))
+		assert.True(t, b.add(PriceUpdate{Device: deviceOne}))
+		assert.True(t, b.add(capabilities.OnOffState{Device: deviceOne, State: false}))
+
+		select {
+		case event := <-delivered:
+			assert.Equal(t, StateChangeBatch{Events: []interface{}{
+				capabilities.OnOffState{Device: deviceOne, State: false},
+				capabilities.OnOffState{Device: deviceTwo, State: true},
+				PriceUpdate{Device: deviceOne},
+			}}, event)
+		case <-time.After(time.Second):
+			assert.Fail(t, "batch was not delivered")
+		}
+
+		assert.True(t, b.add(capabilities.OnOffState{Device: deviceOne, State: true}))
+
+		select {
+		case event := <-delivered:
+			assert.Equal(t, StateChangeBatch{Events: []interface{}{capabilities.OnOffState{Device: deviceOne, State: true}}}, event)
+		case <-time.After(time.Second):
+			assert.Fail(t, "second batch was not delivered")
+		}
+	})
+
+	t.Run("includes every transition of alarm and occupancy state changes", func(t *testing.T) {
+		b := newEventBatcher()
+		b.window = time.Minute
+
+		var delivered []interface{}
+		b.deliver = func(event interface{}) { delivered = append(delivered, event) }
+
+		assert.True(t, b.add(IASZoneStatusChanged{Device: deviceOne, Status: IASZoneStatus{Alarm1: true}}))
+		assert.True(t, b.add(OccupancyChanged{Device: deviceTwo, Status: OccupancyStatus{Occupied: true}}))
+		assert.True(t, b.add(IASZoneStatusChanged{Device: deviceOne, Status: IASZoneStatus{Alarm1: false}}))
+		assert.True(t, b.add(OccupancyChanged{Device: deviceTwo, Status: OccupancyStatus{Occupied: false}}))
+
+		b.flush()
+
+		assert.Equal(t, []interface{}{StateChangeBatch{Events: []interface{}{
+			IASZoneStatusChanged{Device: deviceOne, Status: IASZoneStatus{Alarm1: true}},
+			OccupancyChanged{Device: deviceTwo, Status: OccupancyStatus{Occupied: true}},
+			IASZoneStatusChanged{Device: deviceOne, Status: IASZoneStatus{Alarm1: false}},
+			OccupancyChanged{Device: deviceTwo, Status: OccupancyStatus{Occupied: false}},
+		}}}, delivered)
+	})
+
+	t.Run("flush delivers pending state changes immediately, and nothing if there are none", func(t *testing.T) {
+		b := newEventBatcher()
+		b.window = time.Minute
+
+		var delivered []interface{}
+		b.deliver = func(event interface{}) { delivered = append(delivered, event) }
+
+		b.flush()
+		assert.Empty(t, delivered)
+
+		b.add(capabilities.OnOffState{Device: deviceOne, State: true})
+		b.flush()
+
+		assert.Equal(t, []interface{}{StateChangeBatch{Events: []interface{}{capabilities.OnOffState{Device: deviceOne, State: true}}}}, delivered)
+	})
+}
+
+func TestZigbeeGateway_sendEvent_Batching(t *testing.T) {
+	t.Run("delivers other events immediately while state changes are batched", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		zgw.eventBatcher.window = 10 * time.Millisecond
+
+		device := da.Device{Identifier: IEEEAddressWithSubIdentifier{IEEEAddress: zigbee.IEEEAddress(0x01)}}
+
+		zgw.sendEvent(capabilities.OnOffState{Device: device, State: true})
+		zgw.sendEvent(da.DeviceAdded{Device: device})
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		event, err := zgw.ReadEvent(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, da.DeviceAdded{Device: device}, event)
+
+		event, err = zgw.ReadEvent(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, StateChangeBatch{Events: []interface{}{capabilities.OnOffState{Device: device, State: true}}}, event)
+	})
+}
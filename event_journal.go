@@ -0,0 +1,72 @@
+package zda
+
+import (
+	"errors"
+	"sync"
+)
+
+var EventJournalDisabled = errors.New("event journal is disabled")
+var EventJournalExhausted = errors.New("event journal no longer holds events from sequence requested")
+
+// SequencedEvent is an event emitted by the gateway along with its sequence number. Sequence numbers increase
+// monotonically, starting at 1, for every event emitted.
+type SequencedEvent struct {
+	Sequence uint64
+	Event    interface{}
+}
+
+type zdaEventJournal struct {
+	mutex *sync.Mutex
+
+	size         int
+	entries      []SequencedEvent
+	lastSequence uint64
+}
+
+func newEventJournal() *zdaEventJournal {
+	return &zdaEventJournal{mutex: &sync.Mutex{}}
+}
+
+// record assigns the next sequence number to the event, and retains it if the journal is enabled. The journal lock is
+// held while deliver is called, guaranteeing events are delivered in sequence order.
+func (j *zdaEventJournal) record(event interface{}, deliver func(SequencedEvent)) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.lastSequence++
+	sequenced := SequencedEvent{Sequence: j.lastSequence, Event: event}
+
+	if j.size > 0 {
+		if len(j.entries) >= j.size {
+			j.entries = append(j.entries[:0], j.entries[len(j.entries)-j.size+1:]...)
+		}
+
+		j.entries = append(j.entries, sequenced)
+	}
+
+	deliver(sequenced)
+}
+
+func (j *zdaEventJournal) since(sequence uint64) ([]SequencedEvent, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.size == 0 {
+		return nil, EventJournalDisabled
+	}
+
+	if sequence >= j.lastSequence {
+		return []SequencedEvent{}, nil
+	}
+
+	if len(j.entries) == 0 || j.entries[0].Sequence > sequence+1 {
+		return nil, EventJournalExhausted
+	}
+
+	start := int(sequence + 1 - j.entries[0].Sequence)
+
+	events := make([]SequencedEvent, len(j.entries)-start)
+	copy(events, j.entries[start:])
+
+	return events, nil
+}
@@ -0,0 +1,83 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestZdaEventJournal(t *testing.T) {
+	t.Run("assigns monotonic sequence numbers even when disabled", func(t *testing.T) {
+		journal := newEventJournal()
+
+		var delivered []SequencedEvent
+		deliver := func(e SequencedEvent) { delivered = append(delivered, e) }
+
+		journal.record("one", deliver)
+		journal.record("two", deliver)
+
+		assert.Equal(t, []SequencedEvent{{Sequence: 1, Event: "one"}, {Sequence: 2, Event: "two"}}, delivered)
+
+		_, err := journal.since(0)
+		assert.Equal(t, EventJournalDisabled, err)
+	})
+
+	t.Run("returns events after the sequence provided", func(t *testing.T) {
+		journal := newEventJournal()
+		journal.size = 5
+
+		for _, event := range []string{"one", "two", "three"} {
+			journal.record(event, func(SequencedEvent) {})
+		}
+
+		events, err := journal.since(1)
+		assert.NoError(t, err)
+		assert.Equal(t, []SequencedEvent{{Sequence: 2, Event: "two"}, {Sequence: 3, Event: "three"}}, events)
+
+		events, err = journal.since(3)
+		assert.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("retains only the most recent events and errors if the sequence is no longer held", func(t *testing.T) {
+		journal := newEventJournal()
+		journal.size = 2
+
+		for _, event := range []string{"one", "two", "three", "four"} {
+			journal.record(event, func(SequencedEvent) {})
+		}
+
+		events, err := journal.since(2)
+		assert.NoError(t, err)
+		assert.Equal(t, []SequencedEvent{{Sequence: 3, Event: "three"}, {Sequence: 4, Event: "four"}}, events)
+
+		_, err = journal.since(1)
+		assert.Equal(t, EventJournalExhausted, err)
+	})
+}
+
+func TestZigbeeGateway_ReplayEvents(t *testing.T) {
+	t.Run("events read from the gateway can be replayed from their sequence number", func(t *testing.T) {
+		zgw := New(new(zigbee.MockProvider), WithEventJournal(10))
+
+		zgw.sendEvent("one")
+		zgw.sendEvent("two")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		first, err := zgw.ReadSequencedEvent(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, SequencedEvent{Sequence: 1, Event: "one"}, first)
+
+		events, err := zgw.ReplayEvents(first.Sequence)
+		assert.NoError(t, err)
+		assert.Equal(t, []SequencedEvent{{Sequence: 2, Event: "two"}}, events)
+
+		event, err := zgw.ReadEvent(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "two", event)
+	})
+}
@@ -0,0 +1,134 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/da"
+	"sync"
+	"time"
+)
+
+// EventSuppression describes how state change events from a device are being suppressed. The capability's state is
+// still updated when an event is suppressed, only the event is withheld.
+type EventSuppression struct {
+	// Interval is the minimum time between state change events for each capability, 0 if events are muted entirely.
+	Interval time.Duration
+	// Capabilities whose events are suppressed, all capabilities of the device if empty.
+	Capabilities []da.Capability
+	// Suppressed is the number of events withheld since suppression was set.
+	Suppressed uint64
+}
+
+type deviceEventSuppression struct {
+	EventSuppression
+	lastSent map[da.Capability]time.Time
+}
+
+type zdaEventSuppressor struct {
+	mutex *sync.Mutex
+	now   func() time.Time
+
+	devices map[da.Identifier]*deviceEventSuppression
+}
+
+func newEventSuppressor(now func() time.Time) *zdaEventSuppressor {
+	return &zdaEventSuppressor{mutex: &sync.Mutex{}, now: now, devices: map[da.Identifier]*deviceEventSuppression{}}
+}
+
+// suppress returns true if the event should be withheld, because it is a state change of a capability being
+// suppressed on its device. If throttling, the event is permitted if no event for the capability has been permitted
+// within the interval.
+func (s *zdaEventSuppressor) suppress(event interface{}) bool {
+	device, capability, isStateChange := stateChange(event)
+
+	if !isStateChange {
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	suppression, found := s.devices[device.Identifier]
+
+	if !found || (len(suppression.Capabilities) > 0 && !isCapabilityInSlice(suppression.Capabilities, capability)) {
+		return false
+	}
+
+	now := s.now()
+
+	if suppression.Interval > 0 {
+		if lastSent, sent := suppression.lastSent[capability]; !sent || now.Sub(lastSent) >= suppression.Interval {
+			suppression.lastSent[capability] = now
+			return false
+		}
+	}
+
+	suppression.Suppressed++
+	return true
+}
+
+func (s *zdaEventSuppressor) set(identifier da.Identifier, interval time.Duration, capabilities []da.Capability) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.devices[identifier] = &deviceEventSuppression{
+		EventSuppression: EventSuppression{Interval: interval, Capabilities: capabilities},
+		lastSent:         map[da.Capability]time.Time{},
+	}
+}
+
+func (s *zdaEventSuppressor) clear(identifier da.Identifier) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.devices, identifier)
+}
+
+// status returns the suppression of the device, nil if its events are not being suppressed.
+func (s *zdaEventSuppressor) status(identifier da.Identifier) *EventSuppression {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	suppression, found := s.devices[identifier]
+
+	if !found {
+		return nil
+	}
+
+	status := suppression.EventSuppression
+	status.Capabilities = append([]da.Capability(nil), suppression.Capabilities...)
+
+	return &status
+}
+
+// SuppressStateChanges withholds state change events from a noisy device, the device's state is still updated and can
+// be queried as normal. If no capabilities are provided all of the device's state changes are suppressed. An interval
+// of 0 mutes the events entirely, otherwise events for each capability are limited to one per interval. Suppression
+// replaces any previously set on the device, and is not retained across restarts of the gateway.
+func (z *ZigbeeGateway) SuppressStateChanges(device da.Device, interval time.Duration, capabilities ...da.Capability) error {
+	if _, err := z.getOverridableDevice(device); err != nil {
+		return err
+	}
+
+	z.eventSuppressor.set(device.Identifier, interval, capabilities)
+
+	return nil
+}
+
+// ClearStateChangeSuppression resumes sending all state change events from the device.
+func (z *ZigbeeGateway) ClearStateChangeSuppression(device da.Device) error {
+	if _, err := z.getOverridableDevice(device); err != nil {
+		return err
+	}
+
+	z.eventSuppressor.clear(device.Identifier)
+
+	return nil
+}
+
+// StateChangeSuppression returns the suppression set on the device, nil if its events are not being suppressed.
+func (z *ZigbeeGateway) StateChangeSuppression(device da.Device) (*EventSuppression, error) {
+	if _, err := z.getOverridableDevice(device); err != nil {
+		return nil, err
+	}
+
+	return z.eventSuppressor.status(device.Identifier), nil
+}
@@ -0,0 +1,119 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_zdaEventSuppressor(t *testing.T) {
+	deviceOne := da.Device{Identifier: IEEEAddressWithSubIdentifier{IEEEAddress: zigbee.IEEEAddress(0x01)}}
+	deviceTwo := da.Device{Identifier: IEEEAddressWithSubIdentifier{IEEEAddress: zigbee.IEEEAddress(0x02)}}
+
+	t.Run("does not suppress events from devices without suppression, or which are not state changes", func(t *testing.T) {
+		s := newEventSuppressor(newFakeClock().Now)
+		s.set(deviceOne.Identifier, 0, nil)
+
+		assert.False(t, s.suppress(capabilities.OnOffState{Device: deviceTwo, State: true}))
+		assert.False(t, s.suppress(da.DeviceAdded{Device: deviceOne}))
+		assert.Nil(t, s.status(deviceTwo.Identifier))
+	})
+
+	t.Run("mutes all state changes of the device if no capabilities are provided", func(t *testing.T) {
+		s := newEventSuppressor(newFakeClock().Now)
+		s.set(deviceOne.Identifier, 0, nil)
+
+		assert.True(t, s.suppress(capabilities.OnOffState{Device: deviceOne, State: true}))
+		assert.True(t, s.suppress(TemperatureReadingChanged{Device: deviceOne}))
+
+		assert.Equal(t, &EventSuppression{Suppressed: 2}, s.status(deviceOne.Identifier))
+	})
+
+	t.Run("only mutes state changes of the capabilities provided", func(t *testing.T) {
+		s := newEventSuppressor(newFakeClock().Now)
+		s.set(deviceOne.Identifier, 0, []da.Capability{TemperatureSensorFlag})
+
+		assert.True(t, s.suppress(TemperatureReadingChanged{Device: deviceOne}))
+		assert.False(t, s.suppress(RelativeHumidityReadingChanged{Device: deviceOne}))
+	})
+
+	t.Run("throttles state changes to one per interval for each capability", func(t *testing.T) {
+		clock := newFakeClock()
+
+		s := newEventSuppressor(clock.Now)
+		s.set(deviceOne.Identifier, time.Minute, nil)
+
+		assert.False(t, s.suppress(TemperatureReadingChanged{Device: deviceOne}))
+		assert.False(t, s.suppress(RelativeHumidityReadingChanged{Device: deviceOne}))
+		assert.True(t, s.suppress(TemperatureReadingChanged{Device: deviceOne}))
+
+		clock.Advance(30 * time.Second)
+		assert.True(t, s.suppress(TemperatureReadingChanged{Device: deviceOne}))
+
+		clock.Advance(30 * time.Second)
+		assert.False(t, s.suppress(TemperatureReadingChanged{Device: deviceOne}))
+
+		assert.Equal(t, uint64(2), s.status(deviceOne.Identifier).Suppressed)
+	})
+
+	t.Run("clear removes suppression from the device", func(t *testing.T) {
+		s := newEventSuppressor(newFakeClock().Now)
+		s.set(deviceOne.Identifier, 0, nil)
+		s.clear(deviceOne.Identifier)
+
+		assert.False(t, s.suppress(capabilities.OnOffState{Device: deviceOne, State: true}))
+		assert.Nil(t, s.status(deviceOne.Identifier))
+	})
+}
+
+func TestZigbeeGateway_SuppressStateChanges(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		assert.Error(t, zgw.SuppressStateChanges(da.Device{}, 0))
+		assert.Error(t, zgw.ClearStateChangeSuppression(da.Device{}))
+
+		_, err := zgw.StateChangeSuppression(da.Device{})
+		assert.Error(t, err)
+	})
+
+	t.Run("withholds suppressed state changes until cleared", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		err := zgw.SuppressStateChanges(iDev.device, 0, capabilities.OnOffFlag)
+		assert.NoError(t, err)
+
+		zgw.sendEvent(capabilities.OnOffState{Device: iDev.device, State: true})
+
+		suppression, err := zgw.StateChangeSuppression(iDev.device)
+		assert.NoError(t, err)
+		assert.Equal(t, &EventSuppression{Capabilities: []da.Capability{capabilities.OnOffFlag}, Suppressed: 1}, suppression)
+
+		err = zgw.ClearStateChangeSuppression(iDev.device)
+		assert.NoError(t, err)
+
+		zgw.sendEvent(capabilities.OnOffState{Device: iDev.device, State: false})
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		for {
+			event, err := zgw.ReadEvent(ctx)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if state, isOnOff := event.(capabilities.OnOffState); isOnOff {
+				assert.False(t, state.State)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+	"time"
+)
+
+const ResetToFactoryDefaultsId = zcl.CommandIdentifier(0x00)
+
+// ResetToFactoryDefaults is sent to a Basic cluster server to reset all of its clusters to their factory defaults.
+type ResetToFactoryDefaults struct{}
+
+func registerBasicCommands(cr *zcl.CommandRegistry) {
+	cr.RegisterLocal(zcl.BasicId, zigbee.NoManufacturer, ResetToFactoryDefaultsId, &ResetToFactoryDefaults{})
+}
+
+// DefaultFactoryResetSettleDelay is how long to wait after a factory reset before checking the outcome, allowing the
+// device time to restart.
+const DefaultFactoryResetSettleDelay = 5 * time.Second
+
+// DeviceFactoryResetCompleted is sent once a factory reset has settled.
+type DeviceFactoryResetCompleted struct {
+	// Device which was reset.
+	Device da.Device
+	// LeftNetwork is true if the node left the network as a result of the reset, if so the device is no longer valid
+	// and the node must be joined again. Otherwise the node has been re-enumerated to pick up its reset configuration.
+	LeftNetwork bool
+}
+
+type zdaFactoryReset struct {
+	nodeStore               nodeStore
+	zclCommunicatorRequests zclCommunicatorRequests
+	nodeEnumerator          nodeEnumerator
+	eventSender             eventSender
+
+	settleDelay time.Duration
+}
+
+// reset sends the factory reset command to the device, and schedules the outcome to be checked after the settle delay.
+func (z *zdaFactoryReset) reset(ctx context.Context, iDev *internalDevice) error {
+	iNode := iDev.node
+
+	iNode.mutex.RLock()
+	iDev.mutex.RLock()
+
+	endpoint, found := findEndpointWithClusterId(iNode, iDev, zcl.BasicId)
+	device := iDev.device
+
+	cmdCtx, cancel := commandContext(ctx, iDev)
+	defer cancel()
+
+	iDev.mutex.RUnlock()
+	iNode.mutex.RUnlock()
+
+	if !found {
+		return fmt.Errorf("unable to find basic cluster on zigbee device in zda")
+	}
+
+	zclMsg := zcl.Message{
+		FrameType:           zcl.FrameLocal,
+		Direction:           zcl.ClientToServer,
+		TransactionSequence: iNode.nextTransactionSequence(),
+		Manufacturer:        zigbee.NoManufacturer,
+		ClusterID:           zcl.BasicId,
+		SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+		DestinationEndpoint: endpoint,
+		Command:             &ResetToFactoryDefaults{},
+	}
+
+	if err := z.zclCommunicatorRequests.Request(cmdCtx, iNode.ieeeAddress, iNode.supportsAPSAck, zclMsg); err != nil {
+		return err
+	}
+
+	time.AfterFunc(z.settleDelay, func() {
+		z.settled(iNode.ieeeAddress, device)
+	})
+
+	return nil
+}
+
+// settled determines if the node is still on the network after a factory reset, if it is then the node is
+// re-enumerated, otherwise it is presumed to have left.
+func (z *zdaFactoryReset) settled(ieeeAddress zigbee.IEEEAddress, device da.Device) {
+	iNode, found := z.nodeStore.getNode(ieeeAddress)
+
+	if found {
+		if err := z.nodeEnumerator.queueEnumeration(context.Background(), iNode); err != nil {
+			log.Printf("failed to queue enumeration after factory reset: %s", err)
+		}
+	}
+
+	z.eventSender.sendEvent(DeviceFactoryResetCompleted{Device: device, LeftNetwork: !found})
+}
+
+// ResetToFactoryDefaults sends the Basic cluster reset command to the device, which returns the device to its factory
+// configuration. Devices differ in how they treat this command, some will clear their settings and stay joined while
+// others will also leave the network. Once the device has settled a DeviceFactoryResetCompleted event is sent, which
+// reports which of these occurred. If the node is still present then it will be re-enumerated.
+func (z *ZigbeeGateway) ResetToFactoryDefaults(ctx context.Context, device da.Device) error {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return err
+	}
+
+	return z.factoryReset.reset(ctx, iDev)
+}
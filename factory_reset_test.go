@@ -0,0 +1,142 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/bytecodec"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func Test_ResetToFactoryDefaults(t *testing.T) {
+	t.Run("marshals and unmarshals correctly", func(t *testing.T) {
+		expectedCommand := ResetToFactoryDefaults{}
+		actualCommand := ResetToFactoryDefaults{}
+
+		var expectedBytes []byte
+
+		actualBytes, err := bytecodec.Marshal(&expectedCommand)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedBytes, actualBytes)
+
+		err = bytecodec.Unmarshal(expectedBytes, &actualCommand)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedCommand, actualCommand)
+	})
+
+	t.Run("the message is registered in the command registry", func(t *testing.T) {
+		cr := zcl.NewCommandRegistry()
+		registerBasicCommands(cr)
+
+		id, err := cr.GetLocalCommandIdentifier(zcl.BasicId, zigbee.NoManufacturer, &ResetToFactoryDefaults{})
+		assert.NoError(t, err)
+		assert.Equal(t, ResetToFactoryDefaultsId, id)
+	})
+}
+
+func TestZdaFactoryReset_reset(t *testing.T) {
+	t.Run("returns error if the device does not have a basic cluster", func(t *testing.T) {
+		zfr := zdaFactoryReset{}
+
+		_, iDev := generateTestNodeAndDevice()
+
+		err := zfr.reset(context.Background(), iDev)
+		assert.Error(t, err)
+	})
+
+	t.Run("sends the reset command, and re-enumerates the node once settled if it is still present", func(t *testing.T) {
+		mockNodeStore := mockNodeStore{}
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		mockNodeEnumerator := mockNodeEnumerator{}
+		mockEventSender := mockEventSender{}
+
+		zfr := zdaFactoryReset{
+			nodeStore:               &mockNodeStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+			nodeEnumerator:          &mockNodeEnumerator,
+			eventSender:             &mockEventSender,
+			settleDelay:             time.Millisecond,
+		}
+
+		iNode, iDev := generateTestNodeAndDevice()
+
+		endpoint := iNode.endpoints[0]
+		description := iNode.endpointDescriptions[endpoint]
+		description.InClusterList = []zigbee.ClusterID{zcl.BasicId}
+		iNode.endpointDescriptions[endpoint] = description
+
+		expectedMessage := zcl.Message{
+			FrameType:           zcl.FrameLocal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: 1,
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           zcl.BasicId,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: endpoint,
+			Command:             &ResetToFactoryDefaults{},
+		}
+
+		mockZclCommunicatorRequests.On("Request", mock.Anything, iNode.ieeeAddress, false, expectedMessage).Return(nil)
+		mockNodeStore.On("getNode", iNode.ieeeAddress).Return(iNode, true)
+		mockNodeEnumerator.On("queueEnumeration", mock.Anything, iNode).Return(nil)
+		mockEventSender.On("sendEvent", DeviceFactoryResetCompleted{Device: iDev.device, LeftNetwork: false})
+
+		err := zfr.reset(context.Background(), iDev)
+		assert.NoError(t, err)
+
+		time.Sleep(10 * time.Millisecond)
+
+		mockZclCommunicatorRequests.AssertExpectations(t)
+		mockNodeStore.AssertExpectations(t)
+		mockNodeEnumerator.AssertExpectations(t)
+		mockEventSender.AssertExpectations(t)
+	})
+
+	t.Run("reports the node left the network if it is no longer present once settled", func(t *testing.T) {
+		mockNodeStore := mockNodeStore{}
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		mockNodeEnumerator := mockNodeEnumerator{}
+		mockEventSender := mockEventSender{}
+
+		zfr := zdaFactoryReset{
+			nodeStore:               &mockNodeStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+			nodeEnumerator:          &mockNodeEnumerator,
+			eventSender:             &mockEventSender,
+			settleDelay:             time.Millisecond,
+		}
+
+		iNode, iDev := generateTestNodeAndDevice()
+
+		endpoint := iNode.endpoints[0]
+		description := iNode.endpointDescriptions[endpoint]
+		description.InClusterList = []zigbee.ClusterID{zcl.BasicId}
+		iNode.endpointDescriptions[endpoint] = description
+
+		mockZclCommunicatorRequests.On("Request", mock.Anything, iNode.ieeeAddress, false, mock.Anything).Return(nil)
+		mockNodeStore.On("getNode", iNode.ieeeAddress).Return(&internalNode{}, false)
+		mockEventSender.On("sendEvent", DeviceFactoryResetCompleted{Device: iDev.device, LeftNetwork: true})
+
+		err := zfr.reset(context.Background(), iDev)
+		assert.NoError(t, err)
+
+		time.Sleep(10 * time.Millisecond)
+
+		mockNodeStore.AssertExpectations(t)
+		mockNodeEnumerator.AssertNotCalled(t, "queueEnumeration", mock.Anything, mock.Anything)
+		mockEventSender.AssertExpectations(t)
+	})
+}
+
+func TestZigbeeGateway_ResetToFactoryDefaults(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		err := zgw.ResetToFactoryDefaults(context.Background(), da.Device{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,128 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/retry"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+)
+
+const (
+	OTAUpgradeCurrentFileVersion = zcl.AttributeID(0x0002)
+	OTAUpgradeManufacturerID     = zcl.AttributeID(0x0007)
+	OTAUpgradeImageTypeID        = zcl.AttributeID(0x0008)
+)
+
+// FirmwareVersion is the firmware image currently running on a device, as reported by its OTA Upgrade client. These
+// values identify which OTA images are applicable to the device.
+type FirmwareVersion struct {
+	FileVersion      uint32
+	ManufacturerCode uint16
+	ImageType        uint16
+}
+
+type zdaFirmwareVersion struct {
+	internalCallbacks       callbacks.Adder
+	zclCommunicatorRequests zclCommunicatorRequests
+}
+
+func (z *zdaFirmwareVersion) Init() {
+	z.internalCallbacks.Add(z.NodeDeferredEnumerationCallback)
+}
+
+func (z *zdaFirmwareVersion) NodeDeferredEnumerationCallback(ctx context.Context, ine internalNodeDeferredEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		dev.firmwareVersion = nil
+
+		if endpoint, found := findEndpointWithOutClusterId(node, dev, zcl.OTAUpgradeId); found {
+			if err := retry.Retry(ctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
+				records, err := z.readClientAttributes(ctx, node, endpoint)
+
+				if err == nil {
+					results := parseReadAttributeResponse(records)
+
+					if fileVersion, ok := results.uintValue(OTAUpgradeCurrentFileVersion); ok {
+						manufacturer, _ := results.uintValue(OTAUpgradeManufacturerID)
+						imageType, _ := results.uintValue(OTAUpgradeImageTypeID)
+
+						dev.firmwareVersion = &FirmwareVersion{
+							FileVersion:      uint32(fileVersion),
+							ManufacturerCode: uint16(manufacturer),
+							ImageType:        uint16(imageType),
+						}
+					}
+				}
+
+				return err
+			}); err != nil {
+				log.Printf("failed to read firmware version: %s", err)
+			}
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// readClientAttributes reads the OTA Upgrade attributes from the device, as the device is the client of the cluster the
+// read must be sent server to client, which the global communicator does not support.
+func (z *zdaFirmwareVersion) readClientAttributes(ctx context.Context, node *internalNode, endpoint zigbee.Endpoint) ([]global.ReadAttributeResponseRecord, error) {
+	zclMsg := zcl.Message{
+		FrameType:           zcl.FrameGlobal,
+		Direction:           zcl.ServerToClient,
+		TransactionSequence: node.nextTransactionSequence(),
+		Manufacturer:        zigbee.NoManufacturer,
+		ClusterID:           zcl.OTAUpgradeId,
+		SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+		DestinationEndpoint: endpoint,
+		Command: &global.ReadAttributes{
+			Identifier: []zcl.AttributeID{OTAUpgradeCurrentFileVersion, OTAUpgradeManufacturerID, OTAUpgradeImageTypeID},
+		},
+	}
+
+	response, err := z.zclCommunicatorRequests.RequestResponse(ctx, node.ieeeAddress, node.supportsAPSAck, zclMsg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	readResponse, ok := response.Command.(*global.ReadAttributesResponse)
+
+	if !ok {
+		return nil, fmt.Errorf("read attributes received command back which was not ReadAttributesResponse")
+	}
+
+	return readResponse.Records, nil
+}
+
+// FirmwareVersion returns the firmware version of the device as read during enumeration, false is returned if the
+// device does not have an OTA Upgrade client or the version could not be read.
+func (z *ZigbeeGateway) FirmwareVersion(device da.Device) (FirmwareVersion, bool, error) {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return FirmwareVersion{}, false, err
+	}
+
+	iDev.mutex.RLock()
+	defer iDev.mutex.RUnlock()
+
+	if iDev.firmwareVersion == nil {
+		return FirmwareVersion{}, false, nil
+	}
+
+	return *iDev.firmwareVersion, true, nil
+}
@@ -0,0 +1,107 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZdaFirmwareVersion_NodeDeferredEnumerationCallback(t *testing.T) {
+	t.Run("reads the firmware version from a device with an OTA Upgrade client", func(t *testing.T) {
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+
+		zfv := zdaFirmwareVersion{
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+
+		node, device := generateTestNodeAndDevice()
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.OutClusterList = []zigbee.ClusterID{zcl.OTAUpgradeId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		expectedMessage := zcl.Message{
+			FrameType:           zcl.FrameGlobal,
+			Direction:           zcl.ServerToClient,
+			TransactionSequence: 1,
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           zcl.OTAUpgradeId,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: deviceEndpoint,
+			Command: &global.ReadAttributes{
+				Identifier: []zcl.AttributeID{OTAUpgradeCurrentFileVersion, OTAUpgradeManufacturerID, OTAUpgradeImageTypeID},
+			},
+		}
+
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, node.ieeeAddress, false, expectedMessage).Return(zcl.Message{
+			Command: &global.ReadAttributesResponse{
+				Records: []global.ReadAttributeResponseRecord{
+					{
+						Identifier:    OTAUpgradeCurrentFileVersion,
+						DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeUnsignedInt32, Value: uint64(0x01020304)},
+					},
+					{
+						Identifier:    OTAUpgradeManufacturerID,
+						DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeUnsignedInt16, Value: uint64(0x117c)},
+					},
+					{
+						Identifier:    OTAUpgradeImageTypeID,
+						DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeUnsignedInt16, Value: uint64(0x2101)},
+					},
+				},
+			},
+		}, nil)
+
+		err := zfv.NodeDeferredEnumerationCallback(context.Background(), internalNodeDeferredEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.Equal(t, &FirmwareVersion{FileVersion: 0x01020304, ManufacturerCode: 0x117c, ImageType: 0x2101}, device.firmwareVersion)
+
+		mockZclCommunicatorRequests.AssertExpectations(t)
+	})
+
+	t.Run("clears the firmware version of a device without an OTA Upgrade client", func(t *testing.T) {
+		zfv := zdaFirmwareVersion{}
+
+		node, device := generateTestNodeAndDevice()
+		device.firmwareVersion = &FirmwareVersion{FileVersion: 1}
+
+		err := zfv.NodeDeferredEnumerationCallback(context.Background(), internalNodeDeferredEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.Nil(t, device.firmwareVersion)
+	})
+}
+
+func TestZigbeeGateway_FirmwareVersion(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		_, _, err := zgw.FirmwareVersion(da.Device{})
+		assert.Error(t, err)
+	})
+
+	t.Run("returns the firmware version if known", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		_, found, err := zgw.FirmwareVersion(iDev.device)
+		assert.NoError(t, err)
+		assert.False(t, found)
+
+		iDev.firmwareVersion = &FirmwareVersion{FileVersion: 0x10}
+
+		version, found, err := zgw.FirmwareVersion(iDev.device)
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, uint32(0x10), version.FileVersion)
+	})
+}
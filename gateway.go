@@ -13,23 +13,34 @@ import (
 	"github.com/shimmeringbee/zigbee"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 const DefaultGatewayHomeAutomationEndpoint = zigbee.Endpoint(0x01)
 
 type ZigbeeGateway struct {
-	provider     zigbee.Provider
-	communicator *communicator.Communicator
+	// Accessed atomically, kept first to guarantee 64 bit alignment.
+	eventsSent    uint64
+	eventsDropped uint64
+
+	provider      zigbee.Provider
+	congestion    *congestionProvider
+	apsEncryption *apsEncryptionProvider
+	communicator  *communicator.Communicator
 
 	self *internalDevice
 
 	context             context.Context
 	contextCancel       context.CancelFunc
 	providerHandlerStop chan bool
+	ready               chan struct{}
 
-	events       chan interface{}
-	capabilities map[Capability]interface{}
+	events          chan SequencedEvent
+	eventJournal    *zdaEventJournal
+	eventBatcher    *zdaEventBatcher
+	eventSuppressor *zdaEventSuppressor
+	capabilities    map[Capability]interface{}
 
 	devices     map[Identifier]*internalDevice
 	devicesLock *sync.RWMutex
@@ -37,28 +48,68 @@ type ZigbeeGateway struct {
 	nodes     map[zigbee.IEEEAddress]*internalNode
 	nodesLock *sync.RWMutex
 
-	callbacks *callbacks.Callbacks
-	poller    *zdaPoller
+	callbacks    *callbacks.Callbacks
+	poller       *zdaPoller
+	resetMonitor *zdaResetMonitor
+	joinThrottle *zdaJoinThrottle
+	factoryReset *zdaFactoryReset
+	firmware     *zdaFirmwareVersion
+	staleness    *zdaStalenessWatchdog
+
+	transactionTracker *zdaTransactionTracker
+	commandRetrier     *retryingCommunicatorRequests
+	attributeCache     *zdaAttributeCache
+	attributeDecoders  *attributeDecoders
+	tracer             *zdaDeviceTracer
+	clock              clock
+
+	fastEnumeration bool
+
+	reEnumerationConcurrency int
+	reEnumerationInterval    time.Duration
+
+	nodeLimit       int
+	nodeLimitPolicy NodeLimitPolicy
+
+	lowBatteryThreshold  float64
+	batteryLifeThreshold time.Duration
 }
 
-func New(provider zigbee.Provider) *ZigbeeGateway {
+func New(provider zigbee.Provider, options ...Option) *ZigbeeGateway {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	zclCommandRegistry := zcl.NewCommandRegistry()
 	global.Register(zclCommandRegistry)
 	onoff.Register(zclCommandRegistry)
+	registerPriceCommands(zclCommandRegistry)
+	registerBasicCommands(zclCommandRegistry)
+	registerLevelControlCommands(zclCommandRegistry)
+	registerColorControlCommands(zclCommandRegistry)
+	registerIdentifyCommands(zclCommandRegistry)
+	registerIASZoneCommands(zclCommandRegistry)
+	registerDoorLockCommands(zclCommandRegistry)
+	registerWindowCoveringCommands(zclCommandRegistry)
+
+	tracer := newDeviceTracer(zclCommandRegistry)
+	apsEncryption := &apsEncryptionProvider{Provider: provider}
+	congestion := newCongestionProvider(apsEncryption, time.Now)
 
 	zgw := &ZigbeeGateway{
-		provider:     provider,
-		communicator: communicator.NewCommunicator(provider, zclCommandRegistry),
+		provider:      congestion,
+		congestion:    congestion,
+		apsEncryption: apsEncryption,
+		communicator:  communicator.NewCommunicator(&tracingProvider{Provider: congestion, tracer: tracer}, zclCommandRegistry),
 
 		self: &internalDevice{mutex: &sync.RWMutex{}},
 
 		providerHandlerStop: make(chan bool, 1),
+		ready:               make(chan struct{}),
 		context:             ctx,
 		contextCancel:       cancel,
 
-		events:       make(chan interface{}, 100),
+		events:       make(chan SequencedEvent, 100),
+		eventJournal: newEventJournal(),
+		eventBatcher: newEventBatcher(),
 		capabilities: map[Capability]interface{}{},
 
 		devices:     map[Identifier]*internalDevice{},
@@ -68,14 +119,62 @@ func New(provider zigbee.Provider) *ZigbeeGateway {
 		nodesLock: &sync.RWMutex{},
 
 		callbacks: callbacks.Create(),
+
+		transactionTracker: newTransactionTracker(),
+		attributeCache:     newAttributeCache(),
+		attributeDecoders:  newAttributeDecoders(),
+		tracer:             tracer,
+
+		reEnumerationConcurrency: DefaultReEnumerationConcurrency,
+		reEnumerationInterval:    DefaultReEnumerationInterval,
+		lowBatteryThreshold:      DefaultLowBatteryThreshold,
+		batteryLifeThreshold:     DefaultBatteryLifeThreshold,
+
+		clock: realClock{},
+	}
+
+	zgw.commandRetrier = &retryingCommunicatorRequests{
+		zclCommunicatorRequests: &trackingCommunicatorRequests{
+			zclCommunicatorRequests: &decodingCommunicatorRequests{
+				zclCommunicatorRequests: &cachingCommunicatorRequests{zclCommunicatorRequests: zgw.communicator, cache: zgw.attributeCache},
+				decoders:                zgw.attributeDecoders,
+			},
+			tracker: zgw.transactionTracker,
+		},
+		policy: DefaultCommandRetryPolicy,
+	}
+	communicatorRequests := &resultCommunicatorRequests{
+		zclCommunicatorRequests: zgw.commandRetrier,
+		eventSender:             zgw,
 	}
+	globalCommunicator := &recordingGlobalCommunicator{
+		zclGlobalCommunicator: &trackingGlobalCommunicator{
+			zclGlobalCommunicator: &decodingGlobalCommunicator{
+				zclGlobalCommunicator: &cachingGlobalCommunicator{zclGlobalCommunicator: zgw.communicator.Global(), cache: zgw.attributeCache},
+				decoders:              zgw.attributeDecoders,
+			},
+			tracker: zgw.transactionTracker,
+		},
+	}
+
+	zgw.attributeCache.Init(zgw.communicator)
+	zgw.eventBatcher.deliver = zgw.publishEvent
+	zgw.eventSuppressor = newEventSuppressor(zgw.clock.Now)
+
+	zgw.poller = &zdaPoller{nodeStore: zgw, clock: zgw.clock, jitterPercentage: DefaultPollJitterPercentage}
+	zgw.joinThrottle = newJoinThrottle()
+	zgw.congestion.onChange = zgw.congestionChanged
 
-	zgw.poller = &zdaPoller{nodeStore: zgw}
+	for _, option := range options {
+		option(zgw)
+	}
 
 	zgw.capabilities[DeviceDiscoveryFlag] = &ZigbeeDeviceDiscovery{
-		gateway:        zgw,
-		networkJoining: zgw.provider,
-		eventSender:    zgw,
+		gateway:           zgw,
+		networkJoining:    zgw.provider,
+		eventSender:       zgw,
+		internalCallbacks: zgw.callbacks,
+		clock:             zgw.clock,
 	}
 
 	zgw.capabilities[EnumerateDeviceFlag] = &ZigbeeEnumerateDevice{
@@ -84,6 +183,9 @@ func New(provider zigbee.Provider) *ZigbeeGateway {
 		eventSender:       zgw,
 		nodeQuerier:       zgw.provider,
 		internalCallbacks: zgw.callbacks,
+		fastEnumeration:   zgw.fastEnumeration,
+
+		zclGlobalCommunicator: globalCommunicator,
 	}
 
 	zgw.capabilities[LocalDebugFlag] = &ZigbeeLocalDebug{gateway: zgw}
@@ -92,7 +194,7 @@ func New(provider zigbee.Provider) *ZigbeeGateway {
 		gateway:               zgw,
 		deviceStore:           zgw,
 		internalCallbacks:     zgw.callbacks,
-		zclGlobalCommunicator: zgw.communicator.Global(),
+		zclGlobalCommunicator: globalCommunicator,
 	}
 
 	zgw.capabilities[OnOffFlag] = &ZigbeeOnOff{
@@ -101,19 +203,235 @@ func New(provider zigbee.Provider) *ZigbeeGateway {
 		deviceStore:              zgw,
 		nodeStore:                zgw,
 		zclCommunicatorCallbacks: zgw.communicator,
-		zclCommunicatorRequests:  zgw.communicator,
-		zclGlobalCommunicator:    zgw.communicator.Global(),
+		zclCommunicatorRequests:  communicatorRequests,
+		zclGlobalCommunicator:    globalCommunicator,
 		nodeBinder:               zgw.provider,
 		poller:                   zgw.poller,
 		eventSender:              zgw,
 	}
 
+	zgw.capabilities[OnOffEffectFlag] = zgw.capabilities[OnOffFlag]
+
+	zgw.capabilities[IlluminanceLevelSensingFlag] = &ZigbeeIlluminanceLevelSensing{
+		gateway:                  zgw,
+		internalCallbacks:        zgw.callbacks,
+		deviceStore:              zgw,
+		nodeStore:                zgw,
+		zclCommunicatorCallbacks: zgw.communicator,
+		zclGlobalCommunicator:    globalCommunicator,
+		nodeBinder:               zgw.provider,
+		eventSender:              zgw,
+	}
+
+	zgw.capabilities[PriceFlag] = &ZigbeePrice{
+		gateway:                  zgw,
+		internalCallbacks:        zgw.callbacks,
+		deviceStore:              zgw,
+		nodeStore:                zgw,
+		zclCommunicatorCallbacks: zgw.communicator,
+		nodeBinder:               zgw.provider,
+		eventSender:              zgw,
+	}
+
+	zgw.capabilities[AnalogOutputFlag] = &ZigbeeAnalogOutput{
+		gateway:                  zgw,
+		internalCallbacks:        zgw.callbacks,
+		deviceStore:              zgw,
+		nodeStore:                zgw,
+		zclCommunicatorCallbacks: zgw.communicator,
+		zclCommunicatorRequests:  communicatorRequests,
+		zclGlobalCommunicator:    globalCommunicator,
+		nodeBinder:               zgw.provider,
+		eventSender:              zgw,
+	}
+
+	zgw.capabilities[ThermostatUIConfigurationFlag] = &ZigbeeThermostatUIConfiguration{
+		gateway:                 zgw,
+		internalCallbacks:       zgw.callbacks,
+		deviceStore:             zgw,
+		zclCommunicatorRequests: communicatorRequests,
+		zclGlobalCommunicator:   globalCommunicator,
+		eventSender:             zgw,
+	}
+
+	zgw.capabilities[ButtonEventsFlag] = &ZigbeeButtonEvents{
+		gateway:                  zgw,
+		internalCallbacks:        zgw.callbacks,
+		deviceStore:              zgw,
+		nodeStore:                zgw,
+		zclCommunicatorCallbacks: zgw.communicator,
+		nodeBinder:               zgw.provider,
+		eventSender:              zgw,
+		now:                      zgw.clock.Now,
+	}
+
+	zgw.capabilities[LevelControlFlag] = &ZigbeeLevelControl{
+		gateway:                 zgw,
+		internalCallbacks:       zgw.callbacks,
+		deviceStore:             zgw,
+		nodeStore:               zgw,
+		zclCommunicatorRequests: communicatorRequests,
+		zclGlobalCommunicator:   globalCommunicator,
+		poller:                  zgw.poller,
+		eventSender:             zgw,
+	}
+
+	zgw.capabilities[ColorControlFlag] = &ZigbeeColorControl{
+		gateway:                 zgw,
+		internalCallbacks:       zgw.callbacks,
+		deviceStore:             zgw,
+		nodeStore:               zgw,
+		zclCommunicatorRequests: communicatorRequests,
+		zclGlobalCommunicator:   globalCommunicator,
+		poller:                  zgw.poller,
+		eventSender:             zgw,
+	}
+
+	zgw.capabilities[TemperatureSensorFlag] = &ZigbeeTemperatureSensor{
+		gateway:                  zgw,
+		internalCallbacks:        zgw.callbacks,
+		deviceStore:              zgw,
+		nodeStore:                zgw,
+		zclCommunicatorCallbacks: zgw.communicator,
+		zclGlobalCommunicator:    globalCommunicator,
+		nodeBinder:               zgw.provider,
+		eventSender:              zgw,
+	}
+
+	zgw.capabilities[RelativeHumiditySensorFlag] = &ZigbeeRelativeHumiditySensor{
+		gateway:               zgw,
+		internalCallbacks:     zgw.callbacks,
+		deviceStore:           zgw,
+		zclGlobalCommunicator: globalCommunicator,
+		poller:                zgw.poller,
+		eventSender:           zgw,
+	}
+
+	zgw.capabilities[PressureSensorFlag] = &ZigbeePressureSensor{
+		gateway:               zgw,
+		internalCallbacks:     zgw.callbacks,
+		deviceStore:           zgw,
+		zclGlobalCommunicator: globalCommunicator,
+		poller:                zgw.poller,
+		eventSender:           zgw,
+	}
+
+	zgw.capabilities[OccupancySensorFlag] = &ZigbeeOccupancySensor{
+		gateway:                  zgw,
+		internalCallbacks:        zgw.callbacks,
+		deviceStore:              zgw,
+		nodeStore:                zgw,
+		zclCommunicatorCallbacks: zgw.communicator,
+		zclGlobalCommunicator:    globalCommunicator,
+		nodeBinder:               zgw.provider,
+		eventSender:              zgw,
+		now:                      zgw.clock.Now,
+	}
+
+	zgw.capabilities[IlluminanceSensorFlag] = &ZigbeeIlluminanceSensor{
+		gateway:               zgw,
+		internalCallbacks:     zgw.callbacks,
+		deviceStore:           zgw,
+		zclGlobalCommunicator: globalCommunicator,
+		poller:                zgw.poller,
+		eventSender:           zgw,
+	}
+
+	zgw.capabilities[BatteryFlag] = &ZigbeeBattery{
+		gateway:               zgw,
+		internalCallbacks:     zgw.callbacks,
+		deviceStore:           zgw,
+		zclGlobalCommunicator: globalCommunicator,
+		poller:                zgw.poller,
+		eventSender:           zgw,
+		lowThreshold:          zgw.lowBatteryThreshold,
+		lifeLowThreshold:      zgw.batteryLifeThreshold,
+		now:                   zgw.clock.Now,
+	}
+
+	zgw.capabilities[IdentifyFlag] = &ZigbeeIdentify{
+		gateway:                 zgw,
+		internalCallbacks:       zgw.callbacks,
+		deviceStore:             zgw,
+		zclCommunicatorRequests: communicatorRequests,
+	}
+
+	zgw.capabilities[ElectricalMeasurementFlag] = &ZigbeeElectricalMeasurement{
+		gateway:               zgw,
+		internalCallbacks:     zgw.callbacks,
+		deviceStore:           zgw,
+		zclGlobalCommunicator: globalCommunicator,
+		poller:                zgw.poller,
+		eventSender:           zgw,
+	}
+
+	zgw.capabilities[MeteringFlag] = &ZigbeeMetering{
+		gateway:               zgw,
+		internalCallbacks:     zgw.callbacks,
+		deviceStore:           zgw,
+		zclGlobalCommunicator: globalCommunicator,
+		poller:                zgw.poller,
+		eventSender:           zgw,
+	}
+
+	zgw.capabilities[IASZoneFlag] = &ZigbeeIASZone{
+		gateway:                  zgw,
+		internalCallbacks:        zgw.callbacks,
+		deviceStore:              zgw,
+		nodeStore:                zgw,
+		zclCommunicatorCallbacks: zgw.communicator,
+		zclCommunicatorRequests:  communicatorRequests,
+		zclGlobalCommunicator:    globalCommunicator,
+		eventSender:              zgw,
+	}
+
+	zgw.capabilities[DoorLockFlag] = &ZigbeeDoorLock{
+		gateway:                  zgw,
+		internalCallbacks:        zgw.callbacks,
+		deviceStore:              zgw,
+		nodeStore:                zgw,
+		zclCommunicatorCallbacks: zgw.communicator,
+		zclCommunicatorRequests:  communicatorRequests,
+		zclGlobalCommunicator:    globalCommunicator,
+		nodeBinder:               zgw.provider,
+		eventSender:              zgw,
+	}
+
+	zgw.capabilities[WindowCoveringFlag] = &ZigbeeWindowCovering{
+		gateway:                 zgw,
+		internalCallbacks:       zgw.callbacks,
+		deviceStore:             zgw,
+		zclCommunicatorRequests: communicatorRequests,
+		zclGlobalCommunicator:   globalCommunicator,
+		poller:                  zgw.poller,
+		eventSender:             zgw,
+	}
+
 	initOrder := []Capability{
 		DeviceDiscoveryFlag,
 		EnumerateDeviceFlag,
 		LocalDebugFlag,
 		HasProductInformationFlag,
 		OnOffFlag,
+		IlluminanceLevelSensingFlag,
+		PriceFlag,
+		AnalogOutputFlag,
+		ThermostatUIConfigurationFlag,
+		ButtonEventsFlag,
+		LevelControlFlag,
+		ColorControlFlag,
+		TemperatureSensorFlag,
+		RelativeHumiditySensorFlag,
+		PressureSensorFlag,
+		OccupancySensorFlag,
+		IlluminanceSensorFlag,
+		BatteryFlag,
+		IdentifyFlag,
+		ElectricalMeasurementFlag,
+		MeteringFlag,
+		IASZoneFlag,
+		DoorLockFlag,
+		WindowCoveringFlag,
 	}
 
 	for _, capability := range initOrder {
@@ -126,6 +444,36 @@ func New(provider zigbee.Provider) *ZigbeeGateway {
 
 	zgw.callbacks.Add(zgw.enableAPSACK)
 
+	zgw.resetMonitor = &zdaResetMonitor{
+		internalCallbacks:     zgw.callbacks,
+		zclGlobalCommunicator: globalCommunicator,
+		poller:                zgw.poller,
+		eventSender:           zgw,
+	}
+	zgw.resetMonitor.Init()
+
+	zgw.firmware = &zdaFirmwareVersion{
+		internalCallbacks:       zgw.callbacks,
+		zclCommunicatorRequests: communicatorRequests,
+	}
+	zgw.firmware.Init()
+
+	zgw.staleness = &zdaStalenessWatchdog{
+		internalCallbacks: zgw.callbacks,
+		poller:            zgw.poller,
+		eventSender:       zgw,
+		now:               zgw.clock.Now,
+	}
+	zgw.staleness.Init()
+
+	zgw.factoryReset = &zdaFactoryReset{
+		nodeStore:               zgw,
+		zclCommunicatorRequests: communicatorRequests,
+		nodeEnumerator:          zgw.capabilities[EnumerateDeviceFlag].(*ZigbeeEnumerateDevice),
+		eventSender:             zgw,
+		settleDelay:             DefaultFactoryResetSettleDelay,
+	}
+
 	return zgw
 }
 
@@ -150,14 +498,29 @@ func (z *ZigbeeGateway) Start() error {
 		}
 	}
 
+	close(z.ready)
+
 	return nil
 }
 
+// WaitReady blocks until the gateway has completed Start, or the context provided expires. Any events relating to
+// devices loaded during Start are queued before the gateway becomes ready, as such a snapshot of Devices() taken after
+// WaitReady returns will contain every device that an event has been queued for during Start.
+func (z *ZigbeeGateway) WaitReady(ctx context.Context) error {
+	select {
+	case <-z.ready:
+		return nil
+	case <-ctx.Done():
+		return zigbee.ContextExpired
+	}
+}
+
 func (z *ZigbeeGateway) Stop() error {
 	z.providerHandlerStop <- true
 	z.contextCancel()
 
 	z.poller.Stop()
+	z.eventBatcher.flush()
 
 	for _, capabilityImpl := range z.capabilities {
 		if stopable, is := capabilityImpl.(CapabilityStopable); is {
@@ -181,37 +544,37 @@ func (z *ZigbeeGateway) providerHandler() {
 
 		switch e := event.(type) {
 		case zigbee.NodeJoinEvent:
-			iNode, found := z.getNode(e.IEEEAddress)
-
-			if !found {
-				iNode = z.addNode(e.IEEEAddress)
-			}
+			_, found := z.getNode(e.IEEEAddress)
 
-			if len(iNode.getDevices()) == 0 {
-				initialDeviceId := iNode.nextDeviceIdentifier()
-
-				z.addDevice(initialDeviceId, iNode)
+			if allowed, reason := z.joinThrottle.allow(e.IEEEAddress, !found); !allowed {
+				if reason == JoinRateExceeded {
+					z.joinThrottle.queue(e)
+				}
 
-				z.callbacks.Call(context.Background(), internalNodeJoin{node: iNode})
+				z.sendEvent(NodeJoinThrottled{IEEEAddress: e.IEEEAddress, Reason: reason})
+				break
 			}
 
+			z.handleNodeJoin(e)
+
 		case zigbee.NodeLeaveEvent:
 			iNode, found := z.getNode(e.IEEEAddress)
 
 			if found {
-				z.callbacks.Call(context.Background(), internalNodeLeave{node: iNode})
-
-				for _, iDev := range iNode.getDevices() {
-					z.removeDevice(iDev.device.Identifier)
-				}
-
-				z.removeNode(e.IEEEAddress)
+				z.forgetNode(iNode)
+			} else {
+				z.joinThrottle.forget(e.IEEEAddress)
 			}
 
 		case zigbee.NodeIncomingMessageEvent:
+			z.tracer.record(e.IEEEAddress, TraceIncoming, e.ApplicationMessage)
 			z.communicator.ProcessIncomingMessage(e)
 		}
 
+		for _, join := range z.joinThrottle.release() {
+			z.handleNodeJoin(join)
+		}
+
 		select {
 		case <-z.providerHandlerStop:
 			return
@@ -220,23 +583,83 @@ func (z *ZigbeeGateway) providerHandler() {
 	}
 }
 
+// handleNodeJoin adds a node which has joined and been admitted by the join throttle, or reconciles it if it is already
+// known.
+func (z *ZigbeeGateway) handleNodeJoin(e zigbee.NodeJoinEvent) {
+	iNode, found := z.getNode(e.IEEEAddress)
+
+	if found {
+		z.reconcileRejoin(iNode, e.Node)
+	} else {
+		if !z.admitNode(e.IEEEAddress) {
+			return
+		}
+
+		iNode = z.addNode(e.IEEEAddress)
+	}
+
+	iNode.mutex.Lock()
+	iNode.logicalType = e.LogicalType
+	iNode.mutex.Unlock()
+
+	if len(iNode.getDevices()) == 0 {
+		initialDeviceId := iNode.nextDeviceIdentifier()
+
+		z.addDevice(initialDeviceId, iNode)
+
+		z.callbacks.Call(context.Background(), internalNodeJoin{node: iNode})
+	}
+}
+
 func (z *ZigbeeGateway) sendEvent(event interface{}) {
-	select {
-	case z.events <- event:
-	default:
-		fmt.Printf("warning could not send event, channel buffer full: %+v", event)
+	if z.eventSuppressor.suppress(event) {
+		return
+	}
+
+	if z.eventBatcher.add(event) {
+		return
 	}
+
+	z.publishEvent(event)
+}
+
+// publishEvent records the event in the journal and delivers it to the consumer.
+func (z *ZigbeeGateway) publishEvent(event interface{}) {
+	z.eventJournal.record(event, func(sequenced SequencedEvent) {
+		atomic.AddUint64(&z.eventsSent, 1)
+
+		select {
+		case z.events <- sequenced:
+		default:
+			atomic.AddUint64(&z.eventsDropped, 1)
+			fmt.Printf("warning could not send event, channel buffer full: %+v", event)
+		}
+	})
 }
 
 func (z *ZigbeeGateway) ReadEvent(ctx context.Context) (interface{}, error) {
+	sequenced, err := z.ReadSequencedEvent(ctx)
+	return sequenced.Event, err
+}
+
+// ReadSequencedEvent reads the next event from the gateway along with its sequence number, the sequence number can be
+// provided to ReplayEvents by a consumer which reconnects to catch up on events it missed.
+func (z *ZigbeeGateway) ReadSequencedEvent(ctx context.Context) (SequencedEvent, error) {
 	select {
-	case event := <-z.events:
-		return event, nil
+	case sequenced := <-z.events:
+		return sequenced, nil
 	case <-ctx.Done():
-		return nil, zigbee.ContextExpired
+		return SequencedEvent{}, zigbee.ContextExpired
 	}
 }
 
+// ReplayEvents returns all events emitted after the sequence number provided. The event journal must have been
+// enabled with WithEventJournal, otherwise EventJournalDisabled is returned. If the journal no longer holds all
+// events since the sequence, EventJournalExhausted is returned and the consumer should resynchronise with Devices().
+func (z *ZigbeeGateway) ReplayEvents(sequence uint64) ([]SequencedEvent, error) {
+	return z.eventJournal.since(sequence)
+}
+
 func (z *ZigbeeGateway) Capability(capability Capability) interface{} {
 	return z.capabilities[capability]
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/shimmeringbee/zigbee"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -14,14 +15,14 @@ import (
 var testGatewayIEEEAddress = zigbee.IEEEAddress(0x0102030405060708)
 var testGatewayNetworkAddress = zigbee.NetworkAddress(0xeeff)
 
-func NewTestZigbeeGateway() (*ZigbeeGateway, *zigbee.MockProvider, func(*testing.T)) {
+func NewTestZigbeeGateway(options ...Option) (*ZigbeeGateway, *zigbee.MockProvider, func(*testing.T)) {
 	mockProvider := new(zigbee.MockProvider)
 
 	mockProvider.On("AdapterNode").Return(zigbee.Node{
 		IEEEAddress:    testGatewayIEEEAddress,
 		NetworkAddress: testGatewayNetworkAddress,
 	})
-	zgw := New(mockProvider)
+	zgw := New(mockProvider, options...)
 
 	return zgw, mockProvider, func(t *testing.T) {
 		zgw.Stop()
@@ -61,6 +62,33 @@ func TestZigbeeGateway_New(t *testing.T) {
 	})
 }
 
+func TestZigbeeGateway_WaitReady(t *testing.T) {
+	t.Run("context which expires before start should result in error", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := zgw.WaitReady(ctx)
+		assert.Error(t, err)
+	})
+
+	t.Run("returns once the gateway has started", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockProvider.On("ReadEvent", mock.Anything).Return(nil, nil).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+		zgw.Start()
+		defer stop(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := zgw.WaitReady(ctx)
+		assert.NoError(t, err)
+	})
+}
+
 func TestZigbeeGateway_Devices(t *testing.T) {
 	t.Run("devices returns self", func(t *testing.T) {
 		zgw, mockProvider, stop := NewTestZigbeeGateway()
@@ -211,7 +239,7 @@ func TestZigbeeGateway_DeviceAdded(t *testing.T) {
 	})
 
 	t.Run("only one DeviceAdded event is sent when a Zigbee device is announced by the provider twice", func(t *testing.T) {
-		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		zgw, mockProvider, stop := NewTestZigbeeGateway(WithJoinDebounce(0))
 		mockCall := mockProvider.On("ReadEvent", mock.Anything).Maybe()
 		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 		mockProvider.On("QueryNodeDescription", mock.Anything, mock.Anything).Maybe().Return(zigbee.NodeDescription{}, nil)
@@ -271,6 +299,110 @@ func TestZigbeeGateway_DeviceAdded(t *testing.T) {
 		assert.NoError(t, err)
 		assert.IsType(t, EnumerateDeviceSuccess{}, actualEventThree)
 	})
+
+	t.Run("a repeated announcement from a Zigbee device within the debounce period is throttled", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockCall := mockProvider.On("ReadEvent", mock.Anything).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		mockProvider.On("QueryNodeDescription", mock.Anything, mock.Anything).Maybe().Return(zigbee.NodeDescription{}, nil)
+		mockProvider.On("QueryNodeEndpoints", mock.Anything, mock.Anything).Maybe().Return([]zigbee.Endpoint{}, nil)
+
+		zgw.Start()
+		defer stop(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+		defer cancel()
+
+		expectedAddress := zigbee.IEEEAddress(0x0102030405060708)
+		join := zigbee.NodeJoinEvent{Node: zigbee.Node{IEEEAddress: expectedAddress}}
+
+		mockCall.RunFn = multipleReadEvents(mockCall, join, join, nil)
+
+		events := readEventsUntilDone(ctx, zgw)
+
+		assert.Contains(t, events, NodeJoinThrottled{IEEEAddress: expectedAddress, Reason: JoinDebounced})
+		assert.Equal(t, 1, countEventsOfType(events, DeviceAdded{}))
+	})
+
+	t.Run("a Zigbee device which leaves and rejoins within the debounce period is added again", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway()
+		mockCall := mockProvider.On("ReadEvent", mock.Anything).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		mockProvider.On("QueryNodeDescription", mock.Anything, mock.Anything).Maybe().Return(zigbee.NodeDescription{}, nil)
+		mockProvider.On("QueryNodeEndpoints", mock.Anything, mock.Anything).Maybe().Return([]zigbee.Endpoint{}, nil)
+
+		zgw.Start()
+		defer stop(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+		defer cancel()
+
+		expectedAddress := zigbee.IEEEAddress(0x0102030405060708)
+		node := zigbee.Node{IEEEAddress: expectedAddress}
+
+		mockCall.RunFn = multipleReadEvents(mockCall, zigbee.NodeJoinEvent{Node: node}, zigbee.NodeLeaveEvent{Node: node}, zigbee.NodeJoinEvent{Node: node}, nil)
+
+		events := readEventsUntilDone(ctx, zgw)
+
+		assert.NotContains(t, events, NodeJoinThrottled{IEEEAddress: expectedAddress, Reason: JoinDebounced})
+		assert.Equal(t, 2, countEventsOfType(events, DeviceAdded{}))
+
+		_, found := zgw.getNode(expectedAddress)
+		assert.True(t, found)
+	})
+
+	t.Run("a Zigbee device announced beyond the join rate limit is added once the window has room", func(t *testing.T) {
+		zgw, mockProvider, stop := NewTestZigbeeGateway(WithJoinRateLimit(1, 50*time.Millisecond))
+		mockCall := mockProvider.On("ReadEvent", mock.Anything).Maybe()
+		mockProvider.On("RegisterAdapterEndpoint", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+		mockProvider.On("QueryNodeDescription", mock.Anything, mock.Anything).Maybe().Return(zigbee.NodeDescription{}, nil)
+		mockProvider.On("QueryNodeEndpoints", mock.Anything, mock.Anything).Maybe().Return([]zigbee.Endpoint{}, nil)
+
+		zgw.Start()
+		defer stop(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		firstAddress := zigbee.IEEEAddress(0x0102030405060708)
+		secondAddress := zigbee.IEEEAddress(0x0807060504030201)
+
+		mockCall.RunFn = multipleReadEvents(mockCall, zigbee.NodeJoinEvent{Node: zigbee.Node{IEEEAddress: firstAddress}}, zigbee.NodeJoinEvent{Node: zigbee.Node{IEEEAddress: secondAddress}}, nil)
+
+		events := readEventsUntilDone(ctx, zgw)
+
+		assert.Contains(t, events, NodeJoinThrottled{IEEEAddress: secondAddress, Reason: JoinRateExceeded})
+		assert.Equal(t, 2, countEventsOfType(events, DeviceAdded{}))
+
+		_, found := zgw.getNode(secondAddress)
+		assert.True(t, found)
+	})
+}
+
+// readEventsUntilDone reads events from the gateway until the context is done.
+func readEventsUntilDone(ctx context.Context, zgw *ZigbeeGateway) []interface{} {
+	var events []interface{}
+
+	for {
+		event, err := zgw.ReadEvent(ctx)
+		if err != nil {
+			return events
+		}
+
+		events = append(events, event)
+	}
+}
+
+func countEventsOfType(events []interface{}, eventType interface{}) int {
+	count := 0
+
+	for _, event := range events {
+		if reflect.TypeOf(event) == reflect.TypeOf(eventType) {
+			count++
+		}
+	}
+
+	return count
 }
 
 func TestZigbeeGateway_DeviceRemoved(t *testing.T) {
@@ -392,13 +524,18 @@ func TestZigbeeGateway_DeviceRemoved(t *testing.T) {
 		_, err = zgw.ReadEvent(ctx)
 		assert.NoError(t, err)
 
-		actualEvent, err := zgw.ReadEvent(ctx)
-		assert.NoError(t, err)
-		assert.Equal(t, expectedEvent[0], actualEvent)
+		var actualEvents []DeviceRemoved
 
-		actualEvent, err = zgw.ReadEvent(ctx)
-		assert.NoError(t, err)
-		assert.Equal(t, expectedEvent[1], actualEvent)
+		for i := 0; i < len(expectedEvent); i++ {
+			actualEvent, err := zgw.ReadEvent(ctx)
+			assert.NoError(t, err)
+
+			if removed, ok := actualEvent.(DeviceRemoved); ok {
+				actualEvents = append(actualEvents, removed)
+			}
+		}
+
+		assert.ElementsMatch(t, expectedEvent, actualEvents)
 
 		assert.True(t, callbackCalled)
 
@@ -4,6 +4,7 @@ go 1.14
 
 require (
 	github.com/davecgh/go-spew v1.1.1
+	github.com/shimmeringbee/bytecodec v0.0.0-20200706123551-2f3d1ec55300
 	github.com/shimmeringbee/callbacks v0.0.0-20200722202022-da0ad0ab563e
 	github.com/shimmeringbee/da v0.0.0-20200720202520-870908c45470
 	github.com/shimmeringbee/retry v0.0.0-20200527220501-bda1ff6caa51
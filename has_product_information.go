@@ -31,42 +31,28 @@ func (z *ZigbeeHasProductInformation) NodeEnumerationCallback(ctx context.Contex
 	for _, iDev := range iNode.devices {
 		iDev.mutex.Lock()
 
-		found := false
-		foundEndpoint := zigbee.Endpoint(0x0000)
-
-		for _, endpoint := range iDev.endpoints {
-			if isClusterIdInSlice(iNode.endpointDescriptions[endpoint].InClusterList, 0x0000) {
-				found = true
-				foundEndpoint = endpoint
-				break
-			}
-		}
-
-		if found {
+		if foundEndpoint, cluster, found := findEndpointForCapability(iNode, iDev, capabilities.HasProductInformationFlag); found {
 			if err := retry.Retry(ctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
-				readRecords, err := z.zclGlobalCommunicator.ReadAttributes(ctx, iNode.ieeeAddress, iNode.supportsAPSAck, zcl.BasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, foundEndpoint, iNode.nextTransactionSequence(), []zcl.AttributeID{0x0004, 0x0005})
+				readRecords, err := z.zclGlobalCommunicator.ReadAttributes(ctx, iNode.ieeeAddress, iNode.supportsAPSAck, cluster, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, foundEndpoint, iNode.nextTransactionSequence(), []zcl.AttributeID{0x0004, 0x0005})
 
 				if err == nil {
-					for _, record := range readRecords {
-						switch record.Identifier {
-						case 0x0004:
-							if record.Status == 0 {
-								iDev.productInformation.Manufacturer = record.DataTypeValue.Value.(string)
-								iDev.productInformation.Present |= capabilities.Manufacturer
-							} else {
-								iDev.productInformation.Manufacturer = ""
-								iDev.productInformation.Present &= ^capabilities.Manufacturer
-							}
-
-						case 0x0005:
-							if record.Status == 0 {
-								iDev.productInformation.Name = record.DataTypeValue.Value.(string)
-								iDev.productInformation.Present |= capabilities.Name
-							} else {
-								iDev.productInformation.Name = ""
-								iDev.productInformation.Present &= ^capabilities.Name
-							}
-						}
+					results := parseReadAttributeResponse(readRecords)
+					markCapabilityUpdated(iDev, capabilities.HasProductInformationFlag)
+
+					if manufacturer, ok := results.stringValue(0x0004); ok {
+						iDev.productInformation.Manufacturer = manufacturer
+						iDev.productInformation.Present |= capabilities.Manufacturer
+					} else {
+						iDev.productInformation.Manufacturer = ""
+						iDev.productInformation.Present &= ^capabilities.Manufacturer
+					}
+
+					if name, ok := results.stringValue(0x0005); ok {
+						iDev.productInformation.Name = name
+						iDev.productInformation.Present |= capabilities.Name
+					} else {
+						iDev.productInformation.Name = ""
+						iDev.productInformation.Present &= ^capabilities.Name
 					}
 				}
 
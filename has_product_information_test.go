@@ -68,10 +68,16 @@ func TestZigbeeHasProductInformation_NodeEnumerationCallback(t *testing.T) {
 		mockDeviceStore.On("getDevice", devices[0].device.Identifier).Return(devices[0], true)
 		mockDeviceStore.On("getDevice", devices[1].device.Identifier).Return(devices[1], true)
 
+		var sequences []uint8
+		recordSequence := func(args mock.Arguments) {
+			sequences = append(sequences, args.Get(7).(uint8))
+		}
+
 		manufactureres := []string{"manu1", "manu2"}
 		products := []string{"product1", "product2"}
 
-		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, node.supportsAPSAck, zcl.BasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(0), uint8(1), []zcl.AttributeID{0x0004, 0x0005}).
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, node.supportsAPSAck, zcl.BasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(0), mock.Anything, []zcl.AttributeID{0x0004, 0x0005}).
+			Run(recordSequence).
 			Return([]global.ReadAttributeResponseRecord{
 				{
 					Identifier: 0x0004,
@@ -91,7 +97,8 @@ func TestZigbeeHasProductInformation_NodeEnumerationCallback(t *testing.T) {
 				},
 			}, nil)
 
-		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, node.supportsAPSAck, zcl.BasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(1), uint8(2), []zcl.AttributeID{0x0004, 0x0005}).
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, node.supportsAPSAck, zcl.BasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(1), mock.Anything, []zcl.AttributeID{0x0004, 0x0005}).
+			Run(recordSequence).
 			Return([]global.ReadAttributeResponseRecord{
 				{
 					Identifier: 0x0004,
@@ -115,6 +122,7 @@ func TestZigbeeHasProductInformation_NodeEnumerationCallback(t *testing.T) {
 
 		err := zhpi.NodeEnumerationCallback(ctx, internalNodeEnumeration{node: node})
 		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint8{1, 2}, sequences)
 
 		assert.Equal(t, []da.Capability{capabilities.HasProductInformationFlag}, devices[0].device.Capabilities)
 		assert.Equal(t, []da.Capability{capabilities.HasProductInformationFlag}, devices[1].device.Capabilities)
@@ -160,10 +168,16 @@ func TestZigbeeHasProductInformation_NodeEnumerationCallback(t *testing.T) {
 		mockDeviceStore.On("getDevice", devices[0].device.Identifier).Return(devices[0], true)
 		mockDeviceStore.On("getDevice", devices[1].device.Identifier).Return(devices[1], true)
 
+		var sequences []uint8
+		recordSequence := func(args mock.Arguments) {
+			sequences = append(sequences, args.Get(7).(uint8))
+		}
+
 		manufacturers := []string{"manu1", "manu2"}
 		products := []string{"product1", "product2"}
 
-		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, node.supportsAPSAck, zcl.BasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(0), uint8(1), []zcl.AttributeID{0x0004, 0x0005}).
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, node.supportsAPSAck, zcl.BasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(0), mock.Anything, []zcl.AttributeID{0x0004, 0x0005}).
+			Run(recordSequence).
 			Return([]global.ReadAttributeResponseRecord{
 				{
 					Identifier: 0x0004,
@@ -180,7 +194,8 @@ func TestZigbeeHasProductInformation_NodeEnumerationCallback(t *testing.T) {
 				},
 			}, nil)
 
-		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, node.supportsAPSAck, zcl.BasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(1), uint8(2), []zcl.AttributeID{0x0004, 0x0005}).
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, node.supportsAPSAck, zcl.BasicId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, zigbee.Endpoint(1), mock.Anything, []zcl.AttributeID{0x0004, 0x0005}).
+			Run(recordSequence).
 			Return([]global.ReadAttributeResponseRecord{
 				{
 					Identifier:    0x0004,
@@ -201,6 +216,7 @@ func TestZigbeeHasProductInformation_NodeEnumerationCallback(t *testing.T) {
 
 		err := zhpi.NodeEnumerationCallback(ctx, internalNodeEnumeration{node: node})
 		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint8{1, 2}, sequences)
 
 		assert.Equal(t, []da.Capability{capabilities.HasProductInformationFlag}, devices[0].device.Capabilities)
 		assert.Equal(t, []da.Capability{capabilities.HasProductInformationFlag}, devices[1].device.Capabilities)
@@ -0,0 +1,503 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/retry"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+)
+
+const (
+	IASZoneZoneState     = zcl.AttributeID(0x0000)
+	IASZoneZoneType      = zcl.AttributeID(0x0001)
+	IASZoneZoneStatus    = zcl.AttributeID(0x0002)
+	IASZoneIASCIEAddress = zcl.AttributeID(0x0010)
+)
+
+const (
+	ZoneEnrollResponseId           = zcl.CommandIdentifier(0x00)
+	ZoneStatusChangeNotificationId = zcl.CommandIdentifier(0x00)
+	ZoneEnrollRequestId            = zcl.CommandIdentifier(0x01)
+)
+
+// ZoneStatusChangeNotification is sent by an IAS Zone server when its zone status changes.
+type ZoneStatusChangeNotification struct {
+	ZoneStatus     uint16
+	ExtendedStatus uint8
+	ZoneID         uint8
+	Delay          uint16
+}
+
+// ZoneEnrollRequest is sent by an IAS Zone server to ask the CIE, whose address it has been given, to enroll it.
+type ZoneEnrollRequest struct {
+	ZoneType         uint16
+	ManufacturerCode uint16
+}
+
+// ZoneEnrollResponse is sent to an IAS Zone server to enroll it with the CIE, assigning it a zone ID.
+type ZoneEnrollResponse struct {
+	EnrollResponseCode uint8
+	ZoneID             uint8
+}
+
+// registerIASZoneCommands registers the IAS Zone cluster commands. The registry does not distinguish direction, and
+// ZoneStatusChangeNotification shares its identifier with ZoneEnrollResponse, so it is registered last so that it is
+// the command unmarshalled when a device notifies.
+func registerIASZoneCommands(cr *zcl.CommandRegistry) {
+	cr.RegisterLocal(zcl.IASZoneId, zigbee.NoManufacturer, ZoneEnrollResponseId, &ZoneEnrollResponse{})
+	cr.RegisterLocal(zcl.IASZoneId, zigbee.NoManufacturer, ZoneStatusChangeNotificationId, &ZoneStatusChangeNotification{})
+	cr.RegisterLocal(zcl.IASZoneId, zigbee.NoManufacturer, ZoneEnrollRequestId, &ZoneEnrollRequest{})
+}
+
+const (
+	iasZoneStateEnrolled         = uint8(0x01)
+	iasZoneEnrollResponseSuccess = uint8(0x00)
+)
+
+// iasZoneDefaultZoneID is assigned to every zone, as zda does not maintain a zone table.
+const iasZoneDefaultZoneID = uint8(0x00)
+
+const (
+	iasZoneStatusAlarm1        = uint16(1 << 0)
+	iasZoneStatusAlarm2        = uint16(1 << 1)
+	iasZoneStatusTamper        = uint16(1 << 2)
+	iasZoneStatusBattery       = uint16(1 << 3)
+	iasZoneStatusSupervision   = uint16(1 << 4)
+	iasZoneStatusRestore       = uint16(1 << 5)
+	iasZoneStatusTrouble       = uint16(1 << 6)
+	iasZoneStatusACMains       = uint16(1 << 7)
+	iasZoneStatusTest          = uint16(1 << 8)
+	iasZoneStatusBatteryDefect = uint16(1 << 9)
+
+	iasZoneAlarmMask = iasZoneStatusAlarm1 | iasZoneStatusAlarm2
+)
+
+// IASZoneType is the kind of security sensor a device is, as defined by the ZoneType attribute.
+type IASZoneType uint16
+
+const (
+	IASZoneTypeStandardCIE       = IASZoneType(0x0000)
+	IASZoneTypeMotionSensor      = IASZoneType(0x000d)
+	IASZoneTypeContactSwitch     = IASZoneType(0x0015)
+	IASZoneTypeFireSensor        = IASZoneType(0x0028)
+	IASZoneTypeWaterSensor       = IASZoneType(0x002a)
+	IASZoneTypeCOSensor          = IASZoneType(0x002b)
+	IASZoneTypePersonalEmergency = IASZoneType(0x002c)
+	IASZoneTypeVibrationSensor   = IASZoneType(0x002d)
+	IASZoneTypeRemoteControl     = IASZoneType(0x010f)
+	IASZoneTypeKeyFob            = IASZoneType(0x0115)
+	IASZoneTypeKeypad            = IASZoneType(0x021d)
+	IASZoneTypeWarningDevice     = IASZoneType(0x0225)
+	IASZoneTypeGlassBreakSensor  = IASZoneType(0x0226)
+	IASZoneTypeSecurityRepeater  = IASZoneType(0x0229)
+	IASZoneTypeUnknown           = IASZoneType(0xffff)
+)
+
+// IASZoneStatus is the decoded ZoneStatus bitmap of an IAS Zone. The meaning of Alarm1 and Alarm2 depends upon the
+// zone type, for example Alarm1 is opened on a contact switch and motion detected on a motion sensor.
+type IASZoneStatus struct {
+	Alarm1             bool
+	Alarm2             bool
+	Tamper             bool
+	BatteryLow         bool
+	SupervisionReports bool
+	RestoreReports     bool
+	Trouble            bool
+	ACMainsFault       bool
+	Test               bool
+	BatteryDefect      bool
+}
+
+// Alarmed returns true if either alarm is active.
+func (s IASZoneStatus) Alarmed() bool {
+	return s.Alarm1 || s.Alarm2
+}
+
+func decodeIASZoneStatus(status uint16) IASZoneStatus {
+	return IASZoneStatus{
+		Alarm1:             status&iasZoneStatusAlarm1 != 0,
+		Alarm2:             status&iasZoneStatusAlarm2 != 0,
+		Tamper:             status&iasZoneStatusTamper != 0,
+		BatteryLow:         status&iasZoneStatusBattery != 0,
+		SupervisionReports: status&iasZoneStatusSupervision != 0,
+		RestoreReports:     status&iasZoneStatusRestore != 0,
+		Trouble:            status&iasZoneStatusTrouble != 0,
+		ACMainsFault:       status&iasZoneStatusACMains != 0,
+		Test:               status&iasZoneStatusTest != 0,
+		BatteryDefect:      status&iasZoneStatusBatteryDefect != 0,
+	}
+}
+
+// IASZoneState is the type of an IAS Zone, its last known status and whether it has been enrolled with zda.
+type IASZoneState struct {
+	Type     IASZoneType
+	Status   IASZoneStatus
+	Enrolled bool
+}
+
+// IASZone is a capability which signifies that a device is a security sensor, such as a contact, motion or smoke
+// sensor.
+type IASZone interface {
+	// Status returns the type of the zone and its last known status.
+	Status(context.Context, da.Device) (IASZoneState, error)
+}
+
+// IASZoneStatusChanged is sent to inform consumers that the status of an IAS Zone has changed.
+type IASZoneStatusChanged struct {
+	// Device whose status changed.
+	Device da.Device
+	// Type of zone.
+	Type IASZoneType
+	// New status of the zone.
+	Status IASZoneStatus
+}
+
+// IASZoneAlarm is sent when an IAS Zone enters or leaves alarm, from either of its alarm bits.
+type IASZoneAlarm struct {
+	Device da.Device
+	Type   IASZoneType
+	Active bool
+}
+
+// IASZoneTamper is sent when an IAS Zone reports it has been tampered with, or that tampering has ended.
+type IASZoneTamper struct {
+	Device da.Device
+	Active bool
+}
+
+// IASZoneBatteryLow is sent when an IAS Zone reports its battery is low, or that it is no longer low.
+type IASZoneBatteryLow struct {
+	Device da.Device
+	Low    bool
+}
+
+// iasZoneState is the zone type, read during enumeration, and the last status received. statusReceived is false
+// until a status has been read or notified.
+type iasZoneState struct {
+	zoneType       IASZoneType
+	status         uint16
+	statusReceived bool
+	enrolled       bool
+}
+
+type ZigbeeIASZone struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+	nodeStore         nodeStore
+
+	zclCommunicatorCallbacks zclCommunicatorCallbacks
+	zclCommunicatorRequests  zclCommunicatorRequests
+	zclGlobalCommunicator    zclGlobalCommunicator
+
+	eventSender eventSender
+}
+
+func (z *ZigbeeIASZone) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+
+	z.zclCommunicatorCallbacks.AddCallback(z.zclCommunicatorCallbacks.NewMatch(func(address zigbee.IEEEAddress, appMsg zigbee.ApplicationMessage, zclMessage zcl.Message) bool {
+		_, canCast := zclMessage.Command.(*ZoneStatusChangeNotification)
+		return canCast
+	}, z.incomingZoneStatusChangeNotification))
+
+	z.zclCommunicatorCallbacks.AddCallback(z.zclCommunicatorCallbacks.NewMatch(func(address zigbee.IEEEAddress, appMsg zigbee.ApplicationMessage, zclMessage zcl.Message) bool {
+		_, canCast := zclMessage.Command.(*ZoneEnrollRequest)
+		return canCast
+	}, z.incomingZoneEnrollRequest))
+}
+
+func (z *ZigbeeIASZone) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, IASZoneFlag); found {
+			addCapability(&dev.device, IASZoneFlag)
+
+			if err := z.enroll(ctx, node, dev, endpoint, cluster); err != nil {
+				log.Printf("failed to enroll ias zone: %s", err)
+			}
+		} else {
+			removeCapability(&dev.device, IASZoneFlag)
+			dev.iasZoneState = iasZoneState{}
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// enroll performs the IAS Zone enrollment handshake, without which a zone will not send notifications. The gateway's
+// address is written as the CIE address, the zone's type, state and status are read, and if the zone is not already
+// enrolled an unsolicited Zone Enroll Response is sent. The node mutex must be held, and the device mutex held for writing, by the caller.
+func (z *ZigbeeIASZone) enroll(ctx context.Context, iNode *internalNode, iDevice *internalDevice, endpoint zigbee.Endpoint, cluster zigbee.ClusterID) error {
+	if err := z.writeCIEAddress(ctx, iNode, endpoint, cluster); err != nil {
+		return fmt.Errorf("failed to write cie address: %w", err)
+	}
+
+	response, endpoint, err := readAttributesWithEndpointFallback(ctx, z.zclGlobalCommunicator, z.eventSender, iNode, iDevice, IASZoneFlag, endpoint, cluster, []zcl.AttributeID{IASZoneZoneState, IASZoneZoneType, IASZoneZoneStatus})
+
+	if err != nil {
+		return fmt.Errorf("failed to read zone attributes: %w", err)
+	}
+
+	results := parseReadAttributeResponse(response)
+
+	iDevice.iasZoneState.zoneType = IASZoneTypeUnknown
+
+	if zoneType, ok := results.uint16Value(IASZoneZoneType); ok {
+		iDevice.iasZoneState.zoneType = IASZoneType(zoneType)
+	}
+
+	if status, ok := results.uintValue(IASZoneZoneStatus); ok {
+		z.setStatus(iDevice, uint16(status))
+	}
+
+	if zoneState, ok := results.uint8Value(IASZoneZoneState); ok && zoneState == iasZoneStateEnrolled {
+		iDevice.iasZoneState.enrolled = true
+		return nil
+	}
+
+	if err := z.sendEnrollResponse(ctx, iNode, endpoint, cluster); err != nil {
+		return fmt.Errorf("failed to send zone enroll response: %w", err)
+	}
+
+	iDevice.iasZoneState.enrolled = true
+	return nil
+}
+
+// writeCIEAddress writes the gateway's address to the zone, the node mutex must be held by the caller.
+func (z *ZigbeeIASZone) writeCIEAddress(ctx context.Context, iNode *internalNode, endpoint zigbee.Endpoint, cluster zigbee.ClusterID) error {
+	cieAddress, ok := z.gateway.Self().Identifier.(zigbee.IEEEAddress)
+
+	if !ok {
+		return fmt.Errorf("gateway does not have an ieee address")
+	}
+
+	return retry.Retry(ctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
+		response, err := z.zclCommunicatorRequests.RequestResponse(ctx, iNode.ieeeAddress, iNode.supportsAPSAck, zcl.Message{
+			FrameType:           zcl.FrameGlobal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: iNode.nextTransactionSequence(),
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           cluster,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: endpoint,
+			Command: &global.WriteAttributes{
+				Records: []global.WriteAttributesRecord{
+					{
+						Identifier:    IASZoneIASCIEAddress,
+						DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeIEEEAddress, Value: cieAddress},
+					},
+				},
+			},
+		})
+
+		if err != nil {
+			return err
+		}
+
+		writeResponse, ok := response.Command.(*global.WriteAttributesResponse)
+
+		if !ok {
+			return fmt.Errorf("write attributes received command back which was not WriteAttributesResponse")
+		}
+
+		for _, record := range writeResponse.Records {
+			if record.Status != 0 {
+				return fmt.Errorf("device rejected write of cie address: status %d", record.Status)
+			}
+		}
+
+		return nil
+	})
+}
+
+// sendEnrollResponse sends a successful Zone Enroll Response to the zone, the node mutex must be held by the caller.
+func (z *ZigbeeIASZone) sendEnrollResponse(ctx context.Context, iNode *internalNode, endpoint zigbee.Endpoint, cluster zigbee.ClusterID) error {
+	return retry.Retry(ctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
+		return z.zclCommunicatorRequests.Request(ctx, iNode.ieeeAddress, iNode.supportsAPSAck, zcl.Message{
+			FrameType:           zcl.FrameLocal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: iNode.nextTransactionSequence(),
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           cluster,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: endpoint,
+			Command:             &ZoneEnrollResponse{EnrollResponseCode: iasZoneEnrollResponseSuccess, ZoneID: iasZoneDefaultZoneID},
+		})
+	})
+}
+
+// setStatus records the zone status of the device, sending IASZoneStatusChanged if it has changed, along with
+// IASZoneAlarm, IASZoneTamper and IASZoneBatteryLow if those bits have changed. The device mutex must be held for
+// writing by the caller.
+func (z *ZigbeeIASZone) setStatus(iDevice *internalDevice, status uint16) {
+	markCapabilityUpdated(iDevice, IASZoneFlag)
+
+	previous := iDevice.iasZoneState
+	iDevice.iasZoneState.status = status
+	iDevice.iasZoneState.statusReceived = true
+
+	if previous.statusReceived && previous.status == status {
+		return
+	}
+
+	zoneType := iDevice.iasZoneState.zoneType
+	decoded := decodeIASZoneStatus(status)
+
+	z.eventSender.sendEvent(IASZoneStatusChanged{Device: iDevice.device, Type: zoneType, Status: decoded})
+
+	changed := status ^ previous.status
+
+	if !previous.statusReceived {
+		changed = status
+	}
+
+	if changed&iasZoneAlarmMask != 0 {
+		z.eventSender.sendEvent(IASZoneAlarm{Device: iDevice.device, Type: zoneType, Active: decoded.Alarmed()})
+	}
+
+	if changed&iasZoneStatusTamper != 0 {
+		z.eventSender.sendEvent(IASZoneTamper{Device: iDevice.device, Active: decoded.Tamper})
+	}
+
+	if changed&iasZoneStatusBattery != 0 {
+		z.eventSender.sendEvent(IASZoneBatteryLow{Device: iDevice.device, Low: decoded.BatteryLow})
+	}
+}
+
+func (z *ZigbeeIASZone) incomingZoneStatusChangeNotification(source communicator.MessageWithSource) {
+	node, found := z.nodeStore.getNode(source.SourceAddress)
+
+	if !found {
+		return
+	}
+
+	notification := source.Message.Command.(*ZoneStatusChangeNotification)
+
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+
+	for _, device := range node.devices {
+		device.mutex.Lock()
+
+		cluster, _ := clusterForCapability(device, IASZoneFlag)
+
+		if isEndpointInSlice(device.endpoints, source.Message.SourceEndpoint) && cluster == source.Message.ClusterID && device.device.HasCapability(IASZoneFlag) {
+			z.setStatus(device, notification.ZoneStatus)
+		}
+
+		device.mutex.Unlock()
+	}
+}
+
+// incomingZoneEnrollRequest responds to zones which ask to be enrolled, such as those which only request enrollment
+// after their CIE address is written, or after they have been power cycled.
+func (z *ZigbeeIASZone) incomingZoneEnrollRequest(source communicator.MessageWithSource) {
+	node, found := z.nodeStore.getNode(source.SourceAddress)
+
+	if !found {
+		return
+	}
+
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+
+	var zones []*internalDevice
+
+	for _, device := range node.devices {
+		device.mutex.RLock()
+
+		cluster, _ := clusterForCapability(device, IASZoneFlag)
+
+		if isEndpointInSlice(device.endpoints, source.Message.SourceEndpoint) && cluster == source.Message.ClusterID && device.device.HasCapability(IASZoneFlag) {
+			zones = append(zones, device)
+		}
+
+		device.mutex.RUnlock()
+	}
+
+	if len(zones) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultNetworkTimeout*DefaultNetworkRetries)
+	defer cancel()
+
+	if err := z.sendEnrollResponse(ctx, node, source.Message.SourceEndpoint, source.Message.ClusterID); err != nil {
+		log.Printf("failed to send zone enroll response: %s", err)
+		return
+	}
+
+	for _, device := range zones {
+		device.mutex.Lock()
+		device.iasZoneState.enrolled = true
+		device.mutex.Unlock()
+	}
+}
+
+func (z *ZigbeeIASZone) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(IASZoneFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+// Status returns the type of the zone and its last known status, NoReadingAvailable is returned if no status has
+// been received from the zone, or it has become stale after a stale timeout was set with SetStaleTimeout.
+func (z *ZigbeeIASZone) Status(ctx context.Context, device da.Device) (IASZoneState, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return IASZoneState{}, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	state := iDevice.iasZoneState
+
+	if !state.statusReceived || capabilityUnavailable(iDevice, IASZoneFlag) {
+		return IASZoneState{}, NoReadingAvailable
+	}
+
+	return IASZoneState{Type: state.zoneType, Status: decodeIASZoneStatus(state.status), Enrolled: state.enrolled}, nil
+}
+
+// stateValues returns whether the zone is alarmed, tampered with or has a low battery.
+func (z *ZigbeeIASZone) stateValues(ctx context.Context, device da.Device) ([]StateValue, error) {
+	state, err := z.Status(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+
+	return []StateValue{
+		{Name: "alarm", Value: state.Status.Alarmed()},
+		{Name: "tamper", Value: state.Status.Tamper},
+		{Name: "battery_low", Value: state.Status.BatteryLow},
+	}, nil
+}
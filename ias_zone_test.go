@@ -0,0 +1,388 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZigbeeIASZone_Contract(t *testing.T) {
+	t.Run("can be assigned to an IASZone", func(t *testing.T) {
+		assert.Implements(t, (*IASZone)(nil), new(ZigbeeIASZone))
+	})
+}
+
+func Test_registerIASZoneCommands(t *testing.T) {
+	t.Run("unmarshals command 0x00 as the status change notification", func(t *testing.T) {
+		cr := zcl.NewCommandRegistry()
+		registerIASZoneCommands(cr)
+
+		command, err := cr.GetLocalCommand(zcl.IASZoneId, zigbee.NoManufacturer, 0x00)
+		assert.NoError(t, err)
+		assert.IsType(t, &ZoneStatusChangeNotification{}, command)
+
+		identifier, err := cr.GetLocalCommandIdentifier(zcl.IASZoneId, zigbee.NoManufacturer, &ZoneEnrollResponse{})
+		assert.NoError(t, err)
+		assert.Equal(t, ZoneEnrollResponseId, identifier)
+	})
+}
+
+func Test_decodeIASZoneStatus(t *testing.T) {
+	t.Run("decodes each bit of the zone status", func(t *testing.T) {
+		assert.Equal(t, IASZoneStatus{Alarm1: true, Tamper: true, BatteryLow: true}, decodeIASZoneStatus(0x000d))
+		assert.Equal(t, IASZoneStatus{Alarm2: true, ACMainsFault: true, BatteryDefect: true}, decodeIASZoneStatus(0x0282))
+		assert.True(t, decodeIASZoneStatus(0x0002).Alarmed())
+		assert.False(t, decodeIASZoneStatus(0x0004).Alarmed())
+	})
+}
+
+func generateTestIASZoneNodeAndDevice() (*internalNode, *internalDevice) {
+	node, device := generateTestNodeAndDevice()
+
+	deviceEndpoint := node.endpoints[0]
+	endpointDescription := node.endpointDescriptions[deviceEndpoint]
+	endpointDescription.InClusterList = []zigbee.ClusterID{zcl.IASZoneId}
+	node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+	return node, device
+}
+
+func expectCIEAddressWrite(mockZclCommunicatorRequests *mockZclCommunicatorRequests, node *internalNode, cieAddress zigbee.IEEEAddress) {
+	mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, node.ieeeAddress, false, zcl.Message{
+		FrameType:           zcl.FrameGlobal,
+		Direction:           zcl.ClientToServer,
+		TransactionSequence: 1,
+		Manufacturer:        zigbee.NoManufacturer,
+		ClusterID:           zcl.IASZoneId,
+		SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+		DestinationEndpoint: node.endpoints[0],
+		Command: &global.WriteAttributes{
+			Records: []global.WriteAttributesRecord{
+				{
+					Identifier:    IASZoneIASCIEAddress,
+					DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeIEEEAddress, Value: cieAddress},
+				},
+			},
+		},
+	}).Return(zcl.Message{Command: &global.WriteAttributesResponse{Records: []global.WriteAttributesResponseRecord{{Status: 0}}}}, nil)
+}
+
+func TestZigbeeIASZone_NodeEnumerationCallback(t *testing.T) {
+	cieAddress := zigbee.IEEEAddress(0x00112233445566)
+
+	t.Run("adds capability, writes the cie address, reads the zone and enrolls it", func(t *testing.T) {
+		mockGateway := mockGateway{}
+		mockGateway.On("Self").Return(da.Device{Identifier: cieAddress})
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zi := ZigbeeIASZone{
+			gateway:                 &mockGateway,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+			zclGlobalCommunicator:   &mockZclGlobalCommunicator,
+			eventSender:             &mockEventSender,
+		}
+
+		node, device := generateTestIASZoneNodeAndDevice()
+
+		expectCIEAddressWrite(&mockZclCommunicatorRequests, node, cieAddress)
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.IASZoneId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], uint8(2), []zcl.AttributeID{IASZoneZoneState, IASZoneZoneType, IASZoneZoneStatus}).Return([]global.ReadAttributeResponseRecord{
+			enum8AttributeRecord(IASZoneZoneState, 0x00),
+			{Identifier: IASZoneZoneType, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeEnum16, Value: uint16(IASZoneTypeContactSwitch)}},
+			{Identifier: IASZoneZoneStatus, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeBitmap16, Value: uint64(0x0000)}},
+		}, nil)
+
+		mockZclCommunicatorRequests.On("Request", mock.Anything, node.ieeeAddress, false, zcl.Message{
+			FrameType:           zcl.FrameLocal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: 3,
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           zcl.IASZoneId,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: node.endpoints[0],
+			Command:             &ZoneEnrollResponse{EnrollResponseCode: 0x00, ZoneID: iasZoneDefaultZoneID},
+		}).Return(nil)
+
+		mockEventSender.On("sendEvent", mock.MatchedBy(func(e IASZoneStatusChanged) bool {
+			return e.Device.Identifier == device.device.Identifier && e.Type == IASZoneTypeContactSwitch && e.Status == IASZoneStatus{}
+		})).Once()
+
+		err := zi.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(IASZoneFlag))
+		assert.Equal(t, iasZoneState{zoneType: IASZoneTypeContactSwitch, statusReceived: true, enrolled: true}, device.iasZoneState)
+	})
+
+	t.Run("does not send an enroll response if the zone is already enrolled", func(t *testing.T) {
+		mockGateway := mockGateway{}
+		mockGateway.On("Self").Return(da.Device{Identifier: cieAddress})
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		mockEventSender.On("sendEvent", mock.Anything)
+
+		zi := ZigbeeIASZone{
+			gateway:                 &mockGateway,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+			zclGlobalCommunicator:   &mockZclGlobalCommunicator,
+			eventSender:             &mockEventSender,
+		}
+
+		node, device := generateTestIASZoneNodeAndDevice()
+
+		expectCIEAddressWrite(&mockZclCommunicatorRequests, node, cieAddress)
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.IASZoneId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], uint8(2), []zcl.AttributeID{IASZoneZoneState, IASZoneZoneType, IASZoneZoneStatus}).Return([]global.ReadAttributeResponseRecord{
+			enum8AttributeRecord(IASZoneZoneState, iasZoneStateEnrolled),
+		}, nil)
+
+		err := zi.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.Equal(t, iasZoneState{zoneType: IASZoneTypeUnknown, enrolled: true}, device.iasZoneState)
+	})
+
+	t.Run("removes capability from device without cluster", func(t *testing.T) {
+		zi := ZigbeeIASZone{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{IASZoneFlag}
+		device.iasZoneState = iasZoneState{enrolled: true}
+
+		err := zi.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(IASZoneFlag))
+		assert.Equal(t, iasZoneState{}, device.iasZoneState)
+	})
+}
+
+func TestZigbeeIASZone_incomingZoneStatusChangeNotification(t *testing.T) {
+	t.Run("updates the status, sending events for the alarm, tamper and battery bits which changed", func(t *testing.T) {
+		mockNodeStore := mockNodeStore{}
+		defer mockNodeStore.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zi := ZigbeeIASZone{
+			nodeStore:   &mockNodeStore,
+			eventSender: &mockEventSender,
+		}
+
+		node, device := generateTestIASZoneNodeAndDevice()
+		device.device.Capabilities = []da.Capability{IASZoneFlag}
+		device.iasZoneState = iasZoneState{zoneType: IASZoneTypeMotionSensor, status: iasZoneStatusBattery, statusReceived: true}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+
+		status := IASZoneStatus{Alarm1: true, Tamper: true}
+
+		mockEventSender.On("sendEvent", IASZoneStatusChanged{Device: device.device, Type: IASZoneTypeMotionSensor, Status: status}).Once()
+		mockEventSender.On("sendEvent", IASZoneAlarm{Device: device.device, Type: IASZoneTypeMotionSensor, Active: true}).Once()
+		mockEventSender.On("sendEvent", IASZoneTamper{Device: device.device, Active: true}).Once()
+		mockEventSender.On("sendEvent", IASZoneBatteryLow{Device: device.device, Low: false}).Once()
+
+		notify := func() {
+			zi.incomingZoneStatusChangeNotification(communicator.MessageWithSource{
+				SourceAddress: node.ieeeAddress,
+				Message: zcl.Message{
+					ClusterID:      zcl.IASZoneId,
+					SourceEndpoint: node.endpoints[0],
+					Command:        &ZoneStatusChangeNotification{ZoneStatus: iasZoneStatusAlarm1 | iasZoneStatusTamper},
+				},
+			})
+		}
+
+		notify()
+		notify()
+
+		assert.Equal(t, iasZoneStatusAlarm1|iasZoneStatusTamper, device.iasZoneState.status)
+	})
+
+	t.Run("notifications from a cluster which does not back the capability on the device are ignored", func(t *testing.T) {
+		mockNodeStore := mockNodeStore{}
+		defer mockNodeStore.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zi := ZigbeeIASZone{
+			nodeStore:   &mockNodeStore,
+			eventSender: &mockEventSender,
+		}
+
+		node, device := generateTestIASZoneNodeAndDevice()
+		device.device.Capabilities = []da.Capability{IASZoneFlag}
+		device.clusterRemaps = map[da.Capability]zigbee.ClusterID{IASZoneFlag: 0xfc00}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+
+		zi.incomingZoneStatusChangeNotification(communicator.MessageWithSource{
+			SourceAddress: node.ieeeAddress,
+			Message: zcl.Message{
+				ClusterID:      zcl.IASZoneId,
+				SourceEndpoint: node.endpoints[0],
+				Command:        &ZoneStatusChangeNotification{ZoneStatus: iasZoneStatusAlarm1},
+			},
+		})
+
+		assert.False(t, device.iasZoneState.statusReceived)
+	})
+}
+
+func TestZigbeeIASZone_incomingZoneEnrollRequest(t *testing.T) {
+	t.Run("responds to the zone and marks it as enrolled", func(t *testing.T) {
+		mockNodeStore := mockNodeStore{}
+		defer mockNodeStore.AssertExpectations(t)
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zi := ZigbeeIASZone{
+			nodeStore:               &mockNodeStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+
+		node, device := generateTestIASZoneNodeAndDevice()
+		device.device.Capabilities = []da.Capability{IASZoneFlag}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+		mockZclCommunicatorRequests.On("Request", mock.Anything, node.ieeeAddress, false, mock.MatchedBy(func(m zcl.Message) bool {
+			response, ok := m.Command.(*ZoneEnrollResponse)
+			return ok && m.DestinationEndpoint == node.endpoints[0] && response.EnrollResponseCode == iasZoneEnrollResponseSuccess
+		})).Return(nil)
+
+		zi.incomingZoneEnrollRequest(communicator.MessageWithSource{
+			SourceAddress: node.ieeeAddress,
+			Message: zcl.Message{
+				ClusterID:      zcl.IASZoneId,
+				SourceEndpoint: node.endpoints[0],
+				Command:        &ZoneEnrollRequest{ZoneType: uint16(IASZoneTypeContactSwitch)},
+			},
+		})
+
+		assert.True(t, device.iasZoneState.enrolled)
+	})
+
+	t.Run("responds on the cluster which backs the capability on the device", func(t *testing.T) {
+		mockNodeStore := mockNodeStore{}
+		defer mockNodeStore.AssertExpectations(t)
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zi := ZigbeeIASZone{
+			nodeStore:               &mockNodeStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+
+		node, device := generateTestIASZoneNodeAndDevice()
+		device.device.Capabilities = []da.Capability{IASZoneFlag}
+		device.clusterRemaps = map[da.Capability]zigbee.ClusterID{IASZoneFlag: 0xfc00}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+		mockZclCommunicatorRequests.On("Request", mock.Anything, node.ieeeAddress, false, mock.MatchedBy(func(m zcl.Message) bool {
+			_, ok := m.Command.(*ZoneEnrollResponse)
+			return ok && m.ClusterID == 0xfc00 && m.DestinationEndpoint == node.endpoints[0]
+		})).Return(nil)
+
+		zi.incomingZoneEnrollRequest(communicator.MessageWithSource{
+			SourceAddress: node.ieeeAddress,
+			Message: zcl.Message{
+				ClusterID:      0xfc00,
+				SourceEndpoint: node.endpoints[0],
+				Command:        &ZoneEnrollRequest{ZoneType: uint16(IASZoneTypeContactSwitch)},
+			},
+		})
+
+		assert.True(t, device.iasZoneState.enrolled)
+	})
+
+	t.Run("does not respond to requests from a cluster which does not back the capability on the device", func(t *testing.T) {
+		mockNodeStore := mockNodeStore{}
+		defer mockNodeStore.AssertExpectations(t)
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zi := ZigbeeIASZone{
+			nodeStore:               &mockNodeStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+
+		node, device := generateTestIASZoneNodeAndDevice()
+		device.device.Capabilities = []da.Capability{IASZoneFlag}
+		device.clusterRemaps = map[da.Capability]zigbee.ClusterID{IASZoneFlag: 0xfc00}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+
+		zi.incomingZoneEnrollRequest(communicator.MessageWithSource{
+			SourceAddress: node.ieeeAddress,
+			Message: zcl.Message{
+				ClusterID:      zcl.IASZoneId,
+				SourceEndpoint: node.endpoints[0],
+				Command:        &ZoneEnrollRequest{ZoneType: uint16(IASZoneTypeContactSwitch)},
+			},
+		})
+
+		assert.False(t, device.iasZoneState.enrolled)
+	})
+}
+
+func TestZigbeeIASZone_Status(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zi := ZigbeeIASZone{gateway: &mockGateway{}}
+
+		_, err := zi.Status(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns error if device does not support it", func(t *testing.T) {
+		zi := ZigbeeIASZone{gateway: &mockGateway{}}
+
+		_, err := zi.Status(context.Background(), da.Device{Gateway: zi.gateway})
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns the zone type and decoded status, or no reading available if none has been received", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zi := ZigbeeIASZone{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zi.gateway
+		device.device.Capabilities = []da.Capability{IASZoneFlag}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		_, err := zi.Status(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+
+		device.iasZoneState = iasZoneState{zoneType: IASZoneTypeWaterSensor, status: iasZoneStatusAlarm1, statusReceived: true, enrolled: true}
+
+		state, err := zi.Status(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, IASZoneState{Type: IASZoneTypeWaterSensor, Status: IASZoneStatus{Alarm1: true}, Enrolled: true}, state)
+	})
+}
@@ -0,0 +1,224 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"time"
+)
+
+const (
+	IdentifyId              = zcl.CommandIdentifier(0x00)
+	IdentifyQueryId         = zcl.CommandIdentifier(0x01)
+	IdentifyQueryResponseId = zcl.CommandIdentifier(0x00)
+)
+
+// zclStatusSuccess is returned in a default response when the command was processed successfully.
+const zclStatusSuccess = uint8(0x00)
+
+// MaximumIdentifyDuration is the longest a device can be asked to identify for, durations are sent in whole seconds.
+const MaximumIdentifyDuration = 0xffff * time.Second
+
+// Identify is sent to an Identify cluster server to make the device identify itself, such as by blinking, for the
+// identify time in seconds. An identify time of 0 stops the device identifying.
+type Identify struct {
+	IdentifyTime uint16
+}
+
+// IdentifyQuery is sent to an Identify cluster server to ask how long it will continue identifying for.
+type IdentifyQuery struct{}
+
+// IdentifyQueryResponse is sent by an Identify cluster server in response to IdentifyQuery, with the remaining
+// identify time in seconds.
+type IdentifyQueryResponse struct {
+	Timeout uint16
+}
+
+// registerIdentifyCommands registers the Identify cluster commands. The registry does not distinguish direction, and
+// IdentifyQueryResponse shares its identifier with Identify, so it is registered last so that it is the command
+// unmarshalled when a device responds.
+func registerIdentifyCommands(cr *zcl.CommandRegistry) {
+	cr.RegisterLocal(zcl.IdentifyId, zigbee.NoManufacturer, IdentifyId, &Identify{})
+	cr.RegisterLocal(zcl.IdentifyId, zigbee.NoManufacturer, IdentifyQueryId, &IdentifyQuery{})
+	cr.RegisterLocal(zcl.IdentifyId, zigbee.NoManufacturer, IdentifyQueryResponseId, &IdentifyQueryResponse{})
+}
+
+// Identifiable is a capability which signifies that a device can identify itself, allowing it to be physically located.
+type Identifiable interface {
+	// Identify makes the device identify itself for the duration, a duration of 0 stops it identifying.
+	Identify(context.Context, da.Device, time.Duration) error
+	// IdentifyQuery returns how long the device will continue identifying for.
+	IdentifyQuery(context.Context, da.Device) (time.Duration, error)
+}
+
+type ZigbeeIdentify struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+
+	zclCommunicatorRequests zclCommunicatorRequests
+}
+
+func (z *ZigbeeIdentify) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+}
+
+func (z *ZigbeeIdentify) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if _, _, found := findEndpointForCapability(node, dev, IdentifyFlag); found {
+			addCapability(&dev.device, IdentifyFlag)
+		} else {
+			removeCapability(&dev.device, IdentifyFlag)
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (z *ZigbeeIdentify) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(IdentifyFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+// validateIdentifyDuration checks the duration can be represented by the Identify command.
+func validateIdentifyDuration(duration time.Duration) error {
+	if duration < 0 || duration > MaximumIdentifyDuration {
+		return fmt.Errorf("identify duration %s is outside of the range 0 to %s", duration, MaximumIdentifyDuration)
+	}
+
+	return nil
+}
+
+func (z *ZigbeeIdentify) validateArguments(iDevice *internalDevice, operation string, args []interface{}) error {
+	if operation == "Identify" {
+		return validateIdentifyDuration(args[0].(time.Duration))
+	}
+
+	return nil
+}
+
+// message builds a message for the Identify cluster of the device. The node and device mutexes must be held by the
+// caller.
+func (z *ZigbeeIdentify) message(iNode *internalNode, iDevice *internalDevice, command interface{}) (zcl.Message, error) {
+	endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, IdentifyFlag)
+
+	if !found {
+		return zcl.Message{}, fmt.Errorf("unable to find identify cluster on zigbee device in zda")
+	}
+
+	return zcl.Message{
+		FrameType:           zcl.FrameLocal,
+		Direction:           zcl.ClientToServer,
+		TransactionSequence: iNode.nextTransactionSequence(),
+		Manufacturer:        zigbee.NoManufacturer,
+		ClusterID:           cluster,
+		SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+		DestinationEndpoint: endpoint,
+		Command:             command,
+	}, nil
+}
+
+// Identify makes the device identify itself for the duration, rounded to the nearest second. A duration of 0 stops the
+// device identifying.
+func (z *ZigbeeIdentify) Identify(ctx context.Context, device da.Device, duration time.Duration) error {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return err
+	}
+
+	if err := validateIdentifyDuration(duration); err != nil {
+		return err
+	}
+
+	iNode := iDevice.node
+
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	seconds := uint16((duration + time.Second/2) / time.Second)
+
+	zclMsg, err := z.message(iNode, iDevice, &Identify{IdentifyTime: seconds})
+	if err != nil {
+		return err
+	}
+
+	cmdCtx, cancel := commandContext(ctx, iDevice)
+	defer cancel()
+
+	return z.zclCommunicatorRequests.Request(cmdCtx, iNode.ieeeAddress, iNode.supportsAPSAck, zclMsg)
+}
+
+// IdentifyQuery returns how long the device will continue identifying for. Devices which are not identifying may
+// respond with a successful Default Response, which is returned as 0, or may not respond to the query, in which case
+// the command timeout error is returned.
+func (z *ZigbeeIdentify) IdentifyQuery(ctx context.Context, device da.Device) (time.Duration, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return 0, err
+	}
+
+	iNode := iDevice.node
+
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	zclMsg, err := z.message(iNode, iDevice, &IdentifyQuery{})
+	if err != nil {
+		return 0, err
+	}
+
+	cmdCtx, cancel := commandContext(ctx, iDevice)
+	defer cancel()
+
+	response, err := z.zclCommunicatorRequests.RequestResponse(cmdCtx, iNode.ieeeAddress, iNode.supportsAPSAck, zclMsg)
+
+	if err != nil {
+		return 0, err
+	}
+
+	switch command := response.Command.(type) {
+	case *IdentifyQueryResponse:
+		return time.Duration(command.Timeout) * time.Second, nil
+	case *global.DefaultResponse:
+		if command.Status != zclStatusSuccess {
+			return 0, fmt.Errorf("device responded with default response: status %d", command.Status)
+		}
+
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("identify query received command back which was not IdentifyQueryResponse")
+	}
+}
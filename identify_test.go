@@ -0,0 +1,218 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestZigbeeIdentify_Contract(t *testing.T) {
+	t.Run("can be assigned to an Identifiable", func(t *testing.T) {
+		assert.Implements(t, (*Identifiable)(nil), new(ZigbeeIdentify))
+	})
+}
+
+func Test_registerIdentifyCommands(t *testing.T) {
+	t.Run("unmarshals command 0x00 as the query response", func(t *testing.T) {
+		cr := zcl.NewCommandRegistry()
+		registerIdentifyCommands(cr)
+
+		command, err := cr.GetLocalCommand(zcl.IdentifyId, zigbee.NoManufacturer, 0x00)
+		assert.NoError(t, err)
+		assert.IsType(t, &IdentifyQueryResponse{}, command)
+
+		identifier, err := cr.GetLocalCommandIdentifier(zcl.IdentifyId, zigbee.NoManufacturer, &Identify{})
+		assert.NoError(t, err)
+		assert.Equal(t, IdentifyId, identifier)
+	})
+}
+
+func TestZigbeeIdentify_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with cluster", func(t *testing.T) {
+		zi := ZigbeeIdentify{}
+
+		node, device := generateTestNodeAndDevice()
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.IdentifyId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		err := zi.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(IdentifyFlag))
+	})
+
+	t.Run("removes capability from device without cluster", func(t *testing.T) {
+		zi := ZigbeeIdentify{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{IdentifyFlag}
+
+		err := zi.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(IdentifyFlag))
+	})
+}
+
+func generateTestIdentifyDevice(zi *ZigbeeIdentify, mockDeviceStore *mockDeviceStore) (*internalNode, *internalDevice) {
+	node, device := generateTestNodeAndDevice()
+	device.device.Gateway = zi.gateway
+	device.device.Capabilities = []da.Capability{IdentifyFlag}
+
+	deviceEndpoint := node.endpoints[0]
+	endpointDescription := node.endpointDescriptions[deviceEndpoint]
+	endpointDescription.InClusterList = []zigbee.ClusterID{zcl.IdentifyId}
+	node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+	mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+	return node, device
+}
+
+func TestZigbeeIdentify_Identify(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zi := ZigbeeIdentify{gateway: &mockGateway{}}
+
+		err := zi.Identify(context.Background(), da.Device{}, time.Second)
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns error if device does not have capability", func(t *testing.T) {
+		zi := ZigbeeIdentify{gateway: &mockGateway{}}
+
+		err := zi.Identify(context.Background(), da.Device{Gateway: zi.gateway}, time.Second)
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns error if duration is out of range", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zi := ZigbeeIdentify{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+		_, device := generateTestIdentifyDevice(&zi, &mockDeviceStore)
+
+		assert.Error(t, zi.Identify(context.Background(), device.device, -time.Second))
+		assert.Error(t, zi.Identify(context.Background(), device.device, MaximumIdentifyDuration+time.Second))
+	})
+
+	t.Run("sends Identify command with the duration in seconds", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zi := ZigbeeIdentify{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		node, device := generateTestIdentifyDevice(&zi, &mockDeviceStore)
+
+		expectedRequest := zcl.Message{
+			FrameType:           zcl.FrameLocal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: 1,
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           zcl.IdentifyId,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: node.endpoints[0],
+			Command:             &Identify{IdentifyTime: 30},
+		}
+		mockZclCommunicatorRequests.On("Request", mock.Anything, node.ieeeAddress, false, expectedRequest).Return(nil)
+
+		err := zi.Identify(context.Background(), device.device, 30*time.Second)
+		assert.NoError(t, err)
+	})
+}
+
+func TestZigbeeIdentify_IdentifyQuery(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zi := ZigbeeIdentify{gateway: &mockGateway{}}
+
+		_, err := zi.IdentifyQuery(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("sends Identify Query and returns the remaining time from the response", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zi := ZigbeeIdentify{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		node, device := generateTestIdentifyDevice(&zi, &mockDeviceStore)
+
+		expectedRequest := zcl.Message{
+			FrameType:           zcl.FrameLocal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: 1,
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           zcl.IdentifyId,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: node.endpoints[0],
+			Command:             &IdentifyQuery{},
+		}
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, node.ieeeAddress, false, expectedRequest).Return(zcl.Message{Command: &IdentifyQueryResponse{Timeout: 12}}, nil)
+
+		remaining, err := zi.IdentifyQuery(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, 12*time.Second, remaining)
+	})
+
+	t.Run("returns 0 if the device responds with a successful Default Response", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zi := ZigbeeIdentify{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		_, device := generateTestIdentifyDevice(&zi, &mockDeviceStore)
+
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(zcl.Message{Command: &global.DefaultResponse{CommandIdentifier: uint8(IdentifyQueryId), Status: zclStatusSuccess}}, nil).Once()
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(zcl.Message{Command: &global.DefaultResponse{CommandIdentifier: uint8(IdentifyQueryId), Status: 0x81}}, nil).Once()
+
+		remaining, err := zi.IdentifyQuery(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Duration(0), remaining)
+
+		_, err = zi.IdentifyQuery(context.Background(), device.device)
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if the response is not an Identify Query Response", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zi := ZigbeeIdentify{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		_, device := generateTestIdentifyDevice(&zi, &mockDeviceStore)
+
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(zcl.Message{Command: &Identify{}}, nil)
+
+		_, err := zi.IdentifyQuery(context.Background(), device.device)
+		assert.Error(t, err)
+	})
+}
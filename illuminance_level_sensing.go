@@ -0,0 +1,203 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/retry"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+)
+
+const (
+	IlluminanceLevelStatus = zcl.AttributeID(0x0000)
+	IlluminanceTargetLevel = zcl.AttributeID(0x0010)
+)
+
+const illuminanceLevelMaximumReportInterval = 300
+
+// LevelStatus is the qualitative illuminance reported by a device relative to its target level.
+type LevelStatus uint8
+
+const (
+	IlluminanceOnTarget    LevelStatus = 0x00
+	IlluminanceBelowTarget LevelStatus = 0x01
+	IlluminanceAboveTarget LevelStatus = 0x02
+)
+
+// IlluminanceLevelSensing is a capability which signifies that a device reports illuminance relative to a target
+// level, rather than a quantitative measurement.
+type IlluminanceLevelSensing interface {
+	// LevelStatus returns the last known level status of the device.
+	LevelStatus(context.Context, da.Device) (LevelStatus, error)
+
+	// TargetLevel returns the illuminance target level configured on the device.
+	TargetLevel(context.Context, da.Device) (uint16, error)
+}
+
+// IlluminanceLevelStatusChanged is sent to inform consumers that a devices level status has changed.
+type IlluminanceLevelStatusChanged struct {
+	// Device whose level status has changed.
+	Device da.Device
+	// New level status of the device.
+	LevelStatus LevelStatus
+}
+
+type ZigbeeIlluminanceLevelSensingState struct {
+	LevelStatus LevelStatus
+	TargetLevel uint16
+}
+
+type ZigbeeIlluminanceLevelSensing struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+	nodeStore         nodeStore
+
+	zclCommunicatorCallbacks zclCommunicatorCallbacks
+	zclGlobalCommunicator    zclGlobalCommunicator
+
+	nodeBinder  zigbee.NodeBinder
+	eventSender eventSender
+}
+
+func (z *ZigbeeIlluminanceLevelSensing) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+
+	z.zclCommunicatorCallbacks.AddCallback(z.zclCommunicatorCallbacks.NewMatch(func(address zigbee.IEEEAddress, appMsg zigbee.ApplicationMessage, zclMessage zcl.Message) bool {
+		_, canCast := zclMessage.Command.(*global.ReportAttributes)
+		return canCast
+	}, z.incomingReportAttributes))
+}
+
+func (z *ZigbeeIlluminanceLevelSensing) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, IlluminanceLevelSensingFlag); found {
+			addCapability(&dev.device, IlluminanceLevelSensingFlag)
+
+			if err := retry.Retry(ctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
+				response, err := z.zclGlobalCommunicator.ReadAttributes(ctx, node.ieeeAddress, node.supportsAPSAck, cluster, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, endpoint, node.nextTransactionSequence(), []zcl.AttributeID{IlluminanceLevelStatus, IlluminanceTargetLevel})
+
+				if err == nil {
+					results := parseReadAttributeResponse(response)
+					markCapabilityUpdated(dev, IlluminanceLevelSensingFlag)
+
+					if value, ok := results.uint8Value(IlluminanceLevelStatus); ok {
+						dev.illuminanceLevelSensingState.LevelStatus = LevelStatus(value)
+					}
+
+					if value, ok := results.uintValue(IlluminanceTargetLevel); ok {
+						dev.illuminanceLevelSensingState.TargetLevel = uint16(value)
+					}
+				}
+
+				return err
+			}); err != nil {
+				log.Printf("failed to read illuminance level sensing attributes: %s", err)
+			}
+
+			bindErr := bindDeviceToController(ctx, z.nodeBinder, z.eventSender, node, dev, endpoint, cluster)
+			if bindErr != nil {
+				log.Printf("failed to bind to zda: %s", bindErr)
+			}
+
+			reportingErr := configureReporting(ctx, z.zclGlobalCommunicator, node, endpoint, cluster, IlluminanceLevelStatus, zcl.TypeEnum8, 0, illuminanceLevelMaximumReportInterval, nil)
+			if reportingErr != nil {
+				log.Printf("failed to configure reporting to zda: %s", reportingErr)
+			}
+
+			mode, reason := reportedUpdateMode(bindErr, reportingErr, UpdateModeNone)
+			setCapabilityUpdateMode(z.eventSender, dev, IlluminanceLevelSensingFlag, mode, reason)
+		} else {
+			removeCapability(&dev.device, IlluminanceLevelSensingFlag)
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (z *ZigbeeIlluminanceLevelSensing) state(device da.Device) (ZigbeeIlluminanceLevelSensingState, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return ZigbeeIlluminanceLevelSensingState{}, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(IlluminanceLevelSensingFlag) {
+		return ZigbeeIlluminanceLevelSensingState{}, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return ZigbeeIlluminanceLevelSensingState{}, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	if capabilityUnavailable(iDevice, IlluminanceLevelSensingFlag) {
+		return ZigbeeIlluminanceLevelSensingState{}, NoReadingAvailable
+	}
+
+	return iDevice.illuminanceLevelSensingState, nil
+}
+
+func (z *ZigbeeIlluminanceLevelSensing) LevelStatus(ctx context.Context, device da.Device) (LevelStatus, error) {
+	state, err := z.state(device)
+	return state.LevelStatus, err
+}
+
+func (z *ZigbeeIlluminanceLevelSensing) TargetLevel(ctx context.Context, device da.Device) (uint16, error) {
+	state, err := z.state(device)
+	return state.TargetLevel, err
+}
+
+func (z *ZigbeeIlluminanceLevelSensing) incomingReportAttributes(source communicator.MessageWithSource) {
+	node, found := z.nodeStore.getNode(source.SourceAddress)
+
+	if !found {
+		return
+	}
+
+	report := source.Message.Command.(*global.ReportAttributes)
+
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+
+	for _, device := range node.devices {
+		device.mutex.Lock()
+
+		cluster, _ := clusterForCapability(device, IlluminanceLevelSensingFlag)
+
+		if isEndpointInSlice(device.endpoints, source.Message.SourceEndpoint) && cluster == source.Message.ClusterID && device.device.HasCapability(IlluminanceLevelSensingFlag) {
+			markCapabilityUpdated(device, IlluminanceLevelSensingFlag)
+
+			for _, attributeReport := range report.Records {
+				switch attributeReport.Identifier {
+				case IlluminanceLevelStatus:
+					value, ok := attributeReport.DataTypeValue.Value.(uint8)
+
+					if ok && LevelStatus(value) != device.illuminanceLevelSensingState.LevelStatus {
+						device.illuminanceLevelSensingState.LevelStatus = LevelStatus(value)
+						z.eventSender.sendEvent(IlluminanceLevelStatusChanged{Device: device.device, LevelStatus: LevelStatus(value)})
+					}
+				}
+			}
+		}
+
+		device.mutex.Unlock()
+	}
+}
@@ -0,0 +1,192 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZigbeeIlluminanceLevelSensing_Contract(t *testing.T) {
+	t.Run("can be assigned to a IlluminanceLevelSensing", func(t *testing.T) {
+		assert.Implements(t, (*IlluminanceLevelSensing)(nil), new(ZigbeeIlluminanceLevelSensing))
+	})
+}
+
+func TestZigbeeIlluminanceLevelSensing_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with cluster, reads state, binds and configures reporting", func(t *testing.T) {
+		mockNodeBinder := mockNodeBinder{}
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+
+		zils := ZigbeeIlluminanceLevelSensing{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			nodeBinder:            &mockNodeBinder,
+		}
+
+		node, device := generateTestNodeAndDevice()
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.IlluminanceLevelSensingId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.IlluminanceLevelSensingId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(1), []zcl.AttributeID{IlluminanceLevelStatus, IlluminanceTargetLevel}).Return([]global.ReadAttributeResponseRecord{
+			{
+				Identifier:    IlluminanceLevelStatus,
+				DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeEnum8, Value: uint8(IlluminanceBelowTarget)},
+			},
+			{
+				Identifier:    IlluminanceTargetLevel,
+				DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeUnsignedInt16, Value: uint64(20000)},
+			},
+		}, nil)
+		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, zcl.IlluminanceLevelSensingId).Return(nil)
+		mockZclGlobalCommunicator.On("ConfigureReporting", mock.Anything, node.ieeeAddress, false, zcl.IlluminanceLevelSensingId, zigbee.NoManufacturer, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, uint8(2), IlluminanceLevelStatus, zcl.TypeEnum8, uint16(0), uint16(illuminanceLevelMaximumReportInterval), nil).Return(nil)
+
+		err := zils.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(IlluminanceLevelSensingFlag))
+		assert.Equal(t, ZigbeeIlluminanceLevelSensingState{LevelStatus: IlluminanceBelowTarget, TargetLevel: 20000}, device.illuminanceLevelSensingState)
+
+		mockNodeBinder.AssertExpectations(t)
+		mockZclGlobalCommunicator.AssertExpectations(t)
+	})
+
+	t.Run("removes capability from device without cluster", func(t *testing.T) {
+		zils := ZigbeeIlluminanceLevelSensing{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{IlluminanceLevelSensingFlag}
+
+		err := zils.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(IlluminanceLevelSensingFlag))
+	})
+}
+
+func TestZigbeeIlluminanceLevelSensing_LevelStatus(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zils := ZigbeeIlluminanceLevelSensing{
+			gateway: &mockGateway{},
+		}
+
+		_, err := zils.LevelStatus(context.Background(), da.Device{})
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if device does not support it", func(t *testing.T) {
+		zils := ZigbeeIlluminanceLevelSensing{
+			gateway: &mockGateway{},
+		}
+
+		_, err := zils.LevelStatus(context.Background(), da.Device{Gateway: zils.gateway})
+		assert.Error(t, err)
+	})
+
+	t.Run("level status is updated and an event sent when reported", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockNodeStore := mockNodeStore{}
+		mockEventSender := mockEventSender{}
+
+		zils := ZigbeeIlluminanceLevelSensing{
+			gateway:     &mockGateway{},
+			nodeStore:   &mockNodeStore,
+			deviceStore: &mockDeviceStore,
+			eventSender: &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zils.gateway
+		device.device.Capabilities = []da.Capability{IlluminanceLevelSensingFlag}
+		device.illuminanceLevelSensingState.TargetLevel = 100
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+		mockEventSender.On("sendEvent", IlluminanceLevelStatusChanged{Device: device.device, LevelStatus: IlluminanceAboveTarget}).Once()
+
+		zils.incomingReportAttributes(communicator.MessageWithSource{
+			SourceAddress: node.ieeeAddress,
+			Message: zcl.Message{
+				FrameType:           zcl.FrameGlobal,
+				Direction:           zcl.ClientToServer,
+				ClusterID:           zcl.IlluminanceLevelSensingId,
+				SourceEndpoint:      node.endpoints[0],
+				DestinationEndpoint: DefaultGatewayHomeAutomationEndpoint,
+				Command: &global.ReportAttributes{
+					Records: []global.ReportAttributesRecord{
+						{
+							Identifier: IlluminanceLevelStatus,
+							DataTypeValue: &zcl.AttributeDataTypeValue{
+								DataType: zcl.TypeEnum8,
+								Value:    uint8(IlluminanceAboveTarget),
+							},
+						},
+					},
+				},
+			},
+		})
+
+		status, err := zils.LevelStatus(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, IlluminanceAboveTarget, status)
+
+		target, err := zils.TargetLevel(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, uint16(100), target)
+
+		mockDeviceStore.AssertExpectations(t)
+		mockNodeStore.AssertExpectations(t)
+		mockEventSender.AssertExpectations(t)
+	})
+
+	t.Run("reports from a cluster which does not back the capability on the device are ignored", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockNodeStore := mockNodeStore{}
+		mockEventSender := mockEventSender{}
+
+		zils := ZigbeeIlluminanceLevelSensing{
+			gateway:     &mockGateway{},
+			nodeStore:   &mockNodeStore,
+			deviceStore: &mockDeviceStore,
+			eventSender: &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zils.gateway
+		device.device.Capabilities = []da.Capability{IlluminanceLevelSensingFlag}
+		device.clusterRemaps = map[da.Capability]zigbee.ClusterID{IlluminanceLevelSensingFlag: 0xfc00}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		zils.incomingReportAttributes(communicator.MessageWithSource{
+			SourceAddress: node.ieeeAddress,
+			Message: zcl.Message{
+				FrameType:      zcl.FrameGlobal,
+				ClusterID:      zcl.IlluminanceLevelSensingId,
+				SourceEndpoint: node.endpoints[0],
+				Command: &global.ReportAttributes{
+					Records: []global.ReportAttributesRecord{
+						{
+							Identifier:    IlluminanceLevelStatus,
+							DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeEnum8, Value: uint8(IlluminanceAboveTarget)},
+						},
+					},
+				},
+			},
+		})
+
+		status, err := zils.LevelStatus(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.NotEqual(t, IlluminanceAboveTarget, status)
+
+		mockEventSender.AssertExpectations(t)
+	})
+}
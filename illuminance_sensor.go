@@ -0,0 +1,207 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+	"math"
+	"time"
+)
+
+const IlluminanceMeasuredValue = zcl.AttributeID(0x0000)
+
+const (
+	// illuminanceTooLowMeasuredValue is reported by devices when the illuminance is below the range they can measure.
+	illuminanceTooLowMeasuredValue = uint64(0x0000)
+	// illuminanceInvalidMeasuredValue is reported by devices which are unable to measure the illuminance.
+	illuminanceInvalidMeasuredValue = uint64(0xffff)
+)
+
+// IlluminanceTooLow is returned by IlluminanceSensor when the device reports the illuminance is too low to measure.
+var IlluminanceTooLow = errors.New("illuminance is below the measurable range")
+
+// illuminancePollInterval is long as illuminance sensors are typically battery powered, and only wake periodically
+// to answer reads.
+const illuminancePollInterval = 5 * time.Minute
+
+// IlluminanceSensor is a capability which signifies that a device measures illuminance.
+type IlluminanceSensor interface {
+	// Reading returns the last illuminance measured by the device, in lux.
+	Reading(context.Context, da.Device) (float64, error)
+}
+
+// IlluminanceReadingChanged is sent to inform consumers that the illuminance measured by a device has changed.
+type IlluminanceReadingChanged struct {
+	// Device whose reading has changed.
+	Device da.Device
+	// New illuminance measured, in lux.
+	Illuminance float64
+}
+
+// illuminanceSensorState is the raw logarithmic measured value of the device, received is false until the first value
+// has been read.
+type illuminanceSensorState struct {
+	measuredValue uint64
+	received      bool
+}
+
+type ZigbeeIlluminanceSensor struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+
+	zclGlobalCommunicator zclGlobalCommunicator
+
+	poller      poller
+	eventSender eventSender
+}
+
+func (z *ZigbeeIlluminanceSensor) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+	z.internalCallbacks.Add(z.NodeJoinCallback)
+}
+
+func (z *ZigbeeIlluminanceSensor) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, IlluminanceSensorFlag); found {
+			addCapability(&dev.device, IlluminanceSensorFlag)
+			setCapabilityUpdateMode(z.eventSender, dev, IlluminanceSensorFlag, UpdateModePolled, "polled")
+
+			if err := z.readMeasuredValue(ctx, node, dev, endpoint, cluster); err != nil {
+				log.Printf("failed to read illuminance measured value: %s", err)
+			}
+		} else {
+			removeCapability(&dev.device, IlluminanceSensorFlag)
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (z *ZigbeeIlluminanceSensor) NodeJoinCallback(ctx context.Context, join internalNodeJoin) error {
+	z.poller.AddNode(join.node, illuminancePollInterval, z.pollNode)
+	return nil
+}
+
+// readMeasuredValue reads the measured value from the device and updates the cached reading. The node mutex must be
+// held, and the device mutex held for writing, by the caller.
+func (z *ZigbeeIlluminanceSensor) readMeasuredValue(ctx context.Context, iNode *internalNode, iDevice *internalDevice, endpoint zigbee.Endpoint, cluster zigbee.ClusterID) error {
+	response, _, err := readAttributesWithEndpointFallback(ctx, z.zclGlobalCommunicator, z.eventSender, iNode, iDevice, IlluminanceSensorFlag, endpoint, cluster, []zcl.AttributeID{IlluminanceMeasuredValue})
+
+	if err == nil {
+		if value, ok := parseReadAttributeResponse(response).uintValue(IlluminanceMeasuredValue); ok {
+			z.setMeasuredValue(iDevice, value)
+		}
+	}
+
+	return err
+}
+
+// setMeasuredValue records the raw measured value of the device, sending an event if the illuminance has changed. The
+// device mutex must be held for writing by the caller.
+func (z *ZigbeeIlluminanceSensor) setMeasuredValue(iDevice *internalDevice, value uint64) {
+	markCapabilityUpdated(iDevice, IlluminanceSensorFlag)
+
+	previous := iDevice.illuminanceSensorState
+	iDevice.illuminanceSensorState = illuminanceSensorState{measuredValue: value, received: true}
+
+	if lux, err := illuminanceToLux(value); err == nil && (!previous.received || previous.measuredValue != value) {
+		z.eventSender.sendEvent(IlluminanceReadingChanged{Device: iDevice.device, Illuminance: lux})
+	}
+}
+
+// illuminanceToLux converts the logarithmic measured value to lux, IlluminanceTooLow and NoReadingAvailable are
+// returned for the values reserved to indicate the illuminance could not be measured.
+func illuminanceToLux(value uint64) (float64, error) {
+	switch value {
+	case illuminanceTooLowMeasuredValue:
+		return 0, IlluminanceTooLow
+	case illuminanceInvalidMeasuredValue:
+		return 0, NoReadingAvailable
+	default:
+		return math.Pow(10, float64(value-1)/10000), nil
+	}
+}
+
+func (z *ZigbeeIlluminanceSensor) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(IlluminanceSensorFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+// Reading returns the last illuminance measured by the device, in lux. NoReadingAvailable is returned if no measurement
+// has been received, the device reported that its measurement is invalid, or the reading is stale. IlluminanceTooLow
+// is returned if the device reported the illuminance is below the range it can measure.
+func (z *ZigbeeIlluminanceSensor) Reading(ctx context.Context, device da.Device) (float64, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return 0, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	if !iDevice.illuminanceSensorState.received || capabilityUnavailable(iDevice, IlluminanceSensorFlag) {
+		return 0, NoReadingAvailable
+	}
+
+	return illuminanceToLux(iDevice.illuminanceSensorState.measuredValue)
+}
+
+func (z *ZigbeeIlluminanceSensor) stateValues(ctx context.Context, device da.Device) ([]StateValue, error) {
+	reading, err := z.Reading(ctx, device)
+	return readingStateValues("illuminance", UnitLux, reading, err)
+}
+
+func (z *ZigbeeIlluminanceSensor) pollNode(pctx context.Context, iNode *internalNode) {
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	for _, iDevice := range iNode.devices {
+		z.pollDevice(pctx, iNode, iDevice)
+	}
+}
+
+// pollDevice reads the measured value of the device, if it has the capability. The node mutex must be held by the
+// caller.
+func (z *ZigbeeIlluminanceSensor) pollDevice(pctx context.Context, iNode *internalNode, iDevice *internalDevice) {
+	iDevice.mutex.Lock()
+	defer iDevice.mutex.Unlock()
+
+	if !iDevice.device.HasCapability(IlluminanceSensorFlag) {
+		return
+	}
+
+	if endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, IlluminanceSensorFlag); found {
+		if err := z.readMeasuredValue(pctx, iNode, iDevice, endpoint, cluster); err != nil {
+			log.Printf("failed to query illuminance in zda: %s", err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZigbeeIlluminanceSensor_Contract(t *testing.T) {
+	t.Run("can be assigned to a IlluminanceSensor", func(t *testing.T) {
+		assert.Implements(t, (*IlluminanceSensor)(nil), new(ZigbeeIlluminanceSensor))
+	})
+}
+
+func generateTestIlluminanceNodeAndDevice() (*internalNode, *internalDevice) {
+	node, device := generateTestNodeAndDevice()
+
+	deviceEndpoint := node.endpoints[0]
+	endpointDescription := node.endpointDescriptions[deviceEndpoint]
+	endpointDescription.InClusterList = []zigbee.ClusterID{zcl.IlluminanceMeasurementId}
+	node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+	return node, device
+}
+
+func illuminanceResponse(value uint64) []global.ReadAttributeResponseRecord {
+	return []global.ReadAttributeResponseRecord{uint16AttributeRecord(IlluminanceMeasuredValue, value)}
+}
+
+func Test_illuminanceToLux(t *testing.T) {
+	t.Run("converts the logarithmic measured value to lux", func(t *testing.T) {
+		lux, err := illuminanceToLux(1)
+		assert.NoError(t, err)
+		assert.Equal(t, 1.0, lux)
+
+		lux, err = illuminanceToLux(30001)
+		assert.NoError(t, err)
+		assert.InDelta(t, 1000.0, lux, 0.0001)
+	})
+
+	t.Run("returns errors for the reserved values", func(t *testing.T) {
+		_, err := illuminanceToLux(0x0000)
+		assert.Equal(t, IlluminanceTooLow, err)
+
+		_, err = illuminanceToLux(0xffff)
+		assert.Equal(t, NoReadingAvailable, err)
+	})
+}
+
+func TestZigbeeIlluminanceSensor_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with cluster and reads measured value", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zis := ZigbeeIlluminanceSensor{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestIlluminanceNodeAndDevice()
+		deviceEndpoint := node.endpoints[0]
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.IlluminanceMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(1), []zcl.AttributeID{IlluminanceMeasuredValue}).Return(illuminanceResponse(20001), nil)
+		mockEventSender.On("sendEvent", mock.MatchedBy(func(e IlluminanceReadingChanged) bool {
+			return e.Device.Identifier == device.device.Identifier && e.Illuminance == 100
+		}))
+
+		err := zis.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(IlluminanceSensorFlag))
+		assert.Equal(t, illuminanceSensorState{measuredValue: 20001, received: true}, device.illuminanceSensorState)
+	})
+
+	t.Run("removes capability from device without cluster", func(t *testing.T) {
+		zis := ZigbeeIlluminanceSensor{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{IlluminanceSensorFlag}
+
+		err := zis.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(IlluminanceSensorFlag))
+	})
+}
+
+func TestZigbeeIlluminanceSensor_NodeJoinCallback(t *testing.T) {
+	t.Run("registers new nodes with the poller when they join", func(t *testing.T) {
+		node := &internalNode{}
+
+		mockPoller := mockPoller{}
+		defer mockPoller.AssertExpectations(t)
+
+		zis := ZigbeeIlluminanceSensor{poller: &mockPoller}
+
+		mockPoller.On("AddNode", node, illuminancePollInterval, mock.AnythingOfType("func(context.Context, *zda.internalNode)"))
+
+		err := zis.NodeJoinCallback(context.Background(), internalNodeJoin{node: node})
+		assert.NoError(t, err)
+	})
+}
+
+func TestZigbeeIlluminanceSensor_Reading(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zis := ZigbeeIlluminanceSensor{gateway: &mockGateway{}}
+
+		_, err := zis.Reading(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns error if device does not support it", func(t *testing.T) {
+		zis := ZigbeeIlluminanceSensor{gateway: &mockGateway{}}
+
+		_, err := zis.Reading(context.Background(), da.Device{Gateway: zis.gateway})
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns the last reading in lux, or an error if it could not be measured", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zis := ZigbeeIlluminanceSensor{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestIlluminanceNodeAndDevice()
+		device.device.Gateway = zis.gateway
+		device.device.Capabilities = []da.Capability{IlluminanceSensorFlag}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		_, err := zis.Reading(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+
+		device.illuminanceSensorState = illuminanceSensorState{measuredValue: 10001, received: true}
+
+		reading, err := zis.Reading(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.InDelta(t, 10.0, reading, 0.0001)
+
+		device.illuminanceSensorState = illuminanceSensorState{measuredValue: illuminanceTooLowMeasuredValue, received: true}
+
+		_, err = zis.Reading(context.Background(), device.device)
+		assert.Equal(t, IlluminanceTooLow, err)
+
+		device.illuminanceSensorState = illuminanceSensorState{measuredValue: illuminanceInvalidMeasuredValue, received: true}
+
+		_, err = zis.Reading(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+	})
+}
+
+func TestZigbeeIlluminanceSensor_pollNode(t *testing.T) {
+	t.Run("reads the measured value, sending an event only if it changed", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zis := ZigbeeIlluminanceSensor{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestIlluminanceNodeAndDevice()
+		device.device.Capabilities = []da.Capability{IlluminanceSensorFlag}
+		device.illuminanceSensorState = illuminanceSensorState{measuredValue: 1, received: true}
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.IlluminanceMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], mock.Anything, []zcl.AttributeID{IlluminanceMeasuredValue}).Return(illuminanceResponse(1), nil).Once()
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.IlluminanceMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], mock.Anything, []zcl.AttributeID{IlluminanceMeasuredValue}).Return(illuminanceResponse(illuminanceTooLowMeasuredValue), nil).Once()
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.IlluminanceMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], mock.Anything, []zcl.AttributeID{IlluminanceMeasuredValue}).Return(illuminanceResponse(10001), nil).Once()
+		mockEventSender.On("sendEvent", mock.MatchedBy(func(e IlluminanceReadingChanged) bool {
+			return e.Illuminance > 9.999 && e.Illuminance < 10.001
+		})).Once()
+
+		zis.pollNode(context.Background(), node)
+		zis.pollNode(context.Background(), node)
+		zis.pollNode(context.Background(), node)
+
+		assert.Equal(t, illuminanceSensorState{measuredValue: 10001, received: true}, device.illuminanceSensorState)
+	})
+}
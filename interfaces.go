@@ -112,7 +112,7 @@ func (m *mockZclCommunicatorRequests) Request(ctx context.Context, address zigbe
 
 func (m *mockZclCommunicatorRequests) RequestResponse(ctx context.Context, address zigbee.IEEEAddress, requireAck bool, message zcl.Message) (zcl.Message, error) {
 	args := m.Called(ctx, address, requireAck, message)
-	return args.Get(1).(zcl.Message), args.Error(1)
+	return args.Get(0).(zcl.Message), args.Error(1)
 }
 
 type zclGlobalCommunicator interface {
@@ -238,3 +238,16 @@ func (m *mockNodeQuerier) QueryNodeEndpointDescription(ctx context.Context, netw
 	args := m.Called(ctx, networkAddress, endpoint)
 	return args.Get(0).(zigbee.EndpointDescription), args.Error(1)
 }
+
+type nodeEnumerator interface {
+	queueEnumeration(ctx context.Context, node *internalNode) error
+}
+
+type mockNodeEnumerator struct {
+	mock.Mock
+}
+
+func (m *mockNodeEnumerator) queueEnumeration(ctx context.Context, node *internalNode) error {
+	args := m.Called(ctx, node)
+	return args.Error(0)
+}
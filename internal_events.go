@@ -11,3 +11,9 @@ type internalNodeLeave struct {
 type internalNodeEnumeration struct {
 	node *internalNode
 }
+
+// internalNodeDeferredEnumeration is called after internalNodeEnumeration, for reads which are not required for the
+// node to be usable. With fast enumeration it is called in the background after the node has been made available.
+type internalNodeDeferredEnumeration struct {
+	node *internalNode
+}
@@ -0,0 +1,163 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/zigbee"
+	"sync"
+	"time"
+)
+
+const DefaultJoinDebounce = 10 * time.Second
+const DefaultJoinRateLimit = 10
+const DefaultJoinRateWindow = 10 * time.Second
+
+type JoinThrottleReason uint8
+
+const (
+	// JoinDebounced indicates the node had already joined within the debounce window.
+	JoinDebounced JoinThrottleReason = iota
+	// JoinRateExceeded indicates too many new nodes have been admitted within the rate window, the join has been
+	// queued and the node will be admitted once the window has room.
+	JoinRateExceeded
+)
+
+// NodeJoinThrottled is sent when a join from the provider is suppressed or delayed rather than being enumerated.
+type NodeJoinThrottled struct {
+	IEEEAddress zigbee.IEEEAddress
+	Reason      JoinThrottleReason
+}
+
+type zdaJoinThrottle struct {
+	mutex *sync.Mutex
+
+	debounce   time.Duration
+	rateLimit  int
+	rateWindow time.Duration
+
+	lastJoin map[zigbee.IEEEAddress]time.Time
+	admitted []time.Time
+	queued   []zigbee.NodeJoinEvent
+
+	now func() time.Time
+}
+
+func newJoinThrottle() *zdaJoinThrottle {
+	return &zdaJoinThrottle{
+		mutex:      &sync.Mutex{},
+		debounce:   DefaultJoinDebounce,
+		rateLimit:  DefaultJoinRateLimit,
+		rateWindow: DefaultJoinRateWindow,
+		lastJoin:   map[zigbee.IEEEAddress]time.Time{},
+		now:        time.Now,
+	}
+}
+
+// allow determines if a join from the node should be processed, newNode should be true if the node is not already
+// known to the gateway. Only new nodes count towards the rate limit, as they are the ones which cause an interview.
+func (t *zdaJoinThrottle) allow(ieeeAddress zigbee.IEEEAddress, newNode bool) (bool, JoinThrottleReason) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := t.now()
+
+	for address, at := range t.lastJoin {
+		if now.Sub(at) >= t.debounce {
+			delete(t.lastJoin, address)
+		}
+	}
+
+	if _, found := t.lastJoin[ieeeAddress]; found {
+		return false, JoinDebounced
+	}
+
+	if newNode && t.rateLimit > 0 {
+		t.expireAdmitted(now)
+
+		if len(t.admitted) >= t.rateLimit {
+			return false, JoinRateExceeded
+		}
+
+		t.admitted = append(t.admitted, now)
+	}
+
+	if t.debounce > 0 {
+		t.lastJoin[ieeeAddress] = now
+	}
+
+	return true, JoinDebounced
+}
+
+// expireAdmitted discards admissions which have left the rate window, the mutex must be held by the caller.
+func (t *zdaJoinThrottle) expireAdmitted(now time.Time) {
+	var recent []time.Time
+
+	for _, at := range t.admitted {
+		if now.Sub(at) < t.rateWindow {
+			recent = append(recent, at)
+		}
+	}
+
+	t.admitted = recent
+}
+
+// queue holds a join which exceeded the rate limit until release admits it. A node is only queued once, a later
+// announcement replacing its queued join.
+func (t *zdaJoinThrottle) queue(join zigbee.NodeJoinEvent) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for i, queued := range t.queued {
+		if queued.IEEEAddress == join.IEEEAddress {
+			t.queued[i] = join
+			return
+		}
+	}
+
+	t.queued = append(t.queued, join)
+}
+
+// release returns the queued joins which may now be admitted as the rate window has room, in the order they were
+// queued. Released joins count towards the rate limit as if they had been allowed.
+func (t *zdaJoinThrottle) release() []zigbee.NodeJoinEvent {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if len(t.queued) == 0 {
+		return nil
+	}
+
+	now := t.now()
+	t.expireAdmitted(now)
+
+	var released []zigbee.NodeJoinEvent
+
+	for len(t.queued) > 0 && (t.rateLimit <= 0 || len(t.admitted) < t.rateLimit) {
+		join := t.queued[0]
+		t.queued = t.queued[1:]
+
+		t.admitted = append(t.admitted, now)
+
+		if t.debounce > 0 {
+			t.lastJoin[join.IEEEAddress] = now
+		}
+
+		released = append(released, join)
+	}
+
+	return released
+}
+
+// forget removes any debounce record or queued join for the node, so that if it rejoins after leaving it is treated
+// as a new node.
+func (t *zdaJoinThrottle) forget(ieeeAddress zigbee.IEEEAddress) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.lastJoin, ieeeAddress)
+
+	for i, queued := range t.queued {
+		if queued.IEEEAddress == ieeeAddress {
+			t.queued = append(t.queued[:i], t.queued[i+1:]...)
+			return
+		}
+	}
+}
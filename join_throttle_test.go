@@ -0,0 +1,157 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestZdaJoinThrottle_allow(t *testing.T) {
+	t.Run("allows the first join from a node", func(t *testing.T) {
+		throttle := newJoinThrottle()
+
+		allowed, _ := throttle.allow(zigbee.IEEEAddress(0x01), true)
+		assert.True(t, allowed)
+	})
+
+	t.Run("suppresses a repeated join from a node within the debounce period, and allows it after", func(t *testing.T) {
+		now := time.Now()
+
+		throttle := newJoinThrottle()
+		throttle.now = func() time.Time { return now }
+
+		allowed, _ := throttle.allow(zigbee.IEEEAddress(0x01), true)
+		assert.True(t, allowed)
+
+		now = now.Add(DefaultJoinDebounce / 2)
+
+		allowed, reason := throttle.allow(zigbee.IEEEAddress(0x01), false)
+		assert.False(t, allowed)
+		assert.Equal(t, JoinDebounced, reason)
+
+		now = now.Add(DefaultJoinDebounce)
+
+		allowed, _ = throttle.allow(zigbee.IEEEAddress(0x01), false)
+		assert.True(t, allowed)
+	})
+
+	t.Run("suppresses new nodes beyond the rate limit until the window has passed", func(t *testing.T) {
+		now := time.Now()
+
+		throttle := newJoinThrottle()
+		throttle.rateLimit = 2
+		throttle.now = func() time.Time { return now }
+
+		allowed, _ := throttle.allow(zigbee.IEEEAddress(0x01), true)
+		assert.True(t, allowed)
+
+		allowed, _ = throttle.allow(zigbee.IEEEAddress(0x02), true)
+		assert.True(t, allowed)
+
+		allowed, reason := throttle.allow(zigbee.IEEEAddress(0x03), true)
+		assert.False(t, allowed)
+		assert.Equal(t, JoinRateExceeded, reason)
+
+		now = now.Add(DefaultJoinRateWindow)
+
+		allowed, _ = throttle.allow(zigbee.IEEEAddress(0x03), true)
+		assert.True(t, allowed)
+	})
+
+	t.Run("does not count known nodes towards the rate limit", func(t *testing.T) {
+		throttle := newJoinThrottle()
+		throttle.rateLimit = 1
+
+		allowed, _ := throttle.allow(zigbee.IEEEAddress(0x01), false)
+		assert.True(t, allowed)
+
+		allowed, _ = throttle.allow(zigbee.IEEEAddress(0x02), true)
+		assert.True(t, allowed)
+	})
+
+	t.Run("allows repeated joins if debounce is disabled", func(t *testing.T) {
+		throttle := newJoinThrottle()
+		throttle.debounce = 0
+
+		allowed, _ := throttle.allow(zigbee.IEEEAddress(0x01), false)
+		assert.True(t, allowed)
+
+		allowed, _ = throttle.allow(zigbee.IEEEAddress(0x01), false)
+		assert.True(t, allowed)
+	})
+}
+
+func TestZdaJoinThrottle_release(t *testing.T) {
+	t.Run("releases nothing if no joins are queued", func(t *testing.T) {
+		throttle := newJoinThrottle()
+
+		assert.Empty(t, throttle.release())
+	})
+
+	t.Run("releases queued joins in order once the rate window has room", func(t *testing.T) {
+		now := time.Now()
+
+		throttle := newJoinThrottle()
+		throttle.rateLimit = 1
+		throttle.now = func() time.Time { return now }
+
+		allowed, _ := throttle.allow(zigbee.IEEEAddress(0x01), true)
+		assert.True(t, allowed)
+
+		joinTwo := zigbee.NodeJoinEvent{Node: zigbee.Node{IEEEAddress: 0x02}}
+		joinThree := zigbee.NodeJoinEvent{Node: zigbee.Node{IEEEAddress: 0x03}}
+
+		throttle.queue(joinTwo)
+		throttle.queue(joinThree)
+		throttle.queue(joinTwo)
+
+		assert.Empty(t, throttle.release())
+
+		now = now.Add(DefaultJoinRateWindow)
+		assert.Equal(t, []zigbee.NodeJoinEvent{joinTwo}, throttle.release())
+
+		now = now.Add(DefaultJoinRateWindow)
+		assert.Equal(t, []zigbee.NodeJoinEvent{joinThree}, throttle.release())
+
+		assert.Empty(t, throttle.release())
+	})
+
+	t.Run("debounces a released node's repeated joins", func(t *testing.T) {
+		now := time.Now()
+
+		throttle := newJoinThrottle()
+		throttle.rateLimit = 1
+		throttle.now = func() time.Time { return now }
+
+		throttle.queue(zigbee.NodeJoinEvent{Node: zigbee.Node{IEEEAddress: 0x01}})
+		assert.Len(t, throttle.release(), 1)
+
+		allowed, reason := throttle.allow(zigbee.IEEEAddress(0x01), false)
+		assert.False(t, allowed)
+		assert.Equal(t, JoinDebounced, reason)
+	})
+}
+
+func TestZdaJoinThrottle_forget(t *testing.T) {
+	t.Run("allows a node to rejoin within the debounce period after being forgotten", func(t *testing.T) {
+		throttle := newJoinThrottle()
+
+		allowed, _ := throttle.allow(zigbee.IEEEAddress(0x01), true)
+		assert.True(t, allowed)
+
+		throttle.forget(zigbee.IEEEAddress(0x01))
+
+		allowed, _ = throttle.allow(zigbee.IEEEAddress(0x01), true)
+		assert.True(t, allowed)
+	})
+
+	t.Run("removes a queued join for the node", func(t *testing.T) {
+		throttle := newJoinThrottle()
+
+		throttle.queue(zigbee.NodeJoinEvent{Node: zigbee.Node{IEEEAddress: 0x01}})
+		throttle.forget(zigbee.IEEEAddress(0x01))
+
+		assert.Empty(t, throttle.release())
+	})
+}
@@ -0,0 +1,287 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+	"time"
+)
+
+const (
+	MoveToLevelId          = zcl.CommandIdentifier(0x00)
+	MoveToLevelWithOnOffId = zcl.CommandIdentifier(0x04)
+)
+
+const LevelControlCurrentLevel = zcl.AttributeID(0x0000)
+
+// MaximumLevel is the highest level which may be requested, 0xff is reserved by the ZCL.
+const MaximumLevel = uint8(0xfe)
+
+// MaximumLevelTransitionTime is the longest transition which can be requested, transitions are sent in tenths of a
+// second and 0xffff is reserved to mean the device's default.
+const MaximumLevelTransitionTime = 0xfffe * 100 * time.Millisecond
+
+const levelControlPollInterval = 30 * time.Second
+
+// MoveToLevel is sent to a Level Control cluster server to move to a level over the transition time, in tenths of a
+// second.
+type MoveToLevel struct {
+	Level          uint8
+	TransitionTime uint16
+}
+
+// MoveToLevelWithOnOff behaves as MoveToLevel, but also turns the device on if the level is above the minimum, or off
+// if it reaches the minimum.
+type MoveToLevelWithOnOff struct {
+	Level          uint8
+	TransitionTime uint16
+}
+
+func registerLevelControlCommands(cr *zcl.CommandRegistry) {
+	cr.RegisterLocal(zcl.LevelControlId, zigbee.NoManufacturer, MoveToLevelId, &MoveToLevel{})
+	cr.RegisterLocal(zcl.LevelControlId, zigbee.NoManufacturer, MoveToLevelWithOnOffId, &MoveToLevelWithOnOff{})
+}
+
+// LevelControl is a capability which signifies that a device has a variable level, such as the brightness of a
+// dimmable bulb.
+type LevelControl interface {
+	// MoveToLevel moves the device to the level over the transition time, turning the device on or off as required.
+	MoveToLevel(context.Context, da.Device, uint8, time.Duration) error
+
+	// CurrentLevel returns the last known level of the device.
+	CurrentLevel(context.Context, da.Device) (uint8, error)
+}
+
+// LevelChanged is sent to inform consumers that a devices level has changed.
+type LevelChanged struct {
+	// Device whose level has changed.
+	Device da.Device
+	// New level of the device.
+	Level uint8
+}
+
+type ZigbeeLevelControl struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+	nodeStore         nodeStore
+
+	zclCommunicatorRequests zclCommunicatorRequests
+	zclGlobalCommunicator   zclGlobalCommunicator
+
+	poller      poller
+	eventSender eventSender
+}
+
+func (z *ZigbeeLevelControl) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+	z.internalCallbacks.Add(z.NodeJoinCallback)
+}
+
+func (z *ZigbeeLevelControl) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, LevelControlFlag); found {
+			addCapability(&dev.device, LevelControlFlag)
+			setCapabilityUpdateMode(z.eventSender, dev, LevelControlFlag, UpdateModePolled, "polled")
+
+			if err := z.readCurrentLevel(ctx, node, dev, endpoint, cluster); err != nil {
+				log.Printf("failed to read current level: %s", err)
+			}
+		} else {
+			removeCapability(&dev.device, LevelControlFlag)
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (z *ZigbeeLevelControl) NodeJoinCallback(ctx context.Context, join internalNodeJoin) error {
+	z.poller.AddNode(join.node, levelControlPollInterval, z.pollNode)
+	return nil
+}
+
+// readCurrentLevel reads the current level from the device and updates the cached level. The node mutex must be held,
+// and the device mutex held for writing, by the caller.
+func (z *ZigbeeLevelControl) readCurrentLevel(ctx context.Context, iNode *internalNode, iDevice *internalDevice, endpoint zigbee.Endpoint, cluster zigbee.ClusterID) error {
+	response, _, err := readAttributesWithEndpointFallback(ctx, z.zclGlobalCommunicator, z.eventSender, iNode, iDevice, LevelControlFlag, endpoint, cluster, []zcl.AttributeID{LevelControlCurrentLevel})
+
+	if err == nil {
+		if level, ok := parseReadAttributeResponse(response).uintValue(LevelControlCurrentLevel); ok {
+			z.setLevel(iDevice, uint8(level))
+		}
+	}
+
+	return err
+}
+
+func (z *ZigbeeLevelControl) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(LevelControlFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+// validateMoveToLevel checks the level and transition time can be represented by the Move to Level command.
+func validateMoveToLevel(level uint8, transitionTime time.Duration) error {
+	if level > MaximumLevel {
+		return fmt.Errorf("level %d is above the maximum of %d", level, MaximumLevel)
+	}
+
+	if transitionTime < 0 || transitionTime > MaximumLevelTransitionTime {
+		return fmt.Errorf("transition time %s is outside of the range 0 to %s", transitionTime, MaximumLevelTransitionTime)
+	}
+
+	return nil
+}
+
+// transitionTenths converts a transition time to the tenths of a second used by the ZCL, rounding to the nearest tenth.
+func transitionTenths(transitionTime time.Duration) uint16 {
+	return uint16((transitionTime + 50*time.Millisecond) / (100 * time.Millisecond))
+}
+
+func (z *ZigbeeLevelControl) validateArguments(iDevice *internalDevice, operation string, args []interface{}) error {
+	if operation == "MoveToLevel" {
+		return validateMoveToLevel(args[0].(uint8), args[1].(time.Duration))
+	}
+
+	return nil
+}
+
+func (z *ZigbeeLevelControl) MoveToLevel(ctx context.Context, device da.Device, level uint8, transitionTime time.Duration) error {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return err
+	}
+
+	if err := validateMoveToLevel(level, transitionTime); err != nil {
+		return err
+	}
+
+	iNode := iDevice.node
+
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, LevelControlFlag)
+
+	if !found {
+		return fmt.Errorf("unable to find level control cluster on zigbee device in zda")
+	}
+
+	zclMsg := zcl.Message{
+		FrameType:           zcl.FrameLocal,
+		Direction:           zcl.ClientToServer,
+		TransactionSequence: iNode.nextTransactionSequence(),
+		Manufacturer:        zigbee.NoManufacturer,
+		ClusterID:           cluster,
+		SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+		DestinationEndpoint: endpoint,
+		Command: &MoveToLevelWithOnOff{
+			Level:          level,
+			TransitionTime: transitionTenths(transitionTime),
+		},
+	}
+
+	cmdCtx, cancel := commandContext(ctx, iDevice)
+	defer cancel()
+
+	if err := z.zclCommunicatorRequests.Request(cmdCtx, iNode.ieeeAddress, iNode.supportsAPSAck, zclMsg); err != nil {
+		return err
+	}
+
+	time.AfterFunc(transitionTime+delayAfterSetForPolling, func() {
+		ctx, done := context.WithTimeout(context.Background(), DefaultNetworkTimeout)
+		defer done()
+
+		iNode.mutex.RLock()
+		defer iNode.mutex.RUnlock()
+
+		z.pollDevice(ctx, iNode, iDevice)
+	})
+
+	return nil
+}
+
+func (z *ZigbeeLevelControl) CurrentLevel(ctx context.Context, device da.Device) (uint8, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return 0, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	return iDevice.currentLevel, nil
+}
+
+func (z *ZigbeeLevelControl) stateValues(ctx context.Context, device da.Device) ([]StateValue, error) {
+	level, err := z.CurrentLevel(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+
+	return []StateValue{{Name: "level", Value: float64(level)}}, nil
+}
+
+func (z *ZigbeeLevelControl) setLevel(device *internalDevice, level uint8) {
+	markCapabilityUpdated(device, LevelControlFlag)
+
+	if device.currentLevel != level {
+		device.currentLevel = level
+		z.eventSender.sendEvent(LevelChanged{Device: device.device, Level: level})
+	}
+}
+
+func (z *ZigbeeLevelControl) pollNode(pctx context.Context, iNode *internalNode) {
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	for _, iDevice := range iNode.devices {
+		z.pollDevice(pctx, iNode, iDevice)
+	}
+}
+
+// pollDevice reads the current level of the device, if it has the capability and is mains powered. The node mutex
+// must be held by the caller.
+func (z *ZigbeeLevelControl) pollDevice(pctx context.Context, iNode *internalNode, iDevice *internalDevice) {
+	iDevice.mutex.Lock()
+	defer iDevice.mutex.Unlock()
+
+	if !iDevice.device.HasCapability(LevelControlFlag) || iNode.nodeDesc.LogicalType != zigbee.Router {
+		return
+	}
+
+	if endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, LevelControlFlag); found {
+		if err := z.readCurrentLevel(pctx, iNode, iDevice, endpoint, cluster); err != nil {
+			log.Printf("failed to query current level in zda: %s", err)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestZigbeeLevelControl_Contract(t *testing.T) {
+	t.Run("can be assigned to a LevelControl", func(t *testing.T) {
+		assert.Implements(t, (*LevelControl)(nil), new(ZigbeeLevelControl))
+	})
+}
+
+func TestZigbeeLevelControl_Init(t *testing.T) {
+	t.Run("initialises the level control capability by registering internalCallbacks", func(t *testing.T) {
+		mIntCallbacks := mockAdderCaller{}
+		defer mIntCallbacks.AssertExpectations(t)
+
+		zlc := ZigbeeLevelControl{internalCallbacks: &mIntCallbacks}
+
+		mIntCallbacks.On("Add", mock.Anything).Twice()
+
+		zlc.Init()
+	})
+}
+
+func currentLevelResponse(level uint8) []global.ReadAttributeResponseRecord {
+	return []global.ReadAttributeResponseRecord{
+		{
+			Identifier:    LevelControlCurrentLevel,
+			DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeUnsignedInt8, Value: uint64(level)},
+		},
+	}
+}
+
+func TestZigbeeLevelControl_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with Level Control cluster and reads the current level", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zlc := ZigbeeLevelControl{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.LevelControlId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.LevelControlId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(1), []zcl.AttributeID{LevelControlCurrentLevel}).Return(currentLevelResponse(0x80), nil)
+		mockEventSender.On("sendEvent", mock.MatchedBy(func(event LevelChanged) bool {
+			return event.Device.Identifier == device.device.Identifier && event.Level == 0x80
+		}))
+
+		err := zlc.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(LevelControlFlag))
+		assert.Equal(t, uint8(0x80), device.currentLevel)
+	})
+
+	t.Run("removes capability from device without Level Control cluster", func(t *testing.T) {
+		zlc := ZigbeeLevelControl{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{LevelControlFlag}
+
+		err := zlc.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(LevelControlFlag))
+	})
+}
+
+func TestZigbeeLevelControl_NodeJoinCallback(t *testing.T) {
+	t.Run("registers new nodes with the poller when they join", func(t *testing.T) {
+		node := &internalNode{}
+
+		mockPoller := mockPoller{}
+		defer mockPoller.AssertExpectations(t)
+
+		zlc := ZigbeeLevelControl{poller: &mockPoller}
+
+		mockPoller.On("AddNode", node, levelControlPollInterval, mock.AnythingOfType("func(context.Context, *zda.internalNode)"))
+
+		err := zlc.NodeJoinCallback(context.Background(), internalNodeJoin{node: node})
+		assert.NoError(t, err)
+	})
+}
+
+func TestZigbeeLevelControl_MoveToLevel(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zlc := ZigbeeLevelControl{gateway: &mockGateway{}}
+
+		err := zlc.MoveToLevel(context.Background(), da.Device{}, 0x10, 0)
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns error if device does not have capability", func(t *testing.T) {
+		zlc := ZigbeeLevelControl{gateway: &mockGateway{}}
+
+		err := zlc.MoveToLevel(context.Background(), da.Device{Gateway: zlc.gateway}, 0x10, 0)
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns error if level or transition time are out of range", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zlc := ZigbeeLevelControl{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zlc.gateway
+		device.device.Capabilities = []da.Capability{LevelControlFlag}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		assert.Error(t, zlc.MoveToLevel(context.Background(), device.device, 0xff, 0))
+		assert.Error(t, zlc.MoveToLevel(context.Background(), device.device, 0x10, MaximumLevelTransitionTime+time.Second))
+		assert.Error(t, zlc.MoveToLevel(context.Background(), device.device, 0x10, -time.Second))
+	})
+
+	t.Run("sends Move to Level with On/Off command to endpoint on device", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zlc := ZigbeeLevelControl{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zlc.gateway
+		device.device.Capabilities = []da.Capability{LevelControlFlag}
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.LevelControlId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		expectedRequest := zcl.Message{
+			FrameType:           zcl.FrameLocal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: 1,
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           zcl.LevelControlId,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: deviceEndpoint,
+			Command:             &MoveToLevelWithOnOff{Level: 0xfe, TransitionTime: 15},
+		}
+		mockZclCommunicatorRequests.On("Request", mock.Anything, node.ieeeAddress, false, expectedRequest).Return(nil)
+
+		err := zlc.MoveToLevel(context.Background(), device.device, 0xfe, 1500*time.Millisecond)
+		assert.NoError(t, err)
+	})
+
+	t.Run("polls the level once the transition has completed", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zlc := ZigbeeLevelControl{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+			zclGlobalCommunicator:   &mockZclGlobalCommunicator,
+			eventSender:             &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		node.nodeDesc.LogicalType = zigbee.Router
+		device.device.Gateway = zlc.gateway
+		device.device.Capabilities = []da.Capability{LevelControlFlag}
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.LevelControlId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+		mockZclCommunicatorRequests.On("Request", mock.Anything, node.ieeeAddress, false, mock.Anything).Return(nil)
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.LevelControlId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(2), []zcl.AttributeID{LevelControlCurrentLevel}).Return(currentLevelResponse(0x20), nil)
+		mockEventSender.On("sendEvent", LevelChanged{Device: device.device, Level: 0x20})
+
+		err := zlc.MoveToLevel(context.Background(), device.device, 0x20, 0)
+		assert.NoError(t, err)
+
+		time.Sleep(delayAfterSetForPolling + 100*time.Millisecond)
+
+		device.mutex.RLock()
+		assert.Equal(t, uint8(0x20), device.currentLevel)
+		device.mutex.RUnlock()
+	})
+}
+
+func TestZigbeeLevelControl_CurrentLevel(t *testing.T) {
+	t.Run("returns error if device does not have capability", func(t *testing.T) {
+		zlc := ZigbeeLevelControl{gateway: &mockGateway{}}
+
+		_, err := zlc.CurrentLevel(context.Background(), da.Device{Gateway: zlc.gateway})
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns the last known level of the device", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		zlc := ZigbeeLevelControl{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zlc.gateway
+		device.device.Capabilities = []da.Capability{LevelControlFlag}
+		device.currentLevel = 0x42
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		level, err := zlc.CurrentLevel(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, uint8(0x42), level)
+	})
+}
+
+func TestZigbeeLevelControl_pollNode(t *testing.T) {
+	t.Run("reads the level of mains powered devices, sending an event only if it changed", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zlc := ZigbeeLevelControl{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		node.nodeDesc.LogicalType = zigbee.Router
+		device.device.Capabilities = []da.Capability{LevelControlFlag}
+		device.currentLevel = 0x10
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.LevelControlId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.LevelControlId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, mock.Anything, []zcl.AttributeID{LevelControlCurrentLevel}).Return(currentLevelResponse(0x10), nil).Once()
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.LevelControlId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, mock.Anything, []zcl.AttributeID{LevelControlCurrentLevel}).Return(currentLevelResponse(0x30), nil).Once()
+		mockEventSender.On("sendEvent", LevelChanged{Device: device.device, Level: 0x30}).Once()
+
+		zlc.pollNode(context.Background(), node)
+		zlc.pollNode(context.Background(), node)
+
+		assert.Equal(t, uint8(0x30), device.currentLevel)
+	})
+
+	t.Run("does not read the level of sleepy devices", func(t *testing.T) {
+		zlc := ZigbeeLevelControl{}
+
+		node, device := generateTestNodeAndDevice()
+		node.nodeDesc.LogicalType = zigbee.EndDevice
+		device.device.Capabilities = []da.Capability{LevelControlFlag}
+
+		zlc.pollNode(context.Background(), node)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/shimmeringbee/da"
 	"github.com/shimmeringbee/da/capabilities"
 	"github.com/shimmeringbee/zigbee"
+	"time"
 )
 
 const ZigbeeLocalDebugMediaType = "application/vnd.shimmeringbee.zda.localdebug+json"
@@ -17,11 +18,28 @@ type ZigbeeLocalDebug struct {
 type LocalDebugNodeData struct {
 	IEEEAddress     string
 	NodeDescription zigbee.NodeDescription
+	Role            DeviceRole
+
+	EnumerationState          EnumerationState
+	FailedEnumerationAttempts int
+	LastEnumerationError      string
 
 	Endpoints            []int
 	EndpointDescriptions map[zigbee.Endpoint]zigbee.EndpointDescription
+	EndpointProfiles     map[zigbee.Endpoint]zigbee.ProfileID
+	ManufacturerClusters map[zigbee.Endpoint]EndpointManufacturerClusters
+	ClusterRevisions     map[zigbee.Endpoint]map[zigbee.ClusterID]uint16
 
 	Devices map[string]LocalDebugDeviceData
+
+	ResetCountSupported bool
+	ResetCount          uint16
+
+	Bindings  []NodeBindingStatus
+	Reporting []NodeReportingConfiguration
+
+	InFlightTransactions      int
+	OldestInFlightTransaction time.Duration
 }
 
 type LocalDebugDeviceData struct {
@@ -33,6 +51,15 @@ type LocalDebugDeviceData struct {
 
 	ProductName         string
 	ProductManufacturer string
+	FirmwareVersion     *FirmwareVersion
+
+	EnumerationResult EnumerationResult
+
+	CapabilityLastUpdated map[da.Capability]time.Time
+	UpdateModes           []CapabilityUpdateModeStatus
+	Attributes            []AttributeCacheEntry
+
+	EventSuppression *EventSuppression
 }
 
 func (z *ZigbeeLocalDebug) Start(ctx context.Context, device da.Device) error {
@@ -73,6 +100,14 @@ func (z *ZigbeeLocalDebug) Start(ctx context.Context, device da.Device) error {
 			AssignedEndpoints:   endpoints,
 			ProductName:         dev.productInformation.Name,
 			ProductManufacturer: dev.productInformation.Manufacturer,
+			FirmwareVersion:     dev.firmwareVersion,
+			EnumerationResult:   dev.enumerationResult,
+
+			CapabilityLastUpdated: capabilityUpdatedTimes(dev),
+			UpdateModes:           capabilityUpdateModes(dev),
+			Attributes:            z.gateway.attributeCache.entries(iNode.ieeeAddress, dev.endpoints),
+
+			EventSuppression: z.gateway.eventSuppressor.status(id),
 		}
 		dev.mutex.RUnlock()
 	}
@@ -83,12 +118,36 @@ func (z *ZigbeeLocalDebug) Start(ctx context.Context, device da.Device) error {
 		endpoints = append(endpoints, int(endpoint))
 	}
 
+	inFlight, oldestInFlight := z.gateway.transactionTracker.status(iNode.ieeeAddress)
+
+	var lastEnumerationError string
+
+	if iNode.lastEnumerationError != nil {
+		lastEnumerationError = iNode.lastEnumerationError.Error()
+	}
+
 	debug := LocalDebugNodeData{
-		IEEEAddress:          iNode.ieeeAddress.String(),
-		NodeDescription:      iNode.nodeDesc,
+		IEEEAddress:     iNode.ieeeAddress.String(),
+		NodeDescription: iNode.nodeDesc,
+		Role:            roleFromLogicalType(iNode.logicalType),
+
+		EnumerationState:          iNode.enumerationState,
+		FailedEnumerationAttempts: iNode.failedEnumerationAttempts,
+		LastEnumerationError:      lastEnumerationError,
+
 		Endpoints:            endpoints,
 		EndpointDescriptions: iNode.endpointDescriptions,
+		EndpointProfiles:     endpointProfiles(iNode, iNode.endpoints),
+		ManufacturerClusters: endpointManufacturerClusters(iNode, iNode.endpoints),
+		ClusterRevisions:     iNode.clusterRevisions,
 		Devices:              devices,
+		ResetCountSupported:  iNode.resetCount.Supported,
+		ResetCount:           iNode.resetCount.Count,
+		Bindings:             bindingStatuses(iNode),
+		Reporting:            reportingConfigurations(iNode, iNode.endpoints),
+
+		InFlightTransactions:      inFlight,
+		OldestInFlightTransaction: oldestInFlight,
 	}
 
 	iNode.mutex.RUnlock()
@@ -97,3 +156,33 @@ func (z *ZigbeeLocalDebug) Start(ctx context.Context, device da.Device) error {
 
 	return nil
 }
+
+// QueryEndpointDescription issues a simple descriptor request to the devices node for the endpoint, returning the
+// description live from the node rather than from the cache populated during enumeration. The endpoint need not be
+// known to the gateway. Any non-success ZDO status is reported by the provider as an error.
+func (z *ZigbeeLocalDebug) QueryEndpointDescription(ctx context.Context, device da.Device, endpoint zigbee.Endpoint) (zigbee.EndpointDescription, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return zigbee.EndpointDescription{}, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(capabilities.LocalDebugFlag) {
+		return zigbee.EndpointDescription{}, da.DeviceDoesNotHaveCapability
+	}
+
+	iDev, found := z.gateway.getDevice(device.Identifier)
+
+	if !found {
+		return zigbee.EndpointDescription{}, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, DefaultNetworkTimeout)
+	defer cancel()
+
+	description, err := z.gateway.provider.QueryNodeEndpointDescription(queryCtx, iDev.node.ieeeAddress, endpoint)
+
+	if err != nil {
+		return zigbee.EndpointDescription{}, fmt.Errorf("failed to query endpoint description: %w", err)
+	}
+
+	return description, nil
+}
@@ -2,6 +2,7 @@ package zda
 
 import (
 	"context"
+	"errors"
 	"github.com/shimmeringbee/da"
 	. "github.com/shimmeringbee/da/capabilities"
 	"github.com/shimmeringbee/zigbee"
@@ -76,22 +77,36 @@ func TestZigbeeLocalDebugCapabilities_Start(t *testing.T) {
 		}
 
 		node.endpoints = []zigbee.Endpoint{0x01, 0x02}
+		node.logicalType = zigbee.Router
+		node.failedEnumerationAttempts = 2
+		node.lastEnumerationError = errors.New("timeout")
 
 		device := zgw.addDevice(expectedDevId, node)
 		device.endpoints = []zigbee.Endpoint{0x01}
 		device.deviceID = 0x02
 		device.deviceVersion = 0x03
 
+		updatedAt := time.Now()
+		device.capabilityUpdated = map[da.Capability]time.Time{OnOffFlag: updatedAt}
+
 		expectedDebug := LocalDebugNodeData{
-			IEEEAddress:          expectedIEEEAddress.String(),
-			NodeDescription:      zigbee.NodeDescription{},
-			Endpoints:            []int{0x01, 0x02},
-			EndpointDescriptions: map[zigbee.Endpoint]zigbee.EndpointDescription{},
+			IEEEAddress:               expectedIEEEAddress.String(),
+			NodeDescription:           zigbee.NodeDescription{},
+			Role:                      RoleRouter,
+			EnumerationState:          EnumerationNotStarted,
+			FailedEnumerationAttempts: 2,
+			LastEnumerationError:      "timeout",
+			Endpoints:                 []int{0x01, 0x02},
+			EndpointDescriptions:      map[zigbee.Endpoint]zigbee.EndpointDescription{},
+			EndpointProfiles:          map[zigbee.Endpoint]zigbee.ProfileID{},
+			ManufacturerClusters:      map[zigbee.Endpoint]EndpointManufacturerClusters{},
 			Devices: map[string]LocalDebugDeviceData{expectedDevId.String(): {
 				Identifier:        expectedDevId.String(),
 				AssignedEndpoints: []int{0x01},
 				DeviceId:          0x02,
 				DeviceVersion:     0x03,
+
+				CapabilityLastUpdated: map[da.Capability]time.Time{OnOffFlag: updatedAt},
 			}},
 		}
 
@@ -119,3 +134,47 @@ func TestZigbeeLocalDebugCapabilities_Start(t *testing.T) {
 		assert.Equal(t, ZigbeeLocalDebugMediaType, success.MediaType)
 	})
 }
+
+func TestZigbeeLocalDebugCapabilities_QueryEndpointDescription(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		zld := zgw.capabilities[LocalDebugFlag].(*ZigbeeLocalDebug)
+
+		_, err := zld.QueryEndpointDescription(context.Background(), da.Device{}, 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("returns the endpoint description queried live from the node", func(t *testing.T) {
+		zgw, mockProvider, _ := NewTestZigbeeGateway()
+		zld := zgw.capabilities[LocalDebugFlag].(*ZigbeeLocalDebug)
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		expectedDescription := zigbee.EndpointDescription{
+			Endpoint:      1,
+			ProfileID:     zigbee.ProfileHomeAutomation,
+			DeviceID:      0x0100,
+			InClusterList: []zigbee.ClusterID{0x0006},
+		}
+
+		mockProvider.On("QueryNodeEndpointDescription", mock.Anything, node.ieeeAddress, zigbee.Endpoint(1)).Return(expectedDescription, nil)
+
+		description, err := zld.QueryEndpointDescription(context.Background(), iDev.device, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedDescription, description)
+	})
+
+	t.Run("returns an error if the node rejects the request", func(t *testing.T) {
+		zgw, mockProvider, _ := NewTestZigbeeGateway()
+		zld := zgw.capabilities[LocalDebugFlag].(*ZigbeeLocalDebug)
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		mockProvider.On("QueryNodeEndpointDescription", mock.Anything, node.ieeeAddress, zigbee.Endpoint(2)).Return(zigbee.EndpointDescription{}, errors.New("status not active"))
+
+		_, err := zld.QueryEndpointDescription(context.Background(), iDev.device, 2)
+		assert.Error(t, err)
+	})
+}
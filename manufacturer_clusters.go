@@ -0,0 +1,72 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zigbee"
+)
+
+// firstManufacturerSpecificCluster is the start of the cluster range reserved for manufacturer specific clusters.
+const firstManufacturerSpecificCluster = zigbee.ClusterID(0xfc00)
+
+// EndpointManufacturerClusters lists the manufacturer specific clusters present on an endpoint.
+type EndpointManufacturerClusters struct {
+	InClusters  []zigbee.ClusterID
+	OutClusters []zigbee.ClusterID
+}
+
+func isManufacturerSpecificCluster(cluster zigbee.ClusterID) bool {
+	return cluster >= firstManufacturerSpecificCluster
+}
+
+func manufacturerSpecificClusters(clusters []zigbee.ClusterID) []zigbee.ClusterID {
+	var found []zigbee.ClusterID
+
+	for _, cluster := range clusters {
+		if isManufacturerSpecificCluster(cluster) {
+			found = append(found, cluster)
+		}
+	}
+
+	return found
+}
+
+// endpointManufacturerClusters returns the manufacturer specific clusters on each of the endpoints provided, endpoints
+// without any are omitted. The node mutex must be held by the caller.
+func endpointManufacturerClusters(iNode *internalNode, endpoints []zigbee.Endpoint) map[zigbee.Endpoint]EndpointManufacturerClusters {
+	clusters := map[zigbee.Endpoint]EndpointManufacturerClusters{}
+
+	for _, endpoint := range endpoints {
+		description := iNode.endpointDescriptions[endpoint]
+
+		found := EndpointManufacturerClusters{
+			InClusters:  manufacturerSpecificClusters(description.InClusterList),
+			OutClusters: manufacturerSpecificClusters(description.OutClusterList),
+		}
+
+		if len(found.InClusters) > 0 || len(found.OutClusters) > 0 {
+			clusters[endpoint] = found
+		}
+	}
+
+	return clusters
+}
+
+// ManufacturerSpecificClusters returns the manufacturer specific clusters (0xfc00 and above) found on the endpoints of
+// the device during enumeration, keyed by endpoint. These are vendor extensions which zda does not interpret.
+func (z *ZigbeeGateway) ManufacturerSpecificClusters(device da.Device) (map[zigbee.Endpoint]EndpointManufacturerClusters, error) {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return nil, err
+	}
+
+	iNode := iDev.node
+
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	iDev.mutex.RLock()
+	defer iDev.mutex.RUnlock()
+
+	return endpointManufacturerClusters(iNode, iDev.endpoints), nil
+}
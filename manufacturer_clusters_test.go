@@ -0,0 +1,50 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestZigbeeGateway_ManufacturerSpecificClusters(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		_, err := zgw.ManufacturerSpecificClusters(da.Device{})
+		assert.Error(t, err)
+	})
+
+	t.Run("returns only manufacturer specific clusters on the devices endpoints", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		node.endpoints = []zigbee.Endpoint{0x01, 0x02, 0x03}
+		node.endpointDescriptions[0x01] = zigbee.EndpointDescription{
+			Endpoint:       0x01,
+			InClusterList:  []zigbee.ClusterID{zcl.BasicId, zcl.OnOffId, 0xfc00},
+			OutClusterList: []zigbee.ClusterID{zcl.OnOffId, 0xfc57},
+		}
+		node.endpointDescriptions[0x02] = zigbee.EndpointDescription{
+			Endpoint:      0x02,
+			InClusterList: []zigbee.ClusterID{zcl.OnOffId},
+		}
+		node.endpointDescriptions[0x03] = zigbee.EndpointDescription{
+			Endpoint:      0x03,
+			InClusterList: []zigbee.ClusterID{0xff01},
+		}
+		iDev.endpoints = []zigbee.Endpoint{0x01, 0x02}
+
+		clusters, err := zgw.ManufacturerSpecificClusters(iDev.device)
+		assert.NoError(t, err)
+
+		expected := map[zigbee.Endpoint]EndpointManufacturerClusters{
+			0x01: {InClusters: []zigbee.ClusterID{0xfc00}, OutClusters: []zigbee.ClusterID{0xfc57}},
+		}
+
+		assert.Equal(t, expected, clusters)
+	})
+}
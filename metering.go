@@ -0,0 +1,296 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+	"time"
+)
+
+const (
+	MeteringCurrentSummationDelivered = zcl.AttributeID(0x0000)
+	MeteringUnitOfMeasure             = zcl.AttributeID(0x0300)
+	MeteringMultiplier                = zcl.AttributeID(0x0301)
+	MeteringDivisor                   = zcl.AttributeID(0x0302)
+)
+
+// MeteringUnit is the unit of the summation measured by a meter, as defined by the UnitOfMeasure attribute.
+type MeteringUnit uint8
+
+const (
+	MeteringUnitKilowattHours     = MeteringUnit(0x00)
+	MeteringUnitCubicMeters       = MeteringUnit(0x01)
+	MeteringUnitCubicFeet         = MeteringUnit(0x02)
+	MeteringUnitCentumCubicFeet   = MeteringUnit(0x03)
+	MeteringUnitUSGallons         = MeteringUnit(0x04)
+	MeteringUnitImperialGallons   = MeteringUnit(0x05)
+	MeteringUnitBTUs              = MeteringUnit(0x06)
+	MeteringUnitLiters            = MeteringUnit(0x07)
+	MeteringUnitKilopascalsGauge  = MeteringUnit(0x08)
+	MeteringUnitKilopascals       = MeteringUnit(0x09)
+	MeteringUnitThousandCubicFeet = MeteringUnit(0x0a)
+	MeteringUnitUnitless          = MeteringUnit(0x0b)
+	MeteringUnitMegajoules        = MeteringUnit(0x0c)
+)
+
+// meteringUnitBCDFormatting is set in the UnitOfMeasure attribute if the meter displays its values as BCD, it has
+// no bearing on how the attributes are transmitted and so is masked off.
+const meteringUnitBCDFormatting = uint8(0x80)
+
+// meteringInvalidSummation is the non-value of a 48-bit unsigned integer, reported by meters unable to measure.
+const meteringInvalidSummation = uint64(0xffffffffffff)
+
+// meteringPollInterval is longer than for instantaneous power, as the summation changes slowly and consumers are
+// interested in consumption over hours or days.
+const meteringPollInterval = 5 * time.Minute
+
+// Metering is a capability which signifies that a device measures the cumulative quantity of a commodity, such as
+// energy, delivered through it.
+type Metering interface {
+	// Reading returns the last summation delivered measured by the device, in the device's unit, kWh for energy.
+	Reading(context.Context, da.Device) (float64, error)
+	// Unit returns the unit of the summation measured by the device.
+	Unit(context.Context, da.Device) (MeteringUnit, error)
+}
+
+// MeteringReadingChanged is sent to inform consumers that the summation delivered measured by a device has changed.
+type MeteringReadingChanged struct {
+	// Device whose reading has changed.
+	Device da.Device
+	// New summation delivered, in Unit.
+	Summation float64
+	// Unit of the summation.
+	Unit MeteringUnit
+}
+
+// meteringState is the scaling and unit of the device, which are constant and so only read once, and the summation
+// last measured. scaled is false until the scaling has been read, available is false until a valid summation has been
+// read.
+type meteringState struct {
+	unit       MeteringUnit
+	multiplier uint64
+	divisor    uint64
+	scaled     bool
+
+	summation float64
+	available bool
+}
+
+type ZigbeeMetering struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+
+	zclGlobalCommunicator zclGlobalCommunicator
+
+	poller      poller
+	eventSender eventSender
+}
+
+func (z *ZigbeeMetering) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+	z.internalCallbacks.Add(z.NodeJoinCallback)
+}
+
+func (z *ZigbeeMetering) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, MeteringFlag); found {
+			addCapability(&dev.device, MeteringFlag)
+			setCapabilityUpdateMode(z.eventSender, dev, MeteringFlag, UpdateModePolled, "polled")
+			dev.meteringState.scaled = false
+
+			if err := z.readSummation(ctx, node, dev, endpoint, cluster); err != nil {
+				log.Printf("failed to read summation delivered: %s", err)
+			}
+		} else {
+			removeCapability(&dev.device, MeteringFlag)
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (z *ZigbeeMetering) NodeJoinCallback(ctx context.Context, join internalNodeJoin) error {
+	z.poller.AddNode(join.node, meteringPollInterval, z.pollNode)
+	return nil
+}
+
+// readSummation reads the summation delivered from the device and updates the cached reading. The unit and scaling
+// are read along with the summation if they have not yet been read. The node mutex must be held, and the device mutex
+// held for writing, by the caller.
+func (z *ZigbeeMetering) readSummation(ctx context.Context, iNode *internalNode, iDevice *internalDevice, endpoint zigbee.Endpoint, cluster zigbee.ClusterID) error {
+	attributes := []zcl.AttributeID{MeteringCurrentSummationDelivered}
+
+	if !iDevice.meteringState.scaled {
+		attributes = append(attributes, MeteringUnitOfMeasure, MeteringMultiplier, MeteringDivisor)
+	}
+
+	response, _, err := readAttributesWithEndpointFallback(ctx, z.zclGlobalCommunicator, z.eventSender, iNode, iDevice, MeteringFlag, endpoint, cluster, attributes)
+
+	if err == nil {
+		markCapabilityUpdated(iDevice, MeteringFlag)
+
+		results := parseReadAttributeResponse(response)
+		state := &iDevice.meteringState
+
+		if !state.scaled {
+			unit, _ := results.uint8Value(MeteringUnitOfMeasure)
+			state.unit = MeteringUnit(unit &^ meteringUnitBCDFormatting)
+			state.multiplier, _ = results.uintValue(MeteringMultiplier)
+			state.divisor, _ = results.uintValue(MeteringDivisor)
+			state.scaled = true
+		}
+
+		summation, ok := results.uintValue(MeteringCurrentSummationDelivered)
+		z.setSummation(iDevice, summationToUnits(summation, state.multiplier, state.divisor), ok && summation != meteringInvalidSummation)
+	}
+
+	return err
+}
+
+// summationToUnits applies the device's scaling to the raw summation. A multiplier or divisor of 0 is not permitted by
+// the ZCL, but is reported by meters which do not support scaling, and so is treated as 1.
+func summationToUnits(summation uint64, multiplier uint64, divisor uint64) float64 {
+	if multiplier == 0 {
+		multiplier = 1
+	}
+
+	if divisor == 0 {
+		divisor = 1
+	}
+
+	return float64(summation) * float64(multiplier) / float64(divisor)
+}
+
+// setSummation records the summation of the device, sending an event if it has changed. The device mutex must be held
+// for writing by the caller.
+func (z *ZigbeeMetering) setSummation(iDevice *internalDevice, summation float64, available bool) {
+	state := &iDevice.meteringState
+
+	previousSummation, previousAvailable := state.summation, state.available
+	state.summation, state.available = summation, available
+
+	if available && (!previousAvailable || previousSummation != summation) {
+		z.eventSender.sendEvent(MeteringReadingChanged{Device: iDevice.device, Summation: summation, Unit: state.unit})
+	}
+}
+
+func (z *ZigbeeMetering) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(MeteringFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+// Reading returns the last summation delivered measured by the device, in the unit returned by Unit. NoReadingAvailable
+// is returned if no summation has been received, the device reported that its summation is invalid, or it is stale.
+func (z *ZigbeeMetering) Reading(ctx context.Context, device da.Device) (float64, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return 0, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	if !iDevice.meteringState.available || capabilityUnavailable(iDevice, MeteringFlag) {
+		return 0, NoReadingAvailable
+	}
+
+	return iDevice.meteringState.summation, nil
+}
+
+// Unit returns the unit of the summation measured by the device, NoReadingAvailable is returned if it has not yet been
+// read.
+func (z *ZigbeeMetering) Unit(ctx context.Context, device da.Device) (MeteringUnit, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return 0, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	if !iDevice.meteringState.scaled {
+		return 0, NoReadingAvailable
+	}
+
+	return iDevice.meteringState.unit, nil
+}
+
+var meteringUnitSymbols = map[MeteringUnit]string{
+	MeteringUnitKilowattHours:     "kWh",
+	MeteringUnitCubicMeters:       "m³",
+	MeteringUnitCubicFeet:         "ft³",
+	MeteringUnitCentumCubicFeet:   "ccf",
+	MeteringUnitUSGallons:         "US gal",
+	MeteringUnitImperialGallons:   "imp gal",
+	MeteringUnitBTUs:              "BTU",
+	MeteringUnitLiters:            "L",
+	MeteringUnitKilopascalsGauge:  "kPa",
+	MeteringUnitKilopascals:       "kPa",
+	MeteringUnitThousandCubicFeet: "mcf",
+	MeteringUnitMegajoules:        "MJ",
+}
+
+// stateValues returns the summation delivered, with the symbol of the meter's unit.
+func (z *ZigbeeMetering) stateValues(ctx context.Context, device da.Device) ([]StateValue, error) {
+	unit, err := z.Unit(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+
+	reading, err := z.Reading(ctx, device)
+	return readingStateValues("summation", meteringUnitSymbols[unit], reading, err)
+}
+
+func (z *ZigbeeMetering) pollNode(pctx context.Context, iNode *internalNode) {
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	for _, iDevice := range iNode.devices {
+		z.pollDevice(pctx, iNode, iDevice)
+	}
+}
+
+// pollDevice reads the summation delivered of the device, if it has the capability. The node mutex must be held by
+// the caller.
+func (z *ZigbeeMetering) pollDevice(pctx context.Context, iNode *internalNode, iDevice *internalDevice) {
+	iDevice.mutex.Lock()
+	defer iDevice.mutex.Unlock()
+
+	if !iDevice.device.HasCapability(MeteringFlag) {
+		return
+	}
+
+	if endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, MeteringFlag); found {
+		if err := z.readSummation(pctx, iNode, iDevice, endpoint, cluster); err != nil {
+			log.Printf("failed to query summation delivered in zda: %s", err)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/bytecodec"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZigbeeMetering_Contract(t *testing.T) {
+	t.Run("can be assigned to a Metering", func(t *testing.T) {
+		assert.Implements(t, (*Metering)(nil), new(ZigbeeMetering))
+	})
+}
+
+func generateTestMeteringNodeAndDevice() (*internalNode, *internalDevice) {
+	node, device := generateTestNodeAndDevice()
+
+	deviceEndpoint := node.endpoints[0]
+	endpointDescription := node.endpointDescriptions[deviceEndpoint]
+	endpointDescription.InClusterList = []zigbee.ClusterID{zcl.MeteringId}
+	node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+	return node, device
+}
+
+func uint48AttributeRecord(id zcl.AttributeID, value uint64) global.ReadAttributeResponseRecord {
+	return global.ReadAttributeResponseRecord{Identifier: id, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeUnsignedInt48, Value: value}}
+}
+
+func uint24AttributeRecord(id zcl.AttributeID, value uint64) global.ReadAttributeResponseRecord {
+	return global.ReadAttributeResponseRecord{Identifier: id, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeUnsignedInt24, Value: value}}
+}
+
+func enum8AttributeRecord(id zcl.AttributeID, value uint8) global.ReadAttributeResponseRecord {
+	return global.ReadAttributeResponseRecord{Identifier: id, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeEnum8, Value: value}}
+}
+
+func Test_summationToUnits(t *testing.T) {
+	t.Run("applies the multiplier and divisor", func(t *testing.T) {
+		assert.Equal(t, 1234.5, summationToUnits(1234500, 1, 1000))
+		assert.Equal(t, 40.0, summationToUnits(20, 2, 1))
+	})
+
+	t.Run("treats a multiplier or divisor of zero as one", func(t *testing.T) {
+		assert.Equal(t, 1234.0, summationToUnits(1234, 0, 0))
+	})
+}
+
+func TestZigbeeMetering_readSummation(t *testing.T) {
+	t.Run("decodes a 48-bit summation beyond the range of 32 bits", func(t *testing.T) {
+		data := []byte{
+			0x00, 0x00, // Identifier
+			0x00,                               // Status
+			0x25,                               // DataType, uint48
+			0xbc, 0x9a, 0x78, 0x56, 0x34, 0x12, // Value
+		}
+
+		record := global.ReadAttributeResponseRecord{}
+		err := bytecodec.Unmarshal(data, &record)
+		assert.NoError(t, err)
+
+		value, ok := parseReadAttributeResponse([]global.ReadAttributeResponseRecord{record}).uintValue(MeteringCurrentSummationDelivered)
+		assert.True(t, ok)
+		assert.Equal(t, uint64(0x123456789abc), value)
+	})
+}
+
+func TestZigbeeMetering_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with cluster and reads the unit and scaling along with the summation", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zm := ZigbeeMetering{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestMeteringNodeAndDevice()
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.MeteringId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], uint8(1), []zcl.AttributeID{MeteringCurrentSummationDelivered, MeteringUnitOfMeasure, MeteringMultiplier, MeteringDivisor}).Return([]global.ReadAttributeResponseRecord{
+			uint48AttributeRecord(MeteringCurrentSummationDelivered, 0x100000000),
+			enum8AttributeRecord(MeteringUnitOfMeasure, 0x80),
+			uint24AttributeRecord(MeteringMultiplier, 1),
+			uint24AttributeRecord(MeteringDivisor, 1000),
+		}, nil)
+		mockEventSender.On("sendEvent", mock.MatchedBy(func(e MeteringReadingChanged) bool {
+			return e.Device.Identifier == device.device.Identifier && e.Summation == 4294967.296 && e.Unit == MeteringUnitKilowattHours
+		}))
+
+		err := zm.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(MeteringFlag))
+		assert.Equal(t, meteringState{unit: MeteringUnitKilowattHours, multiplier: 1, divisor: 1000, scaled: true, summation: 4294967.296, available: true}, device.meteringState)
+	})
+
+	t.Run("removes capability from device without cluster", func(t *testing.T) {
+		zm := ZigbeeMetering{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{MeteringFlag}
+
+		err := zm.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(MeteringFlag))
+	})
+}
+
+func TestZigbeeMetering_NodeJoinCallback(t *testing.T) {
+	t.Run("registers new nodes with the poller when they join", func(t *testing.T) {
+		node := &internalNode{}
+
+		mockPoller := mockPoller{}
+		defer mockPoller.AssertExpectations(t)
+
+		zm := ZigbeeMetering{poller: &mockPoller}
+
+		mockPoller.On("AddNode", node, meteringPollInterval, mock.AnythingOfType("func(context.Context, *zda.internalNode)"))
+
+		err := zm.NodeJoinCallback(context.Background(), internalNodeJoin{node: node})
+		assert.NoError(t, err)
+	})
+}
+
+func TestZigbeeMetering_Reading(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zm := ZigbeeMetering{gateway: &mockGateway{}}
+
+		_, err := zm.Reading(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns error if device does not support it", func(t *testing.T) {
+		zm := ZigbeeMetering{gateway: &mockGateway{}}
+
+		_, err := zm.Reading(context.Background(), da.Device{Gateway: zm.gateway})
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns the last reading, or no reading available if none has been received", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zm := ZigbeeMetering{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zm.gateway
+		device.device.Capabilities = []da.Capability{MeteringFlag}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		_, err := zm.Reading(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+
+		device.meteringState = meteringState{summation: 12.5, available: true}
+
+		reading, err := zm.Reading(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, 12.5, reading)
+	})
+}
+
+func TestZigbeeMetering_Unit(t *testing.T) {
+	t.Run("returns the unit once read, or no reading available if not yet read", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zm := ZigbeeMetering{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zm.gateway
+		device.device.Capabilities = []da.Capability{MeteringFlag}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		_, err := zm.Unit(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+
+		device.meteringState = meteringState{unit: MeteringUnitCubicMeters, scaled: true}
+
+		unit, err := zm.Unit(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, MeteringUnitCubicMeters, unit)
+	})
+}
+
+func TestZigbeeMetering_pollNode(t *testing.T) {
+	t.Run("reads only the summation once the scaling is known, sending an event only if it changed", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zm := ZigbeeMetering{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestMeteringNodeAndDevice()
+		device.device.Capabilities = []da.Capability{MeteringFlag}
+		device.meteringState = meteringState{multiplier: 1, divisor: 100, scaled: true, summation: 10, available: true}
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.MeteringId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], uint8(1), []zcl.AttributeID{MeteringCurrentSummationDelivered}).Return([]global.ReadAttributeResponseRecord{uint48AttributeRecord(MeteringCurrentSummationDelivered, 1000)}, nil).Once()
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.MeteringId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], uint8(2), []zcl.AttributeID{MeteringCurrentSummationDelivered}).Return([]global.ReadAttributeResponseRecord{uint48AttributeRecord(MeteringCurrentSummationDelivered, meteringInvalidSummation)}, nil).Once()
+
+		zm.pollNode(context.Background(), node)
+		zm.pollNode(context.Background(), node)
+
+		assert.False(t, device.meteringState.available)
+	})
+}
@@ -15,12 +15,23 @@ type internalNode struct {
 	// Mutable, locking must be obtained first.
 	devices map[IEEEAddressWithSubIdentifier]*internalDevice
 
-	nodeDesc             zigbee.NodeDescription
+	nodeDesc         zigbee.NodeDescription
+	logicalType      zigbee.LogicalType
+	enumerationState EnumerationState
+
+	failedEnumerationAttempts int
+	lastEnumerationError      error
+
 	endpoints            []zigbee.Endpoint
 	endpointDescriptions map[zigbee.Endpoint]zigbee.EndpointDescription
+	clusterRevisions     map[zigbee.Endpoint]map[zigbee.ClusterID]uint16
 
 	transactionSequences chan uint8
 	supportsAPSAck       bool
+
+	resetCount nodeResetCount
+	bindings   map[nodeBindingKey]NodeBindingStatus
+	reporting  map[nodeReportingKey]NodeReportingConfiguration
 }
 
 func (z *ZigbeeGateway) getNode(ieeeAddress zigbee.IEEEAddress) (*internalNode, bool) {
@@ -39,8 +50,12 @@ func (z *ZigbeeGateway) addNode(ieeeAddress zigbee.IEEEAddress) *internalNode {
 		ieeeAddress: ieeeAddress,
 		mutex:       &sync.RWMutex{},
 		devices:     map[IEEEAddressWithSubIdentifier]*internalDevice{},
+		logicalType: zigbee.Unknown,
+
+		enumerationState: EnumerationNotStarted,
 
 		endpointDescriptions: map[zigbee.Endpoint]zigbee.EndpointDescription{},
+		bindings:             map[nodeBindingKey]NodeBindingStatus{},
 
 		transactionSequences: make(chan uint8, math.MaxUint8),
 		supportsAPSAck:       false,
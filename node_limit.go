@@ -0,0 +1,109 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/zigbee"
+	"sort"
+	"time"
+)
+
+type NodeLimitPolicy uint8
+
+const (
+	// RejectNewNodes ignores joins from new nodes once the limit is reached.
+	RejectNewNodes NodeLimitPolicy = iota
+	// EvictLeastRecentlySeen forgets the node which has gone longest without providing data to make room for the new
+	// node, nodes which have never provided data are evicted first.
+	EvictLeastRecentlySeen
+)
+
+// NodeJoinRejected is sent when a new node is not managed because the node limit has been reached. The provider
+// offers no means to request a node leaves, so the node may remain on the network, but zda will not enumerate it.
+type NodeJoinRejected struct {
+	IEEEAddress zigbee.IEEEAddress
+	Limit       int
+}
+
+// NodeEvicted is sent when a node is forgotten to make room for a new node under the EvictLeastRecentlySeen policy,
+// DeviceRemoved events are sent for its devices as normal.
+type NodeEvicted struct {
+	IEEEAddress zigbee.IEEEAddress
+	// JoiningNode is the new node that the evicted node made room for.
+	JoiningNode zigbee.IEEEAddress
+	Limit       int
+}
+
+// admitNode determines if a new node may be managed within the node limit, evicting an existing node if the policy
+// permits.
+func (z *ZigbeeGateway) admitNode(ieeeAddress zigbee.IEEEAddress) bool {
+	if z.nodeLimit <= 0 {
+		return true
+	}
+
+	z.nodesLock.RLock()
+	var nodes []*internalNode
+	for _, iNode := range z.nodes {
+		nodes = append(nodes, iNode)
+	}
+	z.nodesLock.RUnlock()
+
+	if len(nodes) < z.nodeLimit {
+		return true
+	}
+
+	if z.nodeLimitPolicy != EvictLeastRecentlySeen {
+		z.sendEvent(NodeJoinRejected{IEEEAddress: ieeeAddress, Limit: z.nodeLimit})
+		return false
+	}
+
+	lastUpdated := map[zigbee.IEEEAddress]time.Time{}
+
+	for _, iNode := range nodes {
+		lastUpdated[iNode.ieeeAddress] = nodeLastUpdated(iNode)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		iUpdated, jUpdated := lastUpdated[nodes[i].ieeeAddress], lastUpdated[nodes[j].ieeeAddress]
+
+		if !iUpdated.Equal(jUpdated) {
+			return iUpdated.Before(jUpdated)
+		}
+
+		return nodes[i].ieeeAddress < nodes[j].ieeeAddress
+	})
+
+	for _, evicted := range nodes[:len(nodes)-z.nodeLimit+1] {
+		z.forgetNode(evicted)
+		z.sendEvent(NodeEvicted{IEEEAddress: evicted.ieeeAddress, JoiningNode: ieeeAddress, Limit: z.nodeLimit})
+	}
+
+	return true
+}
+
+// forgetNode removes the node and its devices from the gateway, as if it had left the network.
+func (z *ZigbeeGateway) forgetNode(iNode *internalNode) {
+	z.callbacks.Call(context.Background(), internalNodeLeave{node: iNode})
+
+	for _, iDev := range iNode.getDevices() {
+		z.removeDevice(iDev.device.Identifier)
+	}
+
+	z.removeNode(iNode.ieeeAddress)
+	z.attributeCache.forget(iNode.ieeeAddress)
+	z.joinThrottle.forget(iNode.ieeeAddress)
+}
+
+// nodeLastUpdated returns the most recent time any device of the node provided data, the time is zero if none have.
+func nodeLastUpdated(iNode *internalNode) time.Time {
+	var lastUpdated time.Time
+
+	for _, iDev := range iNode.getDevices() {
+		iDev.mutex.RLock()
+		if updated := deviceLastUpdated(iDev); updated.After(lastUpdated) {
+			lastUpdated = updated
+		}
+		iDev.mutex.RUnlock()
+	}
+
+	return lastUpdated
+}
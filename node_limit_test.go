@@ -0,0 +1,90 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestZigbeeGateway_admitNode(t *testing.T) {
+	t.Run("admits any node if there is no limit", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		zgw.addNode(zigbee.IEEEAddress(0x01))
+
+		assert.True(t, zgw.admitNode(zigbee.IEEEAddress(0x02)))
+	})
+
+	t.Run("admits nodes while below the limit", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		zgw.nodeLimit = 2
+		zgw.addNode(zigbee.IEEEAddress(0x01))
+
+		assert.True(t, zgw.admitNode(zigbee.IEEEAddress(0x02)))
+	})
+
+	t.Run("rejects new nodes at the limit, sending an event", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		zgw.nodeLimit = 1
+		zgw.addNode(zigbee.IEEEAddress(0x01))
+
+		assert.False(t, zgw.admitNode(zigbee.IEEEAddress(0x02)))
+
+		_, found := zgw.getNode(zigbee.IEEEAddress(0x01))
+		assert.True(t, found)
+
+		event, err := zgw.ReadEvent(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, NodeJoinRejected{IEEEAddress: zigbee.IEEEAddress(0x02), Limit: 1}, event)
+	})
+
+	t.Run("evicts the least recently seen node at the limit", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		zgw.nodeLimit = 2
+		zgw.nodeLimitPolicy = EvictLeastRecentlySeen
+
+		recent := zgw.addNode(zigbee.IEEEAddress(0x01))
+		recentDevice := zgw.addDevice(recent.nextDeviceIdentifier(), recent)
+		recentDevice.capabilityUpdated = map[da.Capability]time.Time{PriceFlag: time.Now()}
+
+		old := zgw.addNode(zigbee.IEEEAddress(0x02))
+		oldDevice := zgw.addDevice(old.nextDeviceIdentifier(), old)
+		oldDevice.capabilityUpdated = map[da.Capability]time.Time{PriceFlag: time.Now().Add(-time.Hour)}
+
+		assert.True(t, zgw.admitNode(zigbee.IEEEAddress(0x03)))
+
+		_, found := zgw.getNode(zigbee.IEEEAddress(0x01))
+		assert.True(t, found)
+
+		_, found = zgw.getNode(zigbee.IEEEAddress(0x02))
+		assert.False(t, found)
+
+		_, found = zgw.getDevice(oldDevice.device.Identifier)
+		assert.False(t, found)
+
+		var events []interface{}
+		for len(zgw.events) > 0 {
+			event, _ := zgw.ReadEvent(context.Background())
+			events = append(events, event)
+		}
+
+		assert.Contains(t, events, NodeEvicted{IEEEAddress: zigbee.IEEEAddress(0x02), JoiningNode: zigbee.IEEEAddress(0x03), Limit: 2})
+	})
+
+	t.Run("evicts nodes which have never provided data first", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		zgw.nodeLimit = 1
+		zgw.nodeLimitPolicy = EvictLeastRecentlySeen
+
+		zgw.addNode(zigbee.IEEEAddress(0x01))
+
+		assert.True(t, zgw.admitNode(zigbee.IEEEAddress(0x02)))
+
+		_, found := zgw.getNode(zigbee.IEEEAddress(0x01))
+		assert.False(t, found)
+	})
+}
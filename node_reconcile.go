@@ -0,0 +1,38 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+)
+
+// NodeStateReconciled is sent when a known node joins with a logical type which disagrees with the node descriptor
+// cached from its last enumeration, this usually indicates the node has been reset or reflashed. The live information
+// is trusted and the node is re-enumerated.
+type NodeStateReconciled struct {
+	IEEEAddress       zigbee.IEEEAddress
+	CachedLogicalType zigbee.LogicalType
+	LiveLogicalType   zigbee.LogicalType
+}
+
+// reconcileRejoin compares a join of an already known node against the state cached from its last enumeration, if
+// they disagree then the node is queued for enumeration to replace the cached state. Nodes which have not completed
+// enumeration have no cached state to compare against.
+func (z *ZigbeeGateway) reconcileRejoin(iNode *internalNode, live zigbee.Node) {
+	iNode.mutex.RLock()
+	enumerated := iNode.enumerationState == EnumerationComplete
+	cached := iNode.nodeDesc.LogicalType
+	iNode.mutex.RUnlock()
+
+	if !enumerated || cached == live.LogicalType {
+		return
+	}
+
+	log.Printf("node %s rejoined with logical type %d, but was enumerated as %d, re-enumerating", live.IEEEAddress, live.LogicalType, cached)
+	z.sendEvent(NodeStateReconciled{IEEEAddress: live.IEEEAddress, CachedLogicalType: cached, LiveLogicalType: live.LogicalType})
+
+	if err := z.capabilities[capabilities.EnumerateDeviceFlag].(*ZigbeeEnumerateDevice).queueEnumeration(context.Background(), iNode); err != nil {
+		log.Printf("failed to queue enumeration of reconciled node: %s", err)
+	}
+}
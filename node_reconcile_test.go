@@ -0,0 +1,64 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestZigbeeGateway_reconcileRejoin(t *testing.T) {
+	t.Run("re-enumerates and sends an event if a rejoining node disagrees with its cached node descriptor", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		zed := zgw.capabilities[capabilities.EnumerateDeviceFlag].(*ZigbeeEnumerateDevice)
+		zed.queue = make(chan *internalNode, 1)
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		node.enumerationState = EnumerationComplete
+		node.nodeDesc = zigbee.NodeDescription{LogicalType: zigbee.EndDevice}
+
+		zgw.reconcileRejoin(node, zigbee.Node{IEEEAddress: node.ieeeAddress, LogicalType: zigbee.Router})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		event, err := zgw.ReadEvent(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, NodeStateReconciled{IEEEAddress: node.ieeeAddress, CachedLogicalType: zigbee.EndDevice, LiveLogicalType: zigbee.Router}, event)
+
+		assert.Len(t, zed.queue, 1)
+		assert.Equal(t, EnumerationPending, node.enumerationState)
+	})
+
+	t.Run("does nothing if the rejoining node agrees with its cached node descriptor", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		zed := zgw.capabilities[capabilities.EnumerateDeviceFlag].(*ZigbeeEnumerateDevice)
+		zed.queue = make(chan *internalNode, 1)
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		node.enumerationState = EnumerationComplete
+		node.nodeDesc = zigbee.NodeDescription{LogicalType: zigbee.Router}
+
+		zgw.reconcileRejoin(node, zigbee.Node{IEEEAddress: node.ieeeAddress, LogicalType: zigbee.Router})
+
+		assert.Len(t, zed.queue, 0)
+		assert.Equal(t, EnumerationComplete, node.enumerationState)
+	})
+
+	t.Run("does nothing if the node has not completed enumeration", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		zed := zgw.capabilities[capabilities.EnumerateDeviceFlag].(*ZigbeeEnumerateDevice)
+		zed.queue = make(chan *internalNode, 1)
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+
+		zgw.reconcileRejoin(node, zigbee.Node{IEEEAddress: node.ieeeAddress, LogicalType: zigbee.Router})
+
+		assert.Len(t, zed.queue, 0)
+	})
+}
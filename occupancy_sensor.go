@@ -0,0 +1,227 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/retry"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+	"time"
+)
+
+const OccupancySensingOccupancy = zcl.AttributeID(0x0000)
+
+// occupiedBit is the bit of the Occupancy bitmap which indicates the sensed area is occupied, the remaining bits are
+// reserved.
+const occupiedBit = 0x01
+
+const occupancyMaximumReportInterval = 300
+
+// OccupancySensor is a capability which signifies that a device senses the occupancy of an area, such as a PIR motion
+// sensor.
+type OccupancySensor interface {
+	// Status returns the last known occupancy of the device.
+	Status(context.Context, da.Device) (OccupancyStatus, error)
+}
+
+// OccupancyStatus is the occupancy sensed by a device.
+type OccupancyStatus struct {
+	Occupied bool
+	// LastChanged is the time the occupancy last changed, it is zero if no change has been seen since the gateway
+	// started.
+	LastChanged time.Time
+}
+
+// OccupancyChanged is sent to inform consumers that the occupancy sensed by a device has changed.
+type OccupancyChanged struct {
+	// Device whose occupancy has changed.
+	Device da.Device
+	// New occupancy status of the device.
+	Status OccupancyStatus
+}
+
+// occupancySensorState is the occupancy last sensed by the device, received is false until the first value has been
+// read or reported.
+type occupancySensorState struct {
+	status   OccupancyStatus
+	received bool
+}
+
+type ZigbeeOccupancySensor struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+	nodeStore         nodeStore
+
+	zclCommunicatorCallbacks zclCommunicatorCallbacks
+	zclGlobalCommunicator    zclGlobalCommunicator
+
+	nodeBinder  zigbee.NodeBinder
+	eventSender eventSender
+
+	now func() time.Time
+}
+
+func (z *ZigbeeOccupancySensor) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+
+	z.zclCommunicatorCallbacks.AddCallback(z.zclCommunicatorCallbacks.NewMatch(func(address zigbee.IEEEAddress, appMsg zigbee.ApplicationMessage, zclMessage zcl.Message) bool {
+		_, canCast := zclMessage.Command.(*global.ReportAttributes)
+		return canCast
+	}, z.incomingReportAttributes))
+}
+
+func (z *ZigbeeOccupancySensor) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, OccupancySensorFlag); found {
+			addCapability(&dev.device, OccupancySensorFlag)
+
+			if err := retry.Retry(ctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
+				response, err := z.zclGlobalCommunicator.ReadAttributes(ctx, node.ieeeAddress, node.supportsAPSAck, cluster, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, endpoint, node.nextTransactionSequence(), []zcl.AttributeID{OccupancySensingOccupancy})
+
+				if err == nil {
+					if value, ok := parseReadAttributeResponse(response).uintValue(OccupancySensingOccupancy); ok {
+						z.setOccupancy(dev, value)
+					}
+				}
+
+				return err
+			}); err != nil {
+				log.Printf("failed to read occupancy: %s", err)
+			}
+
+			bindErr := bindDeviceToController(ctx, z.nodeBinder, z.eventSender, node, dev, endpoint, cluster)
+			if bindErr != nil {
+				log.Printf("failed to bind to zda: %s", bindErr)
+			}
+
+			reportingErr := configureReporting(ctx, z.zclGlobalCommunicator, node, endpoint, cluster, OccupancySensingOccupancy, zcl.TypeBitmap8, 0, occupancyMaximumReportInterval, nil)
+			if reportingErr != nil {
+				log.Printf("failed to configure reporting to zda: %s", reportingErr)
+			}
+
+			mode, reason := reportedUpdateMode(bindErr, reportingErr, UpdateModeNone)
+			setCapabilityUpdateMode(z.eventSender, dev, OccupancySensorFlag, mode, reason)
+		} else {
+			removeCapability(&dev.device, OccupancySensorFlag)
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// setOccupancy records the occupancy bitmap of the device, sending an event if the occupancy has changed. The first
+// value received does not set LastChanged, as when the occupancy changed is unknown. The device mutex must be held
+// for writing by the caller.
+func (z *ZigbeeOccupancySensor) setOccupancy(iDevice *internalDevice, bitmap uint64) {
+	markCapabilityUpdated(iDevice, OccupancySensorFlag)
+
+	occupied := bitmap&occupiedBit == occupiedBit
+	state := &iDevice.occupancySensorState
+
+	if state.received && state.status.Occupied == occupied {
+		return
+	}
+
+	if state.received {
+		state.status.LastChanged = z.now()
+	}
+
+	state.status.Occupied = occupied
+	state.received = true
+
+	z.eventSender.sendEvent(OccupancyChanged{Device: iDevice.device, Status: state.status})
+}
+
+func (z *ZigbeeOccupancySensor) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(OccupancySensorFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+// Status returns the last known occupancy of the device, NoReadingAvailable is returned if the occupancy has not yet
+// been read or reported, or has become stale.
+func (z *ZigbeeOccupancySensor) Status(ctx context.Context, device da.Device) (OccupancyStatus, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return OccupancyStatus{}, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	if !iDevice.occupancySensorState.received || capabilityUnavailable(iDevice, OccupancySensorFlag) {
+		return OccupancyStatus{}, NoReadingAvailable
+	}
+
+	return iDevice.occupancySensorState.status, nil
+}
+
+func (z *ZigbeeOccupancySensor) stateValues(ctx context.Context, device da.Device) ([]StateValue, error) {
+	status, err := z.Status(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+
+	return []StateValue{{Name: "occupied", Value: status.Occupied}}, nil
+}
+
+func (z *ZigbeeOccupancySensor) incomingReportAttributes(source communicator.MessageWithSource) {
+	node, found := z.nodeStore.getNode(source.SourceAddress)
+
+	if !found {
+		return
+	}
+
+	report := source.Message.Command.(*global.ReportAttributes)
+
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+
+	for _, device := range node.devices {
+		device.mutex.Lock()
+
+		cluster, _ := clusterForCapability(device, OccupancySensorFlag)
+
+		if isEndpointInSlice(device.endpoints, source.Message.SourceEndpoint) && cluster == source.Message.ClusterID && device.device.HasCapability(OccupancySensorFlag) {
+			for _, attributeReport := range report.Records {
+				if attributeReport.Identifier != OccupancySensingOccupancy {
+					continue
+				}
+
+				if value, ok := attributeReport.DataTypeValue.Value.(uint64); ok {
+					z.setOccupancy(device, value)
+				}
+			}
+		}
+
+		device.mutex.Unlock()
+	}
+}
@@ -0,0 +1,203 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestZigbeeOccupancySensor_Contract(t *testing.T) {
+	t.Run("can be assigned to a OccupancySensor", func(t *testing.T) {
+		assert.Implements(t, (*OccupancySensor)(nil), new(ZigbeeOccupancySensor))
+	})
+}
+
+func occupancyReport(node *internalNode, bitmap uint64) communicator.MessageWithSource {
+	return communicator.MessageWithSource{
+		SourceAddress: node.ieeeAddress,
+		Message: zcl.Message{
+			FrameType:           zcl.FrameGlobal,
+			Direction:           zcl.ClientToServer,
+			ClusterID:           zcl.OccupancySensingId,
+			SourceEndpoint:      node.endpoints[0],
+			DestinationEndpoint: DefaultGatewayHomeAutomationEndpoint,
+			Command: &global.ReportAttributes{
+				Records: []global.ReportAttributesRecord{
+					{
+						Identifier:    OccupancySensingOccupancy,
+						DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeBitmap8, Value: bitmap},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestZigbeeOccupancySensor_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with cluster, reads occupancy, binds and configures reporting", func(t *testing.T) {
+		mockNodeBinder := mockNodeBinder{}
+		defer mockNodeBinder.AssertExpectations(t)
+
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zos := ZigbeeOccupancySensor{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			nodeBinder:            &mockNodeBinder,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.OccupancySensingId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.OccupancySensingId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(1), []zcl.AttributeID{OccupancySensingOccupancy}).Return([]global.ReadAttributeResponseRecord{
+			{
+				Identifier:    OccupancySensingOccupancy,
+				DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeBitmap8, Value: uint64(0x01)},
+			},
+		}, nil)
+		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, zcl.OccupancySensingId).Return(nil)
+		mockZclGlobalCommunicator.On("ConfigureReporting", mock.Anything, node.ieeeAddress, false, zcl.OccupancySensingId, zigbee.NoManufacturer, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, uint8(2), OccupancySensingOccupancy, zcl.TypeBitmap8, uint16(0), uint16(occupancyMaximumReportInterval), nil).Return(nil)
+		mockEventSender.On("sendEvent", mock.MatchedBy(func(e OccupancyChanged) bool {
+			return e.Device.Identifier == device.device.Identifier && e.Status.Occupied
+		}))
+
+		err := zos.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(OccupancySensorFlag))
+		assert.Equal(t, occupancySensorState{status: OccupancyStatus{Occupied: true}, received: true}, device.occupancySensorState)
+	})
+
+	t.Run("removes capability from device without cluster", func(t *testing.T) {
+		zos := ZigbeeOccupancySensor{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{OccupancySensorFlag}
+
+		err := zos.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(OccupancySensorFlag))
+	})
+}
+
+func TestZigbeeOccupancySensor_Status(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zos := ZigbeeOccupancySensor{gateway: &mockGateway{}}
+
+		_, err := zos.Status(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns error if device does not support it", func(t *testing.T) {
+		zos := ZigbeeOccupancySensor{gateway: &mockGateway{}}
+
+		_, err := zos.Status(context.Background(), da.Device{Gateway: zos.gateway})
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns no reading available if occupancy has not been received", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zos := ZigbeeOccupancySensor{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zos.gateway
+		device.device.Capabilities = []da.Capability{OccupancySensorFlag}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		_, err := zos.Status(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+	})
+
+	t.Run("occupancy is updated and an event sent only when reports change it", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		mockNodeStore := mockNodeStore{}
+		defer mockNodeStore.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		clock := newFakeClock()
+
+		zos := ZigbeeOccupancySensor{
+			gateway:     &mockGateway{},
+			nodeStore:   &mockNodeStore,
+			deviceStore: &mockDeviceStore,
+			eventSender: &mockEventSender,
+			now:         clock.Now,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zos.gateway
+		device.device.Capabilities = []da.Capability{OccupancySensorFlag}
+		device.occupancySensorState = occupancySensorState{received: true}
+
+		changedAt := clock.Now().Add(time.Minute)
+		expectedStatus := OccupancyStatus{Occupied: true, LastChanged: changedAt}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+		mockEventSender.On("sendEvent", OccupancyChanged{Device: device.device, Status: expectedStatus}).Once()
+
+		zos.incomingReportAttributes(occupancyReport(node, 0x00))
+
+		clock.Advance(time.Minute)
+		zos.incomingReportAttributes(occupancyReport(node, 0x01))
+
+		clock.Advance(time.Minute)
+		zos.incomingReportAttributes(occupancyReport(node, 0x01))
+
+		status, err := zos.Status(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedStatus, status)
+	})
+
+	t.Run("reports from a cluster which does not back the capability on the device are ignored", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockNodeStore := mockNodeStore{}
+		defer mockNodeStore.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zos := ZigbeeOccupancySensor{
+			gateway:     &mockGateway{},
+			nodeStore:   &mockNodeStore,
+			deviceStore: &mockDeviceStore,
+			eventSender: &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zos.gateway
+		device.device.Capabilities = []da.Capability{OccupancySensorFlag}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+		device.clusterRemaps = map[da.Capability]zigbee.ClusterID{OccupancySensorFlag: 0xfc00}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+
+		zos.incomingReportAttributes(occupancyReport(node, 0x01))
+
+		_, err := zos.Status(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+	})
+}
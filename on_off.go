@@ -16,9 +16,26 @@ import (
 	"time"
 )
 
+// OffEffect is the effect a device should use when turned off with OffWithEffect.
+type OffEffect uint8
+
+const (
+	DelayedAllOff OffEffect = 0x00
+	DyingLight    OffEffect = 0x01
+)
+
+// OnOffEffect is a capability which signifies that an OnOff device supports the lighting extensions, allowing it to
+// be turned off with an effect rather than abruptly.
+type OnOffEffect interface {
+	// OffWithEffect turns the device off using the effect and variant specified, see the ZCL specification for the
+	// meaning of variants for each effect.
+	OffWithEffect(context.Context, da.Device, OffEffect, uint8) error
+}
+
 type ZigbeeOnOffState struct {
 	State           bool
 	requiresPolling bool
+	supportsEffects bool
 }
 
 type ZigbeeOnOff struct {
@@ -44,9 +61,12 @@ func (z *ZigbeeOnOff) Init() {
 	z.internalCallbacks.Add(z.NodeEnumerationCallback)
 	z.internalCallbacks.Add(z.NodeJoinCallback)
 
+	// Reports from every cluster are matched, as the on off capability may be remapped onto another cluster. Matchers
+	// are run on the provider's event loop and so must not take node or device locks, reports are instead filtered
+	// against each device's cluster by incomingReportAttributes.
 	z.zclCommunicatorCallbacks.AddCallback(z.zclCommunicatorCallbacks.NewMatch(func(address zigbee.IEEEAddress, appMsg zigbee.ApplicationMessage, zclMessage zcl.Message) bool {
 		_, canCast := zclMessage.Command.(*global.ReportAttributes)
-		return zclMessage.ClusterID == zcl.OnOffId && canCast
+		return canCast
 	}, z.incomingReportAttributes))
 }
 
@@ -61,24 +81,52 @@ func (z *ZigbeeOnOff) NodeEnumerationCallback(ctx context.Context, ine internalN
 
 		dev.onOffState.requiresPolling = false
 
-		if endpoint, found := findEndpointWithClusterId(node, dev, zcl.OnOffId); found {
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, capabilities.OnOffFlag); found {
 			addCapability(&dev.device, capabilities.OnOffFlag)
 
-			if err := retry.Retry(ctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
-				return z.nodeBinder.BindNodeToController(ctx, node.ieeeAddress, endpoint, DefaultGatewayHomeAutomationEndpoint, zcl.OnOffId)
-			}); err != nil {
-				log.Printf("failed to bind to zda: %s", err)
+			bindErr := bindDeviceToController(ctx, z.nodeBinder, z.eventSender, node, dev, endpoint, cluster)
+			if bindErr != nil {
+				log.Printf("failed to bind to zda: %s", bindErr)
 				dev.onOffState.requiresPolling = true
 			}
 
+			reportingErr := configureReporting(ctx, z.zclGlobalCommunicator, node, endpoint, cluster, onoff.OnOff, zcl.TypeBoolean, 0, 60, nil)
+			if reportingErr != nil {
+				log.Printf("failed to configure reporting to zda: %s", reportingErr)
+				dev.onOffState.requiresPolling = true
+			}
+
+			fallback := UpdateModeNone
+
+			if node.nodeDesc.LogicalType == zigbee.Router {
+				fallback = UpdateModePolled
+			}
+
+			mode, reason := reportedUpdateMode(bindErr, reportingErr, fallback)
+			setCapabilityUpdateMode(z.eventSender, dev, capabilities.OnOffFlag, mode, reason)
+
+			dev.onOffState.supportsEffects = false
+
 			if err := retry.Retry(ctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
-				return z.zclGlobalCommunicator.ConfigureReporting(ctx, node.ieeeAddress, node.supportsAPSAck, zcl.OnOffId, zigbee.NoManufacturer, endpoint, DefaultGatewayHomeAutomationEndpoint, node.nextTransactionSequence(), onoff.OnOff, zcl.TypeBoolean, 0, 60, nil)
+				response, err := z.zclGlobalCommunicator.ReadAttributes(ctx, node.ieeeAddress, node.supportsAPSAck, cluster, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, endpoint, node.nextTransactionSequence(), []zcl.AttributeID{onoff.GlobalSceneControl})
+
+				if err == nil {
+					_, dev.onOffState.supportsEffects = parseReadAttributeResponse(response).values[onoff.GlobalSceneControl]
+				}
+
+				return err
 			}); err != nil {
-				log.Printf("failed to configure reporting to zda: %s", err)
-				dev.onOffState.requiresPolling = true
+				log.Printf("failed to read on off lighting extensions: %s", err)
+			}
+
+			if dev.onOffState.supportsEffects {
+				addCapability(&dev.device, OnOffEffectFlag)
+			} else {
+				removeCapability(&dev.device, OnOffEffectFlag)
 			}
 		} else {
 			removeCapability(&dev.device, capabilities.OnOffFlag)
+			removeCapability(&dev.device, OnOffEffectFlag)
 		}
 
 		dev.mutex.Unlock()
@@ -115,7 +163,7 @@ func (z *ZigbeeOnOff) sendCommand(ctx context.Context, device da.Device, command
 	iDevice.mutex.RLock()
 	defer iDevice.mutex.RUnlock()
 
-	endpoint, found := findEndpointWithClusterId(iNode, iDevice, zcl.OnOffId)
+	endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, capabilities.OnOffFlag)
 
 	if !found {
 		return fmt.Errorf("unable to find on off cluster on zigbee device in zda")
@@ -126,13 +174,16 @@ func (z *ZigbeeOnOff) sendCommand(ctx context.Context, device da.Device, command
 		Direction:           zcl.ClientToServer,
 		TransactionSequence: iNode.nextTransactionSequence(),
 		Manufacturer:        0,
-		ClusterID:           zcl.OnOffId,
+		ClusterID:           cluster,
 		SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
 		DestinationEndpoint: endpoint,
 		Command:             command,
 	}
 
-	err := z.zclCommunicatorRequests.Request(ctx, iNode.ieeeAddress, iNode.supportsAPSAck, zclMsg)
+	cmdCtx, cancel := commandContext(ctx, iDevice)
+	defer cancel()
+
+	err := z.zclCommunicatorRequests.Request(cmdCtx, iNode.ieeeAddress, iNode.supportsAPSAck, zclMsg)
 
 	if err == nil && iDevice.onOffState.requiresPolling {
 		time.AfterFunc(delayAfterSetForPolling, func() {
@@ -153,8 +204,63 @@ func (z *ZigbeeOnOff) Off(ctx context.Context, device da.Device) error {
 	return z.sendCommand(ctx, device, &onoff.Off{})
 }
 
+func (z *ZigbeeOnOff) OffWithEffect(ctx context.Context, device da.Device, effect OffEffect, variant uint8) error {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(OnOffEffectFlag) {
+		return da.DeviceDoesNotHaveCapability
+	}
+
+	if err := validateOffEffect(effect, variant); err != nil {
+		return err
+	}
+
+	if err := z.sendCommand(ctx, device, &onoff.OffWithEffect{EffectIdentifier: uint8(effect), EffectVariant: variant}); err != nil {
+		return err
+	}
+
+	if iDevice, found := z.deviceStore.getDevice(device.Identifier); found {
+		iDevice.mutex.Lock()
+		if iDevice.onOffState.State {
+			z.setState(iDevice, false)
+		}
+		iDevice.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// validateOffEffect checks the variant is defined by the ZCL for the effect.
+func validateOffEffect(effect OffEffect, variant uint8) error {
+	switch effect {
+	case DelayedAllOff:
+		if variant > 0x02 {
+			return fmt.Errorf("variant %d is not valid for delayed all off effect", variant)
+		}
+	case DyingLight:
+		if variant > 0x00 {
+			return fmt.Errorf("variant %d is not valid for dying light effect", variant)
+		}
+	default:
+		return fmt.Errorf("unknown off effect %d", effect)
+	}
+
+	return nil
+}
+
+func (z *ZigbeeOnOff) validateArguments(iDevice *internalDevice, operation string, args []interface{}) error {
+	if operation == "OffWithEffect" {
+		return validateOffEffect(args[0].(OffEffect), args[1].(uint8))
+	}
+
+	return nil
+}
+
 func (z *ZigbeeOnOff) setState(device *internalDevice, newState bool) {
 	device.onOffState.State = newState
+	markCapabilityUpdated(device, capabilities.OnOffFlag)
 	z.eventSender.sendEvent(capabilities.OnOffState{Device: device.device, State: newState})
 }
 
@@ -179,6 +285,15 @@ func (z *ZigbeeOnOff) State(ctx context.Context, device da.Device) (bool, error)
 	return iDevice.onOffState.State, nil
 }
 
+func (z *ZigbeeOnOff) stateValues(ctx context.Context, device da.Device) ([]StateValue, error) {
+	state, err := z.State(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+
+	return []StateValue{{Name: "on", Value: state}}, nil
+}
+
 func (z *ZigbeeOnOff) incomingReportAttributes(source communicator.MessageWithSource) {
 	node, found := z.nodeStore.getNode(source.SourceAddress)
 
@@ -194,7 +309,9 @@ func (z *ZigbeeOnOff) incomingReportAttributes(source communicator.MessageWithSo
 	for _, device := range node.devices {
 		device.mutex.Lock()
 
-		if isEndpointInSlice(device.endpoints, source.Message.SourceEndpoint) {
+		cluster, _ := clusterForCapability(device, capabilities.OnOffFlag)
+
+		if isEndpointInSlice(device.endpoints, source.Message.SourceEndpoint) && cluster == source.Message.ClusterID {
 			if device.device.HasCapability(capabilities.OnOffFlag) {
 				for _, attributeReport := range report.Records {
 					switch attributeReport.Identifier {
@@ -226,12 +343,12 @@ func (z *ZigbeeOnOff) pollDevice(pctx context.Context, iNode *internalNode, iDev
 	iDevice.mutex.RLock()
 
 	if iDevice.device.HasCapability(capabilities.OnOffFlag) && iDevice.onOffState.requiresPolling && iNode.nodeDesc.LogicalType == zigbee.Router {
-		endpoint, found := findEndpointWithClusterId(iNode, iDevice, zcl.OnOffId)
+		endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, capabilities.OnOffFlag)
 		iDevice.mutex.RUnlock()
 
 		if found {
 			if err := retry.Retry(pctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
-				response, err := z.zclGlobalCommunicator.ReadAttributes(ctx, iNode.ieeeAddress, iNode.supportsAPSAck, zcl.OnOffId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, endpoint, iNode.nextTransactionSequence(), []zcl.AttributeID{onoff.OnOff})
+				response, err := z.zclGlobalCommunicator.ReadAttributes(ctx, iNode.ieeeAddress, iNode.supportsAPSAck, cluster, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, endpoint, iNode.nextTransactionSequence(), []zcl.AttributeID{onoff.OnOff})
 
 				if err == nil && len(response) == 1 {
 					state, ok := response[0].DataTypeValue.Value.(bool)
@@ -48,6 +48,33 @@ func TestZigbeeOnOff_Init(t *testing.T) {
 
 		mIntCallbacks.AssertExpectations(t)
 	})
+
+	t.Run("matches attribute reports from any cluster without consulting the node store", func(t *testing.T) {
+		mIntCallbacks := mockAdderCaller{}
+		mZclCallbacks := mockZclCommunicatorCallbacks{}
+
+		zoo := ZigbeeOnOff{
+			internalCallbacks:        &mIntCallbacks,
+			zclCommunicatorCallbacks: &mZclCallbacks,
+		}
+
+		mIntCallbacks.On("Add", mock.Anything).Twice()
+
+		var matcher communicator.Matcher
+
+		returnedMatch := communicator.Match{Id: 1}
+		mZclCallbacks.On("NewMatch", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			matcher = args.Get(0).(communicator.Matcher)
+		}).Return(returnedMatch).Once()
+		mZclCallbacks.On("AddCallback", returnedMatch).Once()
+
+		zoo.Init()
+
+		address := zigbee.GenerateLocalAdministeredIEEEAddress()
+
+		assert.True(t, matcher(address, zigbee.ApplicationMessage{}, zcl.Message{ClusterID: 0xfc00, Command: &global.ReportAttributes{}}))
+		assert.False(t, matcher(address, zigbee.ApplicationMessage{}, zcl.Message{ClusterID: zcl.OnOffId, Command: &global.ReadAttributes{}}))
+	})
 }
 
 func TestZigbeeOnOff_NodeEnumerationCallback(t *testing.T) {
@@ -72,12 +99,81 @@ func TestZigbeeOnOff_NodeEnumerationCallback(t *testing.T) {
 
 		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, zcl.OnOffId).Return(nil)
 		mockZclGlobalCommunicator.On("ConfigureReporting", mock.Anything, node.ieeeAddress, false, zcl.OnOffId, zigbee.NoManufacturer, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, expectedTransactionSeq, onoff.OnOff, zcl.TypeBoolean, uint16(0), uint16(60), nil).Return(nil)
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.OnOffId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(2), []zcl.AttributeID{onoff.GlobalSceneControl}).Return([]global.ReadAttributeResponseRecord{{Identifier: onoff.GlobalSceneControl, Status: 0x86}}, nil)
 
 		err := zoo.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
 		assert.NoError(t, err)
 
 		has := device.device.HasCapability(capabilities.OnOffFlag)
 		assert.True(t, has)
+		assert.False(t, device.device.HasCapability(OnOffEffectFlag))
+
+		mockNodeBinder.AssertExpectations(t)
+		mockZclGlobalCommunicator.AssertExpectations(t)
+	})
+
+	t.Run("uses the remapped cluster when the on off capability has been remapped on the device", func(t *testing.T) {
+		mockNodeBinder := mockNodeBinder{}
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+
+		zoo := ZigbeeOnOff{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			nodeBinder:            &mockNodeBinder,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zoo.gateway
+		device.clusterRemaps = map[da.Capability]zigbee.ClusterID{capabilities.OnOffFlag: 0xfc00}
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{0xfc00}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, zigbee.ClusterID(0xfc00)).Return(nil)
+		mockZclGlobalCommunicator.On("ConfigureReporting", mock.Anything, node.ieeeAddress, false, zigbee.ClusterID(0xfc00), zigbee.NoManufacturer, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, uint8(1), onoff.OnOff, zcl.TypeBoolean, uint16(0), uint16(60), nil).Return(nil)
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zigbee.ClusterID(0xfc00), zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(2), []zcl.AttributeID{onoff.GlobalSceneControl}).Return([]global.ReadAttributeResponseRecord{}, nil)
+
+		err := zoo.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(capabilities.OnOffFlag))
+
+		mockNodeBinder.AssertExpectations(t)
+		mockZclGlobalCommunicator.AssertExpectations(t)
+	})
+
+	t.Run("adds OnOffEffect capability to device which supports the lighting extensions", func(t *testing.T) {
+		mockNodeBinder := mockNodeBinder{}
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+
+		zoo := ZigbeeOnOff{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			nodeBinder:            &mockNodeBinder,
+		}
+
+		node, device := generateTestNodeAndDevice()
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.OnOffId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, zcl.OnOffId).Return(nil)
+		mockZclGlobalCommunicator.On("ConfigureReporting", mock.Anything, node.ieeeAddress, false, zcl.OnOffId, zigbee.NoManufacturer, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, mock.Anything, onoff.OnOff, zcl.TypeBoolean, uint16(0), uint16(60), nil).Return(nil)
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.OnOffId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, mock.Anything, []zcl.AttributeID{onoff.GlobalSceneControl}).Return([]global.ReadAttributeResponseRecord{
+			{
+				Identifier:    onoff.GlobalSceneControl,
+				DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeBoolean, Value: true},
+			},
+		}, nil)
+
+		err := zoo.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(OnOffEffectFlag))
+		assert.True(t, device.onOffState.supportsEffects)
+		assert.Equal(t, UpdateModeReported, capabilityUpdateModes(device)[0].Mode)
 
 		mockNodeBinder.AssertExpectations(t)
 		mockZclGlobalCommunicator.AssertExpectations(t)
@@ -86,10 +182,12 @@ func TestZigbeeOnOff_NodeEnumerationCallback(t *testing.T) {
 	t.Run("the device is set to require polling if binding fails", func(t *testing.T) {
 		mockNodeBinder := mockNodeBinder{}
 		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		mockEventSender := mockEventSender{}
 
 		zoo := ZigbeeOnOff{
 			zclGlobalCommunicator: &mockZclGlobalCommunicator,
 			nodeBinder:            &mockNodeBinder,
+			eventSender:           &mockEventSender,
 		}
 
 		node, device := generateTestNodeAndDevice()
@@ -100,8 +198,10 @@ func TestZigbeeOnOff_NodeEnumerationCallback(t *testing.T) {
 		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.OnOffId}
 		node.endpointDescriptions[deviceEndpoint] = endpointDescription
 
-		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, zcl.OnOffId).Return(errors.New("failure")).Times(DefaultNetworkRetries)
+		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, zcl.OnOffId).Return(errors.New("failure")).Times(DefaultBindRetries)
+		mockEventSender.On("sendEvent", mock.IsType(DeviceBindFailed{})).Once()
 		mockZclGlobalCommunicator.On("ConfigureReporting", mock.Anything, node.ieeeAddress, false, zcl.OnOffId, zigbee.NoManufacturer, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, mock.Anything, onoff.OnOff, zcl.TypeBoolean, uint16(0), uint16(60), nil).Return(nil)
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.OnOffId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, mock.Anything, []zcl.AttributeID{onoff.GlobalSceneControl}).Return([]global.ReadAttributeResponseRecord{}, nil)
 
 		err := zoo.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
 		assert.NoError(t, err)
@@ -134,6 +234,7 @@ func TestZigbeeOnOff_NodeEnumerationCallback(t *testing.T) {
 
 		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, zcl.OnOffId).Return(nil)
 		mockZclGlobalCommunicator.On("ConfigureReporting", mock.Anything, node.ieeeAddress, false, zcl.OnOffId, zigbee.NoManufacturer, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, mock.Anything, onoff.OnOff, zcl.TypeBoolean, uint16(0), uint16(60), nil).Return(errors.New("failure")).Times(DefaultNetworkRetries)
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.OnOffId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, mock.Anything, []zcl.AttributeID{onoff.GlobalSceneControl}).Return([]global.ReadAttributeResponseRecord{}, nil)
 
 		err := zoo.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
 		assert.NoError(t, err)
@@ -142,6 +243,7 @@ func TestZigbeeOnOff_NodeEnumerationCallback(t *testing.T) {
 		assert.True(t, has)
 
 		assert.True(t, device.onOffState.requiresPolling)
+		assert.Equal(t, UpdateModeNone, capabilityUpdateModes(device)[0].Mode, "end devices are not polled")
 
 		mockNodeBinder.AssertExpectations(t)
 		mockZclGlobalCommunicator.AssertExpectations(t)
@@ -250,6 +352,58 @@ func TestZigbeeOnOff_On(t *testing.T) {
 	})
 }
 
+func TestZigbeeOnOff_MultipleEndpoints(t *testing.T) {
+	t.Run("a three gang switch is allocated three independently controllable devices", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+
+		zed := ZigbeeEnumerateDevice{deviceStore: zgw}
+		zoo := ZigbeeOnOff{
+			gateway:                 zgw,
+			deviceStore:             zgw,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+
+		ieeeAddress := zigbee.GenerateLocalAdministeredIEEEAddress()
+		node := zgw.addNode(ieeeAddress)
+		zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		node.endpoints = []zigbee.Endpoint{0x01, 0x02, 0x03}
+
+		for _, endpoint := range node.endpoints {
+			node.endpointDescriptions[endpoint] = zigbee.EndpointDescription{
+				Endpoint:      endpoint,
+				ProfileID:     zigbee.ProfileHomeAutomation,
+				DeviceID:      0x0100,
+				DeviceVersion: 1,
+				InClusterList: []zigbee.ClusterID{zcl.OnOffId},
+			}
+		}
+
+		zed.allocateEndpointsToDevices(node)
+
+		devices := node.getDevices()
+		assert.Len(t, devices, 3)
+
+		for _, iDev := range devices {
+			assert.Len(t, iDev.endpoints, 1)
+			iDev.device.Capabilities = []da.Capability{capabilities.OnOffFlag}
+
+			endpoint := iDev.endpoints[0]
+
+			mockZclCommunicatorRequests.On("Request", mock.Anything, ieeeAddress, false, mock.MatchedBy(func(msg zcl.Message) bool {
+				_, isOn := msg.Command.(*onoff.On)
+				return isOn && msg.ClusterID == zcl.OnOffId && msg.DestinationEndpoint == endpoint
+			})).Return(nil).Once()
+
+			err := zoo.On(context.Background(), iDev.device)
+			assert.NoError(t, err)
+		}
+
+		mockZclCommunicatorRequests.AssertExpectations(t)
+	})
+}
+
 func TestZigbeeOnOff_Off(t *testing.T) {
 	t.Run("returns error if device to be enumerated does not belong to gateway", func(t *testing.T) {
 		zoo := ZigbeeOnOff{
@@ -431,6 +585,127 @@ func TestZigbeeOnOff_State(t *testing.T) {
 		mockDeviceStore.AssertExpectations(t)
 		mockNodeStore.AssertExpectations(t)
 	})
+
+	t.Run("reports are only applied from the cluster backing the capability on the device", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockNodeStore := mockNodeStore{}
+		mockEventSender := mockEventSender{}
+		mockEventSender.On("sendEvent", mock.Anything)
+
+		zoo := ZigbeeOnOff{
+			gateway:     &mockGateway{},
+			nodeStore:   &mockNodeStore,
+			deviceStore: &mockDeviceStore,
+			eventSender: &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zoo.gateway
+		device.device.Capabilities = []da.Capability{capabilities.OnOffFlag}
+		device.clusterRemaps = map[da.Capability]zigbee.ClusterID{capabilities.OnOffFlag: 0xfc00}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		report := func(cluster zigbee.ClusterID) {
+			zoo.incomingReportAttributes(communicator.MessageWithSource{
+				SourceAddress: node.ieeeAddress,
+				Message: zcl.Message{
+					FrameType:      zcl.FrameGlobal,
+					ClusterID:      cluster,
+					SourceEndpoint: node.endpoints[0],
+					Command: &global.ReportAttributes{
+						Records: []global.ReportAttributesRecord{
+							{
+								Identifier:    onoff.OnOff,
+								DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeBoolean, Value: true},
+							},
+						},
+					},
+				},
+			})
+		}
+
+		report(zcl.OnOffId)
+
+		value, err := zoo.State(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.False(t, value)
+
+		report(0xfc00)
+
+		value, err = zoo.State(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.True(t, value)
+	})
+}
+
+func TestZigbeeOnOff_OffWithEffect(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zoo := ZigbeeOnOff{
+			gateway: &mockGateway{},
+		}
+
+		err := zoo.OffWithEffect(context.Background(), da.Device{}, DyingLight, 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if device does not support effects", func(t *testing.T) {
+		zoo := ZigbeeOnOff{
+			gateway: &mockGateway{},
+		}
+
+		device := da.Device{Gateway: zoo.gateway, Capabilities: []da.Capability{capabilities.OnOffFlag}}
+
+		err := zoo.OffWithEffect(context.Background(), device, DyingLight, 0)
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("sends OffWithEffect command to endpoint on device, and updates the cached state", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		mockEventSender := mockEventSender{}
+
+		zoo := ZigbeeOnOff{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+			eventSender:             &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zoo.gateway
+		device.device.Capabilities = []da.Capability{capabilities.OnOffFlag, OnOffEffectFlag}
+		device.onOffState.State = true
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.OnOffId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		expectedRequest := zcl.Message{
+			FrameType:           zcl.FrameLocal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: 1,
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           zcl.OnOffId,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: deviceEndpoint,
+			Command:             &onoff.OffWithEffect{EffectIdentifier: uint8(DyingLight), EffectVariant: 0},
+		}
+		mockZclCommunicatorRequests.On("Request", mock.Anything, node.ieeeAddress, false, expectedRequest).Return(nil)
+		mockEventSender.On("sendEvent", capabilities.OnOffState{Device: device.device, State: false}).Once()
+
+		err := zoo.OffWithEffect(context.Background(), device.device, DyingLight, 0)
+		assert.NoError(t, err)
+		assert.False(t, device.onOffState.State)
+
+		mockDeviceStore.AssertExpectations(t)
+		mockZclCommunicatorRequests.AssertExpectations(t)
+		mockEventSender.AssertExpectations(t)
+	})
 }
 
 func generateTestNodeAndDevice() (*internalNode, *internalDevice) {
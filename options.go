@@ -0,0 +1,116 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/zcl"
+	"math"
+	"time"
+)
+
+// Option allows the behaviour of a ZigbeeGateway to be altered when it is constructed with New.
+type Option func(*ZigbeeGateway)
+
+// WithPollJitter sets the percentage by which each poll interval is randomly varied, this spreads polling of
+// devices over time rather than having devices which were added together poll together. A value of 0 disables
+// jitter, values outside of 0 to MaximumPollJitterPercentage are clamped to that range so that intervals are never
+// shortened to near zero.
+func WithPollJitter(percentage float64) Option {
+	return func(z *ZigbeeGateway) {
+		z.poller.jitterPercentage = math.Max(0, math.Min(MaximumPollJitterPercentage, percentage))
+	}
+}
+
+// WithJoinDebounce sets the period during which repeated joins from the same node are ignored, this prevents nodes
+// which announce themselves repeatedly from being enumerated more than once. A value of 0 disables debouncing.
+func WithJoinDebounce(debounce time.Duration) Option {
+	return func(z *ZigbeeGateway) {
+		z.joinThrottle.debounce = debounce
+	}
+}
+
+// WithJoinRateLimit sets the maximum number of new nodes which will be admitted within the window provided, joins
+// beyond this are queued and admitted once the window has room. A limit of 0 disables rate limiting.
+func WithJoinRateLimit(limit int, window time.Duration) Option {
+	return func(z *ZigbeeGateway) {
+		z.joinThrottle.rateLimit = limit
+		z.joinThrottle.rateWindow = window
+	}
+}
+
+// WithPlaintextPINManagement permits commands carrying door lock PIN codes to be sent protected by the network key
+// alone, when the provider does not implement APSEncryptingProvider. Without it such commands fail with
+// APSEncryptionUnavailable, rather than exposing PIN codes to any node holding the network key.
+func WithPlaintextPINManagement() Option {
+	return func(z *ZigbeeGateway) {
+		z.apsEncryption.allowPlaintext = true
+	}
+}
+
+// WithAttributeDecoder sets the decoder used for attributes of the data type read by the gateway, replacing any default
+// decoder. This allows vendor specific data carried in octet strings, arrays or structures to be surfaced as typed
+// values. A nil decoder removes the decoder for the data type.
+func WithAttributeDecoder(dataType zcl.AttributeDataType, decoder AttributeDecoder) Option {
+	return func(z *ZigbeeGateway) {
+		z.attributeDecoders.set(dataType, decoder)
+	}
+}
+
+// WithFastEnumeration enables fast enumeration, only the reads required to establish a node's capabilities and
+// product identity are made before EnumerateDeviceSuccess is sent. Optional reads, such as the firmware version, are
+// made in the background afterwards and EnumerateDeviceFullyEnumerated is sent once they are complete.
+func WithFastEnumeration() Option {
+	return func(z *ZigbeeGateway) {
+		z.fastEnumeration = true
+	}
+}
+
+// WithEventJournal retains the last size events emitted by the gateway, allowing a reconnecting consumer to replay
+// events it missed with ReplayEvents. The journal is disabled by default.
+func WithEventJournal(size int) Option {
+	return func(z *ZigbeeGateway) {
+		z.eventJournal.size = size
+	}
+}
+
+// WithNodeLimit sets the maximum number of nodes the gateway will manage, bounding memory use. When a new node joins
+// beyond the limit it is either rejected or an existing node is evicted, according to the policy. A limit of 0, the
+// default, disables the limit.
+func WithNodeLimit(limit int, policy NodeLimitPolicy) Option {
+	return func(z *ZigbeeGateway) {
+		z.nodeLimit = limit
+		z.nodeLimitPolicy = policy
+	}
+}
+
+// WithStateChangeBatching coalesces events reporting capability state changes which occur within the window into a
+// single StateChangeBatch event, reducing churn for consumers when many devices change together. Other events are not
+// delayed, and so may be delivered before a batch containing state changes which occurred earlier. A window of 0, the
+// default, delivers each state change as its own event.
+func WithStateChangeBatching(window time.Duration) Option {
+	return func(z *ZigbeeGateway) {
+		z.eventBatcher.window = window
+	}
+}
+
+// WithLowBatteryThreshold sets the percentage remaining below which a device's battery is considered low, and
+// BatteryLow is sent. The default is DefaultLowBatteryThreshold.
+func WithLowBatteryThreshold(percentage float64) Option {
+	return func(z *ZigbeeGateway) {
+		z.lowBatteryThreshold = percentage
+	}
+}
+
+// WithBatteryLifeThreshold sets the estimated battery life remaining below which BatteryLifeLow is sent, giving
+// advance warning of a device's battery running out. The default is DefaultBatteryLifeThreshold.
+func WithBatteryLifeThreshold(remaining time.Duration) Option {
+	return func(z *ZigbeeGateway) {
+		z.batteryLifeThreshold = remaining
+	}
+}
+
+// WithCommandRetryPolicy sets how commands which fail to be delivered are resent, replacing
+// DefaultCommandRetryPolicy. Attribute reads are retried separately and are unaffected.
+func WithCommandRetryPolicy(policy CommandRetryPolicy) Option {
+	return func(z *ZigbeeGateway) {
+		z.commandRetrier.policy = policy
+	}
+}
@@ -0,0 +1,106 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestWithPollJitter(t *testing.T) {
+	t.Run("sets the jitter percentage on the gateways poller", func(t *testing.T) {
+		zgw := New(new(zigbee.MockProvider), WithPollJitter(25))
+		assert.Equal(t, 25.0, zgw.poller.jitterPercentage)
+	})
+
+	t.Run("clamps the jitter percentage to between 0 and the maximum", func(t *testing.T) {
+		zgw := New(new(zigbee.MockProvider), WithPollJitter(100))
+		assert.Equal(t, MaximumPollJitterPercentage, zgw.poller.jitterPercentage)
+
+		zgw = New(new(zigbee.MockProvider), WithPollJitter(-10))
+		assert.Equal(t, 0.0, zgw.poller.jitterPercentage)
+	})
+}
+
+func TestWithJoinDebounce(t *testing.T) {
+	t.Run("sets the debounce period on the gateways join throttle", func(t *testing.T) {
+		zgw := New(new(zigbee.MockProvider), WithJoinDebounce(time.Second))
+		assert.Equal(t, time.Second, zgw.joinThrottle.debounce)
+	})
+}
+
+func TestWithJoinRateLimit(t *testing.T) {
+	t.Run("sets the rate limit and window on the gateways join throttle", func(t *testing.T) {
+		zgw := New(new(zigbee.MockProvider), WithJoinRateLimit(5, time.Minute))
+		assert.Equal(t, 5, zgw.joinThrottle.rateLimit)
+		assert.Equal(t, time.Minute, zgw.joinThrottle.rateWindow)
+	})
+}
+
+func TestWithPlaintextPINManagement(t *testing.T) {
+	t.Run("permits the gateway to send commands requiring aps encryption without it", func(t *testing.T) {
+		zgw := New(new(zigbee.MockProvider))
+		assert.False(t, zgw.apsEncryption.allowPlaintext)
+
+		zgw = New(new(zigbee.MockProvider), WithPlaintextPINManagement())
+		assert.True(t, zgw.apsEncryption.allowPlaintext)
+	})
+}
+
+func TestWithAttributeDecoder(t *testing.T) {
+	t.Run("sets the decoder on the gateway only", func(t *testing.T) {
+		const vendorType = zcl.AttributeDataType(0xfe)
+
+		zgw := New(new(zigbee.MockProvider), WithAttributeDecoder(vendorType, func(value interface{}) (interface{}, error) {
+			return len(value.(string)), nil
+		}))
+		assert.Equal(t, 2, zgw.attributeDecoders.decode(vendorType, "ab"))
+
+		zgw = New(new(zigbee.MockProvider))
+		assert.Equal(t, []byte("ab"), zgw.attributeDecoders.decode(vendorType, "ab"))
+	})
+}
+
+func TestWithEventJournal(t *testing.T) {
+	t.Run("sets the size of the gateways event journal", func(t *testing.T) {
+		zgw := New(new(zigbee.MockProvider), WithEventJournal(50))
+		assert.Equal(t, 50, zgw.eventJournal.size)
+	})
+}
+
+func TestWithNodeLimit(t *testing.T) {
+	t.Run("sets the node limit and policy of the gateway", func(t *testing.T) {
+		zgw := New(new(zigbee.MockProvider), WithNodeLimit(20, EvictLeastRecentlySeen))
+		assert.Equal(t, 20, zgw.nodeLimit)
+		assert.Equal(t, EvictLeastRecentlySeen, zgw.nodeLimitPolicy)
+	})
+}
+
+func TestWithStateChangeBatching(t *testing.T) {
+	t.Run("sets the window of the gateways event batcher", func(t *testing.T) {
+		zgw := New(new(zigbee.MockProvider), WithStateChangeBatching(100*time.Millisecond))
+		assert.Equal(t, 100*time.Millisecond, zgw.eventBatcher.window)
+	})
+}
+
+func TestWithLowBatteryThreshold(t *testing.T) {
+	t.Run("sets the low battery threshold of the battery capability", func(t *testing.T) {
+		zgw := New(new(zigbee.MockProvider), WithLowBatteryThreshold(25))
+		assert.Equal(t, 25.0, zgw.capabilities[BatteryFlag].(*ZigbeeBattery).lowThreshold)
+	})
+}
+
+func TestWithBatteryLifeThreshold(t *testing.T) {
+	t.Run("sets the battery life threshold of the battery capability", func(t *testing.T) {
+		zgw := New(new(zigbee.MockProvider), WithBatteryLifeThreshold(72*time.Hour))
+		assert.Equal(t, 72*time.Hour, zgw.capabilities[BatteryFlag].(*ZigbeeBattery).lifeLowThreshold)
+	})
+}
+
+func TestWithCommandRetryPolicy(t *testing.T) {
+	t.Run("sets the policy used to resend commands", func(t *testing.T) {
+		zgw := New(new(zigbee.MockProvider), WithCommandRetryPolicy(CommandRetryPolicy{Retries: 3}))
+		assert.Equal(t, 3, zgw.commandRetrier.policy.Retries)
+	})
+}
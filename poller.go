@@ -3,6 +3,8 @@ package zda
 import (
 	"context"
 	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -10,13 +12,31 @@ const pollerBacklog = 200
 const pollerWorkers = 4
 const workerMaximumJobDuration = 15 * time.Second
 
+const DefaultPollJitterPercentage = 10.0
+
+// MaximumPollJitterPercentage bounds poll jitter, so that a jittered interval is never less than half the interval.
+const MaximumPollJitterPercentage = 50.0
+
 type zdaPoller struct {
+	// Accessed atomically, kept first to guarantee 64 bit alignment.
+	polls uint64
+
 	nodeStore nodeStore
+	clock     clock
 
 	pollerWork chan pollerWork
 	pollerStop chan bool
 
-	rand *rand.Rand
+	jitterPercentage float64
+
+	// slowdown multiplies the interval of every job, it is accessed atomically.
+	slowdown int32
+
+	paused     bool
+	pausedLock sync.RWMutex
+
+	rand     *rand.Rand
+	randLock *sync.Mutex
 }
 
 type pollerWork struct {
@@ -29,11 +49,12 @@ func (p *zdaPoller) Start() {
 	p.pollerStop = make(chan bool, pollerWorkers)
 	p.pollerWork = make(chan pollerWork, pollerBacklog)
 
+	p.rand = rand.New(rand.NewSource(p.clock.Now().UnixNano()))
+	p.randLock = &sync.Mutex{}
+
 	for i := 0; i < pollerWorkers; i++ {
 		go p.worker()
 	}
-
-	p.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
 }
 
 func (p *zdaPoller) Stop() {
@@ -43,9 +64,9 @@ func (p *zdaPoller) Stop() {
 }
 
 func (p *zdaPoller) AddNode(node *internalNode, interval time.Duration, fn func(context.Context, *internalNode)) {
-	initialWait := time.Duration(float64(interval) * p.rand.Float64())
+	initialWait := time.Duration(float64(interval) * p.randomFloat())
 
-	time.AfterFunc(initialWait, func() {
+	p.clock.AfterFunc(initialWait, func() {
 		p.pollerWork <- pollerWork{
 			node:     node,
 			interval: interval,
@@ -61,17 +82,94 @@ func (p *zdaPoller) worker() {
 			_, found := p.nodeStore.getNode(work.node.ieeeAddress)
 
 			if found {
-				ctx, cancel := context.WithTimeout(context.Background(), workerMaximumJobDuration)
-				work.fn(ctx, work.node)
+				if !p.isPaused() {
+					ctx, cancel := context.WithTimeout(context.Background(), workerMaximumJobDuration)
+					work.fn(ctx, work.node)
+					cancel()
 
-				time.AfterFunc(work.interval, func() {
+					atomic.AddUint64(&p.polls, 1)
+				}
+
+				p.clock.AfterFunc(p.jitteredInterval(p.slowedInterval(work.interval)), func() {
 					p.pollerWork <- work
 				})
-
-				cancel()
 			}
 		case <-p.pollerStop:
 			return
 		}
 	}
 }
+
+// Pause stops jobs from being called until Resume is called. Jobs continue to be scheduled while paused, but their
+// poll is skipped, so that on resumption they carry on at their normal interval rather than all firing at once.
+func (p *zdaPoller) Pause() {
+	p.pausedLock.Lock()
+	defer p.pausedLock.Unlock()
+
+	p.paused = true
+}
+
+// Resume allows jobs to be called again after a Pause.
+func (p *zdaPoller) Resume() {
+	p.pausedLock.Lock()
+	defer p.pausedLock.Unlock()
+
+	p.paused = false
+}
+
+// Polls returns the total number of polls performed.
+func (p *zdaPoller) Polls() uint64 {
+	return atomic.LoadUint64(&p.polls)
+}
+
+// setSlowdown multiplies the interval of every job by the factor from their next poll, a factor of 1 restores their
+// normal interval.
+func (p *zdaPoller) setSlowdown(factor int) {
+	atomic.StoreInt32(&p.slowdown, int32(factor))
+}
+
+func (p *zdaPoller) slowedInterval(interval time.Duration) time.Duration {
+	if factor := atomic.LoadInt32(&p.slowdown); factor > 1 {
+		return interval * time.Duration(factor)
+	}
+
+	return interval
+}
+
+func (p *zdaPoller) isPaused() bool {
+	p.pausedLock.RLock()
+	defer p.pausedLock.RUnlock()
+
+	return p.paused
+}
+
+// jitteredInterval returns the interval adjusted by a random amount of up to jitterPercentage in either direction,
+// this prevents nodes which were added at the same time from polling in lock step.
+func (p *zdaPoller) jitteredInterval(interval time.Duration) time.Duration {
+	if p.jitterPercentage <= 0 {
+		return interval
+	}
+
+	maximumJitter := float64(interval) * (p.jitterPercentage / 100.0)
+	jitter := maximumJitter * (2*p.randomFloat() - 1)
+
+	return interval + time.Duration(jitter)
+}
+
+func (p *zdaPoller) randomFloat() float64 {
+	p.randLock.Lock()
+	defer p.randLock.Unlock()
+
+	return p.rand.Float64()
+}
+
+// PausePolling stops all background polling of devices, this is useful to reduce airtime contention during bulk
+// operations. Polling will not resume until ResumePolling is called.
+func (z *ZigbeeGateway) PausePolling() {
+	z.poller.Pause()
+}
+
+// ResumePolling restarts background polling after PausePolling, each job will be next polled at its normal interval.
+func (z *ZigbeeGateway) ResumePolling() {
+	z.poller.Resume()
+}
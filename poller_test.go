@@ -4,10 +4,19 @@ import (
 	"context"
 	"github.com/shimmeringbee/zigbee"
 	"github.com/stretchr/testify/assert"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
+func waitForPoll(t *testing.T, called chan struct{}) {
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		assert.Fail(t, "job was not called")
+	}
+}
+
 func TestZdaPoller(t *testing.T) {
 	t.Run("jobs are called after at least the initial delay, and then called repeatedly", func(t *testing.T) {
 		node := &internalNode{ieeeAddress: zigbee.GenerateLocalAdministeredIEEEAddress()}
@@ -15,22 +24,29 @@ func TestZdaPoller(t *testing.T) {
 		mockNodeStore := mockNodeStore{}
 		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
 
+		clock := newFakeClock()
+
 		poller := zdaPoller{
 			nodeStore: &mockNodeStore,
+			clock:     clock,
 		}
 
 		poller.Start()
 		defer poller.Stop()
 
-		called := 0
+		called := make(chan struct{}, 1)
 
-		poller.AddNode(node, 5*time.Millisecond, func(ctx context.Context, node *internalNode) {
-			called++
+		poller.AddNode(node, time.Minute, func(ctx context.Context, node *internalNode) {
+			called <- struct{}{}
 		})
 
-		time.Sleep(20 * time.Millisecond)
+		for i := 0; i < 3; i++ {
+			clock.Advance(time.Minute)
+			waitForPoll(t, called)
+			assert.True(t, clock.WaitForTimers(1))
+		}
 
-		assert.Greater(t, called, 1)
+		assert.Equal(t, uint64(3), poller.Polls())
 	})
 
 	t.Run("jobs are not called if they are not in the node store", func(t *testing.T) {
@@ -39,21 +55,110 @@ func TestZdaPoller(t *testing.T) {
 		mockNodeStore := mockNodeStore{}
 		mockNodeStore.On("getNode", node.ieeeAddress).Return(&internalNode{}, false)
 
+		clock := newFakeClock()
+
 		poller := zdaPoller{
 			nodeStore: &mockNodeStore,
+			clock:     clock,
 		}
 
 		poller.Start()
 		defer poller.Stop()
 
-		called := false
+		called := int32(0)
 
-		poller.AddNode(node, 5*time.Millisecond, func(ctx context.Context, node *internalNode) {
-			called = true
+		poller.AddNode(node, time.Minute, func(ctx context.Context, node *internalNode) {
+			atomic.AddInt32(&called, 1)
 		})
 
+		clock.Advance(time.Minute)
 		time.Sleep(10 * time.Millisecond)
 
-		assert.False(t, called)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&called))
+		assert.Equal(t, uint64(0), poller.Polls())
+	})
+
+	t.Run("jobs are not called while paused, and are called again after resuming", func(t *testing.T) {
+		node := &internalNode{ieeeAddress: zigbee.GenerateLocalAdministeredIEEEAddress()}
+
+		mockNodeStore := mockNodeStore{}
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+
+		clock := newFakeClock()
+
+		poller := zdaPoller{
+			nodeStore: &mockNodeStore,
+			clock:     clock,
+		}
+
+		poller.Start()
+		defer poller.Stop()
+
+		poller.Pause()
+
+		called := make(chan struct{}, 1)
+
+		poller.AddNode(node, time.Minute, func(ctx context.Context, node *internalNode) {
+			called <- struct{}{}
+		})
+
+		for i := 0; i < 3; i++ {
+			clock.Advance(time.Minute)
+			assert.True(t, clock.WaitForTimers(1))
+		}
+
+		assert.Equal(t, uint64(0), poller.Polls())
+
+		poller.Resume()
+
+		clock.Advance(time.Minute)
+		waitForPoll(t, called)
+		assert.True(t, clock.WaitForTimers(1))
+
+		assert.Equal(t, uint64(1), poller.Polls())
+	})
+}
+
+func TestZdaPoller_jitteredInterval(t *testing.T) {
+	t.Run("intervals are returned unaltered if jitter is disabled", func(t *testing.T) {
+		poller := zdaPoller{clock: newFakeClock()}
+		poller.Start()
+		defer poller.Stop()
+
+		assert.Equal(t, 5*time.Second, poller.jitteredInterval(5*time.Second))
+	})
+
+	t.Run("intervals are distributed within the jitter percentage rather than synchronised", func(t *testing.T) {
+		poller := zdaPoller{clock: newFakeClock(), jitterPercentage: 10}
+		poller.Start()
+		defer poller.Stop()
+
+		interval := 10 * time.Second
+		seen := map[time.Duration]bool{}
+
+		for i := 0; i < 100; i++ {
+			actual := poller.jitteredInterval(interval)
+
+			assert.GreaterOrEqual(t, int64(actual), int64(9*time.Second))
+			assert.LessOrEqual(t, int64(actual), int64(11*time.Second))
+
+			seen[actual] = true
+		}
+
+		assert.Greater(t, len(seen), 1)
+	})
+}
+
+func TestZdaPoller_slowedInterval(t *testing.T) {
+	t.Run("intervals are multiplied by the slowdown factor, and restored when it is reset", func(t *testing.T) {
+		poller := zdaPoller{}
+
+		assert.Equal(t, time.Minute, poller.slowedInterval(time.Minute))
+
+		poller.setSlowdown(4)
+		assert.Equal(t, 4*time.Minute, poller.slowedInterval(time.Minute))
+
+		poller.setSlowdown(1)
+		assert.Equal(t, time.Minute, poller.slowedInterval(time.Minute))
 	})
 }
@@ -0,0 +1,207 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+	"math"
+	"time"
+)
+
+const (
+	PressureMeasuredValue = zcl.AttributeID(0x0000)
+	PressureScaledValue   = zcl.AttributeID(0x0010)
+	PressureScale         = zcl.AttributeID(0x0014)
+)
+
+// pressureInvalidValue is reported in MeasuredValue or ScaledValue by devices which are unable to measure the
+// pressure.
+const pressureInvalidValue = int64(-0x8000)
+
+// pressurePollInterval is long as pressure sensors are typically battery powered, and only wake periodically to
+// answer reads.
+const pressurePollInterval = 5 * time.Minute
+
+// PressureSensor is a capability which signifies that a device measures atmospheric pressure.
+type PressureSensor interface {
+	// Reading returns the last pressure measured by the device, in hectopascals.
+	Reading(context.Context, da.Device) (float64, error)
+}
+
+// PressureReadingChanged is sent to inform consumers that the pressure measured by a device has changed.
+type PressureReadingChanged struct {
+	// Device whose reading has changed.
+	Device da.Device
+	// New pressure measured, in hectopascals.
+	Pressure float64
+}
+
+// pressureSensorState is the pressure last measured by the device, available is false until a valid measurement has
+// been read.
+type pressureSensorState struct {
+	hectopascals float64
+	available    bool
+}
+
+type ZigbeePressureSensor struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+
+	zclGlobalCommunicator zclGlobalCommunicator
+
+	poller      poller
+	eventSender eventSender
+}
+
+func (z *ZigbeePressureSensor) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+	z.internalCallbacks.Add(z.NodeJoinCallback)
+}
+
+func (z *ZigbeePressureSensor) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, PressureSensorFlag); found {
+			addCapability(&dev.device, PressureSensorFlag)
+			setCapabilityUpdateMode(z.eventSender, dev, PressureSensorFlag, UpdateModePolled, "polled")
+
+			if err := z.readPressure(ctx, node, dev, endpoint, cluster); err != nil {
+				log.Printf("failed to read pressure: %s", err)
+			}
+		} else {
+			removeCapability(&dev.device, PressureSensorFlag)
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (z *ZigbeePressureSensor) NodeJoinCallback(ctx context.Context, join internalNodeJoin) error {
+	z.poller.AddNode(join.node, pressurePollInterval, z.pollNode)
+	return nil
+}
+
+// readPressure reads the measured and scaled values from the device and updates the cached reading. The node mutex
+// must be held, and the device mutex held for writing, by the caller.
+func (z *ZigbeePressureSensor) readPressure(ctx context.Context, iNode *internalNode, iDevice *internalDevice, endpoint zigbee.Endpoint, cluster zigbee.ClusterID) error {
+	response, _, err := readAttributesWithEndpointFallback(ctx, z.zclGlobalCommunicator, z.eventSender, iNode, iDevice, PressureSensorFlag, endpoint, cluster, []zcl.AttributeID{PressureMeasuredValue, PressureScaledValue, PressureScale})
+
+	if err == nil {
+		markCapabilityUpdated(iDevice, PressureSensorFlag)
+
+		hectopascals, available := pressureFromResults(parseReadAttributeResponse(response))
+		z.setPressure(iDevice, hectopascals, available)
+	}
+
+	return err
+}
+
+// pressureFromResults returns the pressure in hectopascals, preferring the higher resolution ScaledValue if the
+// device supports it. MeasuredValue is in tenths of a kilopascal, and so is already in hectopascals, while ScaledValue
+// is in kilopascals multiplied by ten to the power of Scale. False is returned if the device did not provide a valid
+// value.
+func pressureFromResults(results readAttributeResults) (float64, bool) {
+	scaledValue, hasScaledValue := results.intValue(PressureScaledValue)
+	scale, hasScale := results.intValue(PressureScale)
+
+	if hasScaledValue && hasScale && scaledValue != pressureInvalidValue {
+		return float64(scaledValue) * math.Pow10(1-int(scale)), true
+	}
+
+	if measuredValue, ok := results.intValue(PressureMeasuredValue); ok && measuredValue != pressureInvalidValue {
+		return float64(measuredValue), true
+	}
+
+	return 0, false
+}
+
+// setPressure records the pressure of the device, sending an event if it has changed. The device mutex must be held
+// for writing by the caller.
+func (z *ZigbeePressureSensor) setPressure(iDevice *internalDevice, hectopascals float64, available bool) {
+	previous := iDevice.pressureSensorState
+	iDevice.pressureSensorState = pressureSensorState{hectopascals: hectopascals, available: available}
+
+	if available && (!previous.available || previous.hectopascals != hectopascals) {
+		z.eventSender.sendEvent(PressureReadingChanged{Device: iDevice.device, Pressure: hectopascals})
+	}
+}
+
+func (z *ZigbeePressureSensor) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(PressureSensorFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+// Reading returns the last pressure measured by the device, in hectopascals. NoReadingAvailable is returned if no
+// measurement has been received, the device reported that its measurement is invalid, or the reading is stale.
+func (z *ZigbeePressureSensor) Reading(ctx context.Context, device da.Device) (float64, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return 0, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	if !iDevice.pressureSensorState.available || capabilityUnavailable(iDevice, PressureSensorFlag) {
+		return 0, NoReadingAvailable
+	}
+
+	return iDevice.pressureSensorState.hectopascals, nil
+}
+
+func (z *ZigbeePressureSensor) stateValues(ctx context.Context, device da.Device) ([]StateValue, error) {
+	reading, err := z.Reading(ctx, device)
+	return readingStateValues("pressure", UnitHectopascal, reading, err)
+}
+
+func (z *ZigbeePressureSensor) pollNode(pctx context.Context, iNode *internalNode) {
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	for _, iDevice := range iNode.devices {
+		z.pollDevice(pctx, iNode, iDevice)
+	}
+}
+
+// pollDevice reads the pressure of the device, if it has the capability. The node mutex must be held by the caller.
+func (z *ZigbeePressureSensor) pollDevice(pctx context.Context, iNode *internalNode, iDevice *internalDevice) {
+	iDevice.mutex.Lock()
+	defer iDevice.mutex.Unlock()
+
+	if !iDevice.device.HasCapability(PressureSensorFlag) {
+		return
+	}
+
+	if endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, PressureSensorFlag); found {
+		if err := z.readPressure(pctx, iNode, iDevice, endpoint, cluster); err != nil {
+			log.Printf("failed to query pressure in zda: %s", err)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZigbeePressureSensor_Contract(t *testing.T) {
+	t.Run("can be assigned to a PressureSensor", func(t *testing.T) {
+		assert.Implements(t, (*PressureSensor)(nil), new(ZigbeePressureSensor))
+	})
+}
+
+func generateTestPressureNodeAndDevice() (*internalNode, *internalDevice) {
+	node, device := generateTestNodeAndDevice()
+
+	deviceEndpoint := node.endpoints[0]
+	endpointDescription := node.endpointDescriptions[deviceEndpoint]
+	endpointDescription.InClusterList = []zigbee.ClusterID{zcl.PressureMeasurementId}
+	node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+	return node, device
+}
+
+func int16AttributeRecord(id zcl.AttributeID, value int64) global.ReadAttributeResponseRecord {
+	return global.ReadAttributeResponseRecord{Identifier: id, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeSignedInt16, Value: value}}
+}
+
+func Test_pressureFromResults(t *testing.T) {
+	t.Run("uses the measured value if the device does not support scaled values", func(t *testing.T) {
+		pressure, ok := pressureFromResults(parseReadAttributeResponse([]global.ReadAttributeResponseRecord{
+			int16AttributeRecord(PressureMeasuredValue, 1013),
+			{Identifier: PressureScaledValue, Status: 0x86},
+			{Identifier: PressureScale, Status: 0x86},
+		}))
+
+		assert.True(t, ok)
+		assert.Equal(t, 1013.0, pressure)
+	})
+
+	t.Run("prefers the scaled value if the device supports it", func(t *testing.T) {
+		pressure, ok := pressureFromResults(parseReadAttributeResponse([]global.ReadAttributeResponseRecord{
+			int16AttributeRecord(PressureMeasuredValue, 1013),
+			int16AttributeRecord(PressureScaledValue, 10132),
+			{Identifier: PressureScale, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeSignedInt8, Value: int64(2)}},
+		}))
+
+		assert.True(t, ok)
+		assert.InDelta(t, 1013.2, pressure, 0.0001)
+	})
+
+	t.Run("returns false if the measurement is invalid", func(t *testing.T) {
+		_, ok := pressureFromResults(parseReadAttributeResponse([]global.ReadAttributeResponseRecord{
+			int16AttributeRecord(PressureMeasuredValue, pressureInvalidValue),
+		}))
+
+		assert.False(t, ok)
+	})
+}
+
+func TestZigbeePressureSensor_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with cluster and reads pressure", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zps := ZigbeePressureSensor{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestPressureNodeAndDevice()
+		deviceEndpoint := node.endpoints[0]
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.PressureMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(1), []zcl.AttributeID{PressureMeasuredValue, PressureScaledValue, PressureScale}).Return([]global.ReadAttributeResponseRecord{
+			int16AttributeRecord(PressureMeasuredValue, 998),
+		}, nil)
+		mockEventSender.On("sendEvent", mock.MatchedBy(func(e PressureReadingChanged) bool {
+			return e.Device.Identifier == device.device.Identifier && e.Pressure == 998
+		}))
+
+		err := zps.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(PressureSensorFlag))
+		assert.Equal(t, pressureSensorState{hectopascals: 998, available: true}, device.pressureSensorState)
+	})
+
+	t.Run("removes capability from device without cluster", func(t *testing.T) {
+		zps := ZigbeePressureSensor{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{PressureSensorFlag}
+
+		err := zps.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(PressureSensorFlag))
+	})
+}
+
+func TestZigbeePressureSensor_NodeJoinCallback(t *testing.T) {
+	t.Run("registers new nodes with the poller when they join", func(t *testing.T) {
+		node := &internalNode{}
+
+		mockPoller := mockPoller{}
+		defer mockPoller.AssertExpectations(t)
+
+		zps := ZigbeePressureSensor{poller: &mockPoller}
+
+		mockPoller.On("AddNode", node, pressurePollInterval, mock.AnythingOfType("func(context.Context, *zda.internalNode)"))
+
+		err := zps.NodeJoinCallback(context.Background(), internalNodeJoin{node: node})
+		assert.NoError(t, err)
+	})
+}
+
+func TestZigbeePressureSensor_Reading(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zps := ZigbeePressureSensor{gateway: &mockGateway{}}
+
+		_, err := zps.Reading(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns error if device does not support it", func(t *testing.T) {
+		zps := ZigbeePressureSensor{gateway: &mockGateway{}}
+
+		_, err := zps.Reading(context.Background(), da.Device{Gateway: zps.gateway})
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns the last reading, or no reading available if there is none", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zps := ZigbeePressureSensor{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestPressureNodeAndDevice()
+		device.device.Gateway = zps.gateway
+		device.device.Capabilities = []da.Capability{PressureSensorFlag}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		_, err := zps.Reading(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+
+		device.pressureSensorState = pressureSensorState{hectopascals: 1005.5, available: true}
+
+		reading, err := zps.Reading(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, 1005.5, reading)
+	})
+}
+
+func TestZigbeePressureSensor_pollNode(t *testing.T) {
+	t.Run("reads the pressure, sending an event only if it changed", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zps := ZigbeePressureSensor{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestPressureNodeAndDevice()
+		device.device.Capabilities = []da.Capability{PressureSensorFlag}
+		device.pressureSensorState = pressureSensorState{hectopascals: 1000, available: true}
+
+		attributes := []zcl.AttributeID{PressureMeasuredValue, PressureScaledValue, PressureScale}
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.PressureMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], mock.Anything, attributes).Return([]global.ReadAttributeResponseRecord{int16AttributeRecord(PressureMeasuredValue, 1000)}, nil).Once()
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.PressureMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], mock.Anything, attributes).Return([]global.ReadAttributeResponseRecord{int16AttributeRecord(PressureMeasuredValue, 1002)}, nil).Once()
+		mockEventSender.On("sendEvent", PressureReadingChanged{Device: device.device, Pressure: 1002}).Once()
+
+		zps.pollNode(context.Background(), node)
+		zps.pollNode(context.Background(), node)
+
+		assert.Equal(t, pressureSensorState{hectopascals: 1002, available: true}, device.pressureSensorState)
+	})
+}
@@ -0,0 +1,202 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+	"math"
+	"time"
+)
+
+const PublishPriceId = zcl.CommandIdentifier(0x00)
+
+// PublishPrice is sent by a Price cluster server to announce the current price, only the mandatory fields are
+// decoded, any optional fields which follow are ignored.
+type PublishPrice struct {
+	ProviderID                        uint32
+	RateLabel                         string
+	IssuerEventID                     uint32
+	CurrentTime                       uint32
+	UnitOfMeasure                     uint8
+	Currency                          uint16
+	PriceTrailingDigitAndPriceTier    uint8
+	NumberOfPriceTiersAndRegisterTier uint8
+	StartTime                         uint32
+	DurationInMinutes                 uint16
+	Price                             uint32
+}
+
+func registerPriceCommands(cr *zcl.CommandRegistry) {
+	cr.RegisterLocal(zcl.PriceId, zigbee.NoManufacturer, PublishPriceId, &PublishPrice{})
+}
+
+// zigbeeEpoch is the origin of ZCL UTCTime values.
+var zigbeeEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Price is a capability which signifies that a device publishes tariff information, such as a smart meter.
+type Price interface {
+	// CurrentPrice returns the most recent price published by the device.
+	CurrentPrice(context.Context, da.Device) (PriceInformation, error)
+}
+
+// PriceInformation is the price block most recently published by a device.
+type PriceInformation struct {
+	ProviderID    uint32
+	RateLabel     string
+	IssuerEventID uint32
+	UnitOfMeasure uint8
+	Currency      uint16
+	Tier          uint8
+
+	// Price is the raw price in the currencies base unit, with TrailingDigits digits to the right of the decimal point.
+	Price          uint32
+	TrailingDigits uint8
+
+	StartTime time.Time
+	Duration  time.Duration
+}
+
+// Value returns the price as a decimal value, in the currencies major unit.
+func (p PriceInformation) Value() float64 {
+	return float64(p.Price) / math.Pow10(int(p.TrailingDigits))
+}
+
+// PriceUpdate is sent to inform consumers that a device has published a new price.
+type PriceUpdate struct {
+	// Device that published the price.
+	Device da.Device
+	// Price published.
+	Price PriceInformation
+}
+
+type ZigbeePriceState struct {
+	Received bool
+	Current  PriceInformation
+}
+
+type ZigbeePrice struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+	nodeStore         nodeStore
+
+	zclCommunicatorCallbacks zclCommunicatorCallbacks
+
+	nodeBinder  zigbee.NodeBinder
+	eventSender eventSender
+}
+
+func (z *ZigbeePrice) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+
+	z.zclCommunicatorCallbacks.AddCallback(z.zclCommunicatorCallbacks.NewMatch(func(address zigbee.IEEEAddress, appMsg zigbee.ApplicationMessage, zclMessage zcl.Message) bool {
+		_, canCast := zclMessage.Command.(*PublishPrice)
+		return canCast
+	}, z.incomingPublishPrice))
+}
+
+func (z *ZigbeePrice) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, PriceFlag); found {
+			addCapability(&dev.device, PriceFlag)
+
+			if err := bindDeviceToController(ctx, z.nodeBinder, z.eventSender, node, dev, endpoint, cluster); err != nil {
+				log.Printf("failed to bind to zda: %s", err)
+			}
+		} else {
+			removeCapability(&dev.device, PriceFlag)
+			dev.priceState = ZigbeePriceState{}
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (z *ZigbeePrice) CurrentPrice(ctx context.Context, device da.Device) (PriceInformation, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return PriceInformation{}, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(PriceFlag) {
+		return PriceInformation{}, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return PriceInformation{}, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	if !iDevice.priceState.Received {
+		return PriceInformation{}, fmt.Errorf("no price has been published by device")
+	}
+
+	return iDevice.priceState.Current, nil
+}
+
+func (z *ZigbeePrice) incomingPublishPrice(source communicator.MessageWithSource) {
+	node, found := z.nodeStore.getNode(source.SourceAddress)
+
+	if !found {
+		return
+	}
+
+	publish := source.Message.Command.(*PublishPrice)
+	price := priceInformationFromPublishPrice(publish)
+
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+
+	for _, device := range node.devices {
+		device.mutex.Lock()
+
+		cluster, _ := clusterForCapability(device, PriceFlag)
+
+		if isEndpointInSlice(device.endpoints, source.Message.SourceEndpoint) && cluster == source.Message.ClusterID && device.device.HasCapability(PriceFlag) {
+			device.priceState = ZigbeePriceState{Received: true, Current: price}
+			markCapabilityUpdated(device, PriceFlag)
+			z.eventSender.sendEvent(PriceUpdate{Device: device.device, Price: price})
+		}
+
+		device.mutex.Unlock()
+	}
+}
+
+func priceInformationFromPublishPrice(publish *PublishPrice) PriceInformation {
+	startTime := zigbeeEpoch.Add(time.Duration(publish.StartTime) * time.Second)
+
+	if publish.StartTime == 0 {
+		startTime = zigbeeEpoch.Add(time.Duration(publish.CurrentTime) * time.Second)
+	}
+
+	return PriceInformation{
+		ProviderID:     publish.ProviderID,
+		RateLabel:      publish.RateLabel,
+		IssuerEventID:  publish.IssuerEventID,
+		UnitOfMeasure:  publish.UnitOfMeasure,
+		Currency:       publish.Currency,
+		Tier:           publish.PriceTrailingDigitAndPriceTier & 0x0f,
+		Price:          publish.Price,
+		TrailingDigits: publish.PriceTrailingDigitAndPriceTier >> 4,
+		StartTime:      startTime,
+		Duration:       time.Duration(publish.DurationInMinutes) * time.Minute,
+	}
+}
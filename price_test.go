@@ -0,0 +1,222 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/bytecodec"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestZigbeePrice_Contract(t *testing.T) {
+	t.Run("can be assigned to a Price", func(t *testing.T) {
+		assert.Implements(t, (*Price)(nil), new(ZigbeePrice))
+	})
+}
+
+func TestPublishPrice(t *testing.T) {
+	t.Run("unmarshals mandatory fields and ignores optional fields", func(t *testing.T) {
+		data := []byte{
+			0x01, 0x00, 0x00, 0x00, // ProviderID
+			0x03, 'D', 'a', 'y', // RateLabel
+			0x02, 0x00, 0x00, 0x00, // IssuerEventID
+			0x10, 0x00, 0x00, 0x00, // CurrentTime
+			0x00,       // UnitOfMeasure
+			0x3a, 0x03, // Currency
+			0x22,                   // PriceTrailingDigitAndPriceTier
+			0x11,                   // NumberOfPriceTiersAndRegisterTier
+			0x00, 0x00, 0x00, 0x00, // StartTime
+			0x3c, 0x00, // DurationInMinutes
+			0x39, 0x30, 0x00, 0x00, // Price
+			0xff, // PriceRatio, optional
+		}
+
+		actual := PublishPrice{}
+		err := bytecodec.Unmarshal(data, &actual)
+		assert.NoError(t, err)
+
+		expected := PublishPrice{
+			ProviderID:                        1,
+			RateLabel:                         "Day",
+			IssuerEventID:                     2,
+			CurrentTime:                       0x10,
+			UnitOfMeasure:                     0,
+			Currency:                          826,
+			PriceTrailingDigitAndPriceTier:    0x22,
+			NumberOfPriceTiersAndRegisterTier: 0x11,
+			StartTime:                         0,
+			DurationInMinutes:                 60,
+			Price:                             12345,
+		}
+
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("the message is registered in the command registry", func(t *testing.T) {
+		cr := zcl.NewCommandRegistry()
+		registerPriceCommands(cr)
+
+		id, err := cr.GetLocalCommandIdentifier(zcl.PriceId, zigbee.NoManufacturer, &PublishPrice{})
+		assert.NoError(t, err)
+		assert.Equal(t, PublishPriceId, id)
+	})
+}
+
+func TestZigbeePrice_CurrentPrice(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zp := ZigbeePrice{
+			gateway: &mockGateway{},
+		}
+
+		_, err := zp.CurrentPrice(context.Background(), da.Device{})
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if device does not support it", func(t *testing.T) {
+		zp := ZigbeePrice{
+			gateway: &mockGateway{},
+		}
+
+		_, err := zp.CurrentPrice(context.Background(), da.Device{Gateway: zp.gateway})
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if no price has been published", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zp := ZigbeePrice{
+			gateway:     &mockGateway{},
+			deviceStore: &mockDeviceStore,
+		}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zp.gateway
+		device.device.Capabilities = []da.Capability{PriceFlag}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		_, err := zp.CurrentPrice(context.Background(), device.device)
+		assert.Error(t, err)
+	})
+
+	t.Run("caches a published price and sends an event", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockNodeStore := mockNodeStore{}
+		mockEventSender := mockEventSender{}
+
+		zp := ZigbeePrice{
+			gateway:     &mockGateway{},
+			deviceStore: &mockDeviceStore,
+			nodeStore:   &mockNodeStore,
+			eventSender: &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zp.gateway
+		device.device.Capabilities = []da.Capability{PriceFlag}
+
+		expectedPrice := PriceInformation{
+			ProviderID:     1,
+			RateLabel:      "Day",
+			Tier:           2,
+			Price:          12345,
+			TrailingDigits: 3,
+			StartTime:      zigbeeEpoch.Add(100 * time.Second),
+			Duration:       time.Hour,
+		}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+		mockEventSender.On("sendEvent", PriceUpdate{Device: device.device, Price: expectedPrice}).Once()
+
+		zp.incomingPublishPrice(communicator.MessageWithSource{
+			SourceAddress: node.ieeeAddress,
+			Message: zcl.Message{
+				ClusterID:      zcl.PriceId,
+				SourceEndpoint: node.endpoints[0],
+				Command: &PublishPrice{
+					ProviderID:                     1,
+					RateLabel:                      "Day",
+					CurrentTime:                    100,
+					PriceTrailingDigitAndPriceTier: 0x32,
+					DurationInMinutes:              60,
+					Price:                          12345,
+				},
+			},
+		})
+
+		price, err := zp.CurrentPrice(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedPrice, price)
+		assert.Equal(t, 12.345, price.Value())
+
+		mockDeviceStore.AssertExpectations(t)
+		mockNodeStore.AssertExpectations(t)
+		mockEventSender.AssertExpectations(t)
+	})
+
+	t.Run("prices published on a cluster which does not back the capability on the device are ignored", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockNodeStore := mockNodeStore{}
+		mockEventSender := mockEventSender{}
+
+		zp := ZigbeePrice{
+			gateway:     &mockGateway{},
+			deviceStore: &mockDeviceStore,
+			nodeStore:   &mockNodeStore,
+			eventSender: &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zp.gateway
+		device.device.Capabilities = []da.Capability{PriceFlag}
+		device.clusterRemaps = map[da.Capability]zigbee.ClusterID{PriceFlag: 0xfc00}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		zp.incomingPublishPrice(communicator.MessageWithSource{
+			SourceAddress: node.ieeeAddress,
+			Message: zcl.Message{
+				ClusterID:      zcl.PriceId,
+				SourceEndpoint: node.endpoints[0],
+				Command:        &PublishPrice{ProviderID: 1, Price: 12345},
+			},
+		})
+
+		_, err := zp.CurrentPrice(context.Background(), device.device)
+		assert.Error(t, err)
+
+		mockEventSender.AssertExpectations(t)
+	})
+}
+
+func TestZigbeePrice_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with price cluster and binds", func(t *testing.T) {
+		mockNodeBinder := mockNodeBinder{}
+
+		zp := ZigbeePrice{
+			nodeBinder: &mockNodeBinder,
+		}
+
+		node, device := generateTestNodeAndDevice()
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.PriceId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, zcl.PriceId).Return(nil)
+
+		err := zp.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(PriceFlag))
+		mockNodeBinder.AssertExpectations(t)
+	})
+}
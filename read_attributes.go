@@ -0,0 +1,68 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+)
+
+// readAttributeResults is a ReadAttributesResponse split into the values of attributes which were read successfully,
+// and the statuses of those which were not.
+type readAttributeResults struct {
+	values map[zcl.AttributeID]interface{}
+	failed map[zcl.AttributeID]uint8
+}
+
+// parseReadAttributeResponse splits the records of a ReadAttributesResponse by status, devices may return success for
+// some attributes and failure, such as unsupported attribute, for others in the same response. Values have already
+// been decoded by the gateway's attribute decoders as they were read.
+func parseReadAttributeResponse(records []global.ReadAttributeResponseRecord) readAttributeResults {
+	results := readAttributeResults{
+		values: map[zcl.AttributeID]interface{}{},
+		failed: map[zcl.AttributeID]uint8{},
+	}
+
+	for _, record := range records {
+		if record.Status != 0 || record.DataTypeValue == nil {
+			results.failed[record.Identifier] = record.Status
+		} else {
+			results.values[record.Identifier] = record.DataTypeValue.Value
+		}
+	}
+
+	return results
+}
+
+func (r readAttributeResults) stringValue(id zcl.AttributeID) (string, bool) {
+	value, ok := r.values[id].(string)
+	return value, ok
+}
+
+func (r readAttributeResults) bytesValue(id zcl.AttributeID) ([]byte, bool) {
+	value, ok := r.values[id].([]byte)
+	return value, ok
+}
+
+func (r readAttributeResults) uintValue(id zcl.AttributeID) (uint64, bool) {
+	value, ok := r.values[id].(uint64)
+	return value, ok
+}
+
+func (r readAttributeResults) uint8Value(id zcl.AttributeID) (uint8, bool) {
+	value, ok := r.values[id].(uint8)
+	return value, ok
+}
+
+func (r readAttributeResults) uint16Value(id zcl.AttributeID) (uint16, bool) {
+	value, ok := r.values[id].(uint16)
+	return value, ok
+}
+
+func (r readAttributeResults) float32Value(id zcl.AttributeID) (float32, bool) {
+	value, ok := r.values[id].(float32)
+	return value, ok
+}
+
+func (r readAttributeResults) intValue(id zcl.AttributeID) (int64, bool) {
+	value, ok := r.values[id].(int64)
+	return value, ok
+}
@@ -0,0 +1,66 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseReadAttributeResponse(t *testing.T) {
+	t.Run("splits a response with mixed statuses into values and failures", func(t *testing.T) {
+		results := parseReadAttributeResponse([]global.ReadAttributeResponseRecord{
+			{
+				Identifier:    0x0004,
+				DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeStringCharacter8, Value: "manufacturer"},
+			},
+			{
+				Identifier: 0x0005,
+				Status:     0x86,
+			},
+			{
+				Identifier:    0x0006,
+				DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeUnsignedInt16, Value: uint64(12)},
+			},
+		})
+
+		assert.Equal(t, map[zcl.AttributeID]interface{}{0x0004: "manufacturer", 0x0006: uint64(12)}, results.values)
+		assert.Equal(t, map[zcl.AttributeID]uint8{0x0005: 0x86}, results.failed)
+	})
+
+	t.Run("treats a successful record without a value as failed", func(t *testing.T) {
+		results := parseReadAttributeResponse([]global.ReadAttributeResponseRecord{{Identifier: 0x0004}})
+
+		assert.Empty(t, results.values)
+		assert.Contains(t, results.failed, zcl.AttributeID(0x0004))
+	})
+
+	t.Run("typed accessors return false for missing, failed or mistyped attributes", func(t *testing.T) {
+		results := parseReadAttributeResponse([]global.ReadAttributeResponseRecord{
+			{
+				Identifier:    0x0001,
+				DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeUnsignedInt8, Value: uint64(1)},
+			},
+			{
+				Identifier: 0x0002,
+				Status:     0x86,
+			},
+		})
+
+		value, ok := results.uintValue(0x0001)
+		assert.True(t, ok)
+		assert.Equal(t, uint64(1), value)
+
+		_, ok = results.stringValue(0x0001)
+		assert.False(t, ok)
+
+		_, ok = results.uintValue(0x0002)
+		assert.False(t, ok)
+
+		_, ok = results.intValue(0x0001)
+		assert.False(t, ok)
+
+		_, ok = results.float32Value(0x0003)
+		assert.False(t, ok)
+	})
+}
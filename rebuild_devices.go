@@ -0,0 +1,37 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zigbee"
+)
+
+// RebuildNodeDevices removes every device on the node the device belongs to and rebuilds them from a fresh
+// enumeration, for use when a node has changed its endpoints or clusters, such as after a firmware update. A
+// DeviceRemoved event is sent for each existing device, followed by DeviceAdded events for the rebuilt devices.
+//
+// Unlike enumeration, this does not attempt to preserve device identifiers, and any per device state such as
+// capability overrides is discarded. Consumers must treat the rebuilt devices as new. The node itself is retained.
+func (z *ZigbeeGateway) RebuildNodeDevices(ctx context.Context, device da.Device) error {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return err
+	}
+
+	iNode := iDev.node
+
+	for _, existing := range iNode.getDevices() {
+		z.removeDevice(existing.device.Identifier)
+	}
+
+	iNode.mutex.Lock()
+	iNode.endpoints = nil
+	iNode.endpointDescriptions = map[zigbee.Endpoint]zigbee.EndpointDescription{}
+	iNode.mutex.Unlock()
+
+	z.addDevice(iNode.nextDeviceIdentifier(), iNode)
+
+	return z.capabilities[capabilities.EnumerateDeviceFlag].(*ZigbeeEnumerateDevice).queueEnumeration(ctx, iNode)
+}
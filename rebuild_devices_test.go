@@ -0,0 +1,66 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestZigbeeGateway_RebuildNodeDevices(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		err := zgw.RebuildNodeDevices(context.Background(), da.Device{})
+		assert.Error(t, err)
+	})
+
+	t.Run("removes all devices on the node, adds a fresh device and queues the node for enumeration", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		zed := zgw.capabilities[capabilities.EnumerateDeviceFlag].(*ZigbeeEnumerateDevice)
+		zed.queue = make(chan *internalNode, 1)
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDevOne := zgw.addDevice(node.nextDeviceIdentifier(), node)
+		iDevTwo := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		node.endpoints = []zigbee.Endpoint{1, 2}
+		node.endpointDescriptions[1] = zigbee.EndpointDescription{Endpoint: 1}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		for i := 0; i < 2; i++ {
+			_, _ = zgw.ReadEvent(ctx)
+		}
+
+		err := zgw.RebuildNodeDevices(context.Background(), iDevOne.device)
+		assert.NoError(t, err)
+
+		var events []interface{}
+
+		for i := 0; i < 4; i++ {
+			event, err := zgw.ReadEvent(ctx)
+			assert.NoError(t, err)
+			events = append(events, event)
+		}
+
+		assert.ElementsMatch(t, []interface{}{da.DeviceRemoved{Device: iDevOne.device}, da.DeviceRemoved{Device: iDevTwo.device}}, events[0:2])
+		assert.IsType(t, da.DeviceAdded{}, events[2])
+		assert.IsType(t, capabilities.EnumerateDeviceStart{}, events[3])
+
+		devices := node.getDevices()
+		assert.Len(t, devices, 1)
+		assert.Empty(t, node.endpoints)
+		assert.Empty(t, node.endpointDescriptions)
+
+		_, found := zgw.getDevice(iDevTwo.device.Identifier)
+		assert.False(t, found)
+
+		assert.Equal(t, node, <-zed.queue)
+	})
+}
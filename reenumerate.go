@@ -0,0 +1,191 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zigbee"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultReEnumerationConcurrency is the number of nodes ReEnumerateAll will interview at once.
+const DefaultReEnumerationConcurrency = 2
+
+// DefaultReEnumerationInterval is the minimum time between ReEnumerateAll starting the interview of each node, this
+// spreads the traffic of re-enumerating a large network over time.
+const DefaultReEnumerationInterval = 500 * time.Millisecond
+
+// ReEnumerationResult summarises the outcome of ReEnumerateAll.
+type ReEnumerationResult struct {
+	Total int
+
+	Succeeded []zigbee.IEEEAddress
+	Failed    map[zigbee.IEEEAddress]error
+	// Unreachable nodes were skipped as none of their devices have provided data within DefaultStaleDeviceAge.
+	Unreachable []zigbee.IEEEAddress
+	// InProgress nodes were skipped as they were already queued for, or undergoing, enumeration.
+	InProgress []zigbee.IEEEAddress
+	// NotStarted nodes were not interviewed because the context was cancelled first.
+	NotStarted []zigbee.IEEEAddress
+}
+
+// ReEnumerationProgress is sent by ReEnumerateAll as each node is completed or skipped. Events for the node's devices
+// are sent by enumeration as normal.
+type ReEnumerationProgress struct {
+	IEEEAddress zigbee.IEEEAddress
+	// Skipped is true if the node was unreachable or already being enumerated, and so was not interviewed.
+	Skipped bool
+	Error   error
+
+	Completed int
+	Total     int
+}
+
+// ReEnumerationCompleted is sent once ReEnumerateAll has finished, or been cancelled.
+type ReEnumerationCompleted struct {
+	Result ReEnumerationResult
+}
+
+// ReEnumerateAll re-enumerates every node on the network, for use after a change which may alter how nodes are
+// interpreted. Nodes are interviewed with bounded concurrency and a minimum interval between each, to avoid a storm
+// of traffic, the interval is lengthened while the provider is congested. Nodes which are unreachable are skipped
+// rather than waited upon, as are nodes already queued for or undergoing enumeration. Cancelling the context prevents any further nodes being started, though nodes already
+// being interviewed run to completion. The context error is returned along with the result if it was cancelled.
+func (z *ZigbeeGateway) ReEnumerateAll(ctx context.Context) (ReEnumerationResult, error) {
+	enumerator := z.capabilities[capabilities.EnumerateDeviceFlag].(*ZigbeeEnumerateDevice)
+
+	z.nodesLock.RLock()
+	var nodes []*internalNode
+	for _, iNode := range z.nodes {
+		nodes = append(nodes, iNode)
+	}
+	z.nodesLock.RUnlock()
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ieeeAddress < nodes[j].ieeeAddress })
+
+	result := ReEnumerationResult{Total: len(nodes), Failed: map[zigbee.IEEEAddress]error{}}
+	resultLock := &sync.Mutex{}
+	completed := 0
+
+	// complete records the outcome of the node, skipped is the list to add the node to if it was not interviewed.
+	complete := func(iNode *internalNode, skipped *[]zigbee.IEEEAddress, err error) {
+		resultLock.Lock()
+		defer resultLock.Unlock()
+
+		switch {
+		case skipped != nil:
+			*skipped = append(*skipped, iNode.ieeeAddress)
+		case err != nil:
+			result.Failed[iNode.ieeeAddress] = err
+		default:
+			result.Succeeded = append(result.Succeeded, iNode.ieeeAddress)
+		}
+
+		completed++
+		z.sendEvent(ReEnumerationProgress{IEEEAddress: iNode.ieeeAddress, Skipped: skipped != nil, Error: err, Completed: completed, Total: result.Total})
+	}
+
+	slots := make(chan struct{}, z.reEnumerationConcurrency)
+	wg := &sync.WaitGroup{}
+
+	var nextStart time.Time
+
+	for i, iNode := range nodes {
+		if !nodeReachable(iNode, z.clock.Now()) {
+			complete(iNode, &result.Unreachable, nil)
+			continue
+		}
+
+		if !waitUntil(ctx, nextStart) || !acquireSlot(ctx, slots) {
+			resultLock.Lock()
+			for _, remaining := range nodes[i:] {
+				result.NotStarted = append(result.NotStarted, remaining.ieeeAddress)
+			}
+			resultLock.Unlock()
+			break
+		}
+
+		if !claimEnumeration(iNode) {
+			<-slots
+			complete(iNode, &result.InProgress, nil)
+			continue
+		}
+
+		interval := z.reEnumerationInterval
+
+		if z.congestion.Congested() {
+			interval *= CongestionSlowdownFactor
+		}
+
+		nextStart = time.Now().Add(interval)
+
+		wg.Add(1)
+
+		go func(iNode *internalNode) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			enumerator.sendStart(iNode)
+
+			complete(iNode, nil, enumerator.enumerate(iNode))
+		}(iNode)
+	}
+
+	wg.Wait()
+
+	z.sendEvent(ReEnumerationCompleted{Result: result})
+
+	return result, ctx.Err()
+}
+
+// nodeReachable returns false if the node's devices have provided data, but none within DefaultStaleDeviceAge. Nodes
+// which have never provided data are presumed reachable.
+func nodeReachable(iNode *internalNode, now time.Time) bool {
+	lastUpdated := nodeLastUpdated(iNode)
+	return lastUpdated.IsZero() || now.Sub(lastUpdated) < DefaultStaleDeviceAge
+}
+
+// claimEnumeration marks the node as pending enumeration, unless it is already queued for or undergoing enumeration,
+// in which case false is returned.
+func claimEnumeration(iNode *internalNode) bool {
+	iNode.mutex.Lock()
+	defer iNode.mutex.Unlock()
+
+	if iNode.enumerationState == EnumerationPending || iNode.enumerationState == EnumerationInterviewing {
+		return false
+	}
+
+	iNode.enumerationState = EnumerationPending
+	return true
+}
+
+// waitUntil blocks until the time provided, returning false if the context is cancelled first.
+func waitUntil(ctx context.Context, at time.Time) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	wait := time.Until(at)
+
+	if wait <= 0 {
+		return true
+	}
+
+	select {
+	case <-time.After(wait):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// acquireSlot blocks until a slot is available, returning false if the context is cancelled first.
+func acquireSlot(ctx context.Context, slots chan struct{}) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,124 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestZigbeeGateway_ReEnumerateAll(t *testing.T) {
+	t.Run("enumerates reachable nodes, skipping unreachable ones, and summarises the outcome", func(t *testing.T) {
+		zgw, mockProvider, _ := NewTestZigbeeGateway()
+		zgw.reEnumerationInterval = time.Millisecond
+
+		succeeding := zgw.addNode(zigbee.IEEEAddress(0x01))
+		zgw.addDevice(succeeding.nextDeviceIdentifier(), succeeding)
+
+		failing := zgw.addNode(zigbee.IEEEAddress(0x02))
+		zgw.addDevice(failing.nextDeviceIdentifier(), failing)
+
+		unreachable := zgw.addNode(zigbee.IEEEAddress(0x03))
+		unreachableDevice := zgw.addDevice(unreachable.nextDeviceIdentifier(), unreachable)
+		unreachableDevice.capabilityUpdated = map[da.Capability]time.Time{PriceFlag: time.Now().Add(-2 * DefaultStaleDeviceAge)}
+
+		expectedErr := errors.New("no response")
+
+		mockProvider.On("QueryNodeDescription", mock.Anything, zigbee.IEEEAddress(0x01)).Return(zigbee.NodeDescription{LogicalType: zigbee.Router}, nil)
+		mockProvider.On("QueryNodeEndpoints", mock.Anything, zigbee.IEEEAddress(0x01)).Return([]zigbee.Endpoint{}, nil)
+		mockProvider.On("QueryNodeDescription", mock.Anything, zigbee.IEEEAddress(0x02)).Return(zigbee.NodeDescription{}, expectedErr)
+
+		result, err := zgw.ReEnumerateAll(context.Background())
+		assert.NoError(t, err)
+
+		assert.Equal(t, 3, result.Total)
+		assert.Equal(t, []zigbee.IEEEAddress{0x01}, result.Succeeded)
+		assert.Equal(t, map[zigbee.IEEEAddress]error{0x02: expectedErr}, result.Failed)
+		assert.Equal(t, []zigbee.IEEEAddress{0x03}, result.Unreachable)
+		assert.Empty(t, result.NotStarted)
+
+		assert.Equal(t, EnumerationComplete, succeeding.enumerationState)
+		assert.Equal(t, EnumerationFailed, failing.enumerationState)
+	})
+
+	t.Run("skips nodes which are already queued for or undergoing enumeration", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		zgw.reEnumerationInterval = time.Millisecond
+
+		pending := zgw.addNode(zigbee.IEEEAddress(0x01))
+		zgw.addDevice(pending.nextDeviceIdentifier(), pending)
+		pending.enumerationState = EnumerationPending
+
+		interviewing := zgw.addNode(zigbee.IEEEAddress(0x02))
+		zgw.addDevice(interviewing.nextDeviceIdentifier(), interviewing)
+		interviewing.enumerationState = EnumerationInterviewing
+
+		result, err := zgw.ReEnumerateAll(context.Background())
+		assert.NoError(t, err)
+
+		assert.Equal(t, []zigbee.IEEEAddress{0x01, 0x02}, result.InProgress)
+		assert.Empty(t, result.Succeeded)
+		assert.Empty(t, result.Failed)
+
+		assert.Equal(t, EnumerationPending, pending.enumerationState)
+		assert.Equal(t, EnumerationInterviewing, interviewing.enumerationState)
+	})
+
+	t.Run("judges whether nodes are reachable by the gateway's clock", func(t *testing.T) {
+		zgw, mockProvider, _ := NewTestZigbeeGateway()
+		clock := newFakeClock()
+		zgw.clock = clock
+
+		recent := zgw.addNode(zigbee.IEEEAddress(0x01))
+		recentDevice := zgw.addDevice(recent.nextDeviceIdentifier(), recent)
+		recentDevice.capabilityUpdated = map[da.Capability]time.Time{PriceFlag: clock.Now()}
+
+		stale := zgw.addNode(zigbee.IEEEAddress(0x02))
+		staleDevice := zgw.addDevice(stale.nextDeviceIdentifier(), stale)
+		staleDevice.capabilityUpdated = map[da.Capability]time.Time{PriceFlag: clock.Now().Add(-2 * DefaultStaleDeviceAge)}
+
+		expectedErr := errors.New("no response")
+		mockProvider.On("QueryNodeDescription", mock.Anything, zigbee.IEEEAddress(0x01)).Return(zigbee.NodeDescription{}, expectedErr)
+
+		result, err := zgw.ReEnumerateAll(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, map[zigbee.IEEEAddress]error{0x01: expectedErr}, result.Failed)
+		assert.Equal(t, []zigbee.IEEEAddress{0x02}, result.Unreachable)
+	})
+
+	t.Run("does not start any nodes once the context is cancelled", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.IEEEAddress(0x01))
+		zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		result, err := zgw.ReEnumerateAll(ctx)
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, []zigbee.IEEEAddress{0x01}, result.NotStarted)
+	})
+}
+
+func Test_nodeReachable(t *testing.T) {
+	t.Run("presumes a node which has never provided data is reachable", func(t *testing.T) {
+		node, _ := generateTestNodeAndDevice()
+		assert.True(t, nodeReachable(node, time.Now()))
+	})
+
+	t.Run("returns false if no device has provided data recently", func(t *testing.T) {
+		now := time.Now()
+
+		node, device := generateTestNodeAndDevice()
+		device.capabilityUpdated = map[da.Capability]time.Time{PriceFlag: now.Add(-2 * DefaultStaleDeviceAge)}
+		assert.False(t, nodeReachable(node, now))
+
+		device.capabilityUpdated[PriceFlag] = now
+		assert.True(t, nodeReachable(node, now))
+	})
+}
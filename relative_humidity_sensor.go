@@ -0,0 +1,187 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+	"time"
+)
+
+const RelativeHumidityMeasuredValue = zcl.AttributeID(0x0000)
+
+// relativeHumidityInvalidMeasuredValue is reported by devices which are unable to measure the humidity.
+const relativeHumidityInvalidMeasuredValue = uint64(0xffff)
+
+// relativeHumidityPollInterval is long as humidity sensors are typically battery powered, and only wake periodically
+// to answer reads.
+const relativeHumidityPollInterval = 5 * time.Minute
+
+// RelativeHumiditySensor is a capability which signifies that a device measures relative humidity.
+type RelativeHumiditySensor interface {
+	// Reading returns the last relative humidity measured by the device, as a percentage.
+	Reading(context.Context, da.Device) (float64, error)
+}
+
+// RelativeHumidityReadingChanged is sent to inform consumers that the relative humidity measured by a device has
+// changed.
+type RelativeHumidityReadingChanged struct {
+	// Device whose reading has changed.
+	Device da.Device
+	// New relative humidity measured, as a percentage.
+	RelativeHumidity float64
+}
+
+// relativeHumiditySensorState is the raw measured value of the device in hundredths of a percent, received is false
+// until the first value has been read.
+type relativeHumiditySensorState struct {
+	measuredValue uint64
+	received      bool
+}
+
+type ZigbeeRelativeHumiditySensor struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+
+	zclGlobalCommunicator zclGlobalCommunicator
+
+	poller      poller
+	eventSender eventSender
+}
+
+func (z *ZigbeeRelativeHumiditySensor) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+	z.internalCallbacks.Add(z.NodeJoinCallback)
+}
+
+func (z *ZigbeeRelativeHumiditySensor) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, RelativeHumiditySensorFlag); found {
+			addCapability(&dev.device, RelativeHumiditySensorFlag)
+			setCapabilityUpdateMode(z.eventSender, dev, RelativeHumiditySensorFlag, UpdateModePolled, "polled")
+
+			if err := z.readMeasuredValue(ctx, node, dev, endpoint, cluster); err != nil {
+				log.Printf("failed to read relative humidity measured value: %s", err)
+			}
+		} else {
+			removeCapability(&dev.device, RelativeHumiditySensorFlag)
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (z *ZigbeeRelativeHumiditySensor) NodeJoinCallback(ctx context.Context, join internalNodeJoin) error {
+	z.poller.AddNode(join.node, relativeHumidityPollInterval, z.pollNode)
+	return nil
+}
+
+// readMeasuredValue reads the measured value from the device and updates the cached reading. The node mutex must be
+// held, and the device mutex held for writing, by the caller.
+func (z *ZigbeeRelativeHumiditySensor) readMeasuredValue(ctx context.Context, iNode *internalNode, iDevice *internalDevice, endpoint zigbee.Endpoint, cluster zigbee.ClusterID) error {
+	response, _, err := readAttributesWithEndpointFallback(ctx, z.zclGlobalCommunicator, z.eventSender, iNode, iDevice, RelativeHumiditySensorFlag, endpoint, cluster, []zcl.AttributeID{RelativeHumidityMeasuredValue})
+
+	if err == nil {
+		if value, ok := parseReadAttributeResponse(response).uintValue(RelativeHumidityMeasuredValue); ok {
+			z.setMeasuredValue(iDevice, value)
+		}
+	}
+
+	return err
+}
+
+// setMeasuredValue records the raw measured value of the device, sending an event if the humidity has changed. The
+// device mutex must be held for writing by the caller.
+func (z *ZigbeeRelativeHumiditySensor) setMeasuredValue(iDevice *internalDevice, value uint64) {
+	markCapabilityUpdated(iDevice, RelativeHumiditySensorFlag)
+
+	previous := iDevice.relativeHumiditySensorState
+	iDevice.relativeHumiditySensorState = relativeHumiditySensorState{measuredValue: value, received: true}
+
+	if value != relativeHumidityInvalidMeasuredValue && (!previous.received || previous.measuredValue != value) {
+		z.eventSender.sendEvent(RelativeHumidityReadingChanged{Device: iDevice.device, RelativeHumidity: float64(value) / 100})
+	}
+}
+
+func (z *ZigbeeRelativeHumiditySensor) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(RelativeHumiditySensorFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+// Reading returns the last relative humidity measured by the device, as a percentage. NoReadingAvailable is returned
+// if no measurement has been received, the device reported that its measurement is invalid, or the reading is stale.
+func (z *ZigbeeRelativeHumiditySensor) Reading(ctx context.Context, device da.Device) (float64, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return 0, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	state := iDevice.relativeHumiditySensorState
+
+	if !state.received || state.measuredValue == relativeHumidityInvalidMeasuredValue || capabilityUnavailable(iDevice, RelativeHumiditySensorFlag) {
+		return 0, NoReadingAvailable
+	}
+
+	return float64(state.measuredValue) / 100, nil
+}
+
+func (z *ZigbeeRelativeHumiditySensor) stateValues(ctx context.Context, device da.Device) ([]StateValue, error) {
+	reading, err := z.Reading(ctx, device)
+	return readingStateValues("relative_humidity", UnitPercent, reading, err)
+}
+
+func (z *ZigbeeRelativeHumiditySensor) pollNode(pctx context.Context, iNode *internalNode) {
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	for _, iDevice := range iNode.devices {
+		z.pollDevice(pctx, iNode, iDevice)
+	}
+}
+
+// pollDevice reads the measured value of the device, if it has the capability. The node mutex must be held by the
+// caller.
+func (z *ZigbeeRelativeHumiditySensor) pollDevice(pctx context.Context, iNode *internalNode, iDevice *internalDevice) {
+	iDevice.mutex.Lock()
+	defer iDevice.mutex.Unlock()
+
+	if !iDevice.device.HasCapability(RelativeHumiditySensorFlag) {
+		return
+	}
+
+	if endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, RelativeHumiditySensorFlag); found {
+		if err := z.readMeasuredValue(pctx, iNode, iDevice, endpoint, cluster); err != nil {
+			log.Printf("failed to query relative humidity in zda: %s", err)
+		}
+	}
+}
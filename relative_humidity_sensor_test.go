@@ -0,0 +1,171 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZigbeeRelativeHumiditySensor_Contract(t *testing.T) {
+	t.Run("can be assigned to a RelativeHumiditySensor", func(t *testing.T) {
+		assert.Implements(t, (*RelativeHumiditySensor)(nil), new(ZigbeeRelativeHumiditySensor))
+	})
+}
+
+func relativeHumidityResponse(value uint64) []global.ReadAttributeResponseRecord {
+	return []global.ReadAttributeResponseRecord{
+		{
+			Identifier:    RelativeHumidityMeasuredValue,
+			DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeUnsignedInt16, Value: value},
+		},
+	}
+}
+
+func TestZigbeeRelativeHumiditySensor_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with cluster and reads measured value", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zrhs := ZigbeeRelativeHumiditySensor{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.RelativeHumidityMeasurementId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.RelativeHumidityMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(1), []zcl.AttributeID{RelativeHumidityMeasuredValue}).Return(relativeHumidityResponse(4575), nil)
+		mockEventSender.On("sendEvent", mock.MatchedBy(func(e RelativeHumidityReadingChanged) bool {
+			return e.Device.Identifier == device.device.Identifier && e.RelativeHumidity == 45.75
+		}))
+
+		err := zrhs.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(RelativeHumiditySensorFlag))
+		assert.Equal(t, relativeHumiditySensorState{measuredValue: 4575, received: true}, device.relativeHumiditySensorState)
+	})
+
+	t.Run("removes capability from device without cluster", func(t *testing.T) {
+		zrhs := ZigbeeRelativeHumiditySensor{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{RelativeHumiditySensorFlag}
+
+		err := zrhs.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(RelativeHumiditySensorFlag))
+	})
+}
+
+func TestZigbeeRelativeHumiditySensor_NodeJoinCallback(t *testing.T) {
+	t.Run("registers new nodes with the poller when they join", func(t *testing.T) {
+		node := &internalNode{}
+
+		mockPoller := mockPoller{}
+		defer mockPoller.AssertExpectations(t)
+
+		zrhs := ZigbeeRelativeHumiditySensor{poller: &mockPoller}
+
+		mockPoller.On("AddNode", node, relativeHumidityPollInterval, mock.AnythingOfType("func(context.Context, *zda.internalNode)"))
+
+		err := zrhs.NodeJoinCallback(context.Background(), internalNodeJoin{node: node})
+		assert.NoError(t, err)
+	})
+}
+
+func TestZigbeeRelativeHumiditySensor_Reading(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zrhs := ZigbeeRelativeHumiditySensor{gateway: &mockGateway{}}
+
+		_, err := zrhs.Reading(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns error if device does not support it", func(t *testing.T) {
+		zrhs := ZigbeeRelativeHumiditySensor{gateway: &mockGateway{}}
+
+		_, err := zrhs.Reading(context.Background(), da.Device{Gateway: zrhs.gateway})
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns the last reading, or no reading available if it was invalid", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zrhs := ZigbeeRelativeHumiditySensor{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zrhs.gateway
+		device.device.Capabilities = []da.Capability{RelativeHumiditySensorFlag}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		_, err := zrhs.Reading(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+
+		device.relativeHumiditySensorState = relativeHumiditySensorState{measuredValue: 6000, received: true}
+
+		reading, err := zrhs.Reading(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, 60.0, reading)
+
+		device.relativeHumiditySensorState = relativeHumiditySensorState{measuredValue: relativeHumidityInvalidMeasuredValue, received: true}
+
+		_, err = zrhs.Reading(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+	})
+}
+
+func TestZigbeeRelativeHumiditySensor_pollNode(t *testing.T) {
+	t.Run("reads the measured value, sending an event only if it changed", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zrhs := ZigbeeRelativeHumiditySensor{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{RelativeHumiditySensorFlag}
+		device.relativeHumiditySensorState = relativeHumiditySensorState{measuredValue: 5000, received: true}
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.RelativeHumidityMeasurementId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.RelativeHumidityMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, mock.Anything, []zcl.AttributeID{RelativeHumidityMeasuredValue}).Return(relativeHumidityResponse(5000), nil).Once()
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.RelativeHumidityMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, mock.Anything, []zcl.AttributeID{RelativeHumidityMeasuredValue}).Return(relativeHumidityResponse(5210), nil).Once()
+		mockEventSender.On("sendEvent", RelativeHumidityReadingChanged{Device: device.device, RelativeHumidity: 52.1}).Once()
+
+		zrhs.pollNode(context.Background(), node)
+		zrhs.pollNode(context.Background(), node)
+
+		assert.Equal(t, relativeHumiditySensorState{measuredValue: 5210, received: true}, device.relativeHumiditySensorState)
+	})
+
+	t.Run("does not read devices without the capability", func(t *testing.T) {
+		zrhs := ZigbeeRelativeHumiditySensor{}
+
+		node, _ := generateTestNodeAndDevice()
+
+		zrhs.pollNode(context.Background(), node)
+	})
+}
@@ -0,0 +1,103 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/retry"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"sort"
+	"time"
+)
+
+// NodeReportingConfiguration records the reporting intervals zda has configured for an attribute on a node, allowing
+// consumers to set expectations of how often a reading will update.
+type NodeReportingConfiguration struct {
+	Endpoint  zigbee.Endpoint
+	Cluster   zigbee.ClusterID
+	Attribute zcl.AttributeID
+
+	MinimumInterval time.Duration
+	MaximumInterval time.Duration
+}
+
+type nodeReportingKey struct {
+	endpoint  zigbee.Endpoint
+	cluster   zigbee.ClusterID
+	attribute zcl.AttributeID
+}
+
+// configureReporting configures the device to report the attribute to the gateway, retrying on failure. If the device
+// accepts the configuration the intervals are recorded on the node, otherwise any previous record is removed. The node
+// mutex must be held for writing by the caller.
+func configureReporting(ctx context.Context, zclGlobalCommunicator zclGlobalCommunicator, iNode *internalNode, endpoint zigbee.Endpoint, cluster zigbee.ClusterID, attribute zcl.AttributeID, dataType zcl.AttributeDataType, minimumInterval uint16, maximumInterval uint16, reportableChange interface{}) error {
+	err := retry.Retry(ctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
+		return zclGlobalCommunicator.ConfigureReporting(ctx, iNode.ieeeAddress, iNode.supportsAPSAck, cluster, zigbee.NoManufacturer, endpoint, DefaultGatewayHomeAutomationEndpoint, iNode.nextTransactionSequence(), attribute, dataType, minimumInterval, maximumInterval, reportableChange)
+	})
+
+	key := nodeReportingKey{endpoint: endpoint, cluster: cluster, attribute: attribute}
+
+	if err != nil {
+		delete(iNode.reporting, key)
+		return err
+	}
+
+	if iNode.reporting == nil {
+		iNode.reporting = map[nodeReportingKey]NodeReportingConfiguration{}
+	}
+
+	iNode.reporting[key] = NodeReportingConfiguration{
+		Endpoint:        endpoint,
+		Cluster:         cluster,
+		Attribute:       attribute,
+		MinimumInterval: time.Duration(minimumInterval) * time.Second,
+		MaximumInterval: time.Duration(maximumInterval) * time.Second,
+	}
+
+	return nil
+}
+
+// reportingConfigurations returns the reporting configurations of a node on the endpoints provided, ordered by
+// endpoint, cluster and attribute. The node mutex must be held by the caller.
+func reportingConfigurations(iNode *internalNode, endpoints []zigbee.Endpoint) []NodeReportingConfiguration {
+	var configurations []NodeReportingConfiguration
+
+	for _, configuration := range iNode.reporting {
+		if isEndpointInSlice(endpoints, configuration.Endpoint) {
+			configurations = append(configurations, configuration)
+		}
+	}
+
+	sort.Slice(configurations, func(i, j int) bool {
+		if configurations[i].Endpoint != configurations[j].Endpoint {
+			return configurations[i].Endpoint < configurations[j].Endpoint
+		}
+
+		if configurations[i].Cluster != configurations[j].Cluster {
+			return configurations[i].Cluster < configurations[j].Cluster
+		}
+
+		return configurations[i].Attribute < configurations[j].Attribute
+	})
+
+	return configurations
+}
+
+// ReportingConfiguration returns the reporting intervals zda has configured for the attributes of the device.
+func (z *ZigbeeGateway) ReportingConfiguration(device da.Device) ([]NodeReportingConfiguration, error) {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return nil, err
+	}
+
+	iNode := iDev.node
+
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	iDev.mutex.RLock()
+	defer iDev.mutex.RUnlock()
+
+	return reportingConfigurations(iNode, iDev.endpoints), nil
+}
@@ -0,0 +1,75 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func Test_configureReporting(t *testing.T) {
+	t.Run("records the intervals on the node if the device accepts the configuration", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		node, _ := generateTestNodeAndDevice()
+
+		mockZclGlobalCommunicator.On("ConfigureReporting", mock.Anything, node.ieeeAddress, false, zcl.OnOffId, zigbee.NoManufacturer, zigbee.Endpoint(1), DefaultGatewayHomeAutomationEndpoint, uint8(1), zcl.AttributeID(0x0000), zcl.TypeBoolean, uint16(0), uint16(60), nil).Return(nil)
+
+		err := configureReporting(context.Background(), &mockZclGlobalCommunicator, node, 1, zcl.OnOffId, 0x0000, zcl.TypeBoolean, 0, 60, nil)
+		assert.NoError(t, err)
+
+		expected := []NodeReportingConfiguration{{Endpoint: 1, Cluster: zcl.OnOffId, Attribute: 0x0000, MinimumInterval: 0, MaximumInterval: time.Minute}}
+		assert.Equal(t, expected, reportingConfigurations(node, []zigbee.Endpoint{1}))
+	})
+
+	t.Run("removes a previous record if the device rejects the configuration", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+
+		node, _ := generateTestNodeAndDevice()
+		node.reporting = map[nodeReportingKey]NodeReportingConfiguration{
+			{endpoint: 1, cluster: zcl.OnOffId, attribute: 0x0000}: {Endpoint: 1, Cluster: zcl.OnOffId},
+		}
+
+		mockZclGlobalCommunicator.On("ConfigureReporting", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("unsupported"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := configureReporting(ctx, &mockZclGlobalCommunicator, node, 1, zcl.OnOffId, 0x0000, zcl.TypeBoolean, 0, 60, nil)
+		assert.Error(t, err)
+
+		assert.Empty(t, reportingConfigurations(node, []zigbee.Endpoint{1}))
+	})
+}
+
+func TestZigbeeGateway_ReportingConfiguration(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		_, err := zgw.ReportingConfiguration(da.Device{})
+		assert.Error(t, err)
+	})
+
+	t.Run("returns the configurations on the endpoints of the device", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+		iDev.endpoints = []zigbee.Endpoint{2}
+
+		node.reporting = map[nodeReportingKey]NodeReportingConfiguration{
+			{endpoint: 1, cluster: zcl.OnOffId}: {Endpoint: 1, Cluster: zcl.OnOffId},
+			{endpoint: 2, cluster: zcl.OnOffId}: {Endpoint: 2, Cluster: zcl.OnOffId},
+		}
+
+		configurations, err := zgw.ReportingConfiguration(iDev.device)
+		assert.NoError(t, err)
+		assert.Equal(t, []NodeReportingConfiguration{{Endpoint: 2, Cluster: zcl.OnOffId}}, configurations)
+	})
+}
@@ -0,0 +1,106 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/retry"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+	"time"
+)
+
+const DiagnosticsNumberOfResets = zcl.AttributeID(0x0000)
+
+const resetPollInterval = 5 * time.Minute
+
+// DeviceResetCountIncreased is sent when a nodes Diagnostics NumberOfResets attribute increases between reads, this
+// is usually a sign that the device is power cycling due to loose wiring or brownouts.
+type DeviceResetCountIncreased struct {
+	// Device whose node has reset.
+	Device da.Device
+	// Previous reset count read from the node.
+	Previous uint16
+	// Current reset count read from the node.
+	Current uint16
+}
+
+type nodeResetCount struct {
+	Supported bool
+	Count     uint16
+}
+
+type zdaResetMonitor struct {
+	internalCallbacks     callbacks.Adder
+	zclGlobalCommunicator zclGlobalCommunicator
+	poller                poller
+	eventSender           eventSender
+}
+
+func (z *zdaResetMonitor) Init() {
+	z.internalCallbacks.Add(z.NodeDeferredEnumerationCallback)
+	z.internalCallbacks.Add(z.NodeJoinCallback)
+}
+
+func (z *zdaResetMonitor) NodeDeferredEnumerationCallback(ctx context.Context, ine internalNodeDeferredEnumeration) error {
+	z.pollNode(ctx, ine.node)
+	return nil
+}
+
+func (z *zdaResetMonitor) NodeJoinCallback(ctx context.Context, join internalNodeJoin) error {
+	z.poller.AddNode(join.node, resetPollInterval, z.pollNode)
+	return nil
+}
+
+func (z *zdaResetMonitor) pollNode(pctx context.Context, iNode *internalNode) {
+	iNode.mutex.RLock()
+	endpoint, found := findNodeEndpointWithClusterId(iNode, zcl.DiagnosticsId)
+	iNode.mutex.RUnlock()
+
+	if !found {
+		iNode.mutex.Lock()
+		iNode.resetCount = nodeResetCount{}
+		iNode.mutex.Unlock()
+		return
+	}
+
+	if err := retry.Retry(pctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
+		response, err := z.zclGlobalCommunicator.ReadAttributes(ctx, iNode.ieeeAddress, iNode.supportsAPSAck, zcl.DiagnosticsId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, endpoint, iNode.nextTransactionSequence(), []zcl.AttributeID{DiagnosticsNumberOfResets})
+
+		if err == nil && len(response) == 1 {
+			iNode.mutex.Lock()
+			defer iNode.mutex.Unlock()
+
+			if response[0].Status != 0 {
+				iNode.resetCount = nodeResetCount{}
+				return nil
+			}
+
+			if count, ok := response[0].DataTypeValue.Value.(uint64); ok {
+				z.updateResetCount(iNode, uint16(count))
+			}
+		}
+
+		return err
+	}); err != nil {
+		log.Printf("failed to query reset count in zda: %s", err)
+	}
+}
+
+// updateResetCount records the newly read reset count against the node, emitting an event for each device on the
+// node if it has increased since the last read. The node mutex must be held by the caller.
+func (z *zdaResetMonitor) updateResetCount(iNode *internalNode, count uint16) {
+	previous := iNode.resetCount
+	iNode.resetCount = nodeResetCount{Supported: true, Count: count}
+
+	if !previous.Supported || count <= previous.Count {
+		return
+	}
+
+	for _, iDev := range iNode.devices {
+		iDev.mutex.RLock()
+		z.eventSender.sendEvent(DeviceResetCountIncreased{Device: iDev.device, Previous: previous.Count, Current: count})
+		iDev.mutex.RUnlock()
+	}
+}
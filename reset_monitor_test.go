@@ -0,0 +1,147 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZdaResetMonitor_Init(t *testing.T) {
+	t.Run("initialises the reset monitor by registering internalCallbacks", func(t *testing.T) {
+		mIntCallbacks := mockAdderCaller{}
+
+		zrm := zdaResetMonitor{
+			internalCallbacks: &mIntCallbacks,
+		}
+
+		mIntCallbacks.On("Add", mock.Anything).Twice()
+
+		zrm.Init()
+
+		mIntCallbacks.AssertExpectations(t)
+	})
+}
+
+func TestZdaResetMonitor_NodeJoinCallback(t *testing.T) {
+	t.Run("adds node to the poller", func(t *testing.T) {
+		mockPoller := mockPoller{}
+
+		zrm := zdaResetMonitor{
+			poller: &mockPoller,
+		}
+
+		node, _ := generateTestNodeAndDevice()
+
+		mockPoller.On("AddNode", node, resetPollInterval, mock.Anything)
+
+		err := zrm.NodeJoinCallback(context.Background(), internalNodeJoin{node: node})
+		assert.NoError(t, err)
+
+		mockPoller.AssertExpectations(t)
+	})
+}
+
+func TestZdaResetMonitor_pollNode(t *testing.T) {
+	generateNodeWithDiagnostics := func() (*internalNode, *internalDevice) {
+		node, device := generateTestNodeAndDevice()
+
+		endpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[endpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.DiagnosticsId}
+		node.endpointDescriptions[endpoint] = endpointDescription
+
+		return node, device
+	}
+
+	resetCountResponse := func(count uint64) []global.ReadAttributeResponseRecord {
+		return []global.ReadAttributeResponseRecord{
+			{
+				Identifier: DiagnosticsNumberOfResets,
+				Status:     0,
+				DataTypeValue: &zcl.AttributeDataTypeValue{
+					DataType: zcl.TypeUnsignedInt16,
+					Value:    count,
+				},
+			},
+		}
+	}
+
+	t.Run("does not query a node without the diagnostics cluster", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+
+		zrm := zdaResetMonitor{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+		}
+
+		node, _ := generateTestNodeAndDevice()
+
+		zrm.pollNode(context.Background(), node)
+
+		assert.False(t, node.resetCount.Supported)
+		mockZclGlobalCommunicator.AssertExpectations(t)
+	})
+
+	t.Run("records the reset count on first read without sending an event", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		mockEventSender := mockEventSender{}
+
+		zrm := zdaResetMonitor{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, _ := generateNodeWithDiagnostics()
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.DiagnosticsId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], uint8(1), []zcl.AttributeID{DiagnosticsNumberOfResets}).Return(resetCountResponse(4), nil)
+
+		zrm.pollNode(context.Background(), node)
+
+		assert.Equal(t, nodeResetCount{Supported: true, Count: 4}, node.resetCount)
+		mockZclGlobalCommunicator.AssertExpectations(t)
+		mockEventSender.AssertExpectations(t)
+	})
+
+	t.Run("sends an event if the reset count increases between reads", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		mockEventSender := mockEventSender{}
+
+		zrm := zdaResetMonitor{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateNodeWithDiagnostics()
+		node.resetCount = nodeResetCount{Supported: true, Count: 4}
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.DiagnosticsId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], uint8(1), []zcl.AttributeID{DiagnosticsNumberOfResets}).Return(resetCountResponse(6), nil)
+		mockEventSender.On("sendEvent", DeviceResetCountIncreased{Device: device.device, Previous: 4, Current: 6})
+
+		zrm.pollNode(context.Background(), node)
+
+		assert.Equal(t, nodeResetCount{Supported: true, Count: 6}, node.resetCount)
+		mockZclGlobalCommunicator.AssertExpectations(t)
+		mockEventSender.AssertExpectations(t)
+	})
+
+	t.Run("marks the reset count unsupported if the attribute is not present", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+
+		zrm := zdaResetMonitor{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+		}
+
+		node, _ := generateNodeWithDiagnostics()
+		node.resetCount = nodeResetCount{Supported: true, Count: 4}
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.DiagnosticsId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], uint8(1), []zcl.AttributeID{DiagnosticsNumberOfResets}).Return([]global.ReadAttributeResponseRecord{{Identifier: DiagnosticsNumberOfResets, Status: 0x86}}, nil)
+
+		zrm.pollNode(context.Background(), node)
+
+		assert.False(t, node.resetCount.Supported)
+		mockZclGlobalCommunicator.AssertExpectations(t)
+	})
+}
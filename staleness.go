@@ -0,0 +1,163 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"time"
+)
+
+const stalenessCheckInterval = 30 * time.Second
+
+// defaultStaleTimeouts is the time permitted without an update before the reading of a report driven capability is
+// considered unavailable. This allows for several missed reports at the maximum reporting interval configured. IAS
+// zones are absent as they only notify on a change of status, zones which send periodic supervision reports may be
+// monitored by setting a timeout with SetStaleTimeout.
+var defaultStaleTimeouts = map[da.Capability]time.Duration{
+	IlluminanceLevelSensingFlag: 3 * illuminanceLevelMaximumReportInterval * time.Second,
+	TemperatureSensorFlag:       3 * temperatureMaximumReportInterval * time.Second,
+	RelativeHumiditySensorFlag:  3 * relativeHumidityPollInterval,
+	PressureSensorFlag:          3 * pressurePollInterval,
+	OccupancySensorFlag:         3 * occupancyMaximumReportInterval * time.Second,
+	IlluminanceSensorFlag:       3 * illuminancePollInterval,
+	BatteryFlag:                 3 * batteryPollInterval,
+	ElectricalMeasurementFlag:   3 * electricalMeasurementPollInterval,
+	MeteringFlag:                3 * meteringPollInterval,
+	WindowCoveringFlag:          3 * windowCoveringPollInterval,
+}
+
+// CapabilityUnavailable is sent when no update to a capability's reading has been received within its stale timeout,
+// the cached reading should no longer be relied upon.
+type CapabilityUnavailable struct {
+	Device      da.Device
+	Capability  da.Capability
+	LastUpdated time.Time
+}
+
+// CapabilityAvailable is sent when an update is received for a capability which was previously unavailable.
+type CapabilityAvailable struct {
+	Device     da.Device
+	Capability da.Capability
+}
+
+type zdaStalenessWatchdog struct {
+	internalCallbacks callbacks.Adder
+	poller            poller
+	eventSender       eventSender
+
+	now func() time.Time
+}
+
+func (z *zdaStalenessWatchdog) Init() {
+	z.internalCallbacks.Add(z.NodeJoinCallback)
+}
+
+func (z *zdaStalenessWatchdog) NodeJoinCallback(ctx context.Context, join internalNodeJoin) error {
+	z.poller.AddNode(join.node, stalenessCheckInterval, z.checkNode)
+	return nil
+}
+
+// staleTimeout returns the stale timeout of the capability on the device, false is returned if the capability is not
+// monitored. The device mutex must be held by the caller.
+func staleTimeout(iDev *internalDevice, capability da.Capability) (time.Duration, bool) {
+	if timeout, found := iDev.staleTimeouts[capability]; found {
+		return timeout, true
+	}
+
+	timeout, found := defaultStaleTimeouts[capability]
+	return timeout, found
+}
+
+// checkNode compares the last update of every monitored capability on the node's devices against its stale timeout,
+// sending an event whenever a capability becomes unavailable or available again.
+func (z *zdaStalenessWatchdog) checkNode(ctx context.Context, iNode *internalNode) {
+	now := z.now()
+
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	for _, iDev := range iNode.devices {
+		iDev.mutex.Lock()
+
+		for _, capability := range iDev.device.Capabilities {
+			timeout, monitored := staleTimeout(iDev, capability)
+			updated, received := iDev.capabilityUpdated[capability]
+
+			if !monitored || !received {
+				continue
+			}
+
+			stale := now.Sub(updated) > timeout
+
+			if stale && !iDev.unavailableCapabilities[capability] {
+				if iDev.unavailableCapabilities == nil {
+					iDev.unavailableCapabilities = map[da.Capability]bool{}
+				}
+
+				iDev.unavailableCapabilities[capability] = true
+				z.eventSender.sendEvent(CapabilityUnavailable{Device: iDev.device, Capability: capability, LastUpdated: updated})
+			} else if !stale && iDev.unavailableCapabilities[capability] {
+				delete(iDev.unavailableCapabilities, capability)
+				z.eventSender.sendEvent(CapabilityAvailable{Device: iDev.device, Capability: capability})
+			}
+		}
+
+		iDev.mutex.Unlock()
+	}
+}
+
+// capabilityUnavailable returns true if the capability's reading has been marked unavailable, and no update has been
+// received since to make it available again. This is checked immediately rather than waiting for the next check of
+// the node, so a fresh reading is never hidden. The device mutex must be held by the caller.
+func capabilityUnavailable(iDev *internalDevice, capability da.Capability) bool {
+	if !iDev.unavailableCapabilities[capability] {
+		return false
+	}
+
+	timeout, monitored := staleTimeout(iDev, capability)
+	return monitored && time.Since(iDev.capabilityUpdated[capability]) > timeout
+}
+
+// SetStaleTimeout overrides the time permitted without an update before the capability's reading is considered
+// unavailable, this allows any capability to be monitored, or a sensor with a long reporting interval to be given
+// longer. A timeout of 0 restores the default. Sensors, batteries, window coverings and IAS zones return
+// NoReadingAvailable while unavailable, other capabilities continue to return their last value, consumers should
+// check IsCapabilityAvailable.
+func (z *ZigbeeGateway) SetStaleTimeout(device da.Device, capability da.Capability, timeout time.Duration) error {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return err
+	}
+
+	iDev.mutex.Lock()
+	defer iDev.mutex.Unlock()
+
+	if timeout == 0 {
+		delete(iDev.staleTimeouts, capability)
+		return nil
+	}
+
+	if iDev.staleTimeouts == nil {
+		iDev.staleTimeouts = map[da.Capability]time.Duration{}
+	}
+
+	iDev.staleTimeouts[capability] = timeout
+
+	return nil
+}
+
+// IsCapabilityAvailable returns false if the capability's reading has been marked unavailable, as no update has been
+// received within its stale timeout, until an update is received.
+func (z *ZigbeeGateway) IsCapabilityAvailable(device da.Device, capability da.Capability) (bool, error) {
+	iDev, err := z.getOverridableDevice(device)
+
+	if err != nil {
+		return false, err
+	}
+
+	iDev.mutex.RLock()
+	defer iDev.mutex.RUnlock()
+
+	return !capabilityUnavailable(iDev, capability), nil
+}
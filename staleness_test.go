@@ -0,0 +1,154 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestZdaStalenessWatchdog_NodeJoinCallback(t *testing.T) {
+	t.Run("adds the node to the poller to be checked", func(t *testing.T) {
+		mockPoller := mockPoller{}
+		defer mockPoller.AssertExpectations(t)
+
+		zsw := zdaStalenessWatchdog{poller: &mockPoller}
+
+		node, _ := generateTestNodeAndDevice()
+
+		mockPoller.On("AddNode", node, stalenessCheckInterval, mock.Anything)
+
+		err := zsw.NodeJoinCallback(context.Background(), internalNodeJoin{node: node})
+		assert.NoError(t, err)
+	})
+}
+
+func TestZdaStalenessWatchdog_checkNode(t *testing.T) {
+	t.Run("marks a capability unavailable once stale, and available again after an update", func(t *testing.T) {
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		now := time.Now()
+
+		zsw := zdaStalenessWatchdog{eventSender: &mockEventSender, now: func() time.Time { return now }}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{IlluminanceLevelSensingFlag}
+
+		updated := now.Add(-defaultStaleTimeouts[IlluminanceLevelSensingFlag] - time.Second)
+		device.capabilityUpdated = map[da.Capability]time.Time{IlluminanceLevelSensingFlag: updated}
+
+		mockEventSender.On("sendEvent", CapabilityUnavailable{Device: device.device, Capability: IlluminanceLevelSensingFlag, LastUpdated: updated}).Once()
+
+		zsw.checkNode(context.Background(), node)
+		zsw.checkNode(context.Background(), node)
+
+		assert.True(t, device.unavailableCapabilities[IlluminanceLevelSensingFlag])
+
+		device.capabilityUpdated[IlluminanceLevelSensingFlag] = now
+
+		mockEventSender.On("sendEvent", CapabilityAvailable{Device: device.device, Capability: IlluminanceLevelSensingFlag}).Once()
+
+		zsw.checkNode(context.Background(), node)
+
+		assert.False(t, device.unavailableCapabilities[IlluminanceLevelSensingFlag])
+	})
+
+	t.Run("ignores capabilities which are not monitored, or have never been updated", func(t *testing.T) {
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		now := time.Now()
+
+		zsw := zdaStalenessWatchdog{eventSender: &mockEventSender, now: func() time.Time { return now }}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{IlluminanceLevelSensingFlag, PriceFlag}
+		device.capabilityUpdated = map[da.Capability]time.Time{PriceFlag: now.Add(-24 * time.Hour)}
+
+		zsw.checkNode(context.Background(), node)
+	})
+
+	t.Run("uses a stale timeout set on the device", func(t *testing.T) {
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		now := time.Now()
+
+		zsw := zdaStalenessWatchdog{eventSender: &mockEventSender, now: func() time.Time { return now }}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{PriceFlag}
+		device.capabilityUpdated = map[da.Capability]time.Time{PriceFlag: now.Add(-2 * time.Minute)}
+		device.staleTimeouts = map[da.Capability]time.Duration{PriceFlag: time.Minute}
+
+		mockEventSender.On("sendEvent", mock.IsType(CapabilityUnavailable{}))
+
+		zsw.checkNode(context.Background(), node)
+	})
+}
+
+func TestZigbeeGateway_SetStaleTimeout(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		err := zgw.SetStaleTimeout(da.Device{}, PriceFlag, time.Minute)
+		assert.Error(t, err)
+	})
+
+	t.Run("sets and clears the stale timeout of the device", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		err := zgw.SetStaleTimeout(iDev.device, PriceFlag, time.Minute)
+		assert.NoError(t, err)
+
+		timeout, monitored := staleTimeout(iDev, PriceFlag)
+		assert.True(t, monitored)
+		assert.Equal(t, time.Minute, timeout)
+
+		err = zgw.SetStaleTimeout(iDev.device, PriceFlag, 0)
+		assert.NoError(t, err)
+
+		_, monitored = staleTimeout(iDev, PriceFlag)
+		assert.False(t, monitored)
+	})
+}
+
+func TestZigbeeGateway_IsCapabilityAvailable(t *testing.T) {
+	t.Run("returns false only if the capability has been marked unavailable", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		available, err := zgw.IsCapabilityAvailable(iDev.device, IlluminanceLevelSensingFlag)
+		assert.NoError(t, err)
+		assert.True(t, available)
+
+		iDev.unavailableCapabilities = map[da.Capability]bool{IlluminanceLevelSensingFlag: true}
+
+		available, err = zgw.IsCapabilityAvailable(iDev.device, IlluminanceLevelSensingFlag)
+		assert.NoError(t, err)
+		assert.False(t, available)
+	})
+
+	t.Run("returns true once an update has been received, without waiting for the node to be checked", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		iDev.unavailableCapabilities = map[da.Capability]bool{IlluminanceLevelSensingFlag: true}
+		markCapabilityUpdated(iDev, IlluminanceLevelSensingFlag)
+
+		available, err := zgw.IsCapabilityAvailable(iDev.device, IlluminanceLevelSensingFlag)
+		assert.NoError(t, err)
+		assert.True(t, available)
+	})
+}
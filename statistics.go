@@ -0,0 +1,102 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/da"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultStaleDeviceAge is how long since a device last provided data before it is counted as stale.
+const DefaultStaleDeviceAge = time.Hour
+
+// Statistics is an aggregate snapshot of the gateway, intended as a quick overview for operators.
+type Statistics struct {
+	Nodes   int
+	Devices int
+
+	// NodeLimit is the maximum number of nodes the gateway will manage, 0 if there is no limit.
+	NodeLimit int
+
+	// DevicesPerCapability is the number of devices which have each capability.
+	DevicesPerCapability map[da.Capability]int
+
+	// ReachableDevices and StaleDevices count devices which have provided data for any capability within, or outside
+	// of, DefaultStaleDeviceAge. Devices which have never provided data are counted in neither.
+	ReachableDevices int
+	StaleDevices     int
+
+	// Polls is the total number of polls performed by the poller.
+	Polls uint64
+
+	// EventsSent is the total number of events sent to the consumer, EventsDropped is the number of those which could
+	// not be delivered because the event buffer was full.
+	EventsSent    uint64
+	EventsDropped uint64
+
+	// ProviderRejections is the total number of frames the provider has rejected due to backpressure, Congested is
+	// true if it has done so recently.
+	ProviderRejections uint64
+	Congested          bool
+}
+
+// Statistics returns an aggregate snapshot of the gateway's nodes, devices and activity.
+func (z *ZigbeeGateway) Statistics() Statistics {
+	stats := Statistics{
+		DevicesPerCapability: map[da.Capability]int{},
+		NodeLimit:            z.nodeLimit,
+		Polls:                z.poller.Polls(),
+		EventsSent:           atomic.LoadUint64(&z.eventsSent),
+		EventsDropped:        atomic.LoadUint64(&z.eventsDropped),
+		ProviderRejections:   z.congestion.Rejections(),
+		Congested:            z.congestion.Congested(),
+	}
+
+	now := z.clock.Now()
+
+	z.nodesLock.RLock()
+	defer z.nodesLock.RUnlock()
+
+	stats.Nodes = len(z.nodes)
+
+	for _, iNode := range z.nodes {
+		iNode.mutex.RLock()
+
+		for _, iDev := range iNode.devices {
+			iDev.mutex.RLock()
+
+			stats.Devices++
+
+			for _, capability := range iDev.device.Capabilities {
+				stats.DevicesPerCapability[capability]++
+			}
+
+			if lastUpdated := deviceLastUpdated(iDev); !lastUpdated.IsZero() {
+				if now.Sub(lastUpdated) < DefaultStaleDeviceAge {
+					stats.ReachableDevices++
+				} else {
+					stats.StaleDevices++
+				}
+			}
+
+			iDev.mutex.RUnlock()
+		}
+
+		iNode.mutex.RUnlock()
+	}
+
+	return stats
+}
+
+// deviceLastUpdated returns the most recent time any capability of the device was updated, the time is zero if the
+// device has never provided data. The device mutex must be held by the caller.
+func deviceLastUpdated(iDev *internalDevice) time.Time {
+	var lastUpdated time.Time
+
+	for _, updated := range iDev.capabilityUpdated {
+		if updated.After(lastUpdated) {
+			lastUpdated = updated
+		}
+	}
+
+	return lastUpdated
+}
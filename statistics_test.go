@@ -0,0 +1,95 @@
+package zda
+
+import (
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/da/capabilities"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestZigbeeGateway_Statistics(t *testing.T) {
+	t.Run("returns counts of nodes, devices and devices per capability", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDevOne := zgw.addDevice(node.nextDeviceIdentifier(), node)
+		zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		addCapability(&iDevOne.device, capabilities.OnOffFlag)
+
+		stats := zgw.Statistics()
+
+		assert.Equal(t, 1, stats.Nodes)
+		assert.Equal(t, 2, stats.Devices)
+		assert.Equal(t, 2, stats.DevicesPerCapability[capabilities.EnumerateDeviceFlag])
+		assert.Equal(t, 1, stats.DevicesPerCapability[capabilities.OnOffFlag])
+	})
+
+	t.Run("returns the node limit", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		zgw.nodeLimit = 10
+
+		stats := zgw.Statistics()
+
+		assert.Equal(t, 10, stats.NodeLimit)
+	})
+
+	t.Run("counts devices as reachable or stale by when they last provided data", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDevReachable := zgw.addDevice(node.nextDeviceIdentifier(), node)
+		iDevStale := zgw.addDevice(node.nextDeviceIdentifier(), node)
+		zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		iDevReachable.capabilityUpdated = map[da.Capability]time.Time{
+			capabilities.OnOffFlag:                 time.Now().Add(-2 * DefaultStaleDeviceAge),
+			capabilities.HasProductInformationFlag: time.Now(),
+		}
+		iDevStale.capabilityUpdated = map[da.Capability]time.Time{
+			capabilities.OnOffFlag: time.Now().Add(-2 * DefaultStaleDeviceAge),
+		}
+
+		stats := zgw.Statistics()
+
+		assert.Equal(t, 3, stats.Devices)
+		assert.Equal(t, 1, stats.ReachableDevices)
+		assert.Equal(t, 1, stats.StaleDevices)
+	})
+
+	t.Run("judges whether devices are stale by the gateway's clock", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+		clock := newFakeClock()
+		zgw.clock = clock
+
+		node := zgw.addNode(zigbee.GenerateLocalAdministeredIEEEAddress())
+		iDev := zgw.addDevice(node.nextDeviceIdentifier(), node)
+
+		iDev.capabilityUpdated = map[da.Capability]time.Time{
+			capabilities.OnOffFlag: clock.Now(),
+		}
+
+		assert.Equal(t, 1, zgw.Statistics().ReachableDevices)
+
+		clock.Advance(2 * DefaultStaleDeviceAge)
+
+		stats := zgw.Statistics()
+		assert.Equal(t, 0, stats.ReachableDevices)
+		assert.Equal(t, 1, stats.StaleDevices)
+	})
+
+	t.Run("counts events sent and dropped", func(t *testing.T) {
+		zgw, _, _ := NewTestZigbeeGateway()
+
+		for i := 0; i < cap(zgw.events)+1; i++ {
+			zgw.sendEvent(struct{}{})
+		}
+
+		stats := zgw.Statistics()
+
+		assert.Equal(t, uint64(cap(zgw.events)+1), stats.EventsSent)
+		assert.Equal(t, uint64(1), stats.EventsDropped)
+	})
+}
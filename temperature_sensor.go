@@ -0,0 +1,206 @@
+package zda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+)
+
+const TemperatureMeasuredValue = zcl.AttributeID(0x0000)
+
+// temperatureInvalidMeasuredValue is reported by devices which are unable to measure the temperature.
+const temperatureInvalidMeasuredValue = int64(-0x8000)
+
+const (
+	temperatureMaximumReportInterval = 300
+	// temperatureReportableChange is the change required before a device reports, in hundredths of a degree.
+	temperatureReportableChange = 10
+)
+
+// NoReadingAvailable is returned by sensors which have not yet received a reading from the device, whose device has
+// reported that it is unable to take a measurement, or whose reading has become stale, see IsCapabilityAvailable.
+var NoReadingAvailable = errors.New("no reading available")
+
+// TemperatureSensor is a capability which signifies that a device measures temperature.
+type TemperatureSensor interface {
+	// Reading returns the last temperature measured by the device, in degrees Celsius.
+	Reading(context.Context, da.Device) (float64, error)
+}
+
+// TemperatureReadingChanged is sent to inform consumers that the temperature measured by a device has changed.
+type TemperatureReadingChanged struct {
+	// Device whose reading has changed.
+	Device da.Device
+	// New temperature measured, in degrees Celsius.
+	Temperature float64
+}
+
+// temperatureSensorState is the raw measured value of the device in hundredths of a degree, received is false until
+// the first value has been read or reported.
+type temperatureSensorState struct {
+	measuredValue int64
+	received      bool
+}
+
+type ZigbeeTemperatureSensor struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+	nodeStore         nodeStore
+
+	zclCommunicatorCallbacks zclCommunicatorCallbacks
+	zclGlobalCommunicator    zclGlobalCommunicator
+
+	nodeBinder  zigbee.NodeBinder
+	eventSender eventSender
+}
+
+func (z *ZigbeeTemperatureSensor) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+
+	z.zclCommunicatorCallbacks.AddCallback(z.zclCommunicatorCallbacks.NewMatch(func(address zigbee.IEEEAddress, appMsg zigbee.ApplicationMessage, zclMessage zcl.Message) bool {
+		_, canCast := zclMessage.Command.(*global.ReportAttributes)
+		return canCast
+	}, z.incomingReportAttributes))
+}
+
+func (z *ZigbeeTemperatureSensor) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, TemperatureSensorFlag); found {
+			addCapability(&dev.device, TemperatureSensorFlag)
+
+			response, respondingEndpoint, err := readAttributesWithEndpointFallback(ctx, z.zclGlobalCommunicator, z.eventSender, node, dev, TemperatureSensorFlag, endpoint, cluster, []zcl.AttributeID{TemperatureMeasuredValue})
+
+			if err != nil {
+				log.Printf("failed to read temperature measured value: %s", err)
+			} else if value, ok := parseReadAttributeResponse(response).intValue(TemperatureMeasuredValue); ok {
+				z.setMeasuredValue(dev, value)
+			}
+
+			endpoint = respondingEndpoint
+
+			bindErr := bindDeviceToController(ctx, z.nodeBinder, z.eventSender, node, dev, endpoint, cluster)
+			if bindErr != nil {
+				log.Printf("failed to bind to zda: %s", bindErr)
+			}
+
+			reportingErr := configureReporting(ctx, z.zclGlobalCommunicator, node, endpoint, cluster, TemperatureMeasuredValue, zcl.TypeSignedInt16, 0, temperatureMaximumReportInterval, int16(temperatureReportableChange))
+			if reportingErr != nil {
+				log.Printf("failed to configure reporting to zda: %s", reportingErr)
+			}
+
+			mode, reason := reportedUpdateMode(bindErr, reportingErr, UpdateModeNone)
+			setCapabilityUpdateMode(z.eventSender, dev, TemperatureSensorFlag, mode, reason)
+		} else {
+			removeCapability(&dev.device, TemperatureSensorFlag)
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// setMeasuredValue records the raw measured value of the device, sending an event if the temperature has changed. The
+// device mutex must be held for writing by the caller.
+func (z *ZigbeeTemperatureSensor) setMeasuredValue(iDevice *internalDevice, value int64) {
+	markCapabilityUpdated(iDevice, TemperatureSensorFlag)
+
+	previous := iDevice.temperatureSensorState
+	iDevice.temperatureSensorState = temperatureSensorState{measuredValue: value, received: true}
+
+	if value != temperatureInvalidMeasuredValue && (!previous.received || previous.measuredValue != value) {
+		z.eventSender.sendEvent(TemperatureReadingChanged{Device: iDevice.device, Temperature: float64(value) / 100})
+	}
+}
+
+func (z *ZigbeeTemperatureSensor) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(TemperatureSensorFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+// Reading returns the last temperature measured by the device, in degrees Celsius. NoReadingAvailable is returned if
+// no measurement has been received, the device reported that its measurement is invalid, or the reading is stale.
+func (z *ZigbeeTemperatureSensor) Reading(ctx context.Context, device da.Device) (float64, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return 0, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	state := iDevice.temperatureSensorState
+
+	if !state.received || state.measuredValue == temperatureInvalidMeasuredValue || capabilityUnavailable(iDevice, TemperatureSensorFlag) {
+		return 0, NoReadingAvailable
+	}
+
+	return float64(state.measuredValue) / 100, nil
+}
+
+func (z *ZigbeeTemperatureSensor) stateValues(ctx context.Context, device da.Device) ([]StateValue, error) {
+	reading, err := z.Reading(ctx, device)
+	return readingStateValues("temperature", UnitCelsius, reading, err)
+}
+
+func (z *ZigbeeTemperatureSensor) incomingReportAttributes(source communicator.MessageWithSource) {
+	node, found := z.nodeStore.getNode(source.SourceAddress)
+
+	if !found {
+		return
+	}
+
+	report := source.Message.Command.(*global.ReportAttributes)
+
+	node.mutex.RLock()
+	defer node.mutex.RUnlock()
+
+	for _, device := range node.devices {
+		device.mutex.Lock()
+
+		cluster, _ := clusterForCapability(device, TemperatureSensorFlag)
+
+		if isEndpointInSlice(device.endpoints, source.Message.SourceEndpoint) && cluster == source.Message.ClusterID && device.device.HasCapability(TemperatureSensorFlag) {
+			for _, attributeReport := range report.Records {
+				if attributeReport.Identifier != TemperatureMeasuredValue {
+					continue
+				}
+
+				if value, ok := attributeReport.DataTypeValue.Value.(int64); ok {
+					z.setMeasuredValue(device, value)
+				}
+			}
+		}
+
+		device.mutex.Unlock()
+	}
+}
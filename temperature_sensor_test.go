@@ -0,0 +1,212 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zcl/communicator"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZigbeeTemperatureSensor_Contract(t *testing.T) {
+	t.Run("can be assigned to a TemperatureSensor", func(t *testing.T) {
+		assert.Implements(t, (*TemperatureSensor)(nil), new(ZigbeeTemperatureSensor))
+	})
+}
+
+func temperatureReport(node *internalNode, value int64) communicator.MessageWithSource {
+	return communicator.MessageWithSource{
+		SourceAddress: node.ieeeAddress,
+		Message: zcl.Message{
+			FrameType:           zcl.FrameGlobal,
+			Direction:           zcl.ClientToServer,
+			ClusterID:           zcl.TemperatureMeasurementId,
+			SourceEndpoint:      node.endpoints[0],
+			DestinationEndpoint: DefaultGatewayHomeAutomationEndpoint,
+			Command: &global.ReportAttributes{
+				Records: []global.ReportAttributesRecord{
+					{
+						Identifier:    TemperatureMeasuredValue,
+						DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeSignedInt16, Value: value},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestZigbeeTemperatureSensor_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with cluster, reads measured value, binds and configures reporting", func(t *testing.T) {
+		mockNodeBinder := mockNodeBinder{}
+		defer mockNodeBinder.AssertExpectations(t)
+
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zts := ZigbeeTemperatureSensor{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			nodeBinder:            &mockNodeBinder,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.TemperatureMeasurementId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.TemperatureMeasurementId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(1), []zcl.AttributeID{TemperatureMeasuredValue}).Return([]global.ReadAttributeResponseRecord{
+			{
+				Identifier:    TemperatureMeasuredValue,
+				DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeSignedInt16, Value: int64(2150)},
+			},
+		}, nil)
+		mockNodeBinder.On("BindNodeToController", mock.Anything, node.ieeeAddress, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, zcl.TemperatureMeasurementId).Return(nil)
+		mockZclGlobalCommunicator.On("ConfigureReporting", mock.Anything, node.ieeeAddress, false, zcl.TemperatureMeasurementId, zigbee.NoManufacturer, deviceEndpoint, DefaultGatewayHomeAutomationEndpoint, uint8(2), TemperatureMeasuredValue, zcl.TypeSignedInt16, uint16(0), uint16(temperatureMaximumReportInterval), int16(temperatureReportableChange)).Return(nil)
+		mockEventSender.On("sendEvent", mock.MatchedBy(func(e TemperatureReadingChanged) bool {
+			return e.Device.Identifier == device.device.Identifier && e.Temperature == 21.5
+		}))
+
+		err := zts.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(TemperatureSensorFlag))
+		assert.Equal(t, temperatureSensorState{measuredValue: 2150, received: true}, device.temperatureSensorState)
+	})
+
+	t.Run("removes capability from device without cluster", func(t *testing.T) {
+		zts := ZigbeeTemperatureSensor{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{TemperatureSensorFlag}
+
+		err := zts.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(TemperatureSensorFlag))
+	})
+}
+
+func TestZigbeeTemperatureSensor_Reading(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zts := ZigbeeTemperatureSensor{gateway: &mockGateway{}}
+
+		_, err := zts.Reading(context.Background(), da.Device{})
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if device does not support it", func(t *testing.T) {
+		zts := ZigbeeTemperatureSensor{gateway: &mockGateway{}}
+
+		_, err := zts.Reading(context.Background(), da.Device{Gateway: zts.gateway})
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns no reading available if nothing has been received", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zts := ZigbeeTemperatureSensor{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zts.gateway
+		device.device.Capabilities = []da.Capability{TemperatureSensorFlag}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		_, err := zts.Reading(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+	})
+
+	t.Run("reading is updated and an event sent when reported, an invalid measurement results in no reading available", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		mockNodeStore := mockNodeStore{}
+		defer mockNodeStore.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zts := ZigbeeTemperatureSensor{
+			gateway:     &mockGateway{},
+			nodeStore:   &mockNodeStore,
+			deviceStore: &mockDeviceStore,
+			eventSender: &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zts.gateway
+		device.device.Capabilities = []da.Capability{TemperatureSensorFlag}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+		mockEventSender.On("sendEvent", TemperatureReadingChanged{Device: device.device, Temperature: -5.25}).Once()
+
+		zts.incomingReportAttributes(temperatureReport(node, -525))
+		zts.incomingReportAttributes(temperatureReport(node, -525))
+
+		reading, err := zts.Reading(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, -5.25, reading)
+
+		zts.incomingReportAttributes(temperatureReport(node, temperatureInvalidMeasuredValue))
+
+		_, err = zts.Reading(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+	})
+
+	t.Run("returns no reading available if the reading is stale", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zts := ZigbeeTemperatureSensor{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+
+		_, device := generateTestNodeAndDevice()
+		device.device.Gateway = zts.gateway
+		device.device.Capabilities = []da.Capability{TemperatureSensorFlag}
+		device.temperatureSensorState = temperatureSensorState{measuredValue: 2000, received: true}
+		device.unavailableCapabilities = map[da.Capability]bool{TemperatureSensorFlag: true}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		_, err := zts.Reading(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+	})
+
+	t.Run("reports from a cluster which does not back the capability on the device are ignored", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockNodeStore := mockNodeStore{}
+		defer mockNodeStore.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zts := ZigbeeTemperatureSensor{
+			gateway:     &mockGateway{},
+			nodeStore:   &mockNodeStore,
+			deviceStore: &mockDeviceStore,
+			eventSender: &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = zts.gateway
+		device.device.Capabilities = []da.Capability{TemperatureSensorFlag}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+		device.clusterRemaps = map[da.Capability]zigbee.ClusterID{TemperatureSensorFlag: 0xfc00}
+
+		mockNodeStore.On("getNode", node.ieeeAddress).Return(node, true)
+
+		zts.incomingReportAttributes(temperatureReport(node, -525))
+
+		_, err := zts.Reading(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+	})
+}
@@ -0,0 +1,312 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/retry"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+)
+
+const (
+	ThermostatUIConfigurationTemperatureDisplayMode = zcl.AttributeID(0x0000)
+	ThermostatUIConfigurationKeypadLockout          = zcl.AttributeID(0x0001)
+)
+
+// TemperatureDisplayMode is the unit a thermostat displays temperatures in.
+type TemperatureDisplayMode uint8
+
+const (
+	DisplayCelsius    TemperatureDisplayMode = 0x00
+	DisplayFahrenheit TemperatureDisplayMode = 0x01
+)
+
+// KeypadLockout is the degree to which a thermostats physical buttons are locked, the meaning of each level is
+// manufacturer defined, with higher levels restricting more functions.
+type KeypadLockout uint8
+
+const (
+	KeypadUnlocked      KeypadLockout = 0x00
+	KeypadLockoutLevel1 KeypadLockout = 0x01
+	KeypadLockoutLevel2 KeypadLockout = 0x02
+	KeypadLockoutLevel3 KeypadLockout = 0x03
+	KeypadLockoutLevel4 KeypadLockout = 0x04
+	KeypadLockoutLevel5 KeypadLockout = 0x05
+)
+
+// ThermostatUIConfiguration is a capability which signifies that a device has a configurable user interface, such as
+// a thermostat with a display and keypad.
+type ThermostatUIConfiguration interface {
+	// UIConfiguration returns the last known user interface configuration of the device.
+	UIConfiguration(context.Context, da.Device) (ThermostatUIConfigurationState, error)
+
+	// SetTemperatureDisplayMode changes the unit the device displays temperatures in.
+	SetTemperatureDisplayMode(context.Context, da.Device, TemperatureDisplayMode) error
+
+	// SetKeypadLockout changes the degree to which the devices physical buttons are locked.
+	SetKeypadLockout(context.Context, da.Device, KeypadLockout) error
+}
+
+// ThermostatUIConfigurationState is the user interface configuration of a device, devices may support only some of the
+// attributes, DisplayMode and KeypadLockout are only valid if HasDisplayMode and HasKeypadLockout are set.
+type ThermostatUIConfigurationState struct {
+	DisplayMode    TemperatureDisplayMode
+	HasDisplayMode bool
+
+	KeypadLockout    KeypadLockout
+	HasKeypadLockout bool
+}
+
+// ThermostatUIConfigurationChanged is sent to inform consumers that a devices user interface configuration has changed.
+type ThermostatUIConfigurationChanged struct {
+	// Device whose configuration has changed.
+	Device da.Device
+	// New configuration of the device.
+	Configuration ThermostatUIConfigurationState
+}
+
+type ZigbeeThermostatUIConfiguration struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+
+	zclCommunicatorRequests zclCommunicatorRequests
+	zclGlobalCommunicator   zclGlobalCommunicator
+
+	eventSender eventSender
+}
+
+func (z *ZigbeeThermostatUIConfiguration) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+}
+
+func (z *ZigbeeThermostatUIConfiguration) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, found := findEndpointWithClusterId(node, dev, zcl.ThermostatUserInterfaceConfigurationId); found {
+			addCapability(&dev.device, ThermostatUIConfigurationFlag)
+
+			dev.thermostatUIConfigurationState = ThermostatUIConfigurationState{}
+
+			if err := retry.Retry(ctx, DefaultNetworkTimeout, DefaultNetworkRetries, func(ctx context.Context) error {
+				response, err := z.zclGlobalCommunicator.ReadAttributes(ctx, node.ieeeAddress, node.supportsAPSAck, zcl.ThermostatUserInterfaceConfigurationId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, endpoint, node.nextTransactionSequence(), []zcl.AttributeID{ThermostatUIConfigurationTemperatureDisplayMode, ThermostatUIConfigurationKeypadLockout})
+
+				if err == nil {
+					results := parseReadAttributeResponse(response)
+					markCapabilityUpdated(dev, ThermostatUIConfigurationFlag)
+
+					if value, ok := results.uint8Value(ThermostatUIConfigurationTemperatureDisplayMode); ok {
+						dev.thermostatUIConfigurationState.DisplayMode = TemperatureDisplayMode(value)
+						dev.thermostatUIConfigurationState.HasDisplayMode = true
+					}
+
+					if value, ok := results.uint8Value(ThermostatUIConfigurationKeypadLockout); ok {
+						dev.thermostatUIConfigurationState.KeypadLockout = KeypadLockout(value)
+						dev.thermostatUIConfigurationState.HasKeypadLockout = true
+					}
+				}
+
+				return err
+			}); err != nil {
+				log.Printf("failed to read thermostat ui configuration attributes: %s", err)
+			}
+		} else {
+			removeCapability(&dev.device, ThermostatUIConfigurationFlag)
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (z *ZigbeeThermostatUIConfiguration) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(ThermostatUIConfigurationFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+func (z *ZigbeeThermostatUIConfiguration) UIConfiguration(ctx context.Context, device da.Device) (ThermostatUIConfigurationState, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return ThermostatUIConfigurationState{}, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	return iDevice.thermostatUIConfigurationState, nil
+}
+
+func (z *ZigbeeThermostatUIConfiguration) SetTemperatureDisplayMode(ctx context.Context, device da.Device, mode TemperatureDisplayMode) error {
+	if err := validateTemperatureDisplayMode(mode); err != nil {
+		return err
+	}
+
+	return z.writeAttribute(ctx, device, ThermostatUIConfigurationTemperatureDisplayMode, uint8(mode), func(state *ThermostatUIConfigurationState) {
+		state.DisplayMode = mode
+	})
+}
+
+func (z *ZigbeeThermostatUIConfiguration) SetKeypadLockout(ctx context.Context, device da.Device, lockout KeypadLockout) error {
+	if err := validateKeypadLockout(lockout); err != nil {
+		return err
+	}
+
+	return z.writeAttribute(ctx, device, ThermostatUIConfigurationKeypadLockout, uint8(lockout), func(state *ThermostatUIConfigurationState) {
+		state.KeypadLockout = lockout
+	})
+}
+
+func validateTemperatureDisplayMode(mode TemperatureDisplayMode) error {
+	if mode > DisplayFahrenheit {
+		return fmt.Errorf("unknown temperature display mode %d", mode)
+	}
+
+	return nil
+}
+
+func validateKeypadLockout(lockout KeypadLockout) error {
+	if lockout > KeypadLockoutLevel5 {
+		return fmt.Errorf("unknown keypad lockout level %d", lockout)
+	}
+
+	return nil
+}
+
+// validateThermostatUIAttributeSupported checks the device has the thermostat ui configuration attribute being written.
+func validateThermostatUIAttributeSupported(state ThermostatUIConfigurationState, attribute zcl.AttributeID) error {
+	supported := (attribute == ThermostatUIConfigurationTemperatureDisplayMode && state.HasDisplayMode) ||
+		(attribute == ThermostatUIConfigurationKeypadLockout && state.HasKeypadLockout)
+
+	if !supported {
+		return fmt.Errorf("device does not support thermostat ui configuration attribute %d", attribute)
+	}
+
+	return nil
+}
+
+func (z *ZigbeeThermostatUIConfiguration) validateArguments(iDevice *internalDevice, operation string, args []interface{}) error {
+	iDevice.mutex.RLock()
+	state := iDevice.thermostatUIConfigurationState
+	iDevice.mutex.RUnlock()
+
+	switch operation {
+	case "SetTemperatureDisplayMode":
+		if err := validateTemperatureDisplayMode(args[0].(TemperatureDisplayMode)); err != nil {
+			return err
+		}
+
+		return validateThermostatUIAttributeSupported(state, ThermostatUIConfigurationTemperatureDisplayMode)
+	case "SetKeypadLockout":
+		if err := validateKeypadLockout(args[0].(KeypadLockout)); err != nil {
+			return err
+		}
+
+		return validateThermostatUIAttributeSupported(state, ThermostatUIConfigurationKeypadLockout)
+	}
+
+	return nil
+}
+
+// writeAttribute writes the enumeration attribute to the device, update is called with the devices state to apply the
+// change. No write is attempted if the device does not support the attribute.
+func (z *ZigbeeThermostatUIConfiguration) writeAttribute(ctx context.Context, device da.Device, attribute zcl.AttributeID, value uint8, update func(*ThermostatUIConfigurationState)) error {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return err
+	}
+
+	iNode := iDevice.node
+
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	iDevice.mutex.Lock()
+	defer iDevice.mutex.Unlock()
+
+	endpoint, found := findEndpointWithClusterId(iNode, iDevice, zcl.ThermostatUserInterfaceConfigurationId)
+
+	if !found {
+		return fmt.Errorf("unable to find thermostat ui configuration cluster on zigbee device in zda")
+	}
+
+	newState := iDevice.thermostatUIConfigurationState
+
+	if err := validateThermostatUIAttributeSupported(newState, attribute); err != nil {
+		return err
+	}
+
+	update(&newState)
+
+	zclMsg := zcl.Message{
+		FrameType:           zcl.FrameGlobal,
+		Direction:           zcl.ClientToServer,
+		TransactionSequence: iNode.nextTransactionSequence(),
+		Manufacturer:        zigbee.NoManufacturer,
+		ClusterID:           zcl.ThermostatUserInterfaceConfigurationId,
+		SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+		DestinationEndpoint: endpoint,
+		Command: &global.WriteAttributes{
+			Records: []global.WriteAttributesRecord{
+				{
+					Identifier:    attribute,
+					DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeEnum8, Value: value},
+				},
+			},
+		},
+	}
+
+	cmdCtx, cancel := commandContext(ctx, iDevice)
+	defer cancel()
+
+	response, err := z.zclCommunicatorRequests.RequestResponse(cmdCtx, iNode.ieeeAddress, iNode.supportsAPSAck, zclMsg)
+
+	if err != nil {
+		return err
+	}
+
+	writeResponse, ok := response.Command.(*global.WriteAttributesResponse)
+
+	if !ok {
+		return fmt.Errorf("write attributes received command back which was not WriteAttributesResponse")
+	}
+
+	for _, record := range writeResponse.Records {
+		if record.Status != 0 {
+			return fmt.Errorf("device rejected write of thermostat ui configuration attribute %d: status %d", attribute, record.Status)
+		}
+	}
+
+	markCapabilityUpdated(iDevice, ThermostatUIConfigurationFlag)
+
+	if iDevice.thermostatUIConfigurationState != newState {
+		iDevice.thermostatUIConfigurationState = newState
+		z.eventSender.sendEvent(ThermostatUIConfigurationChanged{Device: iDevice.device, Configuration: newState})
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZigbeeThermostatUIConfiguration_Contract(t *testing.T) {
+	t.Run("can be assigned to a ThermostatUIConfiguration", func(t *testing.T) {
+		assert.Implements(t, (*ThermostatUIConfiguration)(nil), new(ZigbeeThermostatUIConfiguration))
+	})
+}
+
+func TestZigbeeThermostatUIConfiguration_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with cluster and reads the supported attributes", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+
+		ztui := ZigbeeThermostatUIConfiguration{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+		}
+
+		node, device := generateTestNodeAndDevice()
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.ThermostatUserInterfaceConfigurationId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.ThermostatUserInterfaceConfigurationId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, deviceEndpoint, uint8(1), []zcl.AttributeID{ThermostatUIConfigurationTemperatureDisplayMode, ThermostatUIConfigurationKeypadLockout}).Return([]global.ReadAttributeResponseRecord{
+			{
+				Identifier:    ThermostatUIConfigurationTemperatureDisplayMode,
+				DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeEnum8, Value: uint8(DisplayFahrenheit)},
+			},
+			{
+				Identifier: ThermostatUIConfigurationKeypadLockout,
+				Status:     0x86,
+			},
+		}, nil)
+
+		err := ztui.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(ThermostatUIConfigurationFlag))
+		assert.Equal(t, ThermostatUIConfigurationState{DisplayMode: DisplayFahrenheit, HasDisplayMode: true}, device.thermostatUIConfigurationState)
+
+		mockZclGlobalCommunicator.AssertExpectations(t)
+	})
+
+	t.Run("removes capability from device without cluster", func(t *testing.T) {
+		ztui := ZigbeeThermostatUIConfiguration{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{ThermostatUIConfigurationFlag}
+
+		err := ztui.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(ThermostatUIConfigurationFlag))
+	})
+}
+
+func TestZigbeeThermostatUIConfiguration_SetKeypadLockout(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		ztui := ZigbeeThermostatUIConfiguration{
+			gateway: &mockGateway{},
+		}
+
+		err := ztui.SetKeypadLockout(context.Background(), da.Device{}, KeypadLockoutLevel1)
+		assert.Error(t, err)
+	})
+
+	t.Run("returns error if device does not support it", func(t *testing.T) {
+		ztui := ZigbeeThermostatUIConfiguration{
+			gateway: &mockGateway{},
+		}
+
+		err := ztui.SetKeypadLockout(context.Background(), da.Device{Gateway: ztui.gateway}, KeypadLockoutLevel1)
+		assert.Error(t, err)
+	})
+
+	t.Run("writes the keypad lockout, and sends an event", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		mockEventSender := mockEventSender{}
+
+		ztui := ZigbeeThermostatUIConfiguration{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+			eventSender:             &mockEventSender,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = ztui.gateway
+		device.device.Capabilities = []da.Capability{ThermostatUIConfigurationFlag}
+		device.thermostatUIConfigurationState = ThermostatUIConfigurationState{HasKeypadLockout: true}
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.ThermostatUserInterfaceConfigurationId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		expectedMessage := zcl.Message{
+			FrameType:           zcl.FrameGlobal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: 1,
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           zcl.ThermostatUserInterfaceConfigurationId,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: deviceEndpoint,
+			Command: &global.WriteAttributes{
+				Records: []global.WriteAttributesRecord{
+					{
+						Identifier:    ThermostatUIConfigurationKeypadLockout,
+						DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeEnum8, Value: uint8(KeypadLockoutLevel2)},
+					},
+				},
+			},
+		}
+
+		expectedState := ThermostatUIConfigurationState{KeypadLockout: KeypadLockoutLevel2, HasKeypadLockout: true}
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, node.ieeeAddress, false, expectedMessage).Return(zcl.Message{
+			Command: &global.WriteAttributesResponse{Records: []global.WriteAttributesResponseRecord{{Status: 0}}},
+		}, nil)
+		mockEventSender.On("sendEvent", ThermostatUIConfigurationChanged{Device: device.device, Configuration: expectedState}).Once()
+
+		err := ztui.SetKeypadLockout(context.Background(), device.device, KeypadLockoutLevel2)
+		assert.NoError(t, err)
+
+		state, err := ztui.UIConfiguration(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedState, state)
+
+		mockDeviceStore.AssertExpectations(t)
+		mockZclCommunicatorRequests.AssertExpectations(t)
+		mockEventSender.AssertExpectations(t)
+	})
+
+	t.Run("returns error without writing if the device does not support the attribute", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+
+		ztui := ZigbeeThermostatUIConfiguration{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = ztui.gateway
+		device.device.Capabilities = []da.Capability{ThermostatUIConfigurationFlag}
+		device.thermostatUIConfigurationState = ThermostatUIConfigurationState{HasDisplayMode: true}
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.ThermostatUserInterfaceConfigurationId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+		err := ztui.SetKeypadLockout(context.Background(), device.device, KeypadLockoutLevel2)
+		assert.Error(t, err)
+		assert.Error(t, ztui.validateArguments(device, "SetKeypadLockout", []interface{}{KeypadLockoutLevel2}))
+		assert.NoError(t, ztui.validateArguments(device, "SetTemperatureDisplayMode", []interface{}{DisplayFahrenheit}))
+
+		mockZclCommunicatorRequests.AssertNotCalled(t, "RequestResponse", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("returns error if the device rejects the write", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+
+		ztui := ZigbeeThermostatUIConfiguration{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Gateway = ztui.gateway
+		device.device.Capabilities = []da.Capability{ThermostatUIConfigurationFlag}
+		device.thermostatUIConfigurationState = ThermostatUIConfigurationState{HasDisplayMode: true}
+
+		deviceEndpoint := node.endpoints[0]
+		endpointDescription := node.endpointDescriptions[deviceEndpoint]
+		endpointDescription.InClusterList = []zigbee.ClusterID{zcl.ThermostatUserInterfaceConfigurationId}
+		node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+		mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+		mockZclCommunicatorRequests.On("RequestResponse", mock.Anything, node.ieeeAddress, false, mock.Anything).Return(zcl.Message{
+			Command: &global.WriteAttributesResponse{Records: []global.WriteAttributesResponseRecord{{Status: 0x87, Identifier: ThermostatUIConfigurationTemperatureDisplayMode}}},
+		}, nil)
+
+		err := ztui.SetTemperatureDisplayMode(context.Background(), device.device, DisplayCelsius)
+		assert.Error(t, err)
+	})
+}
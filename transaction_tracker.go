@@ -0,0 +1,109 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"sync"
+	"time"
+)
+
+// zdaTransactionTracker records the ZCL transactions which have been sent to each node and have not yet completed,
+// either by response or by timing out.
+type zdaTransactionTracker struct {
+	mutex    *sync.Mutex
+	inFlight map[zigbee.IEEEAddress]map[uint8]time.Time
+
+	now func() time.Time
+}
+
+func newTransactionTracker() *zdaTransactionTracker {
+	return &zdaTransactionTracker{
+		mutex:    &sync.Mutex{},
+		inFlight: map[zigbee.IEEEAddress]map[uint8]time.Time{},
+		now:      time.Now,
+	}
+}
+
+func (t *zdaTransactionTracker) begin(address zigbee.IEEEAddress, transactionSequence uint8) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	transactions, found := t.inFlight[address]
+
+	if !found {
+		transactions = map[uint8]time.Time{}
+		t.inFlight[address] = transactions
+	}
+
+	transactions[transactionSequence] = t.now()
+}
+
+func (t *zdaTransactionTracker) end(address zigbee.IEEEAddress, transactionSequence uint8) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.inFlight[address], transactionSequence)
+
+	if len(t.inFlight[address]) == 0 {
+		delete(t.inFlight, address)
+	}
+}
+
+// status returns the number of transactions in flight to the node, and the age of the oldest.
+func (t *zdaTransactionTracker) status(address zigbee.IEEEAddress) (int, time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := t.now()
+	oldest := time.Duration(0)
+
+	for _, startedAt := range t.inFlight[address] {
+		if age := now.Sub(startedAt); age > oldest {
+			oldest = age
+		}
+	}
+
+	return len(t.inFlight[address]), oldest
+}
+
+// trackingCommunicatorRequests wraps zclCommunicatorRequests, tracking each request as in flight until it returns.
+type trackingCommunicatorRequests struct {
+	zclCommunicatorRequests
+	tracker *zdaTransactionTracker
+}
+
+func (r *trackingCommunicatorRequests) Request(ctx context.Context, address zigbee.IEEEAddress, requireAck bool, message zcl.Message) error {
+	r.tracker.begin(address, message.TransactionSequence)
+	defer r.tracker.end(address, message.TransactionSequence)
+
+	return r.zclCommunicatorRequests.Request(ctx, address, requireAck, message)
+}
+
+func (r *trackingCommunicatorRequests) RequestResponse(ctx context.Context, address zigbee.IEEEAddress, requireAck bool, message zcl.Message) (zcl.Message, error) {
+	r.tracker.begin(address, message.TransactionSequence)
+	defer r.tracker.end(address, message.TransactionSequence)
+
+	return r.zclCommunicatorRequests.RequestResponse(ctx, address, requireAck, message)
+}
+
+// trackingGlobalCommunicator wraps zclGlobalCommunicator, tracking each request as in flight until it returns.
+type trackingGlobalCommunicator struct {
+	zclGlobalCommunicator
+	tracker *zdaTransactionTracker
+}
+
+func (r *trackingGlobalCommunicator) ReadAttributes(ctx context.Context, ieeeAddress zigbee.IEEEAddress, requireAck bool, cluster zigbee.ClusterID, code zigbee.ManufacturerCode, sourceEndpoint zigbee.Endpoint, destEndpoint zigbee.Endpoint, transactionSequence uint8, attributes []zcl.AttributeID) ([]global.ReadAttributeResponseRecord, error) {
+	r.tracker.begin(ieeeAddress, transactionSequence)
+	defer r.tracker.end(ieeeAddress, transactionSequence)
+
+	return r.zclGlobalCommunicator.ReadAttributes(ctx, ieeeAddress, requireAck, cluster, code, sourceEndpoint, destEndpoint, transactionSequence, attributes)
+}
+
+func (r *trackingGlobalCommunicator) ConfigureReporting(ctx context.Context, ieeeAddress zigbee.IEEEAddress, requireAck bool, cluster zigbee.ClusterID, code zigbee.ManufacturerCode, sourceEndpoint zigbee.Endpoint, destEndpoint zigbee.Endpoint, transactionSequence uint8, attributeId zcl.AttributeID, dataType zcl.AttributeDataType, minimumReportingInterval uint16, maximumReportingInterval uint16, reportableChange interface{}) error {
+	r.tracker.begin(ieeeAddress, transactionSequence)
+	defer r.tracker.end(ieeeAddress, transactionSequence)
+
+	return r.zclGlobalCommunicator.ConfigureReporting(ctx, ieeeAddress, requireAck, cluster, code, sourceEndpoint, destEndpoint, transactionSequence, attributeId, dataType, minimumReportingInterval, maximumReportingInterval, reportableChange)
+}
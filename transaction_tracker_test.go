@@ -0,0 +1,94 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestZdaTransactionTracker(t *testing.T) {
+	t.Run("reports the number of transactions in flight and the age of the oldest", func(t *testing.T) {
+		now := time.Now()
+
+		tracker := newTransactionTracker()
+		tracker.now = func() time.Time { return now }
+
+		address := zigbee.IEEEAddress(0x01)
+
+		tracker.begin(address, 1)
+		now = now.Add(time.Second)
+		tracker.begin(address, 2)
+		now = now.Add(time.Second)
+
+		count, oldest := tracker.status(address)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, 2*time.Second, oldest)
+
+		tracker.end(address, 1)
+
+		count, oldest = tracker.status(address)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, time.Second, oldest)
+
+		tracker.end(address, 2)
+
+		count, oldest = tracker.status(address)
+		assert.Equal(t, 0, count)
+		assert.Equal(t, time.Duration(0), oldest)
+		assert.Empty(t, tracker.inFlight)
+	})
+}
+
+func TestTrackingCommunicatorRequests(t *testing.T) {
+	t.Run("tracks a request as in flight until it returns", func(t *testing.T) {
+		mockRequests := mockZclCommunicatorRequests{}
+		tracker := newTransactionTracker()
+
+		requests := trackingCommunicatorRequests{zclCommunicatorRequests: &mockRequests, tracker: tracker}
+
+		address := zigbee.IEEEAddress(0x01)
+		message := zcl.Message{TransactionSequence: 7}
+
+		mockRequests.On("Request", mock.Anything, address, false, message).Run(func(args mock.Arguments) {
+			count, _ := tracker.status(address)
+			assert.Equal(t, 1, count)
+		}).Return(nil)
+
+		err := requests.Request(context.Background(), address, false, message)
+		assert.NoError(t, err)
+
+		count, _ := tracker.status(address)
+		assert.Equal(t, 0, count)
+
+		mockRequests.AssertExpectations(t)
+	})
+}
+
+func TestTrackingGlobalCommunicator(t *testing.T) {
+	t.Run("tracks a read attributes as in flight until it returns", func(t *testing.T) {
+		mockGlobal := mockZclGlobalCommunicator{}
+		tracker := newTransactionTracker()
+
+		globalCommunicator := trackingGlobalCommunicator{zclGlobalCommunicator: &mockGlobal, tracker: tracker}
+
+		address := zigbee.IEEEAddress(0x01)
+
+		mockGlobal.On("ReadAttributes", mock.Anything, address, false, zcl.BasicId, zigbee.NoManufacturer, zigbee.Endpoint(1), zigbee.Endpoint(2), uint8(9), []zcl.AttributeID{0x0004}).Run(func(args mock.Arguments) {
+			count, _ := tracker.status(address)
+			assert.Equal(t, 1, count)
+		}).Return([]global.ReadAttributeResponseRecord{}, nil)
+
+		_, err := globalCommunicator.ReadAttributes(context.Background(), address, false, zcl.BasicId, zigbee.NoManufacturer, 1, 2, 9, []zcl.AttributeID{0x0004})
+		assert.NoError(t, err)
+
+		count, _ := tracker.status(address)
+		assert.Equal(t, 0, count)
+
+		mockGlobal.AssertExpectations(t)
+	})
+}
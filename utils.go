@@ -62,3 +62,23 @@ func findEndpointWithClusterId(node *internalNode, device *internalDevice, clust
 
 	return 0, false
 }
+
+func findEndpointWithOutClusterId(node *internalNode, device *internalDevice, clusterId zigbee.ClusterID) (zigbee.Endpoint, bool) {
+	for _, endpoint := range device.endpoints {
+		if isClusterIdInSlice(node.endpointDescriptions[endpoint].OutClusterList, clusterId) {
+			return endpoint, true
+		}
+	}
+
+	return 0, false
+}
+
+func findNodeEndpointWithClusterId(node *internalNode, clusterId zigbee.ClusterID) (zigbee.Endpoint, bool) {
+	for _, endpoint := range node.endpoints {
+		if isClusterIdInSlice(node.endpointDescriptions[endpoint].InClusterList, clusterId) {
+			return endpoint, true
+		}
+	}
+
+	return 0, false
+}
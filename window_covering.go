@@ -0,0 +1,361 @@
+package zda
+
+import (
+	"context"
+	"fmt"
+	"github.com/shimmeringbee/callbacks"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zigbee"
+	"log"
+	"time"
+)
+
+const (
+	WindowCoveringConfigStatus                  = zcl.AttributeID(0x0007)
+	WindowCoveringCurrentPositionLiftPercentage = zcl.AttributeID(0x0008)
+)
+
+const (
+	WindowCoveringUpOpenId             = zcl.CommandIdentifier(0x00)
+	WindowCoveringDownCloseId          = zcl.CommandIdentifier(0x01)
+	WindowCoveringStopId               = zcl.CommandIdentifier(0x02)
+	WindowCoveringGoToLiftPercentageId = zcl.CommandIdentifier(0x05)
+)
+
+// WindowCoveringUpOpen is sent to a Window Covering cluster server to fully open the covering.
+type WindowCoveringUpOpen struct{}
+
+// WindowCoveringDownClose is sent to a Window Covering cluster server to fully close the covering.
+type WindowCoveringDownClose struct{}
+
+// WindowCoveringStop is sent to a Window Covering cluster server to stop any movement of the covering.
+type WindowCoveringStop struct{}
+
+// WindowCoveringGoToLiftPercentage is sent to a Window Covering cluster server to move the covering to a lift
+// percentage.
+type WindowCoveringGoToLiftPercentage struct {
+	PercentageLiftValue uint8
+}
+
+func registerWindowCoveringCommands(cr *zcl.CommandRegistry) {
+	cr.RegisterLocal(zcl.WindowCoveringId, zigbee.NoManufacturer, WindowCoveringUpOpenId, &WindowCoveringUpOpen{})
+	cr.RegisterLocal(zcl.WindowCoveringId, zigbee.NoManufacturer, WindowCoveringDownCloseId, &WindowCoveringDownClose{})
+	cr.RegisterLocal(zcl.WindowCoveringId, zigbee.NoManufacturer, WindowCoveringStopId, &WindowCoveringStop{})
+	cr.RegisterLocal(zcl.WindowCoveringId, zigbee.NoManufacturer, WindowCoveringGoToLiftPercentageId, &WindowCoveringGoToLiftPercentage{})
+}
+
+// windowCoveringReversed is set in the ConfigStatus bitmap if the covering's motor is wired such that its open and
+// close directions are reversed.
+const windowCoveringReversed = uint64(0x04)
+
+// MaximumLiftPercentage is the lift percentage of a fully closed covering.
+const MaximumLiftPercentage = 100
+
+const windowCoveringPollInterval = 1 * time.Minute
+
+// WindowCovering is a capability which signifies that a device is a motorised covering, such as a blind or shade.
+//
+// Positions are lift percentages following the ZCL, 0 is fully open (retracted) and 100 is fully closed (lowered).
+// Coverings whose ConfigStatus indicates their direction is reversed have their positions and commands inverted, so
+// that these semantics hold for every covering.
+type WindowCovering interface {
+	// Open fully opens the covering.
+	Open(context.Context, da.Device) error
+	// Close fully closes the covering.
+	Close(context.Context, da.Device) error
+	// Stop stops any movement of the covering.
+	Stop(context.Context, da.Device) error
+	// GoToLiftPercentage moves the covering to the lift percentage, 0 being fully open and 100 fully closed.
+	GoToLiftPercentage(context.Context, da.Device, uint8) error
+	// Position returns the last known lift percentage of the covering.
+	Position(context.Context, da.Device) (uint8, error)
+}
+
+// WindowCoveringPositionChanged is sent to inform consumers that the lift percentage of a covering has changed.
+type WindowCoveringPositionChanged struct {
+	// Device whose position has changed.
+	Device da.Device
+	// New lift percentage, 0 being fully open and 100 fully closed.
+	Position uint8
+}
+
+// windowCoveringState is the lift percentage last read, with reversal already applied, and whether the covering is
+// reversed. available is false until a valid position has been read.
+type windowCoveringState struct {
+	position  uint8
+	available bool
+	reversed  bool
+}
+
+type ZigbeeWindowCovering struct {
+	gateway da.Gateway
+
+	internalCallbacks callbacks.Adder
+	deviceStore       deviceStore
+
+	zclCommunicatorRequests zclCommunicatorRequests
+	zclGlobalCommunicator   zclGlobalCommunicator
+
+	poller      poller
+	eventSender eventSender
+}
+
+func (z *ZigbeeWindowCovering) Init() {
+	z.internalCallbacks.Add(z.NodeEnumerationCallback)
+	z.internalCallbacks.Add(z.NodeJoinCallback)
+}
+
+func (z *ZigbeeWindowCovering) NodeEnumerationCallback(ctx context.Context, ine internalNodeEnumeration) error {
+	node := ine.node
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	for _, dev := range node.devices {
+		dev.mutex.Lock()
+
+		if endpoint, cluster, found := findEndpointForCapability(node, dev, WindowCoveringFlag); found {
+			addCapability(&dev.device, WindowCoveringFlag)
+			setCapabilityUpdateMode(z.eventSender, dev, WindowCoveringFlag, UpdateModePolled, "polled")
+
+			if err := z.readPosition(ctx, node, dev, endpoint, cluster, true); err != nil {
+				log.Printf("failed to read window covering position: %s", err)
+			}
+		} else {
+			removeCapability(&dev.device, WindowCoveringFlag)
+			dev.windowCoveringState = windowCoveringState{}
+		}
+
+		dev.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (z *ZigbeeWindowCovering) NodeJoinCallback(ctx context.Context, join internalNodeJoin) error {
+	z.poller.AddNode(join.node, windowCoveringPollInterval, z.pollNode)
+	return nil
+}
+
+// readPosition reads the lift percentage of the covering and updates the cached position, the ConfigStatus is read
+// along with it if configuration is true. Percentages above 100, such as the 0xff reported by coverings which have not
+// been calibrated, mark the position as unavailable. The node mutex must be held, and the device mutex held for
+// writing, by the caller.
+func (z *ZigbeeWindowCovering) readPosition(ctx context.Context, iNode *internalNode, iDevice *internalDevice, endpoint zigbee.Endpoint, cluster zigbee.ClusterID, configuration bool) error {
+	attributes := []zcl.AttributeID{WindowCoveringCurrentPositionLiftPercentage}
+
+	if configuration {
+		attributes = append(attributes, WindowCoveringConfigStatus)
+	}
+
+	response, _, err := readAttributesWithEndpointFallback(ctx, z.zclGlobalCommunicator, z.eventSender, iNode, iDevice, WindowCoveringFlag, endpoint, cluster, attributes)
+
+	if err == nil {
+		markCapabilityUpdated(iDevice, WindowCoveringFlag)
+
+		results := parseReadAttributeResponse(response)
+
+		if configuration {
+			configStatus, _ := results.uintValue(WindowCoveringConfigStatus)
+			iDevice.windowCoveringState.reversed = configStatus&windowCoveringReversed != 0
+		}
+
+		if percentage, ok := results.uintValue(WindowCoveringCurrentPositionLiftPercentage); ok && percentage <= MaximumLiftPercentage {
+			z.setPosition(iDevice, applyWindowCoveringReversal(uint8(percentage), iDevice.windowCoveringState.reversed))
+		} else {
+			iDevice.windowCoveringState.available = false
+		}
+	}
+
+	return err
+}
+
+// applyWindowCoveringReversal converts between the lift percentage of a reversed covering and the ZCL semantics of 0
+// being fully open, the conversion is its own inverse.
+func applyWindowCoveringReversal(percentage uint8, reversed bool) uint8 {
+	if reversed {
+		return MaximumLiftPercentage - percentage
+	}
+
+	return percentage
+}
+
+// setPosition records the position of the covering, sending an event if it has changed. The device mutex must be held
+// for writing by the caller.
+func (z *ZigbeeWindowCovering) setPosition(iDevice *internalDevice, position uint8) {
+	state := &iDevice.windowCoveringState
+
+	previousPosition, previousAvailable := state.position, state.available
+	state.position, state.available = position, true
+
+	if !previousAvailable || previousPosition != position {
+		z.eventSender.sendEvent(WindowCoveringPositionChanged{Device: iDevice.device, Position: position})
+	}
+}
+
+func (z *ZigbeeWindowCovering) getDevice(device da.Device) (*internalDevice, error) {
+	if da.DeviceDoesNotBelongToGateway(z.gateway, device) {
+		return nil, da.DeviceDoesNotBelongToGatewayError
+	}
+
+	if !device.HasCapability(WindowCoveringFlag) {
+		return nil, da.DeviceDoesNotHaveCapability
+	}
+
+	iDevice, found := z.deviceStore.getDevice(device.Identifier)
+
+	if !found {
+		return nil, fmt.Errorf("unable to find zigbee device in zda, likely old device")
+	}
+
+	return iDevice, nil
+}
+
+// sendCommand sends the command built by the function to the Window Covering cluster of the device, the function is
+// provided with whether the covering is reversed.
+func (z *ZigbeeWindowCovering) sendCommand(ctx context.Context, device da.Device, command func(reversed bool) interface{}) error {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return err
+	}
+
+	iNode := iDevice.node
+
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, WindowCoveringFlag)
+
+	if !found {
+		return fmt.Errorf("unable to find window covering cluster on zigbee device in zda")
+	}
+
+	zclMsg := zcl.Message{
+		FrameType:           zcl.FrameLocal,
+		Direction:           zcl.ClientToServer,
+		TransactionSequence: iNode.nextTransactionSequence(),
+		Manufacturer:        zigbee.NoManufacturer,
+		ClusterID:           cluster,
+		SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+		DestinationEndpoint: endpoint,
+		Command:             command(iDevice.windowCoveringState.reversed),
+	}
+
+	cmdCtx, cancel := commandContext(ctx, iDevice)
+	defer cancel()
+
+	return z.zclCommunicatorRequests.Request(cmdCtx, iNode.ieeeAddress, iNode.supportsAPSAck, zclMsg)
+}
+
+// Open fully opens the covering, sending Down/Close to coverings which are reversed.
+func (z *ZigbeeWindowCovering) Open(ctx context.Context, device da.Device) error {
+	return z.sendCommand(ctx, device, func(reversed bool) interface{} {
+		if reversed {
+			return &WindowCoveringDownClose{}
+		}
+
+		return &WindowCoveringUpOpen{}
+	})
+}
+
+// Close fully closes the covering, sending Up/Open to coverings which are reversed.
+func (z *ZigbeeWindowCovering) Close(ctx context.Context, device da.Device) error {
+	return z.sendCommand(ctx, device, func(reversed bool) interface{} {
+		if reversed {
+			return &WindowCoveringUpOpen{}
+		}
+
+		return &WindowCoveringDownClose{}
+	})
+}
+
+// Stop stops any movement of the covering.
+func (z *ZigbeeWindowCovering) Stop(ctx context.Context, device da.Device) error {
+	return z.sendCommand(ctx, device, func(bool) interface{} {
+		return &WindowCoveringStop{}
+	})
+}
+
+// validateLiftPercentage checks the percentage is a valid lift percentage.
+func validateLiftPercentage(percentage uint8) error {
+	if percentage > MaximumLiftPercentage {
+		return fmt.Errorf("lift percentage %d is greater than %d", percentage, MaximumLiftPercentage)
+	}
+
+	return nil
+}
+
+func (z *ZigbeeWindowCovering) validateArguments(iDevice *internalDevice, operation string, args []interface{}) error {
+	if operation == "GoToLiftPercentage" {
+		return validateLiftPercentage(args[0].(uint8))
+	}
+
+	return nil
+}
+
+// GoToLiftPercentage moves the covering to the lift percentage, 0 being fully open and 100 fully closed. The percentage
+// is inverted for coverings which are reversed.
+func (z *ZigbeeWindowCovering) GoToLiftPercentage(ctx context.Context, device da.Device, percentage uint8) error {
+	if err := validateLiftPercentage(percentage); err != nil {
+		return err
+	}
+
+	return z.sendCommand(ctx, device, func(reversed bool) interface{} {
+		return &WindowCoveringGoToLiftPercentage{PercentageLiftValue: applyWindowCoveringReversal(percentage, reversed)}
+	})
+}
+
+// Position returns the last known lift percentage of the covering, 0 being fully open and 100 fully closed.
+// NoReadingAvailable is returned if no position has been read, the covering does not know its position, or the position
+// has become stale.
+func (z *ZigbeeWindowCovering) Position(ctx context.Context, device da.Device) (uint8, error) {
+	iDevice, err := z.getDevice(device)
+	if err != nil {
+		return 0, err
+	}
+
+	iDevice.mutex.RLock()
+	defer iDevice.mutex.RUnlock()
+
+	if !iDevice.windowCoveringState.available || capabilityUnavailable(iDevice, WindowCoveringFlag) {
+		return 0, NoReadingAvailable
+	}
+
+	return iDevice.windowCoveringState.position, nil
+}
+
+// stateValues returns the lift percentage of the covering, 0 being fully open and 100 fully closed.
+func (z *ZigbeeWindowCovering) stateValues(ctx context.Context, device da.Device) ([]StateValue, error) {
+	position, err := z.Position(ctx, device)
+	return readingStateValues("position", UnitPercent, float64(position), err)
+}
+
+func (z *ZigbeeWindowCovering) pollNode(pctx context.Context, iNode *internalNode) {
+	iNode.mutex.RLock()
+	defer iNode.mutex.RUnlock()
+
+	for _, iDevice := range iNode.devices {
+		z.pollDevice(pctx, iNode, iDevice)
+	}
+}
+
+// pollDevice reads the lift percentage of the device, if it has the capability. The node mutex must be held by the
+// caller.
+func (z *ZigbeeWindowCovering) pollDevice(pctx context.Context, iNode *internalNode, iDevice *internalDevice) {
+	iDevice.mutex.Lock()
+	defer iDevice.mutex.Unlock()
+
+	if !iDevice.device.HasCapability(WindowCoveringFlag) {
+		return
+	}
+
+	if endpoint, cluster, found := findEndpointForCapability(iNode, iDevice, WindowCoveringFlag); found {
+		if err := z.readPosition(pctx, iNode, iDevice, endpoint, cluster, false); err != nil {
+			log.Printf("failed to query window covering position in zda: %s", err)
+		}
+	}
+}
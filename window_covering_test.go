@@ -0,0 +1,289 @@
+package zda
+
+import (
+	"context"
+	"github.com/shimmeringbee/da"
+	"github.com/shimmeringbee/zcl"
+	"github.com/shimmeringbee/zcl/commands/global"
+	"github.com/shimmeringbee/zigbee"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestZigbeeWindowCovering_Contract(t *testing.T) {
+	t.Run("can be assigned to a WindowCovering", func(t *testing.T) {
+		assert.Implements(t, (*WindowCovering)(nil), new(ZigbeeWindowCovering))
+	})
+}
+
+func generateTestWindowCoveringNodeAndDevice() (*internalNode, *internalDevice) {
+	node, device := generateTestNodeAndDevice()
+
+	deviceEndpoint := node.endpoints[0]
+	endpointDescription := node.endpointDescriptions[deviceEndpoint]
+	endpointDescription.InClusterList = []zigbee.ClusterID{zcl.WindowCoveringId}
+	node.endpointDescriptions[deviceEndpoint] = endpointDescription
+
+	return node, device
+}
+
+func uint8AttributeRecord(id zcl.AttributeID, value uint64) global.ReadAttributeResponseRecord {
+	return global.ReadAttributeResponseRecord{Identifier: id, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeUnsignedInt8, Value: value}}
+}
+
+func bitmap8AttributeRecord(id zcl.AttributeID, value uint64) global.ReadAttributeResponseRecord {
+	return global.ReadAttributeResponseRecord{Identifier: id, DataTypeValue: &zcl.AttributeDataTypeValue{DataType: zcl.TypeBitmap8, Value: value}}
+}
+
+func Test_applyWindowCoveringReversal(t *testing.T) {
+	t.Run("leaves percentages of coverings which are not reversed unchanged", func(t *testing.T) {
+		assert.Equal(t, uint8(30), applyWindowCoveringReversal(30, false))
+	})
+
+	t.Run("inverts percentages of coverings which are reversed", func(t *testing.T) {
+		assert.Equal(t, uint8(70), applyWindowCoveringReversal(30, true))
+		assert.Equal(t, uint8(100), applyWindowCoveringReversal(0, true))
+	})
+}
+
+func TestZigbeeWindowCovering_NodeEnumerationCallback(t *testing.T) {
+	t.Run("adds capability to device with cluster and reads the position and config status", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zwc := ZigbeeWindowCovering{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestWindowCoveringNodeAndDevice()
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.WindowCoveringId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], uint8(1), []zcl.AttributeID{WindowCoveringCurrentPositionLiftPercentage, WindowCoveringConfigStatus}).Return([]global.ReadAttributeResponseRecord{
+			uint8AttributeRecord(WindowCoveringCurrentPositionLiftPercentage, 25),
+			bitmap8AttributeRecord(WindowCoveringConfigStatus, 0x07),
+		}, nil)
+		mockEventSender.On("sendEvent", mock.MatchedBy(func(e WindowCoveringPositionChanged) bool {
+			return e.Device.Identifier == device.device.Identifier && e.Position == 75
+		}))
+
+		err := zwc.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.True(t, device.device.HasCapability(WindowCoveringFlag))
+		assert.Equal(t, windowCoveringState{position: 75, available: true, reversed: true}, device.windowCoveringState)
+		assert.Equal(t, UpdateModePolled, capabilityUpdateModes(device)[0].Mode)
+	})
+
+	t.Run("removes capability from device without cluster", func(t *testing.T) {
+		zwc := ZigbeeWindowCovering{}
+
+		node, device := generateTestNodeAndDevice()
+		device.device.Capabilities = []da.Capability{WindowCoveringFlag}
+		device.windowCoveringState = windowCoveringState{position: 10, available: true, reversed: true}
+
+		err := zwc.NodeEnumerationCallback(context.Background(), internalNodeEnumeration{node: node})
+		assert.NoError(t, err)
+
+		assert.False(t, device.device.HasCapability(WindowCoveringFlag))
+		assert.Equal(t, windowCoveringState{}, device.windowCoveringState)
+	})
+}
+
+func TestZigbeeWindowCovering_NodeJoinCallback(t *testing.T) {
+	t.Run("registers new nodes with the poller when they join", func(t *testing.T) {
+		node := &internalNode{}
+
+		mockPoller := mockPoller{}
+		defer mockPoller.AssertExpectations(t)
+
+		zwc := ZigbeeWindowCovering{poller: &mockPoller}
+
+		mockPoller.On("AddNode", node, windowCoveringPollInterval, mock.AnythingOfType("func(context.Context, *zda.internalNode)"))
+
+		err := zwc.NodeJoinCallback(context.Background(), internalNodeJoin{node: node})
+		assert.NoError(t, err)
+	})
+}
+
+func generateTestWindowCoveringDevice(zwc *ZigbeeWindowCovering, mockDeviceStore *mockDeviceStore) (*internalNode, *internalDevice) {
+	node, device := generateTestWindowCoveringNodeAndDevice()
+	device.device.Gateway = zwc.gateway
+	device.device.Capabilities = []da.Capability{WindowCoveringFlag}
+
+	mockDeviceStore.On("getDevice", device.device.Identifier).Return(device, true)
+
+	return node, device
+}
+
+func TestZigbeeWindowCovering_commands(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zwc := ZigbeeWindowCovering{gateway: &mockGateway{}}
+
+		err := zwc.Open(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns error if device does not have capability", func(t *testing.T) {
+		zwc := ZigbeeWindowCovering{gateway: &mockGateway{}}
+
+		err := zwc.Stop(context.Background(), da.Device{Gateway: zwc.gateway})
+		assert.Equal(t, da.DeviceDoesNotHaveCapability, err)
+	})
+
+	t.Run("returns error if lift percentage is out of range", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zwc := ZigbeeWindowCovering{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+		_, device := generateTestWindowCoveringDevice(&zwc, &mockDeviceStore)
+
+		assert.Error(t, zwc.GoToLiftPercentage(context.Background(), device.device, 101))
+		assert.Error(t, zwc.validateArguments(device, "GoToLiftPercentage", []interface{}{uint8(101)}))
+	})
+
+	t.Run("sends Up/Open command to open the covering", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+		defer mockDeviceStore.AssertExpectations(t)
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zwc := ZigbeeWindowCovering{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		node, device := generateTestWindowCoveringDevice(&zwc, &mockDeviceStore)
+
+		expectedRequest := zcl.Message{
+			FrameType:           zcl.FrameLocal,
+			Direction:           zcl.ClientToServer,
+			TransactionSequence: 1,
+			Manufacturer:        zigbee.NoManufacturer,
+			ClusterID:           zcl.WindowCoveringId,
+			SourceEndpoint:      DefaultGatewayHomeAutomationEndpoint,
+			DestinationEndpoint: node.endpoints[0],
+			Command:             &WindowCoveringUpOpen{},
+		}
+		mockZclCommunicatorRequests.On("Request", mock.Anything, node.ieeeAddress, false, expectedRequest).Return(nil)
+
+		err := zwc.Open(context.Background(), device.device)
+		assert.NoError(t, err)
+	})
+
+	t.Run("sends commands as is to coverings which are not reversed", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zwc := ZigbeeWindowCovering{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		_, device := generateTestWindowCoveringDevice(&zwc, &mockDeviceStore)
+
+		var sent []interface{}
+
+		mockZclCommunicatorRequests.On("Request", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			sent = append(sent, args.Get(3).(zcl.Message).Command)
+		}).Times(3)
+
+		assert.NoError(t, zwc.Close(context.Background(), device.device))
+		assert.NoError(t, zwc.Stop(context.Background(), device.device))
+		assert.NoError(t, zwc.GoToLiftPercentage(context.Background(), device.device, 30))
+
+		assert.Equal(t, []interface{}{&WindowCoveringDownClose{}, &WindowCoveringStop{}, &WindowCoveringGoToLiftPercentage{PercentageLiftValue: 30}}, sent)
+	})
+
+	t.Run("inverts commands sent to coverings which are reversed", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		mockZclCommunicatorRequests := mockZclCommunicatorRequests{}
+		defer mockZclCommunicatorRequests.AssertExpectations(t)
+
+		zwc := ZigbeeWindowCovering{
+			gateway:                 &mockGateway{},
+			deviceStore:             &mockDeviceStore,
+			zclCommunicatorRequests: &mockZclCommunicatorRequests,
+		}
+		_, device := generateTestWindowCoveringDevice(&zwc, &mockDeviceStore)
+		device.windowCoveringState.reversed = true
+
+		var sent []interface{}
+
+		mockZclCommunicatorRequests.On("Request", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			sent = append(sent, args.Get(3).(zcl.Message).Command)
+		}).Times(3)
+
+		assert.NoError(t, zwc.Open(context.Background(), device.device))
+		assert.NoError(t, zwc.Close(context.Background(), device.device))
+		assert.NoError(t, zwc.GoToLiftPercentage(context.Background(), device.device, 30))
+
+		assert.Equal(t, []interface{}{&WindowCoveringDownClose{}, &WindowCoveringUpOpen{}, &WindowCoveringGoToLiftPercentage{PercentageLiftValue: 70}}, sent)
+	})
+}
+
+func TestZigbeeWindowCovering_Position(t *testing.T) {
+	t.Run("returns error if device does not belong to gateway", func(t *testing.T) {
+		zwc := ZigbeeWindowCovering{gateway: &mockGateway{}}
+
+		_, err := zwc.Position(context.Background(), da.Device{})
+		assert.Equal(t, da.DeviceDoesNotBelongToGatewayError, err)
+	})
+
+	t.Run("returns no reading available if no position has been read", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zwc := ZigbeeWindowCovering{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+		_, device := generateTestWindowCoveringDevice(&zwc, &mockDeviceStore)
+
+		_, err := zwc.Position(context.Background(), device.device)
+		assert.Equal(t, NoReadingAvailable, err)
+	})
+
+	t.Run("returns the cached position", func(t *testing.T) {
+		mockDeviceStore := mockDeviceStore{}
+
+		zwc := ZigbeeWindowCovering{gateway: &mockGateway{}, deviceStore: &mockDeviceStore}
+		_, device := generateTestWindowCoveringDevice(&zwc, &mockDeviceStore)
+		device.windowCoveringState = windowCoveringState{position: 40, available: true}
+
+		position, err := zwc.Position(context.Background(), device.device)
+		assert.NoError(t, err)
+		assert.Equal(t, uint8(40), position)
+	})
+}
+
+func TestZigbeeWindowCovering_pollNode(t *testing.T) {
+	t.Run("reads only the position, sending an event if it changed and clearing it if the covering is uncalibrated", func(t *testing.T) {
+		mockZclGlobalCommunicator := mockZclGlobalCommunicator{}
+		defer mockZclGlobalCommunicator.AssertExpectations(t)
+
+		mockEventSender := mockEventSender{}
+		defer mockEventSender.AssertExpectations(t)
+
+		zwc := ZigbeeWindowCovering{
+			zclGlobalCommunicator: &mockZclGlobalCommunicator,
+			eventSender:           &mockEventSender,
+		}
+
+		node, device := generateTestWindowCoveringNodeAndDevice()
+		device.device.Capabilities = []da.Capability{WindowCoveringFlag}
+		device.windowCoveringState = windowCoveringState{position: 10, available: true}
+
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.WindowCoveringId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], uint8(1), []zcl.AttributeID{WindowCoveringCurrentPositionLiftPercentage}).Return([]global.ReadAttributeResponseRecord{uint8AttributeRecord(WindowCoveringCurrentPositionLiftPercentage, 60)}, nil).Once()
+		mockZclGlobalCommunicator.On("ReadAttributes", mock.Anything, node.ieeeAddress, false, zcl.WindowCoveringId, zigbee.NoManufacturer, DefaultGatewayHomeAutomationEndpoint, node.endpoints[0], uint8(2), []zcl.AttributeID{WindowCoveringCurrentPositionLiftPercentage}).Return([]global.ReadAttributeResponseRecord{uint8AttributeRecord(WindowCoveringCurrentPositionLiftPercentage, 0xff)}, nil).Once()
+		mockEventSender.On("sendEvent", WindowCoveringPositionChanged{Device: device.device, Position: 60}).Once()
+
+		zwc.pollNode(context.Background(), node)
+		assert.Equal(t, uint8(60), device.windowCoveringState.position)
+
+		zwc.pollNode(context.Background(), node)
+		assert.False(t, device.windowCoveringState.available)
+	})
+}